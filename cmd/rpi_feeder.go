@@ -1,8 +1,16 @@
 package main
 
 import (
+	"fmt"
+	"os"
+
 	"github.com/imilchev/rpi-feeder/pkg/feeder"
+	"github.com/imilchev/rpi-feeder/pkg/feeder/calibration"
+	feederConfig "github.com/imilchev/rpi-feeder/pkg/feeder/config"
+	"github.com/imilchev/rpi-feeder/pkg/loader"
 	"github.com/imilchev/rpi-feeder/pkg/service"
+	serviceConfig "github.com/imilchev/rpi-feeder/pkg/service/config"
+	"github.com/imilchev/rpi-feeder/pkg/version"
 	"github.com/spf13/cobra"
 	"go.uber.org/zap"
 )
@@ -21,13 +29,17 @@ func newRootCmd() *cobra.Command {
 		SilenceUsage: true,
 	}
 	cmd.AddCommand(newFeederCmd())
+	cmd.AddCommand(newCalibrateCmd())
 	cmd.AddCommand(newServiceCmd())
+	cmd.AddCommand(newConfigCmd())
+	cmd.AddCommand(newVersionCmd())
 
 	return cmd
 }
 
 func newFeederCmd() *cobra.Command {
 	var debug bool
+	var overrides []string
 	cmd := &cobra.Command{
 		Use:          "start [configFilePath]",
 		Short:        "Starts the Raspberry Pi automated feeder.",
@@ -39,7 +51,7 @@ func newFeederCmd() *cobra.Command {
 			}
 			defer zap.S().Sync() //nolint
 
-			fm, err := feeder.NewFeederManager(args[0])
+			fm, err := feeder.NewFeederManager(args[0], overrides)
 			if err != nil {
 				return err
 			}
@@ -53,12 +65,79 @@ func newFeederCmd() *cobra.Command {
 		"debug",
 		false,
 		"Enable debug logging.")
+	addOverridesFlag(cmd, &overrides)
+
+	return cmd
+}
+
+func newCalibrateCmd() *cobra.Command {
+	var debug bool
+	var overrides []string
+	var manual bool
+	var durationsMs []uint
+	var gramsPerPortion float64
+	cmd := &cobra.Command{
+		Use:   "calibrate [configFilePath]",
+		Short: "Calibrates the portionMs of the Raspberry Pi automated feeder.",
+		Long: `Calibrates the portionMs of the Raspberry Pi automated feeder.
+
+The servo is run for a series of test durations and the dispensed food is
+weighed after each of them. The resulting portionMs is written to the config
+file and reported to the service. The feeder must not be running while
+calibrating.`,
+		SilenceUsage: true,
+		Args:         cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := initLogger(debug); err != nil {
+				panic(err)
+			}
+			defer zap.S().Sync() //nolint
+
+			var m calibration.Measurer
+			if manual {
+				m = calibration.NewPromptMeasurer(os.Stdin, os.Stdout)
+			}
+
+			var durations []uint64
+			for _, d := range durationsMs {
+				durations = append(durations, uint64(d))
+			}
+			return feeder.Calibrate(args[0], overrides, durations, gramsPerPortion, m)
+		},
+	}
+
+	cmd.Flags().BoolVar(
+		&debug,
+		"debug",
+		false,
+		"Enable debug logging.")
+	addOverridesFlag(cmd, &overrides)
+	cmd.Flags().BoolVar(
+		&manual,
+		"manual",
+		false,
+		"Weigh the food with a kitchen scale and enter the grams instead of using the load cell.")
+	var defaultDurationsMs []uint
+	for _, d := range calibration.DefaultDurationsMs {
+		defaultDurationsMs = append(defaultDurationsMs, uint(d))
+	}
+	cmd.Flags().UintSliceVar(
+		&durationsMs,
+		"durations",
+		defaultDurationsMs,
+		"The durations in ms to run the servo for.")
+	cmd.Flags().Float64Var(
+		&gramsPerPortion,
+		"grams-per-portion",
+		0,
+		"The weight of 1 portion. Defaults to gramsPerPortion of the scale config.")
 
 	return cmd
 }
 
 func newServiceCmd() *cobra.Command {
 	var debug bool
+	var overrides []string
 	cmd := &cobra.Command{
 		Use:          "web-start [configFilePath]]",
 		Short:        "Starts the Raspberry Pi automated feeder web service.",
@@ -70,7 +149,7 @@ func newServiceCmd() *cobra.Command {
 			}
 			defer zap.S().Sync() //nolint
 
-			app, err := service.NewService(args[0])
+			app, err := service.NewService(args[0], overrides)
 			if err != nil {
 				return err
 			}
@@ -84,10 +163,103 @@ func newServiceCmd() *cobra.Command {
 		"debug",
 		false,
 		"Enable debug logging.")
+	addOverridesFlag(cmd, &overrides)
+
+	return cmd
+}
+
+func newConfigCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:          "config",
+		Short:        "Inspects the configuration of the feeder or the web service.",
+		SilenceUsage: true,
+	}
+	cmd.AddCommand(newConfigPrintCmd())
+
+	return cmd
+}
+
+func newConfigPrintCmd() *cobra.Command {
+	var debug bool
+	var overrides []string
+	var webService bool
+	var redacted bool
+	cmd := &cobra.Command{
+		Use:   "print [configFilePath]",
+		Short: "Prints the configuration and where each setting was loaded from.",
+		Long: `Prints the configuration and where each setting was loaded from.
+
+The configuration is loaded like when starting the feeder or the web service:
+the defaults, then the config file, then the RPI_FEEDER_* environment
+variables, then the --set flags. The configuration is not validated.`,
+		SilenceUsage: true,
+		Args:         cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := initLogger(debug); err != nil {
+				panic(err)
+			}
+			defer zap.S().Sync() //nolint
+
+			var configPath string
+			if len(args) > 0 {
+				configPath = args[0]
+			}
+
+			var cfg interface{}
+			var sources loader.Sources
+			var err error
+			if webService {
+				cfg, sources, err = serviceConfig.LoadConfig(configPath, overrides)
+			} else {
+				cfg, sources, err = feederConfig.LoadConfig(configPath, overrides)
+			}
+			if err != nil {
+				return err
+			}
+			return loader.Print(os.Stdout, cfg, sources, redacted)
+		},
+	}
+
+	cmd.Flags().BoolVar(
+		&debug,
+		"debug",
+		false,
+		"Enable debug logging.")
+	addOverridesFlag(cmd, &overrides)
+	cmd.Flags().BoolVar(
+		&webService,
+		"web",
+		false,
+		"Print the configuration of the web service instead of the feeder.")
+	cmd.Flags().BoolVar(
+		&redacted,
+		"redacted",
+		false,
+		"Hide the values of secrets, e.g. mqtt.password.")
 
 	return cmd
 }
 
+func newVersionCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:          "version",
+		Short:        "Prints the version of the Raspberry Pi automated feeder.",
+		SilenceUsage: true,
+		Args:         cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			fmt.Println(version.Get())
+		},
+	}
+}
+
+func addOverridesFlag(cmd *cobra.Command, overrides *[]string) {
+	cmd.Flags().StringArrayVar(
+		overrides,
+		"set",
+		nil,
+		"Override a setting of the config file, e.g. --set mqtt.keepAlive=30. Can be repeated.")
+}
+
 func initLogger(enableDebug bool) error {
 	var cfg zap.Config
 	if enableDebug {
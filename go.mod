@@ -3,16 +3,21 @@ module github.com/imilchev/rpi-feeder
 go 1.17
 
 require (
+	github.com/BurntSushi/toml v1.2.1
 	github.com/eclipse/paho.golang v0.10.0
 	github.com/go-playground/validator/v10 v10.9.0
 	github.com/gofiber/fiber/v2 v2.25.0
 	github.com/golang-migrate/migrate/v4 v4.15.1
+	github.com/google/uuid v1.3.0
+	github.com/jackc/pgconn v1.10.1
+	github.com/robfig/cron/v3 v3.0.1
 	github.com/spf13/cobra v1.2.1
 	github.com/stianeikeland/go-rpio/v4 v4.5.1
 	github.com/stretchr/testify v1.7.0
 	github.com/valyala/fasthttp v1.32.0
 	go.etcd.io/bbolt v1.3.6
 	go.uber.org/zap v1.19.1
+	gopkg.in/yaml.v3 v3.0.1
 	gorm.io/driver/postgres v1.2.3
 	gorm.io/gorm v1.22.5
 	moul.io/zapgorm2 v1.1.1
@@ -29,7 +34,6 @@ require (
 	github.com/hashicorp/go-multierror v1.1.0 // indirect
 	github.com/inconshreveable/mousetrap v1.0.0 // indirect
 	github.com/jackc/chunkreader/v2 v2.0.1 // indirect
-	github.com/jackc/pgconn v1.10.1 // indirect
 	github.com/jackc/pgio v1.0.0 // indirect
 	github.com/jackc/pgpassfile v1.0.0 // indirect
 	github.com/jackc/pgproto3/v2 v2.2.0 // indirect
@@ -52,5 +56,4 @@ require (
 	golang.org/x/sync v0.0.0-20210220032951-036812b2e83c // indirect
 	golang.org/x/sys v0.0.0-20211013075003-97ac67df715c // indirect
 	golang.org/x/text v0.3.7 // indirect
-	gopkg.in/yaml.v3 v3.0.0-20210107192922-496545a6307b // indirect
 )
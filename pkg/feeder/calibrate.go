@@ -0,0 +1,175 @@
+package feeder
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/imilchev/rpi-feeder/pkg/feeder/calibration"
+	"github.com/imilchev/rpi-feeder/pkg/feeder/config"
+	"github.com/imilchev/rpi-feeder/pkg/feeder/gpio"
+	"github.com/imilchev/rpi-feeder/pkg/feeder/mqtt"
+	"github.com/imilchev/rpi-feeder/pkg/feeder/scale"
+	"github.com/imilchev/rpi-feeder/pkg/feeder/servo"
+	"github.com/imilchev/rpi-feeder/pkg/mqtt/model"
+	"github.com/imilchev/rpi-feeder/pkg/utils"
+	"go.uber.org/zap"
+)
+
+// Calibrate calibrates the portionMs of the feeder, writes it to the config
+// file and reports it to the service. The overrides are applied over the
+// config file like when starting the feeder. The food is weighed by m, or by the
+// load cell if m is nil. The feeder must not be running, since the servo is
+// driven directly.
+func Calibrate(
+	configPath string,
+	overrides []string,
+	durationsMs []uint64,
+	gramsPerPortion float64,
+	m calibration.Measurer) error {
+	cfg, err := config.ReadConfig(configPath, overrides)
+	if err != nil {
+		return err
+	}
+	if err := utils.Validate.Struct(cfg); err != nil {
+		return err
+	}
+
+	g, err := gpio.Open()
+	if err != nil {
+		return err
+	}
+	defer g.Close() //nolint
+
+	sc, err := servo.NewServoController(g, cfg.ServoPin, cfg.Servo)
+	if err != nil {
+		return err
+	}
+	defer sc.Close()
+
+	if m == nil {
+		if cfg.Scale == nil {
+			return errors.New("no scale is configured to weigh the food")
+		}
+		m = calibration.NewScaleMeasurer(scale.NewHx711(g, *cfg.Scale))
+	}
+	if gramsPerPortion == 0 && cfg.Scale != nil {
+		gramsPerPortion = cfg.Scale.GramsPerPortion
+	}
+
+	c, err := calibration.NewCalibrator(sc, cfg.Dispense, m)
+	if err != nil {
+		return err
+	}
+	r, err := c.Calibrate(context.Background(), durationsOrDefault(durationsMs), gramsPerPortion)
+	if err != nil {
+		return err
+	}
+
+	if err := config.SaveCalibration(
+		configPath, r.PortionMs, calibrationConfig(r, gramsPerPortion)); err != nil {
+		return err
+	}
+	zap.S().Infof("Saved portionMs %d to %s.", r.PortionMs, configPath)
+
+	if err := mqtt.SendCalibration(cfg.Mqtt, calibrationMessage(r, gramsPerPortion)); err != nil {
+		zap.S().Warnf("Failed to report calibration to the service. %v", err)
+	}
+	return nil
+}
+
+// calibrate calibrates portionMs with the load cell on request of the
+// service. The calibration goes through the feed queue, such that the servo is
+// not driven by a feeding at the same time. The result is reported to the
+// service, also if the calibration failed.
+func (fm *FeederManager) calibrate(msg model.CalibrateMessage) error {
+	gramsPerPortion := msg.GramsPerPortion
+	var r calibration.Result
+	done, err := fm.queue.Enqueue(func() (err error) {
+		// The scale may change with the config, so it is read once no other
+		// job runs.
+		if gramsPerPortion == 0 && fm.config.Scale != nil {
+			gramsPerPortion = fm.config.Scale.GramsPerPortion
+		}
+		r, err = fm.runCalibration(msg.DurationsMs, gramsPerPortion)
+		return err
+	})
+	if err == nil {
+		err = <-done
+	}
+
+	result := model.CalibrationMessage{Timestamp: time.Now().UTC()}
+	if err != nil {
+		result.Error = err.Error()
+	} else {
+		result = calibrationMessage(r, gramsPerPortion)
+	}
+	if sendErr := fm.mqtt().SendCalibration(result); sendErr != nil {
+		zap.S().Errorf("Failed to send calibration result. %v", sendErr)
+	}
+	return err
+}
+
+func (fm *FeederManager) runCalibration(
+	durationsMs []uint64, gramsPerPortion float64) (calibration.Result, error) {
+	if fm.scale == nil {
+		return calibration.Result{}, errors.New("no scale is configured to weigh the food")
+	}
+
+	c, err := calibration.NewCalibrator(
+		fm.servoController, fm.config.Dispense, calibration.NewScaleMeasurer(fm.scale))
+	if err != nil {
+		return calibration.Result{}, err
+	}
+	r, err := c.Calibrate(context.Background(), durationsOrDefault(durationsMs), gramsPerPortion)
+	if err != nil {
+		return calibration.Result{}, err
+	}
+
+	cc := calibrationConfig(r, gramsPerPortion)
+	if err := config.SaveCalibration(fm.configPath, r.PortionMs, cc); err != nil {
+		return calibration.Result{}, err
+	}
+
+	// The new portionMs is used from the next feeding on, unless the config
+	// received from the service sets it.
+	fm.fileConfig.PortionMs = r.PortionMs
+	fm.fileConfig.Calibration = &cc
+	fm.config.PortionMs = r.PortionMs
+	fm.config.Calibration = &cc
+	fm.resetDispenser()
+	return r, nil
+}
+
+func durationsOrDefault(durationsMs []uint64) []uint64 {
+	if len(durationsMs) == 0 {
+		return calibration.DefaultDurationsMs
+	}
+	return durationsMs
+}
+
+func calibrationConfig(r calibration.Result, gramsPerPortion float64) config.CalibrationConfig {
+	return config.CalibrationConfig{
+		MsPerGram:       r.MsPerGram,
+		OffsetMs:        r.OffsetMs,
+		GramsPerPortion: gramsPerPortion,
+		CalibratedAt:    r.At,
+	}
+}
+
+func calibrationMessage(r calibration.Result, gramsPerPortion float64) model.CalibrationMessage {
+	msg := model.CalibrationMessage{
+		MsPerGram:       r.MsPerGram,
+		OffsetMs:        r.OffsetMs,
+		GramsPerPortion: gramsPerPortion,
+		PortionMs:       r.PortionMs,
+		Timestamp:       r.At,
+	}
+	for _, s := range r.Samples {
+		msg.Samples = append(msg.Samples, model.CalibrationSampleMessage{
+			DurationMs: s.DurationMs,
+			Grams:      s.Grams,
+		})
+	}
+	return msg
+}
@@ -0,0 +1,96 @@
+package calibration
+
+import (
+	"context"
+	"errors"
+	"math"
+	"time"
+
+	"github.com/imilchev/rpi-feeder/pkg/feeder/config"
+	"github.com/imilchev/rpi-feeder/pkg/feeder/servo"
+	"go.uber.org/zap"
+)
+
+// DefaultDurationsMs are the test durations used if none are specified.
+var DefaultDurationsMs = []uint64{500, 1000, 1500, 2000}
+
+// Measurer measures the grams of food dispensed by dispense.
+type Measurer interface {
+	Measure(durationMs uint64, dispense func() error) (float64, error)
+}
+
+// Result is the outcome of a calibration.
+type Result struct {
+	Samples   []Sample
+	MsPerGram float64
+	OffsetMs  float64
+
+	// The portionMs giving the requested grams per portion.
+	PortionMs uint64
+	At        time.Time
+}
+
+// Calibrator determines the portionMs for a servo by dispensing food for a
+// series of test durations and weighing it.
+type Calibrator interface {
+	Calibrate(ctx context.Context, durationsMs []uint64, gramsPerPortion float64) (Result, error)
+}
+
+type calibrator struct {
+	sc       servo.ServoController
+	cfg      config.DispenseConfig
+	measurer Measurer
+}
+
+// NewCalibrator creates a calibrator for the servo. The food is dispensed with
+// the dispense config, such that the calibration matches regular feedings.
+func NewCalibrator(sc servo.ServoController, cfg config.DispenseConfig, m Measurer) (Calibrator, error) {
+	if _, ok := sc.(servo.Stepper); ok {
+		return nil, errors.New("the stepper driver is calibrated in steps, not in ms")
+	}
+	return &calibrator{sc: sc, cfg: cfg, measurer: m}, nil
+}
+
+func (c *calibrator) Calibrate(
+	ctx context.Context, durationsMs []uint64, gramsPerPortion float64) (Result, error) {
+	if gramsPerPortion <= 0 {
+		return Result{}, errors.New("the grams per portion must be positive")
+	}
+
+	var samples []Sample
+	for _, ms := range durationsMs {
+		d := servo.NewDispenser(c.sc, ms, c.cfg)
+		grams, err := c.measurer.Measure(ms, func() error {
+			if d.Dispense(ctx, 1) == 0 {
+				return ctx.Err()
+			}
+			return nil
+		})
+		if err != nil {
+			return Result{}, err
+		}
+		zap.S().Infof("Dispensed %.1fg in %dms.", grams, ms)
+		samples = append(samples, Sample{DurationMs: ms, Grams: grams})
+	}
+
+	msPerGram, offsetMs, err := Fit(samples)
+	if err != nil {
+		return Result{}, err
+	}
+	portionMs := math.Round(msPerGram*gramsPerPortion + offsetMs)
+	if portionMs < 1 {
+		return Result{}, errors.New("the grams per portion are too low for the servo")
+	}
+
+	r := Result{
+		Samples:   samples,
+		MsPerGram: msPerGram,
+		OffsetMs:  offsetMs,
+		PortionMs: uint64(portionMs),
+		At:        time.Now().UTC(),
+	}
+	zap.S().Infof(
+		"Calibrated %.1fms per gram with an offset of %.1fms. %.1fg per portion take %dms.",
+		r.MsPerGram, r.OffsetMs, gramsPerPortion, r.PortionMs)
+	return r, nil
+}
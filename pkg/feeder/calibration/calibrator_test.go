@@ -0,0 +1,115 @@
+package calibration
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/imilchev/rpi-feeder/pkg/feeder/config"
+	"github.com/imilchev/rpi-feeder/pkg/feeder/gpio"
+	"github.com/imilchev/rpi-feeder/pkg/feeder/servo"
+	"github.com/stretchr/testify/suite"
+)
+
+// fakeServoController keeps track of the time spent rotating.
+type fakeServoController struct {
+	started  time.Time
+	rotating time.Duration
+}
+
+func (sc *fakeServoController) RotateClockwise()        { sc.started = time.Now() }
+func (sc *fakeServoController) RotateCounterClockwise() { sc.started = time.Now() }
+func (sc *fakeServoController) Close()                  {}
+func (sc *fakeServoController) Stop() {
+	if !sc.started.IsZero() {
+		sc.rotating += time.Since(sc.started)
+		sc.started = time.Time{}
+	}
+}
+
+// fakeMeasurer reports grams proportional to the duration, as a servo
+// dispensing 1 gram per msPerGram would.
+type fakeMeasurer struct {
+	msPerGram float64
+	offsetMs  float64
+	measured  []uint64
+	err       error
+}
+
+func (m *fakeMeasurer) Measure(durationMs uint64, dispense func() error) (float64, error) {
+	if m.err != nil {
+		return 0, m.err
+	}
+	if err := dispense(); err != nil {
+		return 0, err
+	}
+	m.measured = append(m.measured, durationMs)
+	return (float64(durationMs) - m.offsetMs) / m.msPerGram, nil
+}
+
+type CalibratorSuite struct {
+	suite.Suite
+	sc *fakeServoController
+	m  *fakeMeasurer
+}
+
+func (suite *CalibratorSuite) SetupTest() {
+	suite.sc = &fakeServoController{}
+	suite.m = &fakeMeasurer{msPerGram: 2, offsetMs: 4}
+}
+
+func (suite *CalibratorSuite) TestCalibrate() {
+	c, err := NewCalibrator(suite.sc, config.DispenseConfig{}, suite.m)
+	suite.Require().NoError(err)
+
+	r, err := c.Calibrate(context.Background(), []uint64{10, 20, 30}, 5)
+	suite.NoError(err)
+	suite.Equal([]uint64{10, 20, 30}, suite.m.measured)
+	suite.Len(r.Samples, 3)
+	suite.InDelta(2, r.MsPerGram, 0.001)
+	suite.InDelta(4, r.OffsetMs, 0.001)
+	suite.Equal(uint64(14), r.PortionMs)
+	suite.False(r.At.IsZero())
+
+	// The servo was run for the test durations.
+	suite.GreaterOrEqual(suite.sc.rotating, 60*time.Millisecond)
+}
+
+func (suite *CalibratorSuite) TestCalibrate_MeasureFailed() {
+	suite.m.err = errors.New("not ready")
+	c, err := NewCalibrator(suite.sc, config.DispenseConfig{}, suite.m)
+	suite.Require().NoError(err)
+
+	_, err = c.Calibrate(context.Background(), []uint64{10, 20}, 5)
+	suite.ErrorIs(err, suite.m.err)
+}
+
+func (suite *CalibratorSuite) TestCalibrate_InvalidGramsPerPortion() {
+	c, err := NewCalibrator(suite.sc, config.DispenseConfig{}, suite.m)
+	suite.Require().NoError(err)
+
+	_, err = c.Calibrate(context.Background(), []uint64{10, 20}, 0)
+	suite.Error(err)
+	suite.Empty(suite.m.measured)
+}
+
+func (suite *CalibratorSuite) TestNewCalibrator_Stepper() {
+	g := gpio.NewMemory()
+	sc, err := servo.NewServoController(g, 0, config.ServoConfig{
+		Driver: config.StepperServoDriver,
+		Stepper: &config.StepperConfig{
+			Pins:            [4]uint8{5, 6, 13, 19},
+			StepDelayUs:     1,
+			StepsPerPortion: 1,
+		},
+	})
+	suite.Require().NoError(err)
+
+	_, err = NewCalibrator(sc, config.DispenseConfig{}, suite.m)
+	suite.Error(err)
+}
+
+func TestCalibratorSuite(t *testing.T) {
+	suite.Run(t, new(CalibratorSuite))
+}
@@ -0,0 +1,42 @@
+package calibration
+
+import (
+	"errors"
+	"math"
+)
+
+// Sample is the food dispensed by running the servo for a test duration.
+type Sample struct {
+	DurationMs uint64
+	Grams      float64
+}
+
+// Fit fits the line ms = msPerGram * grams + offsetMs through the samples
+// using least squares. The offset accounts for the time it takes the servo
+// to spin up and for food that does not drop immediately.
+func Fit(samples []Sample) (msPerGram, offsetMs float64, err error) {
+	if len(samples) < 2 {
+		return 0, 0, errors.New("at least 2 samples are needed")
+	}
+
+	n := float64(len(samples))
+	var sumX, sumY, sumXX, sumXY float64
+	for _, s := range samples {
+		x, y := s.Grams, float64(s.DurationMs)
+		sumX += x
+		sumY += y
+		sumXX += x * x
+		sumXY += x * y
+	}
+
+	denominator := n*sumXX - sumX*sumX
+	if math.Abs(denominator) < 1e-9 {
+		return 0, 0, errors.New("the samples must have different weights")
+	}
+	msPerGram = (n*sumXY - sumX*sumY) / denominator
+	if msPerGram <= 0 {
+		return 0, 0, errors.New("the weight does not increase with the duration")
+	}
+	offsetMs = (sumY - msPerGram*sumX) / n
+	return msPerGram, offsetMs, nil
+}
@@ -0,0 +1,60 @@
+package calibration
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+)
+
+type FitSuite struct {
+	suite.Suite
+}
+
+func (suite *FitSuite) TestFit() {
+	// 100ms per gram with a spin-up of 200ms.
+	msPerGram, offsetMs, err := Fit([]Sample{
+		{DurationMs: 700, Grams: 5},
+		{DurationMs: 1200, Grams: 10},
+		{DurationMs: 2200, Grams: 20},
+	})
+	suite.NoError(err)
+	suite.InDelta(100, msPerGram, 0.001)
+	suite.InDelta(200, offsetMs, 0.001)
+}
+
+func (suite *FitSuite) TestFit_LeastSquares() {
+	msPerGram, offsetMs, err := Fit([]Sample{
+		{DurationMs: 950, Grams: 10},
+		{DurationMs: 1050, Grams: 10},
+		{DurationMs: 1950, Grams: 20},
+		{DurationMs: 2050, Grams: 20},
+	})
+	suite.NoError(err)
+	suite.InDelta(100, msPerGram, 0.001)
+	suite.InDelta(0, offsetMs, 0.001)
+}
+
+func (suite *FitSuite) TestFit_TooFewSamples() {
+	_, _, err := Fit([]Sample{{DurationMs: 1000, Grams: 10}})
+	suite.Error(err)
+}
+
+func (suite *FitSuite) TestFit_SameWeights() {
+	_, _, err := Fit([]Sample{
+		{DurationMs: 1000, Grams: 10},
+		{DurationMs: 2000, Grams: 10},
+	})
+	suite.Error(err)
+}
+
+func (suite *FitSuite) TestFit_DecreasingWeights() {
+	_, _, err := Fit([]Sample{
+		{DurationMs: 1000, Grams: 20},
+		{DurationMs: 2000, Grams: 10},
+	})
+	suite.Error(err)
+}
+
+func TestFitSuite(t *testing.T) {
+	suite.Run(t, new(FitSuite))
+}
@@ -0,0 +1,43 @@
+package calibration
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+type promptMeasurer struct {
+	in  *bufio.Reader
+	out io.Writer
+}
+
+// NewPromptMeasurer creates a measurer asking the user to weigh the food
+// with a kitchen scale and to enter the grams.
+func NewPromptMeasurer(in io.Reader, out io.Writer) Measurer {
+	return &promptMeasurer{in: bufio.NewReader(in), out: out}
+}
+
+func (m *promptMeasurer) Measure(durationMs uint64, dispense func() error) (float64, error) {
+	fmt.Fprintf(m.out, "Place an empty container under the dispenser and press Enter.")
+	if _, err := m.in.ReadString('\n'); err != nil {
+		return 0, err
+	}
+	if err := dispense(); err != nil {
+		return 0, err
+	}
+
+	for {
+		fmt.Fprintf(m.out, "Dispensed for %dms. Enter the weight of the food in grams: ", durationMs)
+		line, err := m.in.ReadString('\n')
+		if err != nil {
+			return 0, err
+		}
+		grams, err := strconv.ParseFloat(strings.TrimSpace(line), 64)
+		if err == nil && grams >= 0 {
+			return grams, nil
+		}
+		fmt.Fprintf(m.out, "%q is not a valid weight.\n", strings.TrimSpace(line))
+	}
+}
@@ -0,0 +1,63 @@
+package calibration
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+)
+
+type PromptMeasurerSuite struct {
+	suite.Suite
+	out *bytes.Buffer
+}
+
+func (suite *PromptMeasurerSuite) SetupTest() {
+	suite.out = &bytes.Buffer{}
+}
+
+func (suite *PromptMeasurerSuite) TestMeasure() {
+	m := NewPromptMeasurer(strings.NewReader("\n12.5\n"), suite.out)
+
+	dispensed := false
+	grams, err := m.Measure(1000, func() error {
+		dispensed = true
+		return nil
+	})
+	suite.NoError(err)
+	suite.True(dispensed)
+	suite.Equal(12.5, grams)
+	suite.Contains(suite.out.String(), "Dispensed for 1000ms.")
+}
+
+func (suite *PromptMeasurerSuite) TestMeasure_InvalidWeight() {
+	m := NewPromptMeasurer(strings.NewReader("\nabc\n-1\n7\n"), suite.out)
+
+	grams, err := m.Measure(1000, func() error { return nil })
+	suite.NoError(err)
+	suite.Equal(7.0, grams)
+	suite.Contains(suite.out.String(), `"abc" is not a valid weight.`)
+	suite.Contains(suite.out.String(), `"-1" is not a valid weight.`)
+}
+
+func (suite *PromptMeasurerSuite) TestMeasure_DispenseFailed() {
+	m := NewPromptMeasurer(strings.NewReader("\n12.5\n"), suite.out)
+
+	dispenseErr := errors.New("cancelled")
+	_, err := m.Measure(1000, func() error { return dispenseErr })
+	suite.ErrorIs(err, dispenseErr)
+}
+
+func (suite *PromptMeasurerSuite) TestMeasure_EndOfInput() {
+	m := NewPromptMeasurer(strings.NewReader("\n"), suite.out)
+
+	_, err := m.Measure(1000, func() error { return nil })
+	suite.ErrorIs(err, io.EOF)
+}
+
+func TestPromptMeasurerSuite(t *testing.T) {
+	suite.Run(t, new(PromptMeasurerSuite))
+}
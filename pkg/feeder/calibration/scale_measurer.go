@@ -0,0 +1,38 @@
+package calibration
+
+import (
+	"time"
+
+	"github.com/imilchev/rpi-feeder/pkg/feeder/scale"
+)
+
+// settleDelay is how long to wait after dispensing for the food to settle on
+// the scale before weighing it.
+const settleDelay = time.Second
+
+type scaleMeasurer struct {
+	s           scale.Scale
+	settleDelay time.Duration
+}
+
+// NewScaleMeasurer creates a measurer weighing the food with the load cell.
+func NewScaleMeasurer(s scale.Scale) Measurer {
+	return &scaleMeasurer{s: s, settleDelay: settleDelay}
+}
+
+func (m *scaleMeasurer) Measure(durationMs uint64, dispense func() error) (float64, error) {
+	before, err := m.s.Weight()
+	if err != nil {
+		return 0, err
+	}
+	if err := dispense(); err != nil {
+		return 0, err
+	}
+	time.Sleep(m.settleDelay)
+
+	after, err := m.s.Weight()
+	if err != nil {
+		return 0, err
+	}
+	return after - before, nil
+}
@@ -1,13 +1,208 @@
 package config
 
-import "github.com/imilchev/rpi-feeder/pkg/mqtt/config"
+import (
+	"time"
+
+	"github.com/imilchev/rpi-feeder/pkg/limits"
+	"github.com/imilchev/rpi-feeder/pkg/mqtt/config"
+)
 
 type Config struct {
 	DbPath   string `json:"dbPath"`
-	ServoPin uint8  `json:"servoPin" validate:"gt=0"`
+	ServoPin uint8  `json:"servoPin" validate:"required_unless=Servo.Driver stepper"`
+
+	// How the servo is driven.
+	Servo ServoConfig `json:"servo"`
 
 	// The amount of ms to spin in a direction to drop 1
-	// portion of food.
-	PortionMs uint64            `json:"portionMs" validate:"gt=0"`
-	Mqtt      config.MqttConfig `json:"mqtt" validate:"required"`
+	// portion of food. Not used by the stepper driver.
+	PortionMs uint64 `json:"portionMs" validate:"required_unless=Servo.Driver stepper"`
+
+	// The result of the last calibration of PortionMs. It is written by the
+	// calibration and only informational.
+	Calibration *CalibrationConfig `json:"calibration,omitempty"`
+
+	// How the servo moves to dispense the portions.
+	Dispense DispenseConfig `json:"dispense"`
+
+	// The maximum amount of feedings waiting or being executed. Feed commands
+	// received while the queue is full are rejected. Defaults to 5 if not set.
+	FeedQueueSize uint `json:"feedQueueSize" validate:"gt=0"`
+
+	// The feedings executed by the feeder on its own. The schedule is run
+	// regardless of whether the MQTT broker is reachable.
+	Schedule []ScheduleEntry `json:"schedule" validate:"dive"`
+
+	// What to do with scheduled feedings that were missed while the feeder
+	// was not running.
+	MissedFeedings MissedFeedingsConfig `json:"missedFeedings"`
+
+	// Safety limits enforced for every feeding, regardless of whether it was
+	// scheduled or requested by the service.
+	Limits limits.Limits `json:"limits"`
+
+	// The load cell weighing the served food. Portions are served by time or
+	// steps if not set.
+	Scale *ScaleConfig `json:"scale"`
+
+	// The sensor measuring the food level. No level is measured if not set.
+	FoodLevel *FoodLevelConfig `json:"foodLevel"`
+
+	// How often to send telemetry to the service, in seconds. The telemetry
+	// is also the heartbeat of the feeder. Defaults to 60 if not set.
+	TelemetryIntervalSeconds uint              `json:"telemetryIntervalSeconds" validate:"gt=0"`
+	Mqtt                     config.MqttConfig `json:"mqtt" validate:"required"`
+}
+
+type ScheduleEntry struct {
+	// A standard 5-field cron expression, e.g. "30 8 * * 1-5". Exactly one of
+	// Cron and Time must be set.
+	Cron string `json:"cron" validate:"required_without=Time,excluded_with=Time,omitempty,cron"`
+
+	// A time of day in the 24-hour HH:MM format, e.g. "08:30".
+	Time     string `json:"time" validate:"required_without=Cron,excluded_with=Cron,omitempty,datetime=15:04"`
+	Portions uint   `json:"portions" validate:"gt=0"`
+}
+
+type ServoDriver string
+
+const (
+	// GpioServoDriver generates the servo pulses by toggling ServoPin.
+	GpioServoDriver ServoDriver = "gpio"
+
+	// PwmServoDriver generates the servo pulses with the hardware PWM through
+	// the Linux sysfs interface.
+	PwmServoDriver ServoDriver = "pwm"
+
+	// StepperServoDriver drives a 4-wire stepper motor, e.g. a 28BYJ-48 with
+	// a ULN2003 driver board, instead of a servo.
+	StepperServoDriver ServoDriver = "stepper"
+)
+
+type ServoConfig struct {
+	// Defaults to gpio if not set.
+	Driver ServoDriver `json:"driver" validate:"omitempty,oneof=gpio pwm stepper"`
+
+	// The PWM chip and channel, e.g. channel 0 of chip 0 is exposed as
+	// /sys/class/pwm/pwmchip0/pwm0.
+	PwmChip    uint `json:"pwmChip"`
+	PwmChannel uint `json:"pwmChannel"`
+
+	// The period of the PWM signal and the pulse widths for rotating in each
+	// direction, in microseconds.
+	PeriodUs                uint64 `json:"periodUs" validate:"required_if=Driver pwm"`
+	ClockwisePulseUs        uint64 `json:"clockwisePulseUs" validate:"required_if=Driver pwm,ltefield=PeriodUs"`
+	CounterClockwisePulseUs uint64 `json:"counterClockwisePulseUs" validate:"required_if=Driver pwm,ltefield=PeriodUs"`
+
+	Stepper *StepperConfig `json:"stepper" validate:"required_if=Driver stepper"`
+}
+
+type StepperConfig struct {
+	// The GPIO pins connected to the IN1-IN4 inputs of the driver board.
+	Pins [4]uint8 `json:"pins" validate:"dive,gt=0"`
+
+	// The delay between two steps in microseconds. It determines the speed
+	// of the motor.
+	StepDelayUs uint64 `json:"stepDelayUs" validate:"gt=0"`
+
+	// The amount of steps to drop 1 portion of food. Used instead of
+	// PortionMs.
+	StepsPerPortion uint `json:"stepsPerPortion" validate:"gt=0"`
+
+	// Use the half-step sequence, which gives smoother movement and twice the
+	// steps per revolution.
+	HalfStep bool `json:"halfStep"`
+}
+
+type FoodLevelConfig struct {
+	// The GPIO pins connected to the trigger and echo pins of the HC-SR04
+	// ultrasonic sensor.
+	TriggerPin uint8 `json:"triggerPin" validate:"gt=0"`
+	EchoPin    uint8 `json:"echoPin" validate:"gt=0,nefield=TriggerPin"`
+
+	// How often to measure the food level, in seconds.
+	IntervalSeconds uint `json:"intervalSeconds" validate:"gt=0"`
+
+	// The distances in cm measured by the sensor when the hopper is empty and
+	// when it is full. The level is interpolated linearly between them.
+	EmptyDistanceCm float64 `json:"emptyDistanceCm" validate:"gtfield=FullDistanceCm"`
+	FullDistanceCm  float64 `json:"fullDistanceCm" validate:"gte=0"`
+}
+
+type ScaleConfig struct {
+	// The GPIO pins connected to the DT and SCK pins of the HX711 amplifier.
+	DataPin  uint8 `json:"dataPin" validate:"gt=0"`
+	ClockPin uint8 `json:"clockPin" validate:"gt=0,nefield=DataPin"`
+
+	// The raw HX711 counts per gram, determined by weighing a known weight.
+	// It is negative if the load cell is mounted upside down.
+	CountsPerGram float64 `json:"countsPerGram" validate:"ne=0"`
+
+	// The weight of 1 portion of food.
+	GramsPerPortion float64 `json:"gramsPerPortion" validate:"gt=0"`
+
+	// How far below the target weight a feeding is considered complete.
+	// Without a tolerance an almost complete feeding is topped up with a
+	// whole portion.
+	ToleranceGrams float64 `json:"toleranceGrams" validate:"gte=0"`
+}
+
+type CalibrationConfig struct {
+	// The fitted ms of rotation per gram of food and the offset in ms, which
+	// accounts for the spin-up time of the servo.
+	MsPerGram float64 `json:"msPerGram"`
+	OffsetMs  float64 `json:"offsetMs"`
+
+	// The weight of 1 portion PortionMs was calibrated for.
+	GramsPerPortion float64   `json:"gramsPerPortion"`
+	CalibratedAt    time.Time `json:"calibratedAt"`
+}
+
+type DispenseStrategy string
+
+const (
+	// ContinuousDispense rotates the servo forward for portionMs per portion.
+	ContinuousDispense DispenseStrategy = "continuous"
+
+	// ReverseDispense rotates the servo in cycles of ForwardMs forward
+	// followed by ReverseMs reverse, until portionMs of forward rotation is
+	// accumulated for a portion. Reversing loosens food stuck in the
+	// dispenser.
+	ReverseDispense DispenseStrategy = "reverse"
+)
+
+type DispenseConfig struct {
+	// Defaults to continuous if not set.
+	Strategy  DispenseStrategy `json:"strategy" validate:"omitempty,oneof=continuous reverse"`
+	ForwardMs uint64           `json:"forwardMs" validate:"required_if=Strategy reverse"`
+	ReverseMs uint64           `json:"reverseMs" validate:"required_if=Strategy reverse"`
+
+	// Wiggle the servo back and forth after every UnjamEvery portions. The
+	// wiggle consists of UnjamWiggles moves of UnjamMs in each direction.
+	// Not done if UnjamEvery is not set.
+	UnjamEvery   uint   `json:"unjamEvery"`
+	UnjamWiggles uint   `json:"unjamWiggles" validate:"required_with=UnjamEvery"`
+	UnjamMs      uint64 `json:"unjamMs" validate:"required_with=UnjamEvery"`
+}
+
+type MissedFeedingsPolicy string
+
+const (
+	// SkipMissedFeedings does not serve any of the missed feedings.
+	SkipMissedFeedings MissedFeedingsPolicy = "skip"
+
+	// FeedOnceMissedFeedings serves the most recent missed feeding only.
+	FeedOnceMissedFeedings MissedFeedingsPolicy = "once"
+
+	// FeedAllMissedFeedings serves all missed feedings, up to a cap.
+	FeedAllMissedFeedings MissedFeedingsPolicy = "all"
+)
+
+type MissedFeedingsConfig struct {
+	// Defaults to skip if not set.
+	Policy MissedFeedingsPolicy `json:"policy" validate:"omitempty,oneof=skip once all"`
+
+	// The maximum amount of missed feedings served with the "all" policy. The
+	// most recent ones are served first.
+	MaxCatchUp uint `json:"maxCatchUp" validate:"required_if=Policy all"`
 }
@@ -3,17 +3,84 @@ package config
 import (
 	"encoding/json"
 	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/imilchev/rpi-feeder/pkg/loader"
+)
+
+const (
+	defaultFeedQueueSize            uint = 5
+	defaultTelemetryIntervalSeconds uint = 60
 )
 
-func ReadConfig(configPath string) (*Config, error) {
-	configFile, err := ioutil.ReadFile(configPath)
+// ReadConfig reads the config file at configPath, with the settings from the
+// environment and the overrides applied over it. See loader.Load.
+func ReadConfig(configPath string, overrides []string) (*Config, error) {
+	config, _, err := LoadConfig(configPath, overrides)
+	return config, err
+}
+
+// LoadConfig reads the config like ReadConfig and also returns where each
+// setting was loaded from.
+func LoadConfig(configPath string, overrides []string) (*Config, loader.Sources, error) {
+	config := &Config{
+		FeedQueueSize:            defaultFeedQueueSize,
+		TelemetryIntervalSeconds: defaultTelemetryIntervalSeconds,
+	}
+	sources, err := loader.Load(config, configPath, overrides)
+	if err != nil {
+		return nil, nil, err
+	}
+	return config, sources, nil
+}
+
+// SaveCalibration writes the calibrated portionMs to the config file, keeping
+// its format. Only the portionMs and calibration keys are changed, the other
+// settings are kept as they are.
+func SaveCalibration(configPath string, portionMs uint64, c CalibrationConfig) error {
+	raw, err := loader.ReadFile(configPath)
 	if err != nil {
-		return nil, err
+		return err
 	}
 
-	config := &Config{}
-	if err := json.Unmarshal(configFile, config); err != nil {
-		return nil, err
+	// The calibration is written with the keys of its JSON format.
+	calibration, err := json.Marshal(c)
+	if err != nil {
+		return err
+	}
+	var cRaw map[string]interface{}
+	if err := json.Unmarshal(calibration, &cRaw); err != nil {
+		return err
+	}
+	raw["portionMs"] = portionMs
+	raw["calibration"] = cRaw
+
+	data, err := loader.Encode(configPath, raw)
+	if err != nil {
+		return err
+	}
+	info, err := os.Stat(configPath)
+	if err != nil {
+		return err
+	}
+
+	// Write to a temporary file first, such that the config is not corrupted
+	// if writing fails mid-way.
+	tmp, err := ioutil.TempFile(filepath.Dir(configPath), filepath.Base(configPath)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(tmp.Name(), info.Mode()); err != nil {
+		return err
 	}
-	return config, nil
+	return os.Rename(tmp.Name(), configPath)
 }
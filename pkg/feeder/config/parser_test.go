@@ -0,0 +1,99 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/suite"
+)
+
+type ParserSuite struct {
+	suite.Suite
+	path string
+}
+
+func (suite *ParserSuite) SetupTest() {
+	suite.path = filepath.Join(suite.T().TempDir(), "config.json")
+	suite.Require().NoError(os.WriteFile(suite.path, []byte(`{
+    "dbPath": "./output",
+    "servoPin": 17,
+    "portionMs": 1000,
+    "mqtt": {
+        "server": "mqtt://localhost:1883",
+        "clientId": "dev1"
+    }
+}`), 0600))
+}
+
+func (suite *ParserSuite) TestReadConfig_Defaults() {
+	cfg, err := ReadConfig(suite.path, nil)
+	suite.NoError(err)
+	suite.Equal(defaultFeedQueueSize, cfg.FeedQueueSize)
+	suite.Equal(defaultTelemetryIntervalSeconds, cfg.TelemetryIntervalSeconds)
+	suite.Nil(cfg.Calibration)
+}
+
+func (suite *ParserSuite) TestReadConfig_Overrides() {
+	suite.T().Setenv("RPI_FEEDER_MQTT_PASSWORD", "secret")
+	cfg, err := ReadConfig(suite.path, []string{"portionMs=800"})
+	suite.NoError(err)
+	suite.Equal("secret", cfg.Mqtt.Password)
+	suite.Equal(uint64(800), cfg.PortionMs)
+	suite.Equal("dev1", cfg.Mqtt.ClientId)
+}
+
+func (suite *ParserSuite) TestSaveCalibration() {
+	c := CalibrationConfig{
+		MsPerGram:       95.5,
+		OffsetMs:        120,
+		GramsPerPortion: 10,
+		CalibratedAt:    time.Date(2022, 2, 1, 8, 0, 0, 0, time.UTC),
+	}
+	suite.NoError(SaveCalibration(suite.path, 1075, c))
+
+	cfg, err := ReadConfig(suite.path, nil)
+	suite.NoError(err)
+	suite.Equal(uint64(1075), cfg.PortionMs)
+	suite.Equal(&c, cfg.Calibration)
+
+	// The other settings are kept.
+	suite.Equal("./output", cfg.DbPath)
+	suite.Equal(uint8(17), cfg.ServoPin)
+	suite.Equal("dev1", cfg.Mqtt.ClientId)
+
+	info, err := os.Stat(suite.path)
+	suite.NoError(err)
+	suite.Equal(os.FileMode(0600), info.Mode())
+}
+
+func (suite *ParserSuite) TestSaveCalibration_Yaml() {
+	path := filepath.Join(suite.T().TempDir(), "config.yaml")
+	suite.Require().NoError(os.WriteFile(path, []byte(`
+dbPath: ./output
+servoPin: 17
+portionMs: 1000
+mqtt:
+    server: mqtt://localhost:1883
+    clientId: dev1
+`), 0600))
+
+	c := CalibrationConfig{MsPerGram: 95.5, OffsetMs: 120, GramsPerPortion: 10}
+	suite.NoError(SaveCalibration(path, 1075, c))
+
+	cfg, err := ReadConfig(path, nil)
+	suite.NoError(err)
+	suite.Equal(uint64(1075), cfg.PortionMs)
+	suite.Equal(&c, cfg.Calibration)
+	suite.Equal("dev1", cfg.Mqtt.ClientId)
+}
+
+func (suite *ParserSuite) TestSaveCalibration_FileDoesNotExist() {
+	suite.Error(SaveCalibration(
+		filepath.Join(suite.T().TempDir(), "missing.json"), 1000, CalibrationConfig{}))
+}
+
+func TestParserSuite(t *testing.T) {
+	suite.Run(t, new(ParserSuite))
+}
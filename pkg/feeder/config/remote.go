@@ -0,0 +1,99 @@
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// localOnlyKeys are the settings that cannot be managed by the service. The
+// schedule has its own topic and the database path is specific to the device.
+var localOnlyKeys = []string{"dbPath", "schedule"}
+
+// CheckRemote returns an error if raw is not a valid config received from the
+// service. It must be a JSON object in the format of the config file, without
+// the local only settings. The values themselves are validated once the
+// config is applied.
+func CheckRemote(raw json.RawMessage) error {
+	var keys map[string]json.RawMessage
+	if err := json.Unmarshal(raw, &keys); err != nil || keys == nil {
+		return errors.New("config must be a JSON object")
+	}
+
+	for k, v := range keys {
+		for _, l := range localOnlyKeys {
+			// The keys of the config are matched case-insensitively.
+			if strings.EqualFold(k, l) {
+				return fmt.Errorf("%s cannot be managed remotely", l)
+			}
+		}
+		if strings.EqualFold(k, "mqtt") {
+			var mqttKeys map[string]json.RawMessage
+			if err := json.Unmarshal(v, &mqttKeys); err != nil {
+				return err
+			}
+			for mk := range mqttKeys {
+				// Changing the client id would make the feeder a different
+				// one for the service.
+				if strings.EqualFold(mk, "clientId") {
+					return errors.New("mqtt.clientId cannot be managed remotely")
+				}
+			}
+		}
+	}
+
+	d := json.NewDecoder(bytes.NewReader(raw))
+	d.DisallowUnknownFields()
+	return d.Decode(&Config{})
+}
+
+// ApplyRemote returns a copy of c with the settings of the config received
+// from the service applied over it. Settings not present in raw keep their
+// values from c.
+func ApplyRemote(c Config, raw json.RawMessage) (*Config, error) {
+	if err := CheckRemote(raw); err != nil {
+		return nil, err
+	}
+
+	// Copy through JSON, such that the nested settings of c are not changed.
+	data, err := json.Marshal(c)
+	if err != nil {
+		return nil, err
+	}
+	applied := &Config{}
+	if err := json.Unmarshal(data, applied); err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(raw, applied); err != nil {
+		return nil, err
+	}
+	return applied, nil
+}
+
+// ApplyHot copies the settings that can be changed while the feeder is
+// running from other. Changing the servo, the scale or the MQTT settings
+// requires reinitialising the servo, the scale or the connection to the
+// broker respectively. The schedule has to be passed to the scheduler.
+func (c *Config) ApplyHot(other Config) {
+	c.ServoPin = other.ServoPin
+	c.Servo = other.Servo
+	c.Scale = other.Scale
+	c.Mqtt = other.Mqtt
+	c.PortionMs = other.PortionMs
+	c.Calibration = other.Calibration
+	c.Dispense = other.Dispense
+	c.Limits = other.Limits
+	c.MissedFeedings = other.MissedFeedings
+	c.Schedule = other.Schedule
+}
+
+// RequiresRestart returns whether changing the running config to c requires
+// restarting the feeder, i.e. whether they differ in more than the settings
+// applied by ApplyHot.
+func RequiresRestart(running, c Config) bool {
+	running.ApplyHot(c)
+	return !reflect.DeepEqual(running, c)
+}
@@ -0,0 +1,117 @@
+package config
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/imilchev/rpi-feeder/pkg/limits"
+	"github.com/imilchev/rpi-feeder/pkg/mqtt/config"
+	"github.com/stretchr/testify/suite"
+)
+
+type RemoteSuite struct {
+	suite.Suite
+	config Config
+}
+
+func (suite *RemoteSuite) SetupTest() {
+	suite.config = Config{
+		DbPath:                   "./output",
+		ServoPin:                 17,
+		PortionMs:                1000,
+		FeedQueueSize:            defaultFeedQueueSize,
+		Schedule:                 []ScheduleEntry{{Time: "08:00", Portions: 1}},
+		Scale:                    &ScaleConfig{DataPin: 5, ClockPin: 6, CountsPerGram: 400, GramsPerPortion: 10},
+		TelemetryIntervalSeconds: defaultTelemetryIntervalSeconds,
+		Mqtt: config.MqttConfig{
+			Server:   "mqtt://localhost:1883",
+			ClientId: "dev1",
+		},
+	}
+}
+
+func (suite *RemoteSuite) TestCheckRemote() {
+	suite.NoError(CheckRemote(json.RawMessage(`{}`)))
+	suite.NoError(CheckRemote(json.RawMessage(`{"portionMs": 800, "mqtt": {"keepAlive": 30}}`)))
+}
+
+func (suite *RemoteSuite) TestCheckRemote_Invalid() {
+	for _, raw := range []string{
+		`null`,
+		`[]`,
+		`"portionMs"`,
+		`{"portionMs": "800"}`,
+		`{"unknown": 1}`,
+		`{"dispense": {"unknown": 1}}`,
+		`{"dbPath": "/tmp"}`,
+		`{"DBPATH": "/tmp"}`,
+		`{"schedule": []}`,
+		`{"mqtt": {"clientId": "dev2"}}`,
+	} {
+		suite.Error(CheckRemote(json.RawMessage(raw)), raw)
+	}
+}
+
+func (suite *RemoteSuite) TestApplyRemote() {
+	applied, err := ApplyRemote(suite.config, json.RawMessage(`{
+		"portionMs": 800,
+		"limits": {"maxPortionsPerDay": 6},
+		"scale": {"toleranceGrams": 2}
+	}`))
+	suite.NoError(err)
+
+	expected := suite.config
+	expected.PortionMs = 800
+	expected.Limits = limits.Limits{MaxPortionsPerDay: 6}
+	scale := *suite.config.Scale
+	scale.ToleranceGrams = 2
+	expected.Scale = &scale
+	suite.Equal(expected, *applied)
+
+	// The config the remote one is applied over is not changed.
+	suite.Equal(uint64(1000), suite.config.PortionMs)
+	suite.Zero(suite.config.Scale.ToleranceGrams)
+}
+
+func (suite *RemoteSuite) TestApplyRemote_Invalid() {
+	_, err := ApplyRemote(suite.config, json.RawMessage(`{"dbPath": "/tmp"}`))
+	suite.Error(err)
+}
+
+func (suite *RemoteSuite) TestRequiresRestart() {
+	suite.False(RequiresRestart(suite.config, suite.config))
+
+	c := suite.config
+	c.PortionMs = 800
+	c.Dispense = DispenseConfig{Strategy: ReverseDispense, ForwardMs: 500, ReverseMs: 100}
+	c.Limits = limits.Limits{MaxPortionsPerFeeding: 2}
+	c.MissedFeedings = MissedFeedingsConfig{Policy: FeedOnceMissedFeedings}
+	c.ServoPin = 18
+	c.Servo = ServoConfig{Driver: PwmServoDriver, PeriodUs: 20000, ClockwisePulseUs: 1000, CounterClockwisePulseUs: 2000}
+	c.Scale = nil
+	c.Mqtt.KeepAlive = 30
+	suite.False(RequiresRestart(suite.config, c))
+
+	c.FeedQueueSize = 10
+	suite.True(RequiresRestart(suite.config, c))
+
+	c = suite.config
+	c.FoodLevel = &FoodLevelConfig{TriggerPin: 23, EchoPin: 24, IntervalSeconds: 60, EmptyDistanceCm: 30}
+	suite.True(RequiresRestart(suite.config, c))
+}
+
+func (suite *RemoteSuite) TestApplyHot_Schedule() {
+	c := suite.config
+	c.Schedule = []ScheduleEntry{{Cron: "30 8 * * *", Portions: 2}}
+
+	running := suite.config
+	running.ApplyHot(c)
+	suite.Equal(c.Schedule, running.Schedule)
+
+	// The scheduler takes a new schedule without restarting.
+	suite.False(RequiresRestart(suite.config, c))
+}
+
+func TestRemoteSuite(t *testing.T) {
+	suite.Run(t, new(RemoteSuite))
+}
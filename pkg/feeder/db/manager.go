@@ -3,6 +3,7 @@ package db
 import (
 	"encoding/json"
 	"path/filepath"
+	"time"
 
 	"github.com/imilchev/rpi-feeder/pkg/feeder/db/model"
 	bolt "go.etcd.io/bbolt"
@@ -14,16 +15,29 @@ const (
 )
 
 var (
-	logBucketName = []byte("feeder-log")
+	logBucketName      = []byte("feeder-log")
+	scheduleBucketName = []byte("schedule")
+	commandBucketName  = []byte("commands")
+	historyBucketName  = []byte("feed-history")
+	configBucketName   = []byte("config")
+
+	scheduleKey        = []byte("current")
+	lastScheduleRunKey = []byte("last-run")
+	remoteConfigKey    = []byte("remote")
+	rejectedConfigKey  = []byte("rejected")
 )
 
 func initBuckets(db *bolt.DB) error {
 	zap.S().Debug("Initializing buckets...")
 	return db.Update(func(tx *bolt.Tx) error {
-		if _, err := tx.CreateBucketIfNotExists(logBucketName); err != nil {
-			return err
+		for _, b := range [][]byte{
+			logBucketName, scheduleBucketName, commandBucketName, historyBucketName,
+			configBucketName} {
+			if _, err := tx.CreateBucketIfNotExists(b); err != nil {
+				return err
+			}
+			zap.S().Debugf("Initialized bucket %s.", b)
 		}
-		zap.S().Debugf("Initialized bucket %s.", logBucketName)
 		return nil
 	})
 }
@@ -31,7 +45,67 @@ func initBuckets(db *bolt.DB) error {
 type DbManager interface {
 	AddFeedLog(model.FeedLog) error
 	ListFeedLog() ([]model.FeedLog, error)
+
+	// CountFeedLog returns the amount of feed logs that were not sent to the
+	// service yet.
+	CountFeedLog() (int, error)
 	CleanFeedLog() error
+
+	// SetSchedule persists the schedule, replacing the previous one.
+	SetSchedule(model.Schedule) error
+
+	// GetSchedule returns the persisted schedule or nil if no schedule has
+	// been persisted yet.
+	GetSchedule() (*model.Schedule, error)
+
+	// SetConfig persists the config received from the service, replacing the
+	// previous one.
+	SetConfig(model.Config) error
+
+	// GetConfig returns the persisted config received from the service or nil
+	// if no config has been persisted yet.
+	GetConfig() (*model.Config, error)
+
+	// SetRejectedConfig persists the latest config received from the service
+	// that could not be applied, replacing the previous one.
+	SetRejectedConfig(model.RejectedConfig) error
+
+	// GetRejectedConfig returns the persisted rejected config or nil if no
+	// config has been rejected yet.
+	GetRejectedConfig() (*model.RejectedConfig, error)
+
+	// SetLastScheduleRun persists the time of the last scheduled feeding.
+	SetLastScheduleRun(time.Time) error
+
+	// GetLastScheduleRun returns the time of the last scheduled feeding or
+	// nil if no feeding has been scheduled yet.
+	GetLastScheduleRun() (*time.Time, error)
+
+	// AddExecutedCommand records that the command with the specified id was
+	// executed at time t.
+	AddExecutedCommand(id string, t time.Time) error
+
+	// IsCommandExecuted returns whether the command with the specified id was
+	// executed.
+	IsCommandExecuted(id string) (bool, error)
+
+	// RemoveExecutedCommand removes the record of the command with the
+	// specified id, such that it can be executed again.
+	RemoveExecutedCommand(id string) error
+
+	// CleanExecutedCommands removes the commands executed before t.
+	CleanExecutedCommands(before time.Time) error
+
+	// AddFeedHistory records a served feeding. Unlike the feed log, the
+	// history is kept regardless of whether the feeding was reported to the
+	// service and is used for enforcing the feeding limits.
+	AddFeedHistory(model.FeedLog) error
+
+	// ListFeedHistory returns the feedings served after since.
+	ListFeedHistory(since time.Time) ([]model.FeedLog, error)
+
+	// CleanFeedHistory removes the feedings served before t.
+	CleanFeedHistory(before time.Time) error
 	Close()
 }
 
@@ -93,6 +167,15 @@ func (m *dbManager) ListFeedLog() ([]model.FeedLog, error) {
 	return logs, nil
 }
 
+func (m *dbManager) CountFeedLog() (int, error) {
+	var count int
+	err := m.db.View(func(tx *bolt.Tx) error {
+		count = tx.Bucket(logBucketName).Stats().KeyN
+		return nil
+	})
+	return count, err
+}
+
 func (m *dbManager) CleanFeedLog() error {
 	err := m.db.Update(func(tx *bolt.Tx) error {
 		if err := tx.DeleteBucket(logBucketName); err != nil {
@@ -105,6 +188,248 @@ func (m *dbManager) CleanFeedLog() error {
 	return err
 }
 
+func (m *dbManager) SetSchedule(schedule model.Schedule) error {
+	return m.db.Update(func(tx *bolt.Tx) error {
+		data, err := json.Marshal(schedule)
+		if err != nil {
+			return err
+		}
+		if err := tx.Bucket(scheduleBucketName).Put(scheduleKey, data); err != nil {
+			return err
+		}
+		zap.S().Debugf("Written schedule %+v.", schedule)
+		return nil
+	})
+}
+
+func (m *dbManager) GetSchedule() (*model.Schedule, error) {
+	var schedule *model.Schedule
+	err := m.db.View(func(tx *bolt.Tx) error {
+		data := tx.Bucket(scheduleBucketName).Get(scheduleKey)
+		if data == nil {
+			return nil
+		}
+
+		schedule = &model.Schedule{}
+		return json.Unmarshal(data, schedule)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return schedule, nil
+}
+
+func (m *dbManager) SetConfig(c model.Config) error {
+	return m.db.Update(func(tx *bolt.Tx) error {
+		data, err := json.Marshal(c)
+		if err != nil {
+			return err
+		}
+		if err := tx.Bucket(configBucketName).Put(remoteConfigKey, data); err != nil {
+			return err
+		}
+		zap.S().Debugf("Written config revision %d.", c.Revision)
+		return nil
+	})
+}
+
+func (m *dbManager) GetConfig() (*model.Config, error) {
+	var c *model.Config
+	err := m.db.View(func(tx *bolt.Tx) error {
+		data := tx.Bucket(configBucketName).Get(remoteConfigKey)
+		if data == nil {
+			return nil
+		}
+
+		c = &model.Config{}
+		return json.Unmarshal(data, c)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+func (m *dbManager) SetRejectedConfig(c model.RejectedConfig) error {
+	return m.db.Update(func(tx *bolt.Tx) error {
+		data, err := json.Marshal(c)
+		if err != nil {
+			return err
+		}
+		if err := tx.Bucket(configBucketName).Put(rejectedConfigKey, data); err != nil {
+			return err
+		}
+		zap.S().Debugf("Written rejected config revision %d.", c.Revision)
+		return nil
+	})
+}
+
+func (m *dbManager) GetRejectedConfig() (*model.RejectedConfig, error) {
+	var c *model.RejectedConfig
+	err := m.db.View(func(tx *bolt.Tx) error {
+		data := tx.Bucket(configBucketName).Get(rejectedConfigKey)
+		if data == nil {
+			return nil
+		}
+
+		c = &model.RejectedConfig{}
+		return json.Unmarshal(data, c)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+func (m *dbManager) SetLastScheduleRun(t time.Time) error {
+	return m.db.Update(func(tx *bolt.Tx) error {
+		data, err := json.Marshal(t.UTC())
+		if err != nil {
+			return err
+		}
+		return tx.Bucket(scheduleBucketName).Put(lastScheduleRunKey, data)
+	})
+}
+
+func (m *dbManager) GetLastScheduleRun() (*time.Time, error) {
+	var t *time.Time
+	err := m.db.View(func(tx *bolt.Tx) error {
+		data := tx.Bucket(scheduleBucketName).Get(lastScheduleRunKey)
+		if data == nil {
+			return nil
+		}
+
+		t = &time.Time{}
+		return json.Unmarshal(data, t)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+func (m *dbManager) AddExecutedCommand(id string, t time.Time) error {
+	return m.db.Update(func(tx *bolt.Tx) error {
+		data, err := json.Marshal(t.UTC())
+		if err != nil {
+			return err
+		}
+		return tx.Bucket(commandBucketName).Put([]byte(id), data)
+	})
+}
+
+func (m *dbManager) IsCommandExecuted(id string) (bool, error) {
+	var executed bool
+	err := m.db.View(func(tx *bolt.Tx) error {
+		executed = tx.Bucket(commandBucketName).Get([]byte(id)) != nil
+		return nil
+	})
+	return executed, err
+}
+
+func (m *dbManager) RemoveExecutedCommand(id string) error {
+	return m.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(commandBucketName).Delete([]byte(id))
+	})
+}
+
+func (m *dbManager) CleanExecutedCommands(before time.Time) error {
+	return m.db.Update(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket(commandBucketName)
+
+		// Collect the keys first, since the bucket must not be modified
+		// while iterating over it.
+		var expired [][]byte
+		err := bucket.ForEach(func(k, v []byte) error {
+			var t time.Time
+			if err := json.Unmarshal(v, &t); err != nil {
+				return err
+			}
+			if t.Before(before) {
+				expired = append(expired, k)
+			}
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+
+		for _, k := range expired {
+			if err := bucket.Delete(k); err != nil {
+				return err
+			}
+		}
+		zap.S().Debugf("Removed %d executed commands.", len(expired))
+		return nil
+	})
+}
+
+func (m *dbManager) AddFeedHistory(log model.FeedLog) error {
+	return m.db.Update(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket(historyBucketName)
+		id, err := bucket.NextSequence()
+		if err != nil {
+			return err
+		}
+		log.Id = int(id)
+		data, err := json.Marshal(log)
+		if err != nil {
+			return err
+		}
+		return bucket.Put(itob(log.Id), data)
+	})
+}
+
+func (m *dbManager) ListFeedHistory(since time.Time) ([]model.FeedLog, error) {
+	var logs []model.FeedLog
+	err := m.db.View(func(tx *bolt.Tx) error {
+		return tx.Bucket(historyBucketName).ForEach(func(k, v []byte) error {
+			l := &model.FeedLog{}
+			if err := json.Unmarshal(v, l); err != nil {
+				return err
+			}
+			if l.Timestamp.After(since) {
+				logs = append(logs, *l)
+			}
+			return nil
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return logs, nil
+}
+
+func (m *dbManager) CleanFeedHistory(before time.Time) error {
+	return m.db.Update(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket(historyBucketName)
+
+		// Collect the keys first, since the bucket must not be modified
+		// while iterating over it.
+		var expired [][]byte
+		err := bucket.ForEach(func(k, v []byte) error {
+			l := &model.FeedLog{}
+			if err := json.Unmarshal(v, l); err != nil {
+				return err
+			}
+			if l.Timestamp.Before(before) {
+				expired = append(expired, k)
+			}
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+
+		for _, k := range expired {
+			if err := bucket.Delete(k); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
 func (m *dbManager) Close() {
 	if err := m.db.Close(); err != nil {
 		zap.S().Errorf("Failed to close db %s. %+v", m.path, err)
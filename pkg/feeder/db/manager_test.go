@@ -2,6 +2,7 @@ package db
 
 import (
 	"encoding/json"
+	"fmt"
 	"math/rand"
 	"os"
 	"path/filepath"
@@ -34,6 +35,7 @@ func (suite *DbManagerSuite) AfterTest(suiteName, testName string) {
 func (suite *DbManagerSuite) TestAddFeedLog() {
 	testLog := model.FeedLog{
 		Portions:  uint(rand.Intn(100)),
+		Grams:     float64(rand.Intn(1000)) / 10,
 		Timestamp: time.Now(),
 	}
 	suite.NoError(suite.db.AddFeedLog(testLog))
@@ -51,6 +53,7 @@ func (suite *DbManagerSuite) TestAddFeedLog() {
 
 	suite.Equal(1, len(logs))
 	suite.Equal(testLog.Portions, logs[0].Portions)
+	suite.Equal(testLog.Grams, logs[0].Grams)
 	suite.Equal(testLog.Timestamp.UTC(), logs[0].Timestamp)
 }
 
@@ -86,6 +89,170 @@ func (suite *DbManagerSuite) TestListFeedLog() {
 	suite.EqualValues(testLogs, logs)
 }
 
+func (suite *DbManagerSuite) TestCountFeedLog() {
+	count, err := suite.db.CountFeedLog()
+	suite.NoError(err)
+	suite.Equal(0, count)
+
+	logsCount := rand.Intn(10) + 1
+	for i := 0; i < logsCount; i++ {
+		suite.NoError(suite.db.AddFeedLog(model.FeedLog{
+			Portions:  uint(rand.Intn(100)),
+			Timestamp: time.Now().UTC(),
+		}))
+	}
+
+	count, err = suite.db.CountFeedLog()
+	suite.NoError(err)
+	suite.Equal(logsCount, count)
+
+	suite.NoError(suite.db.CleanFeedLog())
+	count, err = suite.db.CountFeedLog()
+	suite.NoError(err)
+	suite.Equal(0, count)
+}
+
+func (suite *DbManagerSuite) TestSetSchedule() {
+	schedule := model.Schedule{
+		Entries: []model.ScheduleEntry{
+			{Time: "08:00", Portions: uint(rand.Intn(10) + 1)},
+			{Cron: "0 18 * * *", Portions: uint(rand.Intn(10) + 1)},
+		},
+		UpdatedAt: time.Now().UTC(),
+	}
+	suite.NoError(suite.db.SetSchedule(schedule))
+
+	var s model.Schedule
+	suite.NoError(suite.db.db.View(func(tx *bolt.Tx) error {
+		data := tx.Bucket(scheduleBucketName).Get(scheduleKey)
+		suite.NotNil(data)
+		return json.Unmarshal(data, &s)
+	}))
+	suite.Equal(schedule, s)
+}
+
+func (suite *DbManagerSuite) TestGetSchedule() {
+	schedule, err := suite.db.GetSchedule()
+	suite.NoError(err)
+	suite.Nil(schedule)
+
+	expected := model.Schedule{
+		Entries:   []model.ScheduleEntry{{Time: "08:00", Portions: 2}},
+		UpdatedAt: time.Now().UTC(),
+	}
+	suite.NoError(suite.db.SetSchedule(expected))
+
+	schedule, err = suite.db.GetSchedule()
+	suite.NoError(err)
+	suite.Equal(expected, *schedule)
+}
+
+func (suite *DbManagerSuite) TestConfig() {
+	c, err := suite.db.GetConfig()
+	suite.NoError(err)
+	suite.Nil(c)
+
+	expected := model.Config{
+		Revision:  rand.Intn(100) + 1,
+		Config:    json.RawMessage(`{"portionMs":800}`),
+		UpdatedAt: time.Now().UTC(),
+	}
+	suite.NoError(suite.db.SetConfig(expected))
+
+	c, err = suite.db.GetConfig()
+	suite.NoError(err)
+	suite.Equal(expected, *c)
+}
+
+func (suite *DbManagerSuite) TestRejectedConfig() {
+	c, err := suite.db.GetRejectedConfig()
+	suite.NoError(err)
+	suite.Nil(c)
+
+	expected := model.RejectedConfig{
+		Revision:   rand.Intn(100) + 1,
+		Error:      "failed to connect",
+		RejectedAt: time.Now().UTC(),
+	}
+	suite.NoError(suite.db.SetRejectedConfig(expected))
+
+	c, err = suite.db.GetRejectedConfig()
+	suite.NoError(err)
+	suite.Equal(expected, *c)
+
+	// The config in use is not affected.
+	applied, err := suite.db.GetConfig()
+	suite.NoError(err)
+	suite.Nil(applied)
+}
+
+func (suite *DbManagerSuite) TestLastScheduleRun() {
+	t, err := suite.db.GetLastScheduleRun()
+	suite.NoError(err)
+	suite.Nil(t)
+
+	expected := time.Now().UTC()
+	suite.NoError(suite.db.SetLastScheduleRun(expected))
+
+	t, err = suite.db.GetLastScheduleRun()
+	suite.NoError(err)
+	suite.Equal(expected, *t)
+}
+
+func (suite *DbManagerSuite) TestExecutedCommands() {
+	id := fmt.Sprintf("command-%d", rand.Int())
+	executed, err := suite.db.IsCommandExecuted(id)
+	suite.NoError(err)
+	suite.False(executed)
+
+	suite.NoError(suite.db.AddExecutedCommand(id, time.Now()))
+
+	executed, err = suite.db.IsCommandExecuted(id)
+	suite.NoError(err)
+	suite.True(executed)
+
+	suite.NoError(suite.db.RemoveExecutedCommand(id))
+
+	executed, err = suite.db.IsCommandExecuted(id)
+	suite.NoError(err)
+	suite.False(executed)
+}
+
+func (suite *DbManagerSuite) TestCleanExecutedCommands() {
+	now := time.Now()
+	suite.NoError(suite.db.AddExecutedCommand("old", now.Add(-48*time.Hour)))
+	suite.NoError(suite.db.AddExecutedCommand("new", now))
+
+	suite.NoError(suite.db.CleanExecutedCommands(now.Add(-24 * time.Hour)))
+
+	executed, err := suite.db.IsCommandExecuted("old")
+	suite.NoError(err)
+	suite.False(executed)
+
+	executed, err = suite.db.IsCommandExecuted("new")
+	suite.NoError(err)
+	suite.True(executed)
+}
+
+func (suite *DbManagerSuite) TestFeedHistory() {
+	now := time.Now()
+	old := model.FeedLog{Portions: 1, Timestamp: now.Add(-48 * time.Hour)}
+	recent := model.FeedLog{Portions: 2, Timestamp: now.Add(-time.Hour)}
+	suite.NoError(suite.db.AddFeedHistory(old))
+	suite.NoError(suite.db.AddFeedHistory(recent))
+
+	logs, err := suite.db.ListFeedHistory(now.Add(-24 * time.Hour))
+	suite.NoError(err)
+	suite.Equal(1, len(logs))
+	suite.Equal(recent.Portions, logs[0].Portions)
+
+	suite.NoError(suite.db.CleanFeedHistory(now.Add(-24 * time.Hour)))
+	logs, err = suite.db.ListFeedHistory(time.Time{})
+	suite.NoError(err)
+	suite.Equal(1, len(logs))
+	suite.Equal(recent.Portions, logs[0].Portions)
+}
+
 func TestDbManagerSuite(t *testing.T) {
 	suite.Run(t, new(DbManagerSuite))
 }
@@ -0,0 +1,21 @@
+package model
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// Config is the config received from the service.
+type Config struct {
+	Revision  int             `json:"revision"`
+	Config    json.RawMessage `json:"config"`
+	UpdatedAt time.Time       `json:"updatedAt"`
+}
+
+// RejectedConfig is the latest config received from the service that could
+// not be applied.
+type RejectedConfig struct {
+	Revision   int       `json:"revision"`
+	Error      string    `json:"error"`
+	RejectedAt time.Time `json:"rejectedAt"`
+}
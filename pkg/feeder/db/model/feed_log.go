@@ -5,5 +5,6 @@ import "time"
 type FeedLog struct {
 	Id        int       `json:"id"`
 	Portions  uint      `json:"portions"`
+	Grams     float64   `json:"grams"`
 	Timestamp time.Time `json:"timestamp"`
 }
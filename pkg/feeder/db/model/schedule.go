@@ -0,0 +1,14 @@
+package model
+
+import "time"
+
+type Schedule struct {
+	Entries   []ScheduleEntry `json:"entries"`
+	UpdatedAt time.Time       `json:"updatedAt"`
+}
+
+type ScheduleEntry struct {
+	Cron     string `json:"cron"`
+	Time     string `json:"time"`
+	Portions uint   `json:"portions"`
+}
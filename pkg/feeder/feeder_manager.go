@@ -1,30 +1,118 @@
 package feeder
 
 import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
 	"os"
 	"os/signal"
+	"sync"
 	"syscall"
 	"time"
 
 	"github.com/imilchev/rpi-feeder/pkg/feeder/config"
 	"github.com/imilchev/rpi-feeder/pkg/feeder/db"
 	dbm "github.com/imilchev/rpi-feeder/pkg/feeder/db/model"
+	"github.com/imilchev/rpi-feeder/pkg/feeder/gpio"
 	"github.com/imilchev/rpi-feeder/pkg/feeder/mqtt"
+	"github.com/imilchev/rpi-feeder/pkg/feeder/queue"
+	"github.com/imilchev/rpi-feeder/pkg/feeder/scale"
+	"github.com/imilchev/rpi-feeder/pkg/feeder/scheduler"
+	"github.com/imilchev/rpi-feeder/pkg/feeder/sensor"
 	"github.com/imilchev/rpi-feeder/pkg/feeder/servo"
+	"github.com/imilchev/rpi-feeder/pkg/feeder/telemetry"
+	"github.com/imilchev/rpi-feeder/pkg/feeder/update"
+	"github.com/imilchev/rpi-feeder/pkg/limits"
 	"github.com/imilchev/rpi-feeder/pkg/mqtt/model"
 	"github.com/imilchev/rpi-feeder/pkg/utils"
 	"go.uber.org/zap"
 )
 
+// executedCommandsRetention is how long the ids of executed commands are kept
+// for detecting duplicate deliveries.
+const executedCommandsRetention = 7 * 24 * time.Hour
+
+// maxWeighedPortionsFactor limits the portions served by a weighed feeding to
+// this many times the requested portions, such that an empty hopper does not
+// keep the servo running.
+const maxWeighedPortionsFactor = 2
+
+// maxReportedMissedFeedings limits the missed feedings reported to the
+// service, such that a long outage does not result in a huge message. Only
+// the most recent ones are reported and can be caught up on.
+const maxReportedMissedFeedings = 100
+
 type FeederManager struct {
-	config          *config.Config
+	// configPath and overrides are where the config is read from when it is
+	// reloaded. See config.ReadConfig.
+	configPath string
+	overrides  []string
+
+	// config is the running config. It is fileConfig with the config received
+	// from the service applied over it, whose revision is configRevision.
+	config         *config.Config
+	fileConfig     *config.Config
+	configRevision int
+
+	// configPendingRestart is set if some settings of the running config
+	// only take effect once the feeder is restarted.
+	configPendingRestart bool
+
+	// rejectedConfig is the latest config received from the service that
+	// could not be applied, or nil. It is ignored when it is received again.
+	rejectedConfig *dbm.RejectedConfig
+
 	dbManager       db.DbManager
+	gpio            gpio.Gpio
 	servoController servo.ServoController
-	mqttManager     mqtt.MqttManager
+	dispenser       servo.Dispenser
+
+	// scale and weigher weigh the served food. They are nil if no scale is
+	// configured.
+	scale     scale.Scale
+	weigher   scale.WeighingDispenser
+	scheduler scheduler.Scheduler
+	queue     queue.Queue
+
+	// mqttManager is replaced when the MQTT settings change, so it is only
+	// accessed through mqtt. previousReconnects counts the reconnects of the
+	// managers it replaced.
+	mqttMu             sync.RWMutex
+	mqttManager        mqtt.MqttManager
+	previousReconnects uint
+
+	// levelMonitor measures the food level. It is nil if no sensor is
+	// configured.
+	levelMonitor sensor.Monitor
+	telemetry    telemetry.Reporter
+	updater      update.Updater
+
+	// updateResult is the result of the update resumed on start. It is sent
+	// once connected to the broker.
+	updateResult *model.UpdateResultMessage
+
+	// flushMu guards flushing the feed log, which happens whenever the
+	// connection to the broker is up.
+	flushMu sync.Mutex
+
+	// restartChan is closed once a new version was installed or the previous
+	// version was restored, to restart the feeder with it.
+	restartChan chan struct{}
+	restartOnce sync.Once
+
+	// commandsMu guards checking and recording executed commands, as feed
+	// commands are handled concurrently.
+	commandsMu sync.Mutex
+
+	// cancelFeeding cancels the feeding in progress. It is nil if no feeding
+	// is in progress.
+	feedingMu     sync.Mutex
+	cancelFeeding context.CancelFunc
 }
 
-func NewFeederManager(configPath string) (*FeederManager, error) {
-	config, err := config.ReadConfig(configPath)
+func NewFeederManager(configPath string, overrides []string) (*FeederManager, error) {
+	config, err := config.ReadConfig(configPath, overrides)
 	if err != nil {
 		return nil, err
 	}
@@ -38,37 +126,137 @@ func NewFeederManager(configPath string) (*FeederManager, error) {
 		return nil, err
 	}
 
-	servoController, err := servo.NewServoController(config.ServoPin)
+	// The config received from the service is applied over the config file.
+	fileConfig := config
+	config, configRevision := applyStoredConfig(fileConfig, dbManager)
+	rejectedConfig, err := dbManager.GetRejectedConfig()
+	if err != nil {
+		zap.S().Errorf("Failed to read rejected config. %v", err)
+	}
+
+	// A new version that was just installed must connect to the broker in
+	// time, otherwise the previous version is restored.
+	updater, err := update.NewUpdater(config.DbPath)
+	if err != nil {
+		return nil, err
+	}
+	updateResult, err := updater.Resume()
+	if err != nil {
+		zap.S().Errorf("Failed to resume software update. %v", err)
+	}
+
+	g, err := gpio.Open()
+	if err != nil {
+		return nil, err
+	}
+
+	servoController, err := servo.NewServoController(g, config.ServoPin, config.Servo)
 	if err != nil {
 		return nil, err
 	}
 
 	fm := &FeederManager{
+		configPath:      configPath,
+		overrides:       overrides,
 		config:          config,
+		fileConfig:      fileConfig,
+		configRevision:  configRevision,
+		rejectedConfig:  rejectedConfig,
 		dbManager:       dbManager,
+		gpio:            g,
 		servoController: servoController,
+		dispenser:       servo.NewDispenser(servoController, config.PortionMs, config.Dispense),
+		updater:         updater,
+		updateResult:    updateResult,
+		restartChan:     make(chan struct{}),
+	}
+
+	if config.Scale != nil {
+		fm.scale = scale.NewHx711(g, *config.Scale)
+		fm.weigher = scale.NewWeighingDispenser(fm.dispenser, fm.scale, config.Scale.ToleranceGrams)
 	}
 
-	fm.mqttManager, err = mqtt.NewMqttManager(
-		config.Mqtt,
-		func(msg model.FeedMessage) error { return fm.feed(msg.Portions) })
+	if config.FoodLevel != nil {
+		s := sensor.NewHcsr04(g, config.FoodLevel.TriggerPin, config.FoodLevel.EchoPin)
+		fm.levelMonitor = sensor.NewMonitor(s, *config.FoodLevel, fm.sendFoodLevel)
+	}
+
+	fm.queue = queue.NewQueue(config.FeedQueueSize, fm.sendQueueDepth)
+
+	schedule := config.Schedule
+	storedSchedule, err := dbManager.GetSchedule()
 	if err != nil {
 		return nil, err
 	}
+	if storedSchedule != nil {
+		zap.S().Infof(
+			"Using schedule received from the service on %s.", storedSchedule.UpdatedAt)
+		schedule = scheduleFromDb(*storedSchedule)
+	}
 
+	fm.scheduler, err = scheduler.NewScheduler(schedule, fm.scheduledFeed)
+	if err != nil {
+		return nil, err
+	}
+
+	// Connecting is retried in the background, such that the feeder serves
+	// its schedule even if the broker is unreachable.
+	fm.mqttManager, err = fm.connectMqtt(config.Mqtt)
+	if err != nil {
+		return nil, err
+	}
+
+	if configRevision != 0 || rejectedConfig != nil {
+		// The revision is also part of the status sent once connected, so
+		// failing to send it now is fine.
+		fm.mqttManager.SendConfigStatus(fm.configStatus()) //nolint
+	}
+
+	fm.telemetry = telemetry.NewReporter(*config, telemetry.Sources{
+		UnflushedFeedLogs: dbManager.CountFeedLog,
+		MqttReconnects:    fm.mqttReconnects,
+	}, fm.sendTelemetry)
 	return fm, nil
 }
 
 func (fm *FeederManager) Start() error {
 	interrupt := make(chan os.Signal, 1)
 	signal.Notify(interrupt, os.Interrupt, syscall.SIGTERM)
+	hangup := make(chan os.Signal, 1)
+	signal.Notify(hangup, syscall.SIGHUP)
 
 	zap.S().Info("Feeder started.")
+	fm.queue.Start()
 
-	if err := fm.flushFeedLog(); err != nil {
-		zap.S().Error("Failed to flush feed log. %v", err)
+	if err := fm.dbManager.CleanExecutedCommands(
+		time.Now().Add(-executedCommandsRetention)); err != nil {
+		zap.S().Errorf("Failed to clean executed commands. %v", err)
+	}
+
+	missed, err := fm.catchUpMissedFeedings()
+	if err != nil {
+		zap.S().Errorf("Failed to catch up on missed feedings. %v", err)
 	}
 
+	fm.scheduler.Start()
+	if fm.levelMonitor != nil {
+		fm.levelMonitor.Start()
+	}
+	fm.telemetry.Start()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go fm.whenConnected(ctx, missed)
+	go func() {
+		// The update was not confirmed in time and the previous version was
+		// restored.
+		select {
+		case <-fm.updater.RolledBack():
+			fm.restart()
+		case <-ctx.Done():
+		}
+	}()
+
 	// fm.servoController.RotateClockwise()
 	// time.Sleep(3 * time.Second)
 	// zap.S().Debugf("Sending stop signal...")
@@ -76,24 +264,99 @@ func (fm *FeederManager) Start() error {
 
 	// fm.servoController.RotateCounterClockwise()
 
-	//for {
-	//select {
-	//case <-interrupt:
-	<-interrupt
+	restart := false
+loop:
+	for {
+		select {
+		case <-hangup:
+			// Reloading waits for the feeding in progress, so it does not
+			// block shutting down.
+			go func() {
+				if err := fm.reloadConfig(); err != nil {
+					zap.S().Errorf("Failed to reload config. %v", err)
+				}
+			}()
+		case <-interrupt:
+			break loop
+		case <-fm.restartChan:
+			restart = true
+			break loop
+		}
+	}
+	signal.Stop(hangup)
 	zap.S().Info("Shutting down...")
+	cancel()
 
+	fm.telemetry.Stop()
+	if fm.levelMonitor != nil {
+		fm.levelMonitor.Stop()
+	}
+	fm.scheduler.Stop()
+	fm.queue.Stop()
 	fm.servoController.Stop()
 	fm.servoController.Close()
+	if err := fm.gpio.Close(); err != nil {
+		zap.S().Errorf("Failed to close GPIO library. %+v", err)
+	}
 	fm.dbManager.Close()
-	if err := fm.mqttManager.Stop(); err != nil {
+	if err := fm.mqtt().Stop(); err != nil {
 		zap.S().Errorf("Failed to stop MQTT manager. %+v", err)
 	}
 
+	if restart {
+		return fm.updater.Restart()
+	}
 	zap.S().Info("Exit")
 	return nil
 }
 
-func (fm *FeederManager) flushFeedLog() error {
+// whenConnected waits for the first connection to the broker. It then
+// confirms the update resumed on start and reports what happened while the
+// feeder was not running. The update is only confirmed once connected, such
+// that a version that cannot connect is rolled back.
+func (fm *FeederManager) whenConnected(
+	ctx context.Context, missed *model.MissedFeedingCollectionMessage) {
+	for {
+		if err := fm.mqtt().AwaitConnection(ctx); err == nil {
+			break
+		}
+		// The manager was stopped because the MQTT settings changed, so
+		// wait for the one replacing it.
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(time.Second):
+		}
+	}
+
+	updateResult := fm.updateResult
+	if confirmed, err := fm.updater.Confirm(); err != nil {
+		zap.S().Errorf("Failed to confirm software update. %v", err)
+	} else if confirmed != nil {
+		updateResult = confirmed
+	}
+	if updateResult != nil {
+		fm.sendUpdateResult(*updateResult)
+	}
+
+	if missed != nil {
+		if err := fm.mqtt().SendMissedFeedings(*missed); err != nil {
+			zap.S().Errorf("Failed to send missed feedings. %v", err)
+		}
+	}
+}
+
+// mqttConnected sends the feed logs stored while the broker was unreachable.
+func (fm *FeederManager) mqttConnected(m mqtt.MqttManager) {
+	if err := fm.flushFeedLog(m); err != nil {
+		zap.S().Errorf("Failed to flush feed log. %v", err)
+	}
+}
+
+func (fm *FeederManager) flushFeedLog(m mqtt.MqttManager) error {
+	fm.flushMu.Lock()
+	defer fm.flushMu.Unlock()
+
 	feedLog, err := fm.dbManager.ListFeedLog()
 	if err != nil {
 		zap.S().Error("Failed to list feed log.")
@@ -108,10 +371,10 @@ func (fm *FeederManager) flushFeedLog() error {
 	zap.S().Infof("Found %d feed logs to be flushed.", len(feedLog))
 	msg := model.FeedLogCollectionMessage{}
 	for _, f := range feedLog {
-		fmsg := model.FeedLogMessage{Portions: f.Portions, Timestamp: f.Timestamp}
+		fmsg := model.FeedLogMessage{Portions: f.Portions, Grams: f.Grams, Timestamp: f.Timestamp}
 		msg.Value = append(msg.Value, fmsg)
 	}
-	if err := fm.mqttManager.SendFeedLog(msg); err != nil {
+	if err := m.SendFeedLog(msg); err != nil {
 		zap.S().Error("Failed to send feed log.")
 		return err
 	}
@@ -119,30 +382,382 @@ func (fm *FeederManager) flushFeedLog() error {
 	return fm.dbManager.CleanFeedLog()
 }
 
-func (fm *FeederManager) feed(portions uint) error {
+// catchUpMissedFeedings finds the scheduled feedings that were due while the
+// feeder was not running and serves them according to the configured policy.
+// Returns the report of the missed feedings for the service, or nil if none
+// were missed.
+func (fm *FeederManager) catchUpMissedFeedings() (*model.MissedFeedingCollectionMessage, error) {
+	now := time.Now()
+	lastRun, err := fm.dbManager.GetLastScheduleRun()
+	if err != nil {
+		return nil, err
+	}
+	if err := fm.dbManager.SetLastScheduleRun(now); err != nil {
+		return nil, err
+	}
+	if lastRun == nil {
+		zap.S().Debug("No previous scheduled feeding found.")
+		return nil, nil
+	}
+
+	missed, total := fm.scheduler.MissedRuns(*lastRun, now, maxReportedMissedFeedings)
+	if total == 0 {
+		zap.S().Info("No scheduled feedings were missed.")
+		return nil, nil
+	}
+	zap.S().Warnf("Missed %d scheduled feedings since %s.", total, lastRun)
+
+	var catchUp int
+	switch fm.config.MissedFeedings.Policy {
+	case config.FeedOnceMissedFeedings:
+		catchUp = 1
+	case config.FeedAllMissedFeedings:
+		catchUp = int(fm.config.MissedFeedings.MaxCatchUp)
+	}
+	if catchUp > len(missed) {
+		catchUp = len(missed)
+	}
+
+	msg := model.MissedFeedingCollectionMessage{Total: uint(total)}
+	for i, r := range missed {
+		// The most recent feedings are the ones that are caught up on.
+		caughtUp := i >= len(missed)-catchUp
+		if caughtUp {
+			zap.S().Infof("Catching up on feeding scheduled at %s.", r.At)
+			if served, err := fm.enqueueFeed(r.Portions); err != nil {
+				zap.S().Errorf("Failed to catch up on feeding scheduled at %s. %v", r.At, err)
+				fm.sendScheduledFeedFailure(r.At, served, err)
+				caughtUp = false
+			}
+		}
+		msg.Value = append(msg.Value, model.MissedFeedingMessage{
+			Portions:    r.Portions,
+			ScheduledAt: r.At.UTC(),
+			CaughtUp:    caughtUp,
+		})
+	}
+	return &msg, nil
+}
+
+// handleFeedCommand executes a feed command received from the service. A
+// command is executed at most once, even if it is delivered multiple times.
+func (fm *FeederManager) handleFeedCommand(msg model.FeedMessage) (uint, error) {
+	if msg.CommandId == "" {
+		return fm.enqueueFeed(msg.Portions)
+	}
+
+	if err := fm.recordCommand(msg.CommandId); err != nil {
+		return 0, err
+	}
+	served, err := fm.enqueueFeed(msg.Portions)
+	if errors.Is(err, queue.ErrQueueFull) || errors.Is(err, queue.ErrQueueStopped) {
+		// The feeding never ran, so a redelivery of the command must not be
+		// dropped as a duplicate.
+		if err := fm.dbManager.RemoveExecutedCommand(msg.CommandId); err != nil {
+			zap.S().Errorf("Failed to remove executed command %s. %v", msg.CommandId, err)
+		}
+	}
+	return served, err
+}
+
+// cancel stops the feeding in progress.
+func (fm *FeederManager) cancel(msg model.CancelMessage) error {
+	fm.feedingMu.Lock()
+	defer fm.feedingMu.Unlock()
+
+	if fm.cancelFeeding == nil {
+		return errors.New("no feeding is in progress")
+	}
+	zap.S().Info("Cancelling feeding in progress...")
+	fm.cancelFeeding()
+	return nil
+}
+
+// recordCommand records the command as executed. Returns
+// mqtt.ErrDuplicateCommand if it was executed already.
+func (fm *FeederManager) recordCommand(commandId string) error {
+	fm.commandsMu.Lock()
+	defer fm.commandsMu.Unlock()
+
+	executed, err := fm.dbManager.IsCommandExecuted(commandId)
+	if err != nil {
+		return err
+	}
+	if executed {
+		return mqtt.ErrDuplicateCommand
+	}
+
+	// The command is recorded before feeding, such that a restart in the
+	// middle of feeding does not cause the command to be executed again.
+	return fm.dbManager.AddExecutedCommand(commandId, time.Now())
+}
+
+func (fm *FeederManager) scheduledFeed(run scheduler.Run) error {
+	if err := fm.dbManager.SetLastScheduleRun(run.At); err != nil {
+		zap.S().Errorf("Failed to persist last scheduled feeding. %v", err)
+	}
+	served, err := fm.enqueueFeed(run.Portions)
+	if err != nil {
+		fm.sendScheduledFeedFailure(run.At, served, err)
+	}
+	return err
+}
+
+// sendScheduledFeedFailure reports a failed scheduled or caught up feeding to
+// the service, such that it can raise an alert.
+func (fm *FeederManager) sendScheduledFeedFailure(scheduledAt time.Time, served uint, err error) {
+	msg := mqtt.NewFeedResult("", served, err)
+	scheduledAt = scheduledAt.UTC()
+	msg.ScheduledAt = &scheduledAt
+	if err := fm.mqtt().SendFeedResult(msg); err != nil {
+		zap.S().Errorf("Failed to send result of feeding scheduled at %s. %v", scheduledAt, err)
+	}
+}
+
+// enqueueFeed adds the feeding to the feed queue and waits until it is
+// served. All feedings go through the queue, such that the servo is never
+// driven by more than one feeding at a time. Returns the amount of portions
+// that were actually served.
+func (fm *FeederManager) enqueueFeed(portions uint) (uint, error) {
+	var served uint
+	result, err := fm.queue.Enqueue(func() (err error) {
+		served, err = fm.feed(portions)
+		return err
+	})
+	if err != nil {
+		return 0, err
+	}
+	err = <-result
+	return served, err
+}
+
+func (fm *FeederManager) sendQueueDepth(depth uint) {
+	if err := fm.mqtt().SendStatus(depth); err != nil {
+		zap.S().Warnf("Failed to send queue depth %d. %v", depth, err)
+	}
+}
+
+func (fm *FeederManager) sendFoodLevel(r sensor.Reading) {
+	msg := model.FoodLevelMessage{
+		Level:      r.Level,
+		DistanceCm: r.DistanceCm,
+		Timestamp:  r.At,
+	}
+	if err := fm.mqtt().SendFoodLevel(msg); err != nil {
+		zap.S().Warnf("Failed to send food level. %v", err)
+	}
+}
+
+// update installs the version requested by the service and restarts the
+// feeder with it. Installing goes through the feed queue, such that the
+// feeder is not restarted in the middle of a feeding. The new version reports
+// the result once it is connected, so only failures are reported here.
+func (fm *FeederManager) update(msg model.UpdateMessage) error {
+	done, err := fm.queue.Enqueue(func() error {
+		return fm.updater.Install(msg)
+	})
+	if err == nil {
+		err = <-done
+	}
+	if err != nil {
+		fm.sendUpdateResult(model.UpdateResultMessage{
+			Version:   msg.Version,
+			Error:     err.Error(),
+			Timestamp: time.Now().UTC(),
+		})
+		return err
+	}
+
+	fm.restart()
+	return nil
+}
+
+// restart shuts the feeder down and starts the installed executable.
+func (fm *FeederManager) restart() {
+	fm.restartOnce.Do(func() { close(fm.restartChan) })
+}
+
+func (fm *FeederManager) sendUpdateResult(msg model.UpdateResultMessage) {
+	if err := fm.mqtt().SendUpdateResult(msg); err != nil {
+		zap.S().Warnf("Failed to send update result. %v", err)
+	}
+}
+
+func (fm *FeederManager) sendTelemetry(msg model.TelemetryMessage) {
+	if err := fm.mqtt().SendTelemetry(msg); err != nil {
+		zap.S().Warnf("Failed to send telemetry. %v", err)
+	}
+}
+
+// checkLimits returns an error wrapping mqtt.ErrFeedRejected if serving the
+// portions now would exceed the configured limits. Otherwise returns the
+// maximum amount of portions the feeding may serve.
+func (fm *FeederManager) checkLimits(portions uint) (uint, error) {
+	now := time.Now()
+	history, err := fm.dbManager.ListFeedHistory(now.Add(-limits.Window))
+	if err != nil {
+		return 0, err
+	}
+
+	var feedings []limits.Feeding
+	for _, h := range history {
+		feedings = append(feedings, limits.Feeding{Portions: h.Portions, At: h.Timestamp})
+	}
+	if err := fm.config.Limits.Check(portions, feedings, now); err != nil {
+		return 0, fmt.Errorf("%w: %v", mqtt.ErrFeedRejected, err)
+	}
+	return fm.config.Limits.Remaining(feedings, now), nil
+}
+
+// feed serves the portions and returns the amount of portions that were
+// actually served. The feeding can be stopped mid-way with cancel, in which
+// case only the portions served until then are logged.
+func (fm *FeederManager) feed(portions uint) (uint, error) {
+	maxPortions, err := fm.checkLimits(portions)
+	if err != nil {
+		return 0, err
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	fm.feedingMu.Lock()
+	fm.cancelFeeding = cancel
+	fm.feedingMu.Unlock()
+	defer func() {
+		fm.feedingMu.Lock()
+		fm.cancelFeeding = nil
+		fm.feedingMu.Unlock()
+		cancel()
+	}()
+
 	zap.S().Debugf("Serving %d portions...", portions)
-	fm.servoController.RotateClockwise()
+	served, grams, err := fm.dispense(ctx, portions, maxPortions)
+	zap.S().Infof("Served %d portions.", served)
 
-	for i := uint(0); i < portions; i++ {
-		time.Sleep(time.Duration(fm.config.PortionMs) * time.Millisecond)
+	if served > 0 {
+		if err := fm.logFeeding(served, grams); err != nil {
+			return served, err
+		}
+	}
+	if err != nil {
+		return served, err
+	}
+	// A weighed feeding may reach its target with fewer portions, so only a
+	// cancelled feeding is incomplete.
+	if served < portions && ctx.Err() != nil {
+		return served, fmt.Errorf(
+			"%w after %d of %d portions", mqtt.ErrFeedCancelled, served, portions)
+	}
+	return served, nil
+}
+
+// dispense serves the portions, by weight if a scale is configured. A weighed
+// feeding serves at most maxPortions, such that it stays within the limits.
+// Returns the portions the dispenser served and the grams. The grams are 0 if
+// the feeding was not weighed completely, e.g. because the scale could not be
+// read, in which case the remaining portions are served by the dispenser
+// alone.
+func (fm *FeederManager) dispense(ctx context.Context, portions, maxPortions uint) (uint, float64, error) {
+	if fm.weigher == nil {
+		return fm.dispenser.Dispense(ctx, portions), 0, nil
+	}
+
+	weighedPortions := portions * maxWeighedPortionsFactor
+	if weighedPortions > maxPortions {
+		weighedPortions = maxPortions
+	}
+
+	gramsPerPortion := fm.config.Scale.GramsPerPortion
+	target := float64(portions) * gramsPerPortion
+	grams, served, err := fm.weigher.Dispense(ctx, target, weighedPortions)
+
+	switch {
+	case err == nil:
+		zap.S().Infof("Dispensed %.1fg of %.1fg.", grams, target)
+		return served, grams, nil
+	case errors.Is(err, scale.ErrTargetNotReached):
+		return served, grams, fmt.Errorf("%w, dispensed %.1fg of %.1fg", err, grams, target)
+	default:
+		zap.S().Warnf("Failed to weigh food, dispensing the remaining portions by time. %v", err)
+
+		// The weighed grams tell how much of the feeding was served already.
+		weighed := uint(math.Round(grams / gramsPerPortion))
+		if weighed > portions {
+			weighed = portions
+		}
+		remaining := portions - weighed
+		if served+remaining > maxPortions {
+			remaining = maxPortions - served
+		}
+		return served + fm.dispenser.Dispense(ctx, remaining), 0, nil
+	}
+}
+
+// logFeeding records the served portions in the feed history and sends them
+// to the service.
+func (fm *FeederManager) logFeeding(portions uint, grams float64) error {
+	servedAt := time.Now().UTC()
+	if err := fm.dbManager.AddFeedHistory(
+		dbm.FeedLog{Portions: portions, Grams: grams, Timestamp: servedAt}); err != nil {
+		zap.S().Errorf("Failed to add feeding to history. %v", err)
+	}
+	if err := fm.dbManager.CleanFeedHistory(servedAt.Add(-limits.Window)); err != nil {
+		zap.S().Errorf("Failed to clean feed history. %v", err)
 	}
-	fm.servoController.Stop()
-	zap.S().Infof("Served %d portions.", portions)
 
 	// send the log via mqtt and if that fails store it locally
 	msg := model.FeedLogCollectionMessage{
 		Value: []model.FeedLogMessage{
-			{Portions: portions, Timestamp: time.Now().UTC()},
+			{Portions: portions, Grams: grams, Timestamp: servedAt},
 		},
 	}
-	if err := fm.mqttManager.SendFeedLog(msg); err != nil {
+	if err := fm.mqtt().SendFeedLog(msg); err != nil {
 		zap.S().Warnf("Failed to send feed log to server. %v", err)
 
 		feedLog := dbm.FeedLog{
 			Portions:  msg.Value[0].Portions,
+			Grams:     msg.Value[0].Grams,
 			Timestamp: msg.Value[0].Timestamp,
 		}
 		return fm.dbManager.AddFeedLog(feedLog)
 	}
 	return nil
 }
+
+func (fm *FeederManager) updateSchedule(msg model.ScheduleMessage) error {
+	var schedule []config.ScheduleEntry
+	for _, m := range msg.Value {
+		e := config.ScheduleEntry{Cron: m.Cron, Time: m.Time, Portions: m.Portions}
+		if err := utils.Validate.Struct(e); err != nil {
+			return err
+		}
+		schedule = append(schedule, e)
+	}
+
+	if err := fm.scheduler.SetSchedule(schedule); err != nil {
+		return err
+	}
+
+	// Persist the schedule so it survives restarts of the feeder and periods
+	// in which the broker is unreachable.
+	storedSchedule := dbm.Schedule{UpdatedAt: time.Now().UTC()}
+	for _, e := range schedule {
+		storedSchedule.Entries = append(storedSchedule.Entries, dbm.ScheduleEntry{
+			Cron:     e.Cron,
+			Time:     e.Time,
+			Portions: e.Portions,
+		})
+	}
+	return fm.dbManager.SetSchedule(storedSchedule)
+}
+
+func scheduleFromDb(s dbm.Schedule) []config.ScheduleEntry {
+	var schedule []config.ScheduleEntry
+	for _, e := range s.Entries {
+		schedule = append(schedule, config.ScheduleEntry{
+			Cron:     e.Cron,
+			Time:     e.Time,
+			Portions: e.Portions,
+		})
+	}
+	return schedule
+}
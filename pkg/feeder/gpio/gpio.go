@@ -0,0 +1,29 @@
+package gpio
+
+type Mode uint8
+
+const (
+	Input Mode = iota
+	Output
+)
+
+type State uint8
+
+const (
+	Low State = iota
+	High
+)
+
+// Pin is a single GPIO pin.
+type Pin interface {
+	Mode(mode Mode)
+	High()
+	Low()
+	Read() State
+}
+
+// Gpio gives access to the GPIO pins of a backend.
+type Gpio interface {
+	Pin(number uint8) Pin
+	Close() error
+}
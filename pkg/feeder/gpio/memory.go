@@ -0,0 +1,94 @@
+package gpio
+
+import (
+	"sync"
+	"time"
+)
+
+// Event is a change of the state of a pin.
+type Event struct {
+	Pin   uint8
+	State State
+	At    time.Time
+}
+
+// Memory is an in-memory GPIO backend. It keeps the state of the pins and,
+// if created with NewRecording, a timeline of all their changes.
+type Memory struct {
+	mu     sync.Mutex
+	modes  map[uint8]Mode
+	states map[uint8]State
+	record bool
+	events []Event
+}
+
+type memoryPin struct {
+	m      *Memory
+	number uint8
+}
+
+func NewMemory() *Memory {
+	return &Memory{
+		modes:  make(map[uint8]Mode),
+		states: make(map[uint8]State),
+	}
+}
+
+// NewRecording returns an in-memory backend recording every change of the
+// pins.
+func NewRecording() *Memory {
+	m := NewMemory()
+	m.record = true
+	return m
+}
+
+func (m *Memory) Pin(number uint8) Pin {
+	return &memoryPin{m: m, number: number}
+}
+
+func (m *Memory) Close() error {
+	return nil
+}
+
+// Events returns the recorded changes of the pins, ordered by time.
+func (m *Memory) Events() []Event {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return append([]Event(nil), m.events...)
+}
+
+// PinMode returns the mode the pin was set to.
+func (m *Memory) PinMode(number uint8) Mode {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.modes[number]
+}
+
+func (m *Memory) set(number uint8, state State) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.states[number] = state
+	if m.record {
+		m.events = append(m.events, Event{Pin: number, State: state, At: time.Now()})
+	}
+}
+
+func (p *memoryPin) Mode(mode Mode) {
+	p.m.mu.Lock()
+	defer p.m.mu.Unlock()
+	p.m.modes[p.number] = mode
+}
+
+func (p *memoryPin) High() {
+	p.m.set(p.number, High)
+}
+
+func (p *memoryPin) Low() {
+	p.m.set(p.number, Low)
+}
+
+func (p *memoryPin) Read() State {
+	p.m.mu.Lock()
+	defer p.m.mu.Unlock()
+	return p.m.states[p.number]
+}
@@ -0,0 +1,47 @@
+package gpio
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+)
+
+type MemorySuite struct {
+	suite.Suite
+}
+
+func (suite *MemorySuite) TestPin() {
+	m := NewMemory()
+	p := m.Pin(17)
+	p.Mode(Output)
+	suite.Equal(Output, m.PinMode(17))
+	suite.Equal(Low, p.Read())
+
+	p.High()
+	suite.Equal(High, p.Read())
+	suite.Equal(Low, m.Pin(18).Read())
+
+	p.Low()
+	suite.Equal(Low, p.Read())
+
+	// Nothing is recorded unless created with NewRecording.
+	suite.Empty(m.Events())
+}
+
+func (suite *MemorySuite) TestRecording() {
+	m := NewRecording()
+	m.Pin(17).High()
+	m.Pin(18).High()
+	m.Pin(17).Low()
+
+	events := m.Events()
+	suite.Equal(3, len(events))
+	suite.Equal(Event{Pin: 17, State: High, At: events[0].At}, events[0])
+	suite.Equal(Event{Pin: 18, State: High, At: events[1].At}, events[1])
+	suite.Equal(Event{Pin: 17, State: Low, At: events[2].At}, events[2])
+	suite.False(events[2].At.Before(events[0].At))
+}
+
+func TestMemorySuite(t *testing.T) {
+	suite.Run(t, new(MemorySuite))
+}
@@ -0,0 +1,12 @@
+//go:build !arm
+
+package gpio
+
+import "go.uber.org/zap"
+
+// Open returns an in-memory GPIO backend, since there is no GPIO outside of
+// the RaspberryPi.
+func Open() (Gpio, error) {
+	zap.S().Info("Initialized in-memory GPIO.")
+	return NewMemory(), nil
+}
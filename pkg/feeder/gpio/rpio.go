@@ -0,0 +1,59 @@
+//go:build arm
+
+package gpio
+
+import (
+	"github.com/stianeikeland/go-rpio/v4"
+	"go.uber.org/zap"
+)
+
+type rpioGpio struct{}
+
+type rpioPin struct {
+	pin rpio.Pin
+}
+
+// Open initializes the GPIO of the RaspberryPi.
+func Open() (Gpio, error) {
+	if err := rpio.Open(); err != nil {
+		zap.S().Errorf("Could not initialize GPIO library. %+v", err)
+		return nil, err
+	}
+	zap.S().Info("Initialized GPIO library.")
+	return &rpioGpio{}, nil
+}
+
+func (g *rpioGpio) Pin(number uint8) Pin {
+	return &rpioPin{pin: rpio.Pin(number)}
+}
+
+func (g *rpioGpio) Close() error {
+	if err := rpio.Close(); err != nil {
+		return err
+	}
+	zap.S().Info("GPIO library closed.")
+	return nil
+}
+
+func (p *rpioPin) Mode(mode Mode) {
+	if mode == Output {
+		p.pin.Mode(rpio.Output)
+	} else {
+		p.pin.Mode(rpio.Input)
+	}
+}
+
+func (p *rpioPin) High() {
+	p.pin.High()
+}
+
+func (p *rpioPin) Low() {
+	p.pin.Low()
+}
+
+func (p *rpioPin) Read() State {
+	if p.pin.Read() == rpio.High {
+		return High
+	}
+	return Low
+}
@@ -3,8 +3,9 @@ package mqtt
 import (
 	"context"
 	"encoding/json"
-	"fmt"
+	"errors"
 	"net/url"
+	"sync"
 	"time"
 
 	"github.com/eclipse/paho.golang/autopaho"
@@ -12,47 +13,185 @@ import (
 	"github.com/imilchev/rpi-feeder/pkg/mqtt"
 	"github.com/imilchev/rpi-feeder/pkg/mqtt/config"
 	"github.com/imilchev/rpi-feeder/pkg/mqtt/model"
+	"github.com/imilchev/rpi-feeder/pkg/version"
 	"go.uber.org/zap"
 )
 
-type FeedHandler func(model.FeedMessage) error
+// publishTimeout is the maximum time to wait for the broker to acknowledge
+// a published message. Callers fall back to local storage when it expires.
+const publishTimeout = 10 * time.Second
+
+// ErrDuplicateCommand is returned by a FeedHandler for a command that was
+// already executed. No result is reported for such commands, since the result
+// of the original execution was reported already.
+var ErrDuplicateCommand = errors.New("command was already executed")
+
+// ErrFeedRejected is returned by a FeedHandler for a command that was refused
+// because it would exceed the feeding limits. The command is reported as
+// rejected rather than failed.
+var ErrFeedRejected = errors.New("feeding rejected")
+
+// ErrFeedCancelled is returned by a FeedHandler for a command whose feeding
+// was cancelled before all portions were served.
+var ErrFeedCancelled = errors.New("feeding cancelled")
+
+// FeedHandler executes a feed command and returns the amount of portions that
+// were actually served.
+type FeedHandler func(model.FeedMessage) (uint, error)
+type CancelHandler func(model.CancelMessage) error
+type ScheduleHandler func(model.ScheduleMessage) error
+type CalibrateHandler func(model.CalibrateMessage) error
+type UpdateHandler func(model.UpdateMessage) error
+type ConfigHandler func(model.ConfigMessage) error
+
+// ConnectHandler is called with the manager whenever the connection to the
+// broker is up.
+type ConnectHandler func(MqttManager)
 
 type MqttManager interface {
 	SendFeedLog(msg model.FeedLogCollectionMessage) error
+	SendMissedFeedings(msg model.MissedFeedingCollectionMessage) error
+
+	// SendStatus publishes the online status of the feeder together with the
+	// depth of its feed queue. The depth is also used for the status sent on
+	// reconnects.
+	SendStatus(queueDepth uint) error
+
+	// SendFoodLevel publishes the food level as a retained message, such that
+	// the latest level is available to clients that connect later. The level
+	// is also included in the status of the feeder from then on.
+	SendFoodLevel(msg model.FoodLevelMessage) error
+	SendCalibration(msg model.CalibrationMessage) error
+	SendTelemetry(msg model.TelemetryMessage) error
+	SendUpdateResult(msg model.UpdateResultMessage) error
+
+	// SendFeedResult publishes the result of a feeding that was not requested
+	// by a command, e.g. a failed scheduled feeding.
+	SendFeedResult(msg model.FeedResultMessage) error
+
+	// SendConfigStatus publishes the online status of the feeder together
+	// with the state of the config received from the service. The state is
+	// also used for the status sent on reconnects.
+	SendConfigStatus(s model.ConfigStatus) error
+
+	// Reconnects returns the amount of times the connection to the broker was
+	// re-established since the manager was created.
+	Reconnects() uint
+
+	// AwaitConnection blocks until the connection to the broker is up or ctx
+	// is done.
+	AwaitConnection(ctx context.Context) error
 	Stop() error
 }
 
 type mqttManager struct {
-	clientId string
-	c        *autopaho.ConnectionManager
+	clientId   string
+	c          *autopaho.ConnectionManager
+	mu         sync.Mutex
+	queueDepth uint
+	foodLevel  *float64
+
+	configStatus model.ConfigStatus
+
+	// connections counts how many times the connection came up.
+	connections uint
+
+	// schedules holds the latest schedule that is not applied yet.
+	schedules chan *paho.Publish
+	stopped   chan struct{}
 }
 
-func NewMqttManager(cfg config.MqttConfig, fh FeedHandler) (MqttManager, error) {
+// NewMqttManager connects to the broker in the background, retrying until the
+// connection is up. Messages cannot be sent until then, see AwaitConnection.
+func NewMqttManager(
+	cfg config.MqttConfig,
+	fh FeedHandler,
+	ch CancelHandler,
+	sh ScheduleHandler,
+	cah CalibrateHandler,
+	uh UpdateHandler,
+	coh ConfigHandler,
+	onConnect ConnectHandler) (MqttManager, error) {
 	serverUrl, err := url.Parse(cfg.Server)
 	if err != nil {
 		return nil, err
 	}
+	tlsCfg, err := cfg.TlsConfig()
+	if err != nil {
+		return nil, err
+	}
 
+	m := &mqttManager{
+		clientId:  cfg.ClientId,
+		schedules: make(chan *paho.Publish, 1),
+		stopped:   make(chan struct{}),
+	}
+	// The connection may come up before the constructor returns, so the
+	// connect handler waits until the manager is ready to send messages.
+	ready := make(chan struct{})
 	router := paho.NewStandardRouter()
+	// Feed commands are handled in their own goroutine, such that commands
+	// arriving while the feeder is busy can be queued.
+	router.RegisterHandler(
+		mqtt.FeedTopic(&cfg.ClientId),
+		func(p *paho.Publish) { go m.internalFeedHandler(p, fh) })
+	router.RegisterHandler(
+		mqtt.CancelTopic(&cfg.ClientId),
+		func(p *paho.Publish) { internalCancelHandler(p, ch) })
+	// Applying a schedule waits for the feeding in progress, so schedules are
+	// applied in order by their own goroutine. A schedule that is not applied
+	// yet is replaced by a newer one.
 	router.RegisterHandler(
-		fmt.Sprintf("feeder/%s/feed", cfg.ClientId),
-		func(p *paho.Publish) { internalFeedHandler(p, fh) })
+		mqtt.ScheduleTopic(&cfg.ClientId),
+		func(p *paho.Publish) {
+			select {
+			case <-m.schedules:
+			default:
+			}
+			m.schedules <- p
+		})
+	// Calibrating takes a while, so it does not block the other handlers.
+	router.RegisterHandler(
+		mqtt.CalibrateTopic(&cfg.ClientId),
+		func(p *paho.Publish) { go internalCalibrateHandler(p, cah) })
+	// Downloading an update takes a while as well.
+	router.RegisterHandler(
+		mqtt.UpdateTopic(&cfg.ClientId),
+		func(p *paho.Publish) { go internalUpdateHandler(p, uh) })
+	// Applying a config waits for the feeding in progress.
+	router.RegisterHandler(
+		mqtt.ConfigTopic(&cfg.ClientId),
+		func(p *paho.Publish) { go internalConfigHandler(p, coh) })
 
 	pahoCfg := autopaho.ClientConfig{
 		BrokerUrls:        []*url.URL{serverUrl},
+		TlsCfg:            tlsCfg,
 		KeepAlive:         cfg.KeepAlive,
 		ConnectRetryDelay: time.Duration(cfg.ConnectRetryDelay) * time.Second,
 		OnConnectionUp: func(cm *autopaho.ConnectionManager, connAck *paho.Connack) {
 			zap.S().Info("MQTT connection is up.")
-			msg := model.StatusMessage{SoftwareVersion: "dev", Status: model.OnlineStatus}
-			if err := sendStatusMessage(msg, cm, cfg.ClientId); err != nil {
+			m.mu.Lock()
+			m.connections++
+			m.mu.Unlock()
+			if err := sendStatusMessage(m.onlineStatus(), cm, cfg.ClientId); err != nil {
 				zap.S().Errorf("Failed to send status message. %v", err)
 				return
 			}
+			if onConnect != nil {
+				go func() {
+					<-ready
+					onConnect(m)
+				}()
+			}
 
 			if _, err := cm.Subscribe(context.Background(), &paho.Subscribe{
 				Subscriptions: map[string]paho.SubscribeOptions{
-					mqtt.FeedTopic(&cfg.ClientId): {QoS: byte(2)},
+					mqtt.FeedTopic(&cfg.ClientId):      {QoS: byte(2)},
+					mqtt.CancelTopic(&cfg.ClientId):    {QoS: byte(1)},
+					mqtt.ScheduleTopic(&cfg.ClientId):  {QoS: byte(1)},
+					mqtt.CalibrateTopic(&cfg.ClientId): {QoS: byte(1)},
+					mqtt.UpdateTopic(&cfg.ClientId):    {QoS: byte(1)},
+					mqtt.ConfigTopic(&cfg.ClientId):    {QoS: byte(1)},
 				},
 			}); err != nil {
 				zap.S().Errorf("Failed to subscribe (%v). This is likely to mean no messages will be received.", err)
@@ -76,7 +215,7 @@ func NewMqttManager(cfg config.MqttConfig, fh FeedHandler) (MqttManager, error)
 	}
 	pahoCfg.SetUsernamePassword(cfg.Username, []byte(cfg.Password))
 
-	willMsg := model.StatusMessage{SoftwareVersion: "dev", Status: model.OfflineStatus}
+	willMsg := model.StatusMessage{SoftwareVersion: version.Version, Status: model.OfflineStatus}
 	willData, err := json.Marshal(willMsg)
 	if err != nil {
 		return nil, err
@@ -89,19 +228,25 @@ func NewMqttManager(cfg config.MqttConfig, fh FeedHandler) (MqttManager, error)
 		return nil, err
 	}
 
-	m := &mqttManager{clientId: cfg.ClientId, c: cm}
-	if err := cm.AwaitConnection(context.Background()); err != nil {
-		return nil, err
-	}
-
-	zap.S().Infof("Connected to %s.", cfg.Server)
+	m.c = cm
+	close(ready)
+	go m.applySchedules(sh)
+	zap.S().Infof("Connecting to %s...", cfg.Server)
 	return m, nil
 }
 
+func (m *mqttManager) AwaitConnection(ctx context.Context) error {
+	return m.c.AwaitConnection(ctx)
+}
+
 func (m *mqttManager) Stop() error {
-	msg := model.StatusMessage{SoftwareVersion: "dev", Status: model.OfflineStatus}
+	close(m.stopped)
+
+	// The connection is closed even if the broker is unreachable, such that
+	// connecting is not retried anymore.
+	msg := model.StatusMessage{SoftwareVersion: version.Version, Status: model.OfflineStatus}
 	if err := sendStatusMessage(msg, m.c, m.clientId); err != nil {
-		return err
+		zap.S().Warnf("Failed to send offline status. %v", err)
 	}
 
 	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Second)
@@ -119,7 +264,9 @@ func (m *mqttManager) SendFeedLog(msg model.FeedLogCollectionMessage) error {
 		return err
 	}
 
-	_, err = m.c.Publish(context.Background(), &paho.Publish{
+	ctx, cancel := context.WithTimeout(context.Background(), publishTimeout)
+	defer cancel()
+	_, err = m.c.Publish(ctx, &paho.Publish{
 		Topic:   mqtt.FeedLogTopic(&m.clientId),
 		QoS:     byte(2),
 		Payload: data,
@@ -127,13 +274,183 @@ func (m *mqttManager) SendFeedLog(msg model.FeedLogCollectionMessage) error {
 	return err
 }
 
+func (m *mqttManager) SendMissedFeedings(msg model.MissedFeedingCollectionMessage) error {
+	data, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), publishTimeout)
+	defer cancel()
+	_, err = m.c.Publish(ctx, &paho.Publish{
+		Topic:   mqtt.MissedFeedingTopic(&m.clientId),
+		QoS:     byte(2),
+		Payload: data,
+	})
+	return err
+}
+
+func (m *mqttManager) SendFoodLevel(msg model.FoodLevelMessage) error {
+	data, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), publishTimeout)
+	defer cancel()
+	if _, err = m.c.Publish(ctx, &paho.Publish{
+		Topic:   mqtt.FoodLevelTopic(&m.clientId),
+		QoS:     byte(1),
+		Retain:  true,
+		Payload: data,
+	}); err != nil {
+		return err
+	}
+
+	level := msg.Level
+	m.mu.Lock()
+	m.foodLevel = &level
+	m.mu.Unlock()
+	return sendStatusMessage(m.onlineStatus(), m.c, m.clientId)
+}
+
+func (m *mqttManager) SendTelemetry(msg model.TelemetryMessage) error {
+	data, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), publishTimeout)
+	defer cancel()
+	_, err = m.c.Publish(ctx, &paho.Publish{
+		Topic:   mqtt.TelemetryTopic(&m.clientId),
+		QoS:     byte(1),
+		Payload: data,
+	})
+	return err
+}
+
+func (m *mqttManager) SendUpdateResult(msg model.UpdateResultMessage) error {
+	data, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), publishTimeout)
+	defer cancel()
+	_, err = m.c.Publish(ctx, &paho.Publish{
+		Topic:   mqtt.UpdateResultTopic(&m.clientId),
+		QoS:     byte(1),
+		Payload: data,
+	})
+	return err
+}
+
+func (m *mqttManager) Reconnects() uint {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.connections == 0 {
+		return 0
+	}
+	return m.connections - 1
+}
+
+func (m *mqttManager) SendCalibration(msg model.CalibrationMessage) error {
+	return publishCalibration(m.c, m.clientId, msg)
+}
+
+// SendCalibration connects to the broker just to publish the calibration
+// result. It is meant for calibrating while the feeder is not running. A
+// separate client id is used, such that a running feeder is not disconnected.
+func SendCalibration(cfg config.MqttConfig, msg model.CalibrationMessage) error {
+	serverUrl, err := url.Parse(cfg.Server)
+	if err != nil {
+		return err
+	}
+	tlsCfg, err := cfg.TlsConfig()
+	if err != nil {
+		return err
+	}
+
+	pahoCfg := autopaho.ClientConfig{
+		BrokerUrls:        []*url.URL{serverUrl},
+		TlsCfg:            tlsCfg,
+		KeepAlive:         cfg.KeepAlive,
+		ConnectRetryDelay: time.Duration(cfg.ConnectRetryDelay) * time.Second,
+		OnConnectError:    func(err error) { zap.S().Warnf("Error whilst attempting connection: %v", err) },
+		ClientConfig: paho.ClientConfig{
+			ClientID: cfg.ClientId + "-calibration",
+		},
+	}
+	pahoCfg.SetUsernamePassword(cfg.Username, []byte(cfg.Password))
+
+	ctx, cancel := context.WithTimeout(context.Background(), publishTimeout)
+	defer cancel()
+	cm, err := autopaho.NewConnection(ctx, pahoCfg)
+	if err != nil {
+		return err
+	}
+	defer cm.Disconnect(context.Background()) //nolint
+	if err := cm.AwaitConnection(ctx); err != nil {
+		return err
+	}
+	return publishCalibration(cm, cfg.ClientId, msg)
+}
+
+func publishCalibration(cm *autopaho.ConnectionManager, clientId string, msg model.CalibrationMessage) error {
+	data, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), publishTimeout)
+	defer cancel()
+	_, err = cm.Publish(ctx, &paho.Publish{
+		Topic:   mqtt.CalibrationTopic(&clientId),
+		QoS:     byte(1),
+		Payload: data,
+	})
+	return err
+}
+
+func (m *mqttManager) SendStatus(queueDepth uint) error {
+	m.mu.Lock()
+	m.queueDepth = queueDepth
+	m.mu.Unlock()
+	return sendStatusMessage(m.onlineStatus(), m.c, m.clientId)
+}
+
+func (m *mqttManager) SendConfigStatus(s model.ConfigStatus) error {
+	m.mu.Lock()
+	m.configStatus = s
+	m.mu.Unlock()
+	return sendStatusMessage(m.onlineStatus(), m.c, m.clientId)
+}
+
+func (m *mqttManager) onlineStatus() model.StatusMessage {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return model.StatusMessage{
+		SoftwareVersion: version.Version,
+		Status:          model.OnlineStatus,
+		QueueDepth:      m.queueDepth,
+		FoodLevel:       m.foodLevel,
+		ConfigStatus:    m.configStatus,
+	}
+}
+
 func sendStatusMessage(msg model.StatusMessage, cm *autopaho.ConnectionManager, clientId string) error {
 	data, err := json.Marshal(msg)
 	if err != nil {
 		return err
 	}
 
-	_, err = cm.Publish(context.Background(), &paho.Publish{
+	// The status is also sent by the feed queue worker, which must not stall
+	// if the broker does not acknowledge it.
+	ctx, cancel := context.WithTimeout(context.Background(), publishTimeout)
+	defer cancel()
+	_, err = cm.Publish(ctx, &paho.Publish{
 		Topic:   mqtt.StatusTopic(&clientId),
 		QoS:     byte(1),
 		Retain:  true,
@@ -142,15 +459,144 @@ func sendStatusMessage(msg model.StatusMessage, cm *autopaho.ConnectionManager,
 	return err
 }
 
-func internalFeedHandler(p *paho.Publish, fh FeedHandler) {
+func (m *mqttManager) internalFeedHandler(p *paho.Publish, fh FeedHandler) {
 	msg := model.FeedMessage{}
 	if err := json.Unmarshal(p.Payload, &msg); err != nil {
 		zap.S().Errorf("Failed to deserialize message %s. %v", string(p.Payload), err)
 		return
 	}
-	if err := fh(msg); err != nil {
+
+	served, err := fh(msg)
+	if errors.Is(err, ErrDuplicateCommand) {
+		zap.S().Warnf("Ignoring duplicate command %s.", msg.CommandId)
+		return
+	}
+
+	if err != nil {
 		zap.S().Errorf("Failed to feed %d portions. %v", msg.Portions, err)
+	} else {
+		zap.S().Infof("Feed %d portions", msg.Portions)
+	}
+	result := NewFeedResult(msg.CommandId, served, err)
+
+	// Commands without an id do not expect a result.
+	if msg.CommandId == "" {
+		return
+	}
+	if err := m.sendFeedResult(result, p.Properties); err != nil {
+		zap.S().Errorf("Failed to send result of command %s. %v", msg.CommandId, err)
+	}
+}
+
+// NewFeedResult creates the result of a feeding that served the portions and
+// failed with err, if it is not nil.
+func NewFeedResult(commandId string, served uint, err error) model.FeedResultMessage {
+	result := model.FeedResultMessage{CommandId: commandId, Portions: served}
+	if err != nil {
+		result.Error = err.Error()
+		result.Rejected = errors.Is(err, ErrFeedRejected)
+		result.Cancelled = errors.Is(err, ErrFeedCancelled)
+	} else {
+		result.Success = true
+	}
+	result.Timestamp = time.Now().UTC()
+	return result
+}
+
+func (m *mqttManager) SendFeedResult(msg model.FeedResultMessage) error {
+	return m.sendFeedResult(msg, nil)
+}
+
+// sendFeedResult publishes the result of a feed command. If the command
+// specified a response topic, the result is published there together with
+// the command's correlation data.
+func (m *mqttManager) sendFeedResult(
+	msg model.FeedResultMessage, commandProps *paho.PublishProperties) error {
+	data, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+
+	p := &paho.Publish{
+		Topic:   mqtt.FeedResultTopic(&m.clientId),
+		QoS:     byte(2),
+		Payload: data,
+	}
+	if commandProps != nil && commandProps.ResponseTopic != "" {
+		p.Topic = commandProps.ResponseTopic
+		p.Properties = &paho.PublishProperties{CorrelationData: commandProps.CorrelationData}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), publishTimeout)
+	defer cancel()
+	_, err = m.c.Publish(ctx, p)
+	return err
+}
+
+func internalCancelHandler(p *paho.Publish, ch CancelHandler) {
+	msg := model.CancelMessage{}
+	if err := json.Unmarshal(p.Payload, &msg); err != nil {
+		zap.S().Errorf("Failed to deserialize message %s. %v", string(p.Payload), err)
+		return
+	}
+	if err := ch(msg); err != nil {
+		zap.S().Errorf("Failed to cancel feeding. %v", err)
+	}
+}
+
+func (m *mqttManager) applySchedules(sh ScheduleHandler) {
+	for {
+		select {
+		case p := <-m.schedules:
+			internalScheduleHandler(p, sh)
+		case <-m.stopped:
+			return
+		}
+	}
+}
+
+func internalScheduleHandler(p *paho.Publish, sh ScheduleHandler) {
+	msg := model.ScheduleMessage{}
+	if err := json.Unmarshal(p.Payload, &msg); err != nil {
+		zap.S().Errorf("Failed to deserialize message %s. %v", string(p.Payload), err)
+		return
+	}
+	if err := sh(msg); err != nil {
+		zap.S().Errorf("Failed to update schedule. %v", err)
 		return
 	}
-	zap.S().Infof("Feed %d portions", msg.Portions)
+	zap.S().Infof("Schedule updated with %d entries.", len(msg.Value))
+}
+
+func internalCalibrateHandler(p *paho.Publish, cah CalibrateHandler) {
+	msg := model.CalibrateMessage{}
+	if err := json.Unmarshal(p.Payload, &msg); err != nil {
+		zap.S().Errorf("Failed to deserialize message %s. %v", string(p.Payload), err)
+		return
+	}
+	if err := cah(msg); err != nil {
+		zap.S().Errorf("Failed to calibrate. %v", err)
+	}
+}
+
+func internalUpdateHandler(p *paho.Publish, uh UpdateHandler) {
+	msg := model.UpdateMessage{}
+	if err := json.Unmarshal(p.Payload, &msg); err != nil {
+		zap.S().Errorf("Failed to deserialize message %s. %v", string(p.Payload), err)
+		return
+	}
+	if err := uh(msg); err != nil {
+		zap.S().Errorf("Failed to update to version %s. %v", msg.Version, err)
+	}
+}
+
+func internalConfigHandler(p *paho.Publish, coh ConfigHandler) {
+	msg := model.ConfigMessage{}
+	if err := json.Unmarshal(p.Payload, &msg); err != nil {
+		zap.S().Errorf("Failed to deserialize message %s. %v", string(p.Payload), err)
+		return
+	}
+	if err := coh(msg); err != nil {
+		zap.S().Errorf("Failed to apply config revision %d. %v", msg.Revision, err)
+	}
 }
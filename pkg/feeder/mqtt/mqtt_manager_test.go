@@ -0,0 +1,130 @@
+package mqtt
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/imilchev/rpi-feeder/pkg/mqtt"
+	"github.com/imilchev/rpi-feeder/pkg/mqtt/config"
+	"github.com/imilchev/rpi-feeder/pkg/mqtt/model"
+	"github.com/imilchev/rpi-feeder/tests/utils/broker"
+	"github.com/stretchr/testify/suite"
+)
+
+type MqttManagerSuite struct {
+	suite.Suite
+	certs  broker.Certs
+	broker *broker.Broker
+	cfg    config.MqttConfig
+}
+
+func (suite *MqttManagerSuite) SetupTest() {
+	var err error
+	suite.certs, err = broker.GenerateCerts(suite.T().TempDir(), "dev1")
+	suite.Require().NoError(err)
+	suite.broker, err = broker.NewBroker(suite.certs)
+	suite.Require().NoError(err)
+
+	suite.cfg = config.MqttConfig{
+		Server:            suite.broker.Url(),
+		ClientId:          "dev1",
+		KeepAlive:         30,
+		ConnectRetryDelay: 1,
+		CaFile:            suite.certs.CaFile,
+		CertFile:          suite.certs.ClientCertFile,
+		KeyFile:           suite.certs.ClientKeyFile,
+	}
+}
+
+func (suite *MqttManagerSuite) TearDownTest() {
+	suite.broker.Close()
+}
+
+func (suite *MqttManagerSuite) connect(cfg config.MqttConfig) (MqttManager, error) {
+	m, err := NewMqttManager(cfg, nil, nil, nil, nil, nil, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	if err := m.AwaitConnection(ctx); err != nil {
+		m.Stop() //nolint
+		return nil, err
+	}
+	return m, nil
+}
+
+func (suite *MqttManagerSuite) TestNewMqttManager_Tls() {
+	m, err := suite.connect(suite.cfg)
+	suite.Require().NoError(err)
+
+	suite.Equal([]broker.Client{{ClientId: "dev1", CommonName: "dev1"}}, suite.broker.Clients())
+	suite.Eventually(func() bool {
+		return len(suite.broker.Published(mqtt.StatusTopic(&suite.cfg.ClientId))) == 1
+	}, 3*time.Second, 10*time.Millisecond)
+
+	suite.NoError(m.Stop())
+	var status model.StatusMessage
+	published := suite.broker.Published(mqtt.StatusTopic(&suite.cfg.ClientId))
+	suite.Len(published, 2)
+	suite.NoError(json.Unmarshal(published[1].Payload, &status))
+	suite.Equal(model.OfflineStatus, status.Status)
+}
+
+func (suite *MqttManagerSuite) TestNewMqttManager_UnknownCa() {
+	other, err := broker.GenerateCerts(suite.T().TempDir(), "dev1")
+	suite.Require().NoError(err)
+	suite.cfg.CaFile = other.CaFile
+
+	_, err = suite.connect(suite.cfg)
+	suite.Error(err)
+	suite.Empty(suite.broker.Clients())
+}
+
+func (suite *MqttManagerSuite) TestNewMqttManager_NoClientCert() {
+	suite.cfg.CertFile = ""
+	suite.cfg.KeyFile = ""
+
+	_, err := suite.connect(suite.cfg)
+	suite.Error(err)
+	suite.Empty(suite.broker.Clients())
+}
+
+func (suite *MqttManagerSuite) TestNewMqttManager_InvalidTls() {
+	suite.cfg.CaFile = suite.certs.ClientKeyFile
+
+	_, err := suite.connect(suite.cfg)
+	suite.Error(err)
+}
+
+func (suite *MqttManagerSuite) TestNewMqttManager_BrokerDown() {
+	suite.broker.Close()
+
+	m, err := NewMqttManager(suite.cfg, nil, nil, nil, nil, nil, nil, nil)
+	suite.Require().NoError(err)
+	suite.Error(m.SendStatus(1))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	suite.Error(m.AwaitConnection(ctx))
+	suite.NoError(m.Stop())
+}
+
+func (suite *MqttManagerSuite) TestSendCalibration_Tls() {
+	msg := model.CalibrationMessage{PortionMs: 1075, MsPerGram: 95.5, GramsPerPortion: 10}
+	suite.Require().NoError(SendCalibration(suite.cfg, msg))
+
+	suite.Equal([]broker.Client{{ClientId: "dev1-calibration", CommonName: "dev1"}}, suite.broker.Clients())
+	published := suite.broker.Published(mqtt.CalibrationTopic(&suite.cfg.ClientId))
+	suite.Require().Len(published, 1)
+	var received model.CalibrationMessage
+	suite.NoError(json.Unmarshal(published[0].Payload, &received))
+	suite.Equal(msg.PortionMs, received.PortionMs)
+}
+
+func TestMqttManagerSuite(t *testing.T) {
+	suite.Run(t, new(MqttManagerSuite))
+}
@@ -0,0 +1,166 @@
+package queue
+
+import (
+	"errors"
+	"sync"
+
+	"go.uber.org/zap"
+)
+
+var (
+	// ErrQueueFull is returned when a job is enqueued while the queue is at its
+	// maximum depth.
+	ErrQueueFull = errors.New("feed queue is full")
+
+	// ErrQueueStopped is returned for jobs enqueued after the queue was stopped
+	// and for jobs that were still waiting when it was stopped.
+	ErrQueueStopped = errors.New("feed queue is stopped")
+)
+
+// Job is a unit of work executed by the queue.
+type Job func() error
+
+// DepthChangeFunc is called with the current depth when the depth of the
+// queue changes. It is called from its own goroutine, such that it never
+// delays the jobs. Changes that happen while it is running are coalesced into
+// a single call.
+type DepthChangeFunc func(depth uint)
+
+// Queue executes jobs strictly one at a time, in the order they were enqueued.
+type Queue interface {
+	Start()
+	Stop()
+
+	// Enqueue adds a job to the queue. The returned channel receives the
+	// result of the job once it was executed.
+	Enqueue(job Job) (<-chan error, error)
+
+	// Depth returns the amount of jobs that are waiting or being executed.
+	Depth() uint
+}
+
+type item struct {
+	job    Job
+	result chan error
+}
+
+type queue struct {
+	mu            sync.Mutex
+	depth         uint
+	maxDepth      uint
+	isStopped     bool
+	items         chan item
+	stoppedChan   chan struct{}
+	onDepthChange DepthChangeFunc
+
+	// depthChanged signals the notifier that the depth changed. It holds at
+	// most one pending change.
+	depthChanged chan struct{}
+}
+
+func NewQueue(maxDepth uint, onDepthChange DepthChangeFunc) Queue {
+	return &queue{
+		maxDepth:      maxDepth,
+		items:         make(chan item, maxDepth),
+		stoppedChan:   make(chan struct{}),
+		onDepthChange: onDepthChange,
+		depthChanged:  make(chan struct{}, 1),
+	}
+}
+
+func (q *queue) Start() {
+	go q.work()
+	if q.onDepthChange != nil {
+		go q.notifyDepth()
+	}
+}
+
+func (q *queue) Stop() {
+	q.mu.Lock()
+	if q.isStopped {
+		q.mu.Unlock()
+		return
+	}
+	q.isStopped = true
+	close(q.items)
+	q.mu.Unlock()
+
+	// Wait for the job in progress to complete.
+	<-q.stoppedChan
+}
+
+func (q *queue) Enqueue(job Job) (<-chan error, error) {
+	q.mu.Lock()
+	if q.isStopped {
+		q.mu.Unlock()
+		return nil, ErrQueueStopped
+	}
+	if q.depth >= q.maxDepth {
+		q.mu.Unlock()
+		return nil, ErrQueueFull
+	}
+	q.depth++
+
+	// The channel buffer is as large as the maximum depth, so this never
+	// blocks.
+	i := item{job: job, result: make(chan error, 1)}
+	q.items <- i
+	q.mu.Unlock()
+
+	q.notify()
+	return i.result, nil
+}
+
+func (q *queue) Depth() uint {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return q.depth
+}
+
+func (q *queue) work() {
+	for i := range q.items {
+		q.mu.Lock()
+		isStopped := q.isStopped
+		q.mu.Unlock()
+
+		var err error
+		if isStopped {
+			err = ErrQueueStopped
+		} else {
+			err = i.job()
+		}
+
+		q.mu.Lock()
+		q.depth--
+		q.mu.Unlock()
+
+		if !isStopped {
+			q.notify()
+		}
+		i.result <- err
+	}
+	zap.S().Debug("Feed queue stopped.")
+	close(q.stoppedChan)
+}
+
+// notify signals the notifier that the depth changed without waiting for it.
+func (q *queue) notify() {
+	select {
+	case q.depthChanged <- struct{}{}:
+	default:
+		// A change is pending already, the notifier reads the latest depth.
+	}
+}
+
+// notifyDepth calls onDepthChange for the changes of the depth until the
+// queue is stopped.
+func (q *queue) notifyDepth() {
+	for {
+		select {
+		case <-q.depthChanged:
+			q.onDepthChange(q.Depth())
+		case <-q.stoppedChan:
+			return
+		}
+	}
+}
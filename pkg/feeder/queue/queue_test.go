@@ -0,0 +1,179 @@
+package queue
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/suite"
+)
+
+type QueueSuite struct {
+	suite.Suite
+}
+
+func (suite *QueueSuite) TestEnqueue_Serialized() {
+	q := NewQueue(10, nil)
+	q.Start()
+	defer q.Stop()
+
+	var mu sync.Mutex
+	var running, maxRunning int
+	var order []int
+	var results []<-chan error
+	for i := 0; i < 5; i++ {
+		i := i
+		res, err := q.Enqueue(func() error {
+			mu.Lock()
+			running++
+			if running > maxRunning {
+				maxRunning = running
+			}
+			order = append(order, i)
+			mu.Unlock()
+
+			time.Sleep(5 * time.Millisecond)
+
+			mu.Lock()
+			running--
+			mu.Unlock()
+			return nil
+		})
+		suite.Require().NoError(err)
+		results = append(results, res)
+	}
+
+	for _, res := range results {
+		suite.NoError(<-res)
+	}
+	suite.Equal(1, maxRunning)
+	suite.Equal([]int{0, 1, 2, 3, 4}, order)
+	suite.Equal(uint(0), q.Depth())
+}
+
+func (suite *QueueSuite) TestEnqueue_Result() {
+	q := NewQueue(1, nil)
+	q.Start()
+	defer q.Stop()
+
+	expected := errors.New("jammed")
+	res, err := q.Enqueue(func() error { return expected })
+	suite.Require().NoError(err)
+	suite.Equal(expected, <-res)
+}
+
+func (suite *QueueSuite) TestEnqueue_Full() {
+	q := NewQueue(2, nil)
+	q.Start()
+	defer q.Stop()
+
+	block := make(chan struct{})
+	job := func() error {
+		<-block
+		return nil
+	}
+	res1, err := q.Enqueue(job)
+	suite.Require().NoError(err)
+	res2, err := q.Enqueue(job)
+	suite.Require().NoError(err)
+	suite.Equal(uint(2), q.Depth())
+
+	_, err = q.Enqueue(job)
+	suite.Equal(ErrQueueFull, err)
+
+	close(block)
+	suite.NoError(<-res1)
+	suite.NoError(<-res2)
+
+	// Space is available again once the jobs are done.
+	res3, err := q.Enqueue(job)
+	suite.Require().NoError(err)
+	suite.NoError(<-res3)
+}
+
+func (suite *QueueSuite) TestDepthChange() {
+	var mu sync.Mutex
+	var depths []uint
+	q := NewQueue(2, func(depth uint) {
+		mu.Lock()
+		defer mu.Unlock()
+		depths = append(depths, depth)
+	})
+	q.Start()
+	defer q.Stop()
+
+	block := make(chan struct{})
+	res, err := q.Enqueue(func() error {
+		<-block
+		return nil
+	})
+	suite.Require().NoError(err)
+	suite.Eventually(func() bool {
+		mu.Lock()
+		defer mu.Unlock()
+		return len(depths) > 0 && depths[len(depths)-1] == 1
+	}, time.Second, time.Millisecond)
+
+	close(block)
+	suite.NoError(<-res)
+	suite.Eventually(func() bool {
+		mu.Lock()
+		defer mu.Unlock()
+		return depths[len(depths)-1] == 0
+	}, time.Second, time.Millisecond)
+}
+
+func (suite *QueueSuite) TestDepthChange_DoesNotBlock() {
+	// Reporting the depth may take long, e.g. while the broker is down.
+	block := make(chan struct{})
+	defer close(block)
+	q := NewQueue(2, func(depth uint) { <-block })
+	q.Start()
+	defer q.Stop()
+
+	for i := 0; i < 3; i++ {
+		res, err := q.Enqueue(func() error { return nil })
+		suite.Require().NoError(err)
+		select {
+		case err := <-res:
+			suite.NoError(err)
+		case <-time.After(time.Second):
+			suite.FailNow("job was delayed by reporting the depth")
+		}
+	}
+}
+
+func (suite *QueueSuite) TestStop() {
+	q := NewQueue(2, nil)
+	q.Start()
+
+	started := make(chan struct{})
+	block := make(chan struct{})
+	res1, err := q.Enqueue(func() error {
+		close(started)
+		<-block
+		return nil
+	})
+	suite.Require().NoError(err)
+	res2, err := q.Enqueue(func() error { return nil })
+	suite.Require().NoError(err)
+
+	<-started
+	go func() {
+		time.Sleep(5 * time.Millisecond)
+		close(block)
+	}()
+	q.Stop()
+
+	// The job in progress completes, the waiting one is discarded.
+	suite.NoError(<-res1)
+	suite.Equal(ErrQueueStopped, <-res2)
+
+	_, err = q.Enqueue(func() error { return nil })
+	suite.Equal(ErrQueueStopped, err)
+}
+
+func TestQueueSuite(t *testing.T) {
+	suite.Run(t, new(QueueSuite))
+}
@@ -0,0 +1,216 @@
+package feeder
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"time"
+
+	"github.com/imilchev/rpi-feeder/pkg/feeder/config"
+	"github.com/imilchev/rpi-feeder/pkg/feeder/mqtt"
+	"github.com/imilchev/rpi-feeder/pkg/feeder/scale"
+	"github.com/imilchev/rpi-feeder/pkg/feeder/servo"
+	mqttConfig "github.com/imilchev/rpi-feeder/pkg/mqtt/config"
+	"github.com/imilchev/rpi-feeder/pkg/utils"
+	"go.uber.org/zap"
+)
+
+// mqttReconnectTimeout is how long to wait for the connection to the broker
+// after the MQTT settings changed, before the previous settings are restored.
+const mqttReconnectTimeout = 30 * time.Second
+
+// reloadConfig reads the config file again and applies it, together with the
+// config received from the service. Applying goes through the feed queue,
+// such that the settings do not change in the middle of a feeding.
+func (fm *FeederManager) reloadConfig() error {
+	zap.S().Infof("Reloading config %s...", fm.configPath)
+	fileConfig, err := config.ReadConfig(fm.configPath, fm.overrides)
+	if err != nil {
+		return err
+	}
+	if err := utils.Validate.Struct(fileConfig); err != nil {
+		return err
+	}
+
+	var schedule []config.ScheduleEntry
+	done, err := fm.queue.Enqueue(func() error {
+		c, revision := applyStoredConfig(fileConfig, fm.dbManager)
+		pendingRestart, err := fm.applyConfig(c)
+		if err != nil {
+			return err
+		}
+		fm.fileConfig = fileConfig
+		fm.configRevision = revision
+		fm.configPendingRestart = pendingRestart
+		schedule = c.Schedule
+
+		if pendingRestart {
+			zap.S().Warn("Config reloaded partially, restart the feeder to apply it completely.")
+		} else {
+			zap.S().Info("Config reloaded.")
+		}
+		fm.sendConfigStatus()
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	if err := <-done; err != nil {
+		return err
+	}
+
+	// The schedule is replaced outside of the feed queue, since a scheduled
+	// feeding in progress waits for the queue. The schedule received from the
+	// service takes precedence over the one in the config file.
+	storedSchedule, err := fm.dbManager.GetSchedule()
+	if err != nil {
+		return err
+	}
+	if storedSchedule == nil {
+		return fm.scheduler.SetSchedule(schedule)
+	}
+	return nil
+}
+
+// applyConfig changes the running config to c. The servo, the scale and the
+// connection to the broker are reinitialised if their settings changed. Returns
+// whether some settings only take effect once the feeder is restarted. Must
+// only be called while no feeding is in progress.
+func (fm *FeederManager) applyConfig(c *config.Config) (bool, error) {
+	running := *fm.config
+	pendingRestart := config.RequiresRestart(running, *c)
+
+	// The connection is changed first, such that nothing is applied if the
+	// new settings do not work.
+	if running.Mqtt != c.Mqtt {
+		fm.config.Mqtt = c.Mqtt
+		if err := fm.reconnectMqtt(running.Mqtt); err != nil {
+			return false, err
+		}
+	}
+
+	if running.ServoPin != c.ServoPin || !reflect.DeepEqual(running.Servo, c.Servo) {
+		if err := fm.resetServo(c); err != nil {
+			return false, err
+		}
+	}
+
+	if !reflect.DeepEqual(running.Scale, c.Scale) {
+		fm.scale = nil
+		if c.Scale != nil {
+			zap.S().Info("Reinitialising scale...")
+			fm.scale = scale.NewHx711(fm.gpio, *c.Scale)
+		}
+	}
+
+	fm.config.ApplyHot(*c)
+	fm.resetDispenser()
+	return pendingRestart, nil
+}
+
+// resetServo replaces the servo controller with one for the settings of c. If
+// that fails, the previous servo controller is restored.
+func (fm *FeederManager) resetServo(c *config.Config) error {
+	zap.S().Info("Reinitialising servo...")
+	// The previous controller is closed first, since both may use the same
+	// pins.
+	fm.servoController.Stop()
+	fm.servoController.Close()
+
+	sc, err := servo.NewServoController(fm.gpio, c.ServoPin, c.Servo)
+	if err != nil {
+		zap.S().Errorf("Failed to initialise servo, restoring the previous one. %v", err)
+		if fm.servoController, err = servo.NewServoController(
+			fm.gpio, fm.config.ServoPin, fm.config.Servo); err != nil {
+			return fmt.Errorf("failed to restore servo: %w", err)
+		}
+		return errors.New("failed to initialise servo")
+	}
+	fm.servoController = sc
+	return nil
+}
+
+// resetDispenser recreates the dispensers with the running config. Must only
+// be called while no feeding is in progress.
+func (fm *FeederManager) resetDispenser() {
+	fm.dispenser = servo.NewDispenser(fm.servoController, fm.config.PortionMs, fm.config.Dispense)
+	fm.weigher = nil
+	if fm.scale != nil {
+		fm.weigher = scale.NewWeighingDispenser(fm.dispenser, fm.scale, fm.config.Scale.ToleranceGrams)
+	}
+}
+
+// reconnectMqtt replaces the connection to the broker with one for the
+// running MQTT settings. If connecting fails, the previous settings are
+// restored. Feed logs that could not be sent in the meantime were stored
+// locally and are flushed once connected.
+func (fm *FeederManager) reconnectMqtt(previous mqttConfig.MqttConfig) error {
+	server := fm.config.Mqtt.Server
+	zap.S().Infof("Reconnecting to %s...", server)
+	old := fm.mqtt()
+	if err := old.Stop(); err != nil {
+		zap.S().Warnf("Failed to stop MQTT manager. %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), mqttReconnectTimeout)
+	defer cancel()
+	m, connErr := fm.connectMqtt(fm.config.Mqtt)
+	if connErr == nil {
+		if connErr = m.AwaitConnection(ctx); connErr != nil {
+			if err := m.Stop(); err != nil {
+				zap.S().Warnf("Failed to stop MQTT manager. %v", err)
+			}
+		}
+	}
+	if connErr != nil {
+		zap.S().Errorf("Failed to connect with the new MQTT settings, restoring the previous ones. %v", connErr)
+		fm.config.Mqtt = previous
+
+		// The previous settings worked, so connecting with them is retried
+		// in the background.
+		var err error
+		if m, err = fm.connectMqtt(previous); err != nil {
+			return err
+		}
+	}
+
+	fm.mqttMu.Lock()
+	fm.previousReconnects += old.Reconnects() + 1
+	fm.mqttManager = m
+	fm.mqttMu.Unlock()
+
+	// The new manager reports the same state as the previous one.
+	fm.sendConfigStatus()
+	fm.sendQueueDepth(fm.queue.Depth())
+	if connErr != nil {
+		return fmt.Errorf("failed to connect to %s: %w", server, connErr)
+	}
+	return nil
+}
+
+func (fm *FeederManager) connectMqtt(cfg mqttConfig.MqttConfig) (mqtt.MqttManager, error) {
+	return mqtt.NewMqttManager(
+		cfg,
+		fm.handleFeedCommand,
+		fm.cancel,
+		fm.updateSchedule,
+		fm.calibrate,
+		fm.update,
+		fm.updateConfig,
+		fm.mqttConnected)
+}
+
+func (fm *FeederManager) mqtt() mqtt.MqttManager {
+	fm.mqttMu.RLock()
+	defer fm.mqttMu.RUnlock()
+	return fm.mqttManager
+}
+
+// mqttReconnects returns the amount of times the connection to the broker was
+// re-established since the feeder started.
+func (fm *FeederManager) mqttReconnects() uint {
+	fm.mqttMu.RLock()
+	defer fm.mqttMu.RUnlock()
+	return fm.previousReconnects + fm.mqttManager.Reconnects()
+}
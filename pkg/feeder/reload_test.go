@@ -0,0 +1,124 @@
+package feeder
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/imilchev/rpi-feeder/pkg/mqtt/model"
+	"github.com/stretchr/testify/suite"
+)
+
+type ReloadSuite struct {
+	suite.Suite
+	fm      *FeederManager
+	stopped chan error
+}
+
+func (suite *ReloadSuite) SetupTest() {
+	suite.fm, suite.stopped = startFeeder(&suite.Suite)
+}
+
+func (suite *ReloadSuite) TearDownTest() {
+	stopFeeder(&suite.Suite, suite.stopped)
+}
+
+// startFeeder starts a feeder that feeds every second. Returns the channel
+// receiving the result of Start.
+func startFeeder(suite *suite.Suite) (*FeederManager, chan error) {
+	dir := suite.T().TempDir()
+	path := filepath.Join(dir, "config.json")
+
+	// The broker is unreachable, the feeder serves its schedule regardless.
+	suite.Require().NoError(os.WriteFile(path, []byte(fmt.Sprintf(`{
+		"dbPath": %q,
+		"servoPin": 17,
+		"portionMs": 300,
+		"schedule": [{"cron": "@every 1s", "portions": 1}],
+		"mqtt": {
+			"server": "mqtt://127.0.0.1:1",
+			"clientId": "dev1",
+			"keepAlive": 20,
+			"connectRetryDelay": 1
+		}
+	}`, dir)), 0600))
+
+	fm, err := NewFeederManager(path, nil)
+	suite.Require().NoError(err)
+
+	stopped := make(chan error, 1)
+	go func() { stopped <- fm.Start() }()
+
+	// The signals are handled once the scheduler is started.
+	suite.Require().Eventually(func() bool {
+		history, err := fm.dbManager.ListFeedHistory(time.Now().Add(-time.Hour))
+		suite.Require().NoError(err)
+		return len(history) > 0
+	}, 5*time.Second, 50*time.Millisecond)
+	return fm, stopped
+}
+
+// stopFeeder stops the feeder started by startFeeder.
+func stopFeeder(suite *suite.Suite, stopped chan error) {
+	suite.Require().NoError(syscall.Kill(os.Getpid(), syscall.SIGTERM))
+	select {
+	case err := <-stopped:
+		suite.NoError(err)
+	case <-time.After(10 * time.Second):
+		suite.Fail("feeder did not stop")
+	}
+}
+
+func (suite *ReloadSuite) TestReload_DuringScheduledFeeding() {
+	// The feeding keeps the queue busy, such that the reload is queued
+	// behind it and the next scheduled feeding behind the reload.
+	fed := make(chan error, 1)
+	go func() {
+		_, err := suite.fm.handleFeedCommand(model.FeedMessage{Portions: 4})
+		fed <- err
+	}()
+	suite.Require().Eventually(func() bool {
+		return suite.fm.queue.Depth() > 0
+	}, time.Second, 10*time.Millisecond)
+	suite.Require().NoError(syscall.Kill(os.Getpid(), syscall.SIGHUP))
+
+	select {
+	case err := <-fed:
+		suite.NoError(err)
+	case <-time.After(5 * time.Second):
+		suite.FailNow("feeding did not complete")
+	}
+
+	// The queue keeps serving jobs after the reload.
+	done, err := suite.fm.queue.Enqueue(func() error { return nil })
+	suite.Require().NoError(err)
+	select {
+	case err := <-done:
+		suite.NoError(err)
+	case <-time.After(5 * time.Second):
+		suite.FailNow("feed queue is stuck")
+	}
+
+	// The scheduler keeps feeding after the reload.
+	served := len(suite.feedHistory())
+	suite.Eventually(func() bool {
+		return len(suite.feedHistory()) > served
+	}, 3*time.Second, 50*time.Millisecond)
+}
+
+func (suite *ReloadSuite) feedHistory() []time.Time {
+	history, err := suite.fm.dbManager.ListFeedHistory(time.Now().Add(-time.Hour))
+	suite.Require().NoError(err)
+	var times []time.Time
+	for _, h := range history {
+		times = append(times, h.Timestamp)
+	}
+	return times
+}
+
+func TestReloadSuite(t *testing.T) {
+	suite.Run(t, new(ReloadSuite))
+}
@@ -0,0 +1,131 @@
+package feeder
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/imilchev/rpi-feeder/pkg/feeder/config"
+	"github.com/imilchev/rpi-feeder/pkg/feeder/db"
+	dbm "github.com/imilchev/rpi-feeder/pkg/feeder/db/model"
+	"github.com/imilchev/rpi-feeder/pkg/mqtt/model"
+	"github.com/imilchev/rpi-feeder/pkg/utils"
+	"go.uber.org/zap"
+)
+
+// applyStoredConfig applies the persisted config received from the service
+// over the config file. Returns the resulting config and its revision. The
+// config file is used as it is if no valid config was received.
+func applyStoredConfig(fileConfig *config.Config, dbManager db.DbManager) (*config.Config, int) {
+	stored, err := dbManager.GetConfig()
+	if err != nil {
+		zap.S().Errorf("Failed to read config received from the service. %v", err)
+		return fileConfig, 0
+	}
+	if stored == nil {
+		return fileConfig, 0
+	}
+
+	c, err := config.ApplyRemote(*fileConfig, stored.Config)
+	if err == nil {
+		err = utils.Validate.Struct(c)
+	}
+	if err != nil {
+		zap.S().Errorf("Ignoring config revision %d received from the service. %v", stored.Revision, err)
+		return fileConfig, 0
+	}
+	zap.S().Infof("Using config revision %d received from the service on %s.",
+		stored.Revision, stored.UpdatedAt)
+	return c, stored.Revision
+}
+
+// updateConfig applies the config received from the service. The settings
+// that can be changed while the feeder is running are applied right away, the
+// other ones once the feeder is restarted. Applying goes through the feed queue,
+// such that the settings do not change in the middle of a feeding. A config
+// that cannot be applied is rejected and not applied again.
+func (fm *FeederManager) updateConfig(msg model.ConfigMessage) error {
+	done, err := fm.queue.Enqueue(func() error {
+		// Retained configs are received again on every reconnect.
+		if msg.Revision <= fm.configRevision {
+			zap.S().Debugf("Config revision %d is in use already.", fm.configRevision)
+			return nil
+		}
+		if fm.rejectedConfig != nil && msg.Revision <= fm.rejectedConfig.Revision {
+			zap.S().Debugf("Config revision %d was rejected already.", fm.rejectedConfig.Revision)
+			return nil
+		}
+
+		c, err := config.ApplyRemote(*fm.fileConfig, msg.Config)
+		if err == nil {
+			err = utils.Validate.Struct(c)
+		}
+		var pendingRestart bool
+		if err == nil {
+			pendingRestart, err = fm.applyConfig(c)
+		}
+		if err != nil {
+			fm.rejectConfig(msg.Revision, err)
+			return err
+		}
+
+		// Persist the config so it is used after restarts of the feeder.
+		if err := fm.dbManager.SetConfig(dbm.Config{
+			Revision:  msg.Revision,
+			Config:    msg.Config,
+			UpdatedAt: time.Now().UTC(),
+		}); err != nil {
+			return fmt.Errorf("failed to persist config: %w", err)
+		}
+		fm.configRevision = msg.Revision
+		fm.configPendingRestart = pendingRestart
+		if pendingRestart {
+			zap.S().Warnf("Config revision %d is applied partially, restart the feeder to apply it completely.",
+				msg.Revision)
+		} else {
+			zap.S().Infof("Config revision %d applied.", msg.Revision)
+		}
+		fm.sendConfigStatus()
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	return <-done
+}
+
+// rejectConfig records that the config revision could not be applied, such
+// that it is not applied again when the retained config is received again,
+// e.g. once the feeder reconnected with the previous MQTT settings.
+func (fm *FeederManager) rejectConfig(revision int, reason error) {
+	zap.S().Errorf("Rejecting config revision %d. %v", revision, reason)
+	fm.rejectedConfig = &dbm.RejectedConfig{
+		Revision:   revision,
+		Error:      reason.Error(),
+		RejectedAt: time.Now().UTC(),
+	}
+	if err := fm.dbManager.SetRejectedConfig(*fm.rejectedConfig); err != nil {
+		zap.S().Errorf("Failed to persist rejected config revision %d. %v", revision, err)
+	}
+	fm.sendConfigStatus()
+}
+
+// configStatus returns the state of the config received from the service. A
+// rejected revision is only reported while it is newer than the one in use.
+func (fm *FeederManager) configStatus() model.ConfigStatus {
+	s := model.ConfigStatus{
+		ConfigRevision:       fm.configRevision,
+		ConfigPendingRestart: fm.configPendingRestart,
+	}
+	if r := fm.rejectedConfig; r != nil && r.Revision > fm.configRevision {
+		s.ConfigRejectedRevision = r.Revision
+		s.ConfigError = r.Error
+	}
+	return s
+}
+
+func (fm *FeederManager) sendConfigStatus() {
+	s := fm.configStatus()
+	if err := fm.mqtt().SendConfigStatus(s); err != nil {
+		zap.S().Warnf("Failed to send config revision %d. %v", s.ConfigRevision, err)
+	}
+}
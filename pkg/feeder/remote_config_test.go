@@ -0,0 +1,66 @@
+package feeder
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/imilchev/rpi-feeder/pkg/mqtt/model"
+	"github.com/stretchr/testify/suite"
+)
+
+type RemoteConfigSuite struct {
+	suite.Suite
+	fm      *FeederManager
+	stopped chan error
+}
+
+func (suite *RemoteConfigSuite) SetupTest() {
+	suite.fm, suite.stopped = startFeeder(&suite.Suite)
+}
+
+func (suite *RemoteConfigSuite) TearDownTest() {
+	stopFeeder(&suite.Suite, suite.stopped)
+}
+
+func (suite *RemoteConfigSuite) TestUpdateConfig() {
+	suite.NoError(suite.fm.updateConfig(model.ConfigMessage{
+		Revision: 2,
+		Config:   json.RawMessage(`{"portionMs": 200}`),
+	}))
+	suite.Equal(uint64(200), suite.fm.config.PortionMs)
+	suite.Equal(model.ConfigStatus{ConfigRevision: 2}, suite.fm.configStatus())
+
+	stored, err := suite.fm.dbManager.GetConfig()
+	suite.NoError(err)
+	suite.Equal(2, stored.Revision)
+}
+
+func (suite *RemoteConfigSuite) TestUpdateConfig_Rejected() {
+	msg := model.ConfigMessage{Revision: 2, Config: json.RawMessage(`{"feedQueueSize": 0}`)}
+	suite.Error(suite.fm.updateConfig(msg))
+
+	s := suite.fm.configStatus()
+	suite.Equal(0, s.ConfigRevision)
+	suite.Equal(2, s.ConfigRejectedRevision)
+	suite.NotEmpty(s.ConfigError)
+
+	rejected, err := suite.fm.dbManager.GetRejectedConfig()
+	suite.NoError(err)
+	suite.Require().NotNil(rejected)
+	suite.Equal(2, rejected.Revision)
+
+	// The retained config is received again, e.g. on reconnects.
+	suite.NoError(suite.fm.updateConfig(msg))
+	suite.Equal(s, suite.fm.configStatus())
+
+	// A newer revision is applied.
+	suite.NoError(suite.fm.updateConfig(model.ConfigMessage{
+		Revision: 3,
+		Config:   json.RawMessage(`{"portionMs": 200}`),
+	}))
+	suite.Equal(model.ConfigStatus{ConfigRevision: 3}, suite.fm.configStatus())
+}
+
+func TestRemoteConfigSuite(t *testing.T) {
+	suite.Run(t, new(RemoteConfigSuite))
+}
@@ -0,0 +1,91 @@
+package scale
+
+import (
+	"errors"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/imilchev/rpi-feeder/pkg/feeder/config"
+	"github.com/imilchev/rpi-feeder/pkg/feeder/gpio"
+	"go.uber.org/zap"
+)
+
+const (
+	// readyTimeout is how long to wait for a conversion. The HX711 converts
+	// at 10 or 80 samples per second, depending on how it is wired.
+	readyTimeout = time.Second
+
+	// samplesPerWeight is the amount of conversions read for a weight. The
+	// median of the samples is used, which filters out spikes.
+	samplesPerWeight = 3
+)
+
+// ErrNotReady is returned when the HX711 does not finish a conversion in time,
+// e.g. because it is not connected.
+var ErrNotReady = errors.New("load cell amplifier is not ready")
+
+// hx711 is a load cell connected through an HX711 amplifier. The amplifier
+// signals a finished conversion by pulling the data pin low, after which the
+// 24 bit result is shifted out by pulsing the clock pin.
+type hx711 struct {
+	mu            sync.Mutex
+	data          gpio.Pin
+	clock         gpio.Pin
+	countsPerGram float64
+}
+
+func NewHx711(g gpio.Gpio, cfg config.ScaleConfig) Scale {
+	data := g.Pin(cfg.DataPin)
+	data.Mode(gpio.Input)
+	clock := g.Pin(cfg.ClockPin)
+	clock.Mode(gpio.Output)
+
+	// Holding the clock high for more than 60µs powers the HX711 down.
+	clock.Low()
+	zap.S().Infof(
+		"Using pins %d (data) and %d (clock) to weigh the food.", cfg.DataPin, cfg.ClockPin)
+	return &hx711{data: data, clock: clock, countsPerGram: cfg.CountsPerGram}
+}
+
+func (h *hx711) Weight() (float64, error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	var samples []int32
+	for i := 0; i < samplesPerWeight; i++ {
+		v, err := h.read()
+		if err != nil {
+			return 0, err
+		}
+		samples = append(samples, v)
+	}
+	sort.Slice(samples, func(i, j int) bool { return samples[i] < samples[j] })
+	return float64(samples[len(samples)/2]) / h.countsPerGram, nil
+}
+
+// read waits for a conversion and returns its raw value.
+func (h *hx711) read() (int32, error) {
+	deadline := time.Now().Add(readyTimeout)
+	for h.data.Read() == gpio.High {
+		if time.Now().After(deadline) {
+			return 0, ErrNotReady
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	var v uint32
+	for i := 0; i < 24; i++ {
+		h.clock.High()
+		v = v<<1 | uint32(h.data.Read())
+		h.clock.Low()
+	}
+
+	// The 25th pulse selects channel A with a gain of 128 for the next
+	// conversion.
+	h.clock.High()
+	h.clock.Low()
+
+	// The value is a 24 bit two's complement.
+	return int32(v<<8) >> 8, nil
+}
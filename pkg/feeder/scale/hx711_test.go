@@ -0,0 +1,119 @@
+package scale
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/imilchev/rpi-feeder/pkg/feeder/config"
+	"github.com/imilchev/rpi-feeder/pkg/feeder/gpio"
+	"github.com/stretchr/testify/suite"
+)
+
+const (
+	dataPin  = 5
+	clockPin = 6
+)
+
+// hx711Gpio simulates an HX711 that shifts out the raw value on the data pin,
+// one bit on every rising edge of the clock pin.
+type hx711Gpio struct {
+	*gpio.Memory
+
+	mu     sync.Mutex
+	raw    int32
+	busy   bool
+	bit    int
+	pulses int
+}
+
+type clockPinFake struct {
+	gpio.Pin
+	g *hx711Gpio
+}
+
+type dataPinFake struct {
+	gpio.Pin
+	g *hx711Gpio
+}
+
+func (g *hx711Gpio) Pin(number uint8) gpio.Pin {
+	switch number {
+	case clockPin:
+		return &clockPinFake{Pin: g.Memory.Pin(number), g: g}
+	case dataPin:
+		return &dataPinFake{Pin: g.Memory.Pin(number), g: g}
+	}
+	return g.Memory.Pin(number)
+}
+
+func (p *clockPinFake) High() {
+	p.Pin.High()
+	p.g.mu.Lock()
+	defer p.g.mu.Unlock()
+	p.g.pulses++
+	p.g.bit = (p.g.bit + 1) % 25
+}
+
+func (p *dataPinFake) Read() gpio.State {
+	p.g.mu.Lock()
+	defer p.g.mu.Unlock()
+
+	if p.g.busy {
+		return gpio.High
+	}
+	if p.g.bit == 0 || p.g.bit > 24 {
+		// Ready for the next conversion.
+		return gpio.Low
+	}
+	return gpio.State(uint32(p.g.raw) >> (24 - p.g.bit) & 1)
+}
+
+type Hx711Suite struct {
+	suite.Suite
+	gpio *hx711Gpio
+	s    Scale
+}
+
+func (suite *Hx711Suite) SetupTest() {
+	suite.gpio = &hx711Gpio{Memory: gpio.NewMemory()}
+	suite.s = NewHx711(suite.gpio, config.ScaleConfig{
+		DataPin:       dataPin,
+		ClockPin:      clockPin,
+		CountsPerGram: 400,
+	})
+}
+
+func (suite *Hx711Suite) TestNewHx711() {
+	suite.Equal(gpio.Input, suite.gpio.PinMode(dataPin))
+	suite.Equal(gpio.Output, suite.gpio.PinMode(clockPin))
+}
+
+func (suite *Hx711Suite) TestWeight() {
+	suite.gpio.raw = 20000
+
+	w, err := suite.s.Weight()
+	suite.NoError(err)
+	suite.Equal(50.0, w)
+
+	// Every conversion takes 24 pulses for the value and 1 for the gain.
+	suite.Equal(samplesPerWeight*25, suite.gpio.pulses)
+}
+
+func (suite *Hx711Suite) TestWeight_Negative() {
+	suite.gpio.raw = -4000
+
+	w, err := suite.s.Weight()
+	suite.NoError(err)
+	suite.Equal(-10.0, w)
+}
+
+func (suite *Hx711Suite) TestWeight_NotReady() {
+	suite.gpio.busy = true
+
+	_, err := suite.s.Weight()
+	suite.ErrorIs(err, ErrNotReady)
+}
+
+func TestHx711Suite(t *testing.T) {
+	suite.Run(t, new(Hx711Suite))
+}
@@ -0,0 +1,9 @@
+package scale
+
+// Scale weighs the food served by the feeder.
+type Scale interface {
+	// Weight returns the current weight in grams. The weight is relative to
+	// an arbitrary zero point, so only differences between two weights are
+	// meaningful.
+	Weight() (float64, error)
+}
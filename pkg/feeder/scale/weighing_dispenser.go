@@ -0,0 +1,79 @@
+package scale
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/imilchev/rpi-feeder/pkg/feeder/servo"
+	"go.uber.org/zap"
+)
+
+// settleDelay is how long to wait after dispensing for the food to settle on
+// the scale before weighing it.
+const settleDelay = 500 * time.Millisecond
+
+// ErrTargetNotReached is returned when the target weight was not reached
+// within the maximum amount of portions, e.g. because the hopper is empty or
+// the dispenser is jammed.
+var ErrTargetNotReached = errors.New("target weight was not reached")
+
+// WeighingDispenser dispenses food until a target weight is reached.
+type WeighingDispenser interface {
+	// Dispense serves portions one at a time until the target grams are on
+	// the scale or maxPortions were served. Returns the grams that were
+	// dispensed and the portions the dispenser served. Stops mid-way if ctx is
+	// done.
+	Dispense(ctx context.Context, grams float64, maxPortions uint) (float64, uint, error)
+}
+
+type weighingDispenser struct {
+	d              servo.Dispenser
+	s              Scale
+	toleranceGrams float64
+	settleDelay    time.Duration
+}
+
+// NewWeighingDispenser creates a dispenser that weighs the food served by d.
+// The target weight is considered reached when it is within toleranceGrams.
+func NewWeighingDispenser(d servo.Dispenser, s Scale, toleranceGrams float64) WeighingDispenser {
+	return &weighingDispenser{
+		d:              d,
+		s:              s,
+		toleranceGrams: toleranceGrams,
+		settleDelay:    settleDelay,
+	}
+}
+
+func (w *weighingDispenser) Dispense(ctx context.Context, grams float64, maxPortions uint) (float64, uint, error) {
+	tare, err := w.s.Weight()
+	if err != nil {
+		return 0, 0, err
+	}
+
+	var dispensed float64
+	var portions uint
+	for i := uint(0); i < maxPortions && dispensed < grams-w.toleranceGrams; i++ {
+		served := w.d.Dispense(ctx, 1)
+		portions += served
+		time.Sleep(w.settleDelay)
+
+		// Food is weighed even if the feeding was cancelled, since part of
+		// the portion may have been served.
+		weight, err := w.s.Weight()
+		if err != nil {
+			return dispensed, portions, err
+		}
+		dispensed = weight - tare
+		zap.S().Debugf("Dispensed %.1fg of %.1fg.", dispensed, grams)
+
+		if served == 0 {
+			break
+		}
+	}
+
+	if ctx.Err() == nil && dispensed < grams-w.toleranceGrams {
+		return dispensed, portions, ErrTargetNotReached
+	}
+	return dispensed, portions, nil
+}
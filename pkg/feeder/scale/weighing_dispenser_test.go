@@ -0,0 +1,139 @@
+package scale
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+)
+
+// fakeScale weighs the food put on it by fakeDispenser.
+type fakeScale struct {
+	weight float64
+	err    error
+
+	// failAfter makes Weight fail after the amount of successful calls.
+	failAfter int
+	calls     int
+}
+
+func (s *fakeScale) Weight() (float64, error) {
+	s.calls++
+	if s.err != nil && s.calls > s.failAfter {
+		return 0, s.err
+	}
+	return s.weight, nil
+}
+
+// fakeDispenser puts the grams of each portion on the scale, in order. Once
+// all portions were served, it serves nothing, like an empty hopper.
+type fakeDispenser struct {
+	s        *fakeScale
+	portions []float64
+	served   uint
+	cancel   context.CancelFunc
+}
+
+func (d *fakeDispenser) Dispense(ctx context.Context, portions uint) uint {
+	var served uint
+	for ; served < portions; served++ {
+		if ctx.Err() != nil {
+			return served
+		}
+		if len(d.portions) > 0 {
+			d.s.weight += d.portions[0]
+			d.portions = d.portions[1:]
+		}
+		d.served++
+		if d.cancel != nil && len(d.portions) == 0 {
+			d.cancel()
+		}
+	}
+	return served
+}
+
+type WeighingDispenserSuite struct {
+	suite.Suite
+	s *fakeScale
+	d *fakeDispenser
+}
+
+func (suite *WeighingDispenserSuite) SetupTest() {
+	suite.s = &fakeScale{weight: 100}
+	suite.d = &fakeDispenser{s: suite.s}
+}
+
+func (suite *WeighingDispenserSuite) TestDispense() {
+	suite.d.portions = []float64{8, 8, 8, 8}
+
+	grams, portions, err := suite.newDispenser(0).Dispense(context.Background(), 30, 10)
+	suite.NoError(err)
+	suite.Equal(32.0, grams)
+	suite.Equal(uint(4), portions)
+	suite.Equal(uint(4), suite.d.served)
+}
+
+func (suite *WeighingDispenserSuite) TestDispense_WithinTolerance() {
+	suite.d.portions = []float64{10, 9, 10, 10}
+
+	grams, portions, err := suite.newDispenser(2).Dispense(context.Background(), 30, 10)
+	suite.NoError(err)
+	suite.Equal(29.0, grams)
+	suite.Equal(uint(3), portions)
+	suite.Equal(uint(3), suite.d.served)
+}
+
+func (suite *WeighingDispenserSuite) TestDispense_TargetNotReached() {
+	suite.d.portions = []float64{10}
+
+	grams, portions, err := suite.newDispenser(0).Dispense(context.Background(), 30, 6)
+	suite.ErrorIs(err, ErrTargetNotReached)
+	suite.Equal(10.0, grams)
+	suite.Equal(uint(6), portions)
+	suite.Equal(uint(6), suite.d.served)
+}
+
+func (suite *WeighingDispenserSuite) TestDispense_Cancelled() {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	suite.d.portions = []float64{10, 10}
+	suite.d.cancel = cancel
+
+	grams, portions, err := suite.newDispenser(0).Dispense(ctx, 30, 10)
+	suite.NoError(err)
+	suite.Equal(20.0, grams)
+	suite.Equal(uint(2), portions)
+}
+
+func (suite *WeighingDispenserSuite) TestDispense_TareFailed() {
+	suite.s.err = errors.New("not ready")
+	suite.d.portions = []float64{10}
+
+	_, portions, err := suite.newDispenser(0).Dispense(context.Background(), 30, 10)
+	suite.Error(err)
+	suite.Equal(uint(0), portions)
+	suite.Equal(uint(0), suite.d.served)
+}
+
+func (suite *WeighingDispenserSuite) TestDispense_WeighingFailed() {
+	suite.s.err = errors.New("not ready")
+	suite.s.failAfter = 2
+	suite.d.portions = []float64{10, 10, 10}
+
+	grams, portions, err := suite.newDispenser(0).Dispense(context.Background(), 30, 10)
+	suite.Error(err)
+	suite.Equal(10.0, grams)
+	suite.Equal(uint(2), portions)
+	suite.Equal(uint(2), suite.d.served)
+}
+
+func (suite *WeighingDispenserSuite) newDispenser(toleranceGrams float64) WeighingDispenser {
+	w := NewWeighingDispenser(suite.d, suite.s, toleranceGrams).(*weighingDispenser)
+	w.settleDelay = 0
+	return w
+}
+
+func TestWeighingDispenserSuite(t *testing.T) {
+	suite.Run(t, new(WeighingDispenserSuite))
+}
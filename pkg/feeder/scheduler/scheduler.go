@@ -0,0 +1,243 @@
+package scheduler
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/imilchev/rpi-feeder/pkg/feeder/config"
+	"github.com/robfig/cron/v3"
+	"go.uber.org/zap"
+)
+
+// Run is a single execution of a schedule entry.
+type Run struct {
+	At       time.Time
+	Portions uint
+}
+
+type RunFunc func(run Run) error
+
+type Scheduler interface {
+	Start()
+
+	// Stop stops the scheduler and waits for the scheduled feeding in
+	// progress, if any.
+	Stop()
+
+	// MissedRuns returns the most recent limit runs that were due after since
+	// and until (inclusive), ordered by time, and the total amount of runs
+	// that were due.
+	MissedRuns(since, until time.Time, limit int) ([]Run, int)
+
+	// SetSchedule replaces the schedule. If the scheduler is started, the
+	// new schedule takes effect immediately. A scheduled feeding in progress
+	// is not waited for.
+	SetSchedule(cfg []config.ScheduleEntry) error
+}
+
+type entry struct {
+	spec     string
+	schedule cron.Schedule
+	portions uint
+}
+
+type scheduler struct {
+	mu        sync.Mutex
+	entries   []entry
+	run       RunFunc
+	isStarted bool
+	isRunning bool
+
+	// stopChan is closed to stop the running loop. loops counts the loops
+	// that did not return yet, including stopped ones still running a
+	// scheduled feeding.
+	stopChan chan struct{}
+	loops    sync.WaitGroup
+}
+
+func NewScheduler(cfg []config.ScheduleEntry, run RunFunc) (Scheduler, error) {
+	entries, err := parseEntries(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	return &scheduler{
+		entries: entries,
+		run:     run,
+	}, nil
+}
+
+func (s *scheduler) Start() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.isStarted = true
+	s.loop()
+}
+
+func (s *scheduler) Stop() {
+	s.mu.Lock()
+	s.isStarted = false
+	s.halt()
+	s.mu.Unlock()
+
+	// Wait for the scheduled feeding in progress, if any.
+	s.loops.Wait()
+}
+
+func (s *scheduler) SetSchedule(cfg []config.ScheduleEntry) error {
+	entries, err := parseEntries(cfg)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.halt()
+	s.entries = entries
+	zap.S().Infof("Schedule updated to %d entries.", len(entries))
+	if s.isStarted {
+		s.loop()
+	}
+	return nil
+}
+
+func (s *scheduler) MissedRuns(since, until time.Time, limit int) ([]Run, int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var runs []Run
+	total := 0
+	if len(s.entries) == 0 || limit <= 0 {
+		return runs, total
+	}
+
+	for {
+		next, due := nextRun(s.entries, since)
+		if next.IsZero() || next.After(until) {
+			return runs, total
+		}
+		for _, e := range due {
+			runs = append(runs, Run{At: next, Portions: e.portions})
+			total++
+		}
+		if len(runs) > limit {
+			runs = runs[len(runs)-limit:]
+		}
+		since = next
+	}
+}
+
+// loop starts the scheduling loop for the current entries. Must be called
+// with the mutex held.
+func (s *scheduler) loop() {
+	if len(s.entries) == 0 {
+		zap.S().Info("No feeding schedule is configured.")
+		return
+	}
+
+	zap.S().Infof("Starting scheduler with %d entries.", len(s.entries))
+	s.isRunning = true
+	s.stopChan = make(chan struct{})
+	s.loops.Add(1)
+	go func(entries []entry, stop chan struct{}) {
+		defer s.loops.Done()
+		for {
+			next, due := nextRun(entries, time.Now())
+			if next.IsZero() {
+				zap.S().Warn("None of the scheduled feedings is due anymore.")
+				<-stop
+				zap.S().Debug("Scheduler stopped.")
+				return
+			}
+			zap.S().Debugf("Next scheduled feeding is at %s.", next)
+
+			timer := time.NewTimer(time.Until(next))
+			select {
+			case <-timer.C:
+				for _, e := range due {
+					// The schedule may have been replaced while the previous
+					// entry was running.
+					if isStopped(stop) {
+						zap.S().Debug("Scheduler stopped.")
+						return
+					}
+					zap.S().Infof("Executing scheduled feeding %q.", e.spec)
+					if err := s.run(Run{At: next, Portions: e.portions}); err != nil {
+						zap.S().Errorf("Scheduled feeding %q failed. %v", e.spec, err)
+					}
+				}
+			case <-stop:
+				timer.Stop()
+				zap.S().Debug("Scheduler stopped.")
+				return
+			}
+		}
+	}(s.entries, s.stopChan)
+}
+
+// halt stops the scheduling loop if it is running. It does not wait for a
+// scheduled feeding in progress, since the feeding may wait for whoever
+// replaces the schedule, e.g. a job of the feed queue. Must be called with
+// the mutex held.
+func (s *scheduler) halt() {
+	if s.isRunning {
+		close(s.stopChan)
+		s.isRunning = false
+	}
+}
+
+func isStopped(stop chan struct{}) bool {
+	select {
+	case <-stop:
+		return true
+	default:
+		return false
+	}
+}
+
+// nextRun returns the earliest time after now at which at least one of the
+// entries is due, together with all the entries due at that time. Returns the
+// zero time if none of the entries is due anymore.
+func nextRun(entries []entry, now time.Time) (next time.Time, due []entry) {
+	for _, e := range entries {
+		// Specs that never match, e.g. February 30, have no next time.
+		t := e.schedule.Next(now)
+		switch {
+		case t.IsZero():
+			continue
+		case next.IsZero() || t.Before(next):
+			next = t
+			due = []entry{e}
+		case t.Equal(next):
+			due = append(due, e)
+		}
+	}
+	return next, due
+}
+
+func parseEntries(cfg []config.ScheduleEntry) ([]entry, error) {
+	var entries []entry
+	for _, c := range cfg {
+		spec := c.Cron
+		if c.Time != "" {
+			t, err := time.Parse("15:04", c.Time)
+			if err != nil {
+				return nil, fmt.Errorf("invalid schedule time %q. %w", c.Time, err)
+			}
+			spec = fmt.Sprintf("%d %d * * *", t.Minute(), t.Hour())
+		}
+
+		schedule, err := cron.ParseStandard(spec)
+		if err != nil {
+			return nil, fmt.Errorf("invalid schedule cron expression %q. %w", spec, err)
+		}
+		if schedule.Next(time.Now()).IsZero() {
+			return nil, fmt.Errorf("schedule cron expression %q never matches", spec)
+		}
+		entries = append(entries, entry{spec: spec, schedule: schedule, portions: c.Portions})
+	}
+	return entries, nil
+}
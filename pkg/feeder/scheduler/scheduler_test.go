@@ -0,0 +1,250 @@
+package scheduler
+
+import (
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/imilchev/rpi-feeder/pkg/feeder/config"
+	"github.com/robfig/cron/v3"
+	"github.com/stretchr/testify/suite"
+)
+
+type SchedulerSuite struct {
+	suite.Suite
+}
+
+func (suite *SchedulerSuite) TestParseEntries_Time() {
+	entries, err := parseEntries([]config.ScheduleEntry{{Time: "08:30", Portions: 2}})
+	suite.NoError(err)
+	suite.Equal(1, len(entries))
+	suite.Equal("30 8 * * *", entries[0].spec)
+	suite.Equal(uint(2), entries[0].portions)
+}
+
+func (suite *SchedulerSuite) TestParseEntries_Cron() {
+	entries, err := parseEntries([]config.ScheduleEntry{{Cron: "0 18 * * 1-5", Portions: 1}})
+	suite.NoError(err)
+	suite.Equal(1, len(entries))
+	suite.Equal("0 18 * * 1-5", entries[0].spec)
+}
+
+func (suite *SchedulerSuite) TestParseEntries_InvalidTime() {
+	_, err := parseEntries([]config.ScheduleEntry{{Time: "25:00", Portions: 1}})
+	suite.Error(err)
+}
+
+func (suite *SchedulerSuite) TestParseEntries_InvalidCron() {
+	_, err := parseEntries([]config.ScheduleEntry{{Cron: "not a cron", Portions: 1}})
+	suite.Error(err)
+}
+
+func (suite *SchedulerSuite) TestParseEntries_CronNeverMatches() {
+	_, err := parseEntries([]config.ScheduleEntry{{Cron: "0 0 30 2 *", Portions: 1}})
+	suite.Error(err)
+}
+
+func (suite *SchedulerSuite) TestNextRun_NeverMatches() {
+	never, err := cron.ParseStandard("0 0 30 2 *")
+	suite.Require().NoError(err)
+	daily, err := cron.ParseStandard("0 8 * * *")
+	suite.Require().NoError(err)
+
+	now := time.Date(2022, 1, 10, 12, 0, 0, 0, time.Local)
+	next, due := nextRun([]entry{{spec: "0 0 30 2 *", schedule: never, portions: 1}}, now)
+	suite.True(next.IsZero())
+	suite.Empty(due)
+
+	next, due = nextRun([]entry{
+		{spec: "0 0 30 2 *", schedule: never, portions: 1},
+		{spec: "0 8 * * *", schedule: daily, portions: 2},
+	}, now)
+	suite.Equal(time.Date(2022, 1, 11, 8, 0, 0, 0, time.Local), next)
+	suite.Equal(1, len(due))
+	suite.Equal(uint(2), due[0].portions)
+}
+
+func (suite *SchedulerSuite) TestStart_NeverMatches() {
+	never, err := cron.ParseStandard("0 0 30 2 *")
+	suite.Require().NoError(err)
+
+	var runs int32
+	s, err := NewScheduler(nil, func(run Run) error {
+		atomic.AddInt32(&runs, 1)
+		return nil
+	})
+	suite.Require().NoError(err)
+	s.(*scheduler).entries = []entry{{spec: "0 0 30 2 *", schedule: never, portions: 1}}
+
+	s.Start()
+	time.Sleep(50 * time.Millisecond)
+	s.Stop()
+	suite.Zero(atomic.LoadInt32(&runs))
+}
+
+func (suite *SchedulerSuite) TestNextRun() {
+	entries, err := parseEntries([]config.ScheduleEntry{
+		{Time: "18:00", Portions: 1},
+		{Time: "08:00", Portions: 2},
+		{Cron: "0 8 * * *", Portions: 3},
+	})
+	suite.Require().NoError(err)
+
+	now := time.Date(2022, 1, 10, 12, 0, 0, 0, time.Local)
+	next, due := nextRun(entries, now)
+	suite.Equal(time.Date(2022, 1, 10, 18, 0, 0, 0, time.Local), next)
+	suite.Equal(1, len(due))
+	suite.Equal(uint(1), due[0].portions)
+
+	now = time.Date(2022, 1, 10, 19, 0, 0, 0, time.Local)
+	next, due = nextRun(entries, now)
+	suite.Equal(time.Date(2022, 1, 11, 8, 0, 0, 0, time.Local), next)
+	suite.Equal(2, len(due))
+	suite.Equal(uint(2), due[0].portions)
+	suite.Equal(uint(3), due[1].portions)
+}
+
+func (suite *SchedulerSuite) TestStartStop() {
+	s, err := NewScheduler(
+		[]config.ScheduleEntry{{Cron: "* * * * *", Portions: 1}},
+		func(run Run) error { return nil })
+	suite.Require().NoError(err)
+
+	// Stop must return even though the next run is still pending.
+	s.Start()
+	s.Stop()
+}
+
+func (suite *SchedulerSuite) TestSetSchedule() {
+	s, err := NewScheduler(nil, func(run Run) error { return nil })
+	suite.Require().NoError(err)
+
+	s.Start()
+	suite.NoError(s.SetSchedule([]config.ScheduleEntry{{Time: "08:00", Portions: 1}}))
+	suite.True(s.(*scheduler).isRunning)
+	suite.Equal(1, len(s.(*scheduler).entries))
+
+	suite.Error(s.SetSchedule([]config.ScheduleEntry{{Cron: "invalid", Portions: 1}}))
+	suite.Equal(1, len(s.(*scheduler).entries))
+
+	suite.NoError(s.SetSchedule(nil))
+	suite.False(s.(*scheduler).isRunning)
+	s.Stop()
+}
+
+func (suite *SchedulerSuite) TestSetSchedule_DuringRun() {
+	running := make(chan struct{}, 1)
+	release := make(chan struct{})
+	s, err := NewScheduler(
+		[]config.ScheduleEntry{{Cron: "@every 1s", Portions: 1}},
+		func(run Run) error {
+			select {
+			case running <- struct{}{}:
+			default:
+			}
+			<-release
+			return nil
+		})
+	suite.Require().NoError(err)
+
+	s.Start()
+	select {
+	case <-running:
+	case <-time.After(3 * time.Second):
+		suite.FailNow("scheduled run did not start")
+	}
+
+	// The run in progress may wait for whoever replaces the schedule, so
+	// replacing it must not wait for the run.
+	replaced := make(chan error)
+	go func() { replaced <- s.SetSchedule(nil) }()
+	select {
+	case err := <-replaced:
+		suite.NoError(err)
+	case <-time.After(time.Second):
+		suite.FailNow("SetSchedule waited for the run in progress")
+	}
+
+	stopped := make(chan struct{})
+	go func() {
+		s.Stop()
+		close(stopped)
+	}()
+	select {
+	case <-stopped:
+		suite.Fail("Stop did not wait for the run in progress")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	close(release)
+	select {
+	case <-stopped:
+	case <-time.After(time.Second):
+		suite.Fail("Stop did not return after the run completed")
+	}
+}
+
+func (suite *SchedulerSuite) TestMissedRuns() {
+	s, err := NewScheduler([]config.ScheduleEntry{
+		{Time: "08:00", Portions: 1},
+		{Time: "18:00", Portions: 2},
+	}, func(run Run) error { return nil })
+	suite.Require().NoError(err)
+
+	since := time.Date(2022, 1, 10, 8, 0, 0, 0, time.Local)
+	until := time.Date(2022, 1, 11, 18, 0, 0, 0, time.Local)
+	runs, total := s.MissedRuns(since, until, 10)
+	suite.Equal([]Run{
+		{At: time.Date(2022, 1, 10, 18, 0, 0, 0, time.Local), Portions: 2},
+		{At: time.Date(2022, 1, 11, 8, 0, 0, 0, time.Local), Portions: 1},
+		{At: time.Date(2022, 1, 11, 18, 0, 0, 0, time.Local), Portions: 2},
+	}, runs)
+	suite.Equal(3, total)
+
+	runs, total = s.MissedRuns(until, until.Add(time.Hour), 10)
+	suite.Empty(runs)
+	suite.Zero(total)
+}
+
+func (suite *SchedulerSuite) TestMissedRuns_Limit() {
+	s, err := NewScheduler([]config.ScheduleEntry{
+		{Time: "08:00", Portions: 1},
+		{Time: "18:00", Portions: 2},
+	}, func(run Run) error { return nil })
+	suite.Require().NoError(err)
+
+	since := time.Date(2021, 1, 10, 8, 0, 0, 0, time.Local)
+	until := time.Date(2022, 1, 11, 18, 0, 0, 0, time.Local)
+	runs, total := s.MissedRuns(since, until, 2)
+	suite.Equal([]Run{
+		{At: time.Date(2022, 1, 11, 8, 0, 0, 0, time.Local), Portions: 1},
+		{At: time.Date(2022, 1, 11, 18, 0, 0, 0, time.Local), Portions: 2},
+	}, runs)
+	// Both feedings of the 367 days, except the one at since.
+	suite.Equal(2*367-1, total)
+}
+
+func (suite *SchedulerSuite) TestMissedRuns_NeverMatches() {
+	never, err := cron.ParseStandard("0 0 30 2 *")
+	suite.Require().NoError(err)
+	s, err := NewScheduler(nil, func(run Run) error { return nil })
+	suite.Require().NoError(err)
+	s.(*scheduler).entries = []entry{{spec: "0 0 30 2 *", schedule: never, portions: 1}}
+
+	runs, total := s.MissedRuns(time.Now().Add(-24*time.Hour), time.Now(), 10)
+	suite.Empty(runs)
+	suite.Zero(total)
+}
+
+func (suite *SchedulerSuite) TestMissedRuns_NoSchedule() {
+	s, err := NewScheduler(nil, func(run Run) error { return nil })
+	suite.Require().NoError(err)
+
+	runs, total := s.MissedRuns(time.Now().Add(-24*time.Hour), time.Now(), 10)
+	suite.Empty(runs)
+	suite.Zero(total)
+}
+
+func TestSchedulerSuite(t *testing.T) {
+	suite.Run(t, new(SchedulerSuite))
+}
@@ -0,0 +1,83 @@
+package sensor
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/imilchev/rpi-feeder/pkg/feeder/gpio"
+	"go.uber.org/zap"
+)
+
+const (
+	// speedOfSound is the speed of sound in cm/s at 20°C.
+	speedOfSound = 34300
+
+	// triggerPulse is the length of the pulse that starts a measurement.
+	triggerPulse = 10 * time.Microsecond
+
+	// echoTimeout is how long to wait for each edge of the echo pulse. The
+	// echo for the maximum range of 4m is about 23ms long.
+	echoTimeout = 50 * time.Millisecond
+)
+
+// ErrNoEcho is returned when the sensor does not respond with an echo pulse
+// in time, e.g. because it is not connected.
+var ErrNoEcho = errors.New("no echo received from the sensor")
+
+// hcsr04 is an HC-SR04 ultrasonic sensor. A measurement is started with a
+// pulse on the trigger pin, after which the sensor holds the echo pin high
+// for as long as the sound took to travel to the surface and back.
+type hcsr04 struct {
+	mu      sync.Mutex
+	trigger gpio.Pin
+	echo    gpio.Pin
+	now     func() time.Time
+}
+
+func NewHcsr04(g gpio.Gpio, triggerPin, echoPin uint8) DistanceSensor {
+	trigger := g.Pin(triggerPin)
+	trigger.Mode(gpio.Output)
+	trigger.Low()
+	echo := g.Pin(echoPin)
+	echo.Mode(gpio.Input)
+	zap.S().Infof(
+		"Using pins %d (trigger) and %d (echo) to measure the food level.", triggerPin, echoPin)
+	return &hcsr04{trigger: trigger, echo: echo, now: time.Now}
+}
+
+func (s *hcsr04) Distance() (float64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.trigger.High()
+	time.Sleep(triggerPulse)
+	s.trigger.Low()
+
+	start, ok := s.waitForEcho(gpio.High, s.now().Add(echoTimeout))
+	if !ok {
+		return 0, ErrNoEcho
+	}
+	end, ok := s.waitForEcho(gpio.Low, start.Add(echoTimeout))
+	if !ok {
+		return 0, ErrNoEcho
+	}
+
+	// The sound travels the distance twice.
+	return end.Sub(start).Seconds() * speedOfSound / 2, nil
+}
+
+// waitForEcho busy-waits until the echo pin is in the state and returns the
+// time the state was observed. The pulses are too short for sleeping between
+// the reads. Returns false if the deadline passed.
+func (s *hcsr04) waitForEcho(state gpio.State, deadline time.Time) (time.Time, bool) {
+	for {
+		now := s.now()
+		if s.echo.Read() == state {
+			return now, true
+		}
+		if now.After(deadline) {
+			return now, false
+		}
+	}
+}
@@ -0,0 +1,113 @@
+package sensor
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/imilchev/rpi-feeder/pkg/feeder/gpio"
+	"github.com/stretchr/testify/suite"
+)
+
+const (
+	triggerPin = 23
+	echoPin    = 24
+)
+
+// echoGpio simulates an HC-SR04 by answering every trigger pulse with an echo
+// pulse of the configured length. It uses a fake clock that advances on every
+// reading, such that the measured distance does not depend on scheduling.
+type echoGpio struct {
+	*gpio.Memory
+
+	mu        sync.Mutex
+	clock     time.Time
+	echo      time.Duration
+	noEcho    bool
+	triggered time.Time
+}
+
+func (g *echoGpio) now() time.Time {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.clock = g.clock.Add(time.Microsecond)
+	return g.clock
+}
+
+type triggerPinFake struct {
+	gpio.Pin
+	g *echoGpio
+}
+
+type echoPinFake struct {
+	gpio.Pin
+	g *echoGpio
+}
+
+func (g *echoGpio) Pin(number uint8) gpio.Pin {
+	switch number {
+	case triggerPin:
+		return &triggerPinFake{Pin: g.Memory.Pin(number), g: g}
+	case echoPin:
+		return &echoPinFake{Pin: g.Memory.Pin(number), g: g}
+	}
+	return g.Memory.Pin(number)
+}
+
+func (p *triggerPinFake) Low() {
+	p.Pin.Low()
+	p.g.mu.Lock()
+	defer p.g.mu.Unlock()
+	p.g.triggered = p.g.clock
+}
+
+func (p *echoPinFake) Read() gpio.State {
+	p.g.mu.Lock()
+	defer p.g.mu.Unlock()
+
+	// The echo starts shortly after the end of the trigger pulse.
+	start := p.g.triggered.Add(100 * time.Microsecond)
+	now := p.g.clock
+	if p.g.noEcho || p.g.triggered.IsZero() || now.Before(start) || !now.Before(start.Add(p.g.echo)) {
+		return gpio.Low
+	}
+	return gpio.High
+}
+
+type Hcsr04Suite struct {
+	suite.Suite
+	gpio *echoGpio
+	s    DistanceSensor
+}
+
+func (suite *Hcsr04Suite) SetupTest() {
+	suite.gpio = &echoGpio{Memory: gpio.NewMemory(), clock: time.Now()}
+	s := NewHcsr04(suite.gpio, triggerPin, echoPin).(*hcsr04)
+	s.now = suite.gpio.now
+	suite.s = s
+}
+
+func (suite *Hcsr04Suite) TestNewHcsr04() {
+	suite.Equal(gpio.Output, suite.gpio.PinMode(triggerPin))
+	suite.Equal(gpio.Input, suite.gpio.PinMode(echoPin))
+}
+
+func (suite *Hcsr04Suite) TestDistance() {
+	// An echo of 2ms corresponds to 34.3cm.
+	suite.gpio.echo = 2 * time.Millisecond
+
+	d, err := suite.s.Distance()
+	suite.NoError(err)
+	suite.InDelta(34.3, d, 0.1)
+}
+
+func (suite *Hcsr04Suite) TestDistance_NoEcho() {
+	suite.gpio.noEcho = true
+
+	_, err := suite.s.Distance()
+	suite.ErrorIs(err, ErrNoEcho)
+}
+
+func TestHcsr04Suite(t *testing.T) {
+	suite.Run(t, new(Hcsr04Suite))
+}
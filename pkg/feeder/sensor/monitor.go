@@ -0,0 +1,129 @@
+package sensor
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/imilchev/rpi-feeder/pkg/feeder/config"
+	"go.uber.org/zap"
+)
+
+// samplesPerReading is the amount of distance samples taken for a reading.
+// The median of the samples is used, which filters out the occasional bad
+// echo.
+const samplesPerReading = 5
+
+// sampleDelay is the delay between two samples, such that the echo of the
+// previous measurement does not interfere.
+const sampleDelay = 60 * time.Millisecond
+
+// Reading is a food level measurement.
+type Reading struct {
+	// The fill level in percent.
+	Level      float64
+	DistanceCm float64
+	At         time.Time
+}
+
+// ReadingFunc is called with every reading of the monitor.
+type ReadingFunc func(r Reading)
+
+// Monitor periodically measures the food level.
+type Monitor interface {
+	Start()
+	Stop()
+}
+
+type monitor struct {
+	sensor    DistanceSensor
+	interval  time.Duration
+	emptyCm   float64
+	fullCm    float64
+	onReading ReadingFunc
+
+	sampleDelay time.Duration
+
+	stopOnce    sync.Once
+	stopChan    chan struct{}
+	stoppedChan chan struct{}
+}
+
+func NewMonitor(sensor DistanceSensor, cfg config.FoodLevelConfig, onReading ReadingFunc) Monitor {
+	return newMonitor(
+		sensor, time.Duration(cfg.IntervalSeconds)*time.Second, cfg, onReading)
+}
+
+func newMonitor(
+	sensor DistanceSensor,
+	interval time.Duration,
+	cfg config.FoodLevelConfig,
+	onReading ReadingFunc) *monitor {
+	return &monitor{
+		sensor:      sensor,
+		interval:    interval,
+		emptyCm:     cfg.EmptyDistanceCm,
+		fullCm:      cfg.FullDistanceCm,
+		onReading:   onReading,
+		sampleDelay: sampleDelay,
+		stopChan:    make(chan struct{}),
+		stoppedChan: make(chan struct{}),
+	}
+}
+
+func (m *monitor) Start() {
+	go m.run()
+	zap.S().Infof("Measuring the food level every %s.", m.interval)
+}
+
+func (m *monitor) Stop() {
+	m.stopOnce.Do(func() {
+		close(m.stopChan)
+		<-m.stoppedChan
+	})
+}
+
+func (m *monitor) run() {
+	defer close(m.stoppedChan)
+
+	ticker := time.NewTicker(m.interval)
+	defer ticker.Stop()
+	for {
+		m.read()
+		select {
+		case <-ticker.C:
+		case <-m.stopChan:
+			return
+		}
+	}
+}
+
+func (m *monitor) read() {
+	var samples []float64
+	var lastErr error
+	for i := 0; i < samplesPerReading; i++ {
+		if i > 0 {
+			time.Sleep(m.sampleDelay)
+		}
+		d, err := m.sensor.Distance()
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		samples = append(samples, d)
+	}
+	if len(samples) == 0 {
+		zap.S().Errorf("Failed to measure the food level. %v", lastErr)
+		return
+	}
+
+	sort.Float64s(samples)
+	distance := samples[len(samples)/2]
+	r := Reading{
+		Level:      Level(distance, m.emptyCm, m.fullCm),
+		DistanceCm: distance,
+		At:         time.Now().UTC(),
+	}
+	zap.S().Debugf("Food level is %.1f%% (%.1fcm).", r.Level, r.DistanceCm)
+	m.onReading(r)
+}
@@ -0,0 +1,110 @@
+package sensor
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/imilchev/rpi-feeder/pkg/feeder/config"
+	"github.com/stretchr/testify/suite"
+)
+
+// fakeSensor returns the distances in order, repeating the last one. A
+// negative distance is returned as an error.
+type fakeSensor struct {
+	mu        sync.Mutex
+	distances []float64
+}
+
+func (s *fakeSensor) Distance() (float64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	d := s.distances[0]
+	if len(s.distances) > 1 {
+		s.distances = s.distances[1:]
+	}
+	if d < 0 {
+		return 0, errors.New("no echo")
+	}
+	return d, nil
+}
+
+type MonitorSuite struct {
+	suite.Suite
+	cfg      config.FoodLevelConfig
+	mu       sync.Mutex
+	readings []Reading
+}
+
+func (suite *MonitorSuite) SetupTest() {
+	suite.cfg = config.FoodLevelConfig{EmptyDistanceCm: 30, FullDistanceCm: 5}
+	suite.readings = nil
+}
+
+func (suite *MonitorSuite) TestStart_ReadsPeriodically() {
+	m := suite.newMonitor(&fakeSensor{distances: []float64{15}})
+	m.Start()
+	time.Sleep(25 * time.Millisecond)
+	m.Stop()
+
+	readings := suite.getReadings()
+	suite.GreaterOrEqual(len(readings), 2)
+	for _, r := range readings {
+		suite.InDelta(60.0, r.Level, 0.001)
+		suite.Equal(15.0, r.DistanceCm)
+	}
+}
+
+func (suite *MonitorSuite) TestStart_UsesMedian() {
+	m := suite.newMonitor(&fakeSensor{distances: []float64{15, 2, 16, 40, 17}})
+	m.read()
+
+	readings := suite.getReadings()
+	suite.Require().Len(readings, 1)
+	suite.Equal(16.0, readings[0].DistanceCm)
+}
+
+func (suite *MonitorSuite) TestStart_IgnoresFailedSamples() {
+	m := suite.newMonitor(&fakeSensor{distances: []float64{-1, 20, -1, -1, -1}})
+	m.read()
+
+	readings := suite.getReadings()
+	suite.Require().Len(readings, 1)
+	suite.Equal(20.0, readings[0].DistanceCm)
+}
+
+func (suite *MonitorSuite) TestStart_AllSamplesFailed() {
+	m := suite.newMonitor(&fakeSensor{distances: []float64{-1}})
+	m.read()
+
+	suite.Empty(suite.getReadings())
+}
+
+func (suite *MonitorSuite) TestStop_Twice() {
+	m := suite.newMonitor(&fakeSensor{distances: []float64{15}})
+	m.Start()
+	m.Stop()
+	m.Stop()
+}
+
+func (suite *MonitorSuite) newMonitor(s DistanceSensor) *monitor {
+	m := newMonitor(s, 10*time.Millisecond, suite.cfg, func(r Reading) {
+		suite.mu.Lock()
+		defer suite.mu.Unlock()
+		suite.readings = append(suite.readings, r)
+	})
+	m.sampleDelay = 0
+	return m
+}
+
+func (suite *MonitorSuite) getReadings() []Reading {
+	suite.mu.Lock()
+	defer suite.mu.Unlock()
+	return append([]Reading(nil), suite.readings...)
+}
+
+func TestMonitorSuite(t *testing.T) {
+	suite.Run(t, new(MonitorSuite))
+}
@@ -0,0 +1,23 @@
+package sensor
+
+// DistanceSensor measures the distance to the surface of the food in the
+// hopper.
+type DistanceSensor interface {
+	// Distance returns the measured distance in cm.
+	Distance() (float64, error)
+}
+
+// Level converts the distance to a fill level in percent. emptyCm and fullCm
+// are the distances measured when the hopper is empty and full respectively.
+// The level is clamped to the 0-100 range, since the sensor can measure
+// beyond the calibration values, e.g. when the hopper is overfilled.
+func Level(distanceCm, emptyCm, fullCm float64) float64 {
+	level := (emptyCm - distanceCm) / (emptyCm - fullCm) * 100
+	if level < 0 {
+		return 0
+	}
+	if level > 100 {
+		return 100
+	}
+	return level
+}
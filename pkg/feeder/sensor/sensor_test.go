@@ -0,0 +1,26 @@
+package sensor
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+)
+
+type LevelSuite struct {
+	suite.Suite
+}
+
+func (suite *LevelSuite) TestLevel() {
+	suite.Equal(0.0, Level(30, 30, 5))
+	suite.Equal(100.0, Level(5, 30, 5))
+	suite.InDelta(60.0, Level(15, 30, 5), 0.001)
+}
+
+func (suite *LevelSuite) TestLevel_Clamped() {
+	suite.Equal(0.0, Level(40, 30, 5))
+	suite.Equal(100.0, Level(2, 30, 5))
+}
+
+func TestLevelSuite(t *testing.T) {
+	suite.Run(t, new(LevelSuite))
+}
@@ -0,0 +1,106 @@
+package servo
+
+import (
+	"context"
+	"time"
+
+	"github.com/imilchev/rpi-feeder/pkg/feeder/config"
+	"go.uber.org/zap"
+)
+
+// Dispenser drives a servo according to the configured dispense strategy.
+type Dispenser interface {
+	// Dispense serves the portions and returns the amount of portions that
+	// were completely served. Stops mid-way if ctx is done.
+	Dispense(ctx context.Context, portions uint) uint
+}
+
+type dispenser struct {
+	sc        ServoController
+	portionMs uint64
+	cfg       config.DispenseConfig
+}
+
+// NewDispenser creates a dispenser for the servo controller. Steppers dispense
+// their configured amount of steps per portion, regardless of portionMs and
+// the dispense config.
+func NewDispenser(sc ServoController, portionMs uint64, cfg config.DispenseConfig) Dispenser {
+	if s, ok := sc.(Stepper); ok {
+		return &stepperDispenser{s: s}
+	}
+	return &dispenser{sc: sc, portionMs: portionMs, cfg: cfg}
+}
+
+func (d *dispenser) Dispense(ctx context.Context, portions uint) uint {
+	var served uint
+	for served < portions {
+		if !d.dispensePortion(ctx) {
+			return served
+		}
+		served++
+
+		if d.cfg.UnjamEvery > 0 && served%d.cfg.UnjamEvery == 0 && served < portions {
+			if !d.unjam(ctx) {
+				return served
+			}
+		}
+	}
+	return served
+}
+
+func (d *dispenser) dispensePortion(ctx context.Context) bool {
+	if d.cfg.Strategy != config.ReverseDispense {
+		return d.move(ctx, d.sc.RotateClockwise, d.portionMs)
+	}
+
+	for forward := uint64(0); forward < d.portionMs; forward += d.cfg.ForwardMs {
+		ms := d.cfg.ForwardMs
+		if d.portionMs-forward < ms {
+			ms = d.portionMs - forward
+		}
+		if !d.move(ctx, d.sc.RotateClockwise, ms) ||
+			!d.move(ctx, d.sc.RotateCounterClockwise, d.cfg.ReverseMs) {
+			return false
+		}
+	}
+	return true
+}
+
+func (d *dispenser) unjam(ctx context.Context) bool {
+	zap.S().Debug("Wiggling servo to prevent jams...")
+	for i := uint(0); i < d.cfg.UnjamWiggles; i++ {
+		if !d.move(ctx, d.sc.RotateCounterClockwise, d.cfg.UnjamMs) ||
+			!d.move(ctx, d.sc.RotateClockwise, d.cfg.UnjamMs) {
+			return false
+		}
+	}
+	return true
+}
+
+// move rotates the servo for ms milliseconds. Returns false if ctx was done
+// before the time elapsed. The servo is stopped in either case.
+func (d *dispenser) move(ctx context.Context, rotate func(), ms uint64) bool {
+	rotate()
+	defer d.sc.Stop()
+
+	timer := time.NewTimer(time.Duration(ms) * time.Millisecond)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
+
+type stepperDispenser struct {
+	s Stepper
+}
+
+func (d *stepperDispenser) Dispense(ctx context.Context, portions uint) uint {
+	var served uint
+	for served < portions && d.s.Step(ctx, int(d.s.StepsPerPortion())) {
+		served++
+	}
+	return served
+}
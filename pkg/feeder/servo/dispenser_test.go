@@ -0,0 +1,93 @@
+package servo
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/imilchev/rpi-feeder/pkg/feeder/config"
+	"github.com/stretchr/testify/suite"
+)
+
+const (
+	cw   = "cw"
+	ccw  = "ccw"
+	stop = "stop"
+)
+
+// recordingServoController records the movements of the servo.
+type recordingServoController struct {
+	mu        sync.Mutex
+	movements []string
+}
+
+func (sc *recordingServoController) RotateClockwise()        { sc.record(cw) }
+func (sc *recordingServoController) RotateCounterClockwise() { sc.record(ccw) }
+func (sc *recordingServoController) Stop()                   { sc.record(stop) }
+func (sc *recordingServoController) Close()                  {}
+
+func (sc *recordingServoController) record(m string) {
+	sc.mu.Lock()
+	defer sc.mu.Unlock()
+	sc.movements = append(sc.movements, m)
+}
+
+type DispenserSuite struct {
+	suite.Suite
+	sc *recordingServoController
+}
+
+func (suite *DispenserSuite) SetupTest() {
+	suite.sc = &recordingServoController{}
+}
+
+func (suite *DispenserSuite) TestDispense_Continuous() {
+	d := NewDispenser(suite.sc, 1, config.DispenseConfig{})
+	suite.Equal(uint(2), d.Dispense(context.Background(), 2))
+	suite.Equal([]string{cw, stop, cw, stop}, suite.sc.movements)
+}
+
+func (suite *DispenserSuite) TestDispense_Reverse() {
+	d := NewDispenser(suite.sc, 3, config.DispenseConfig{
+		Strategy:  config.ReverseDispense,
+		ForwardMs: 2,
+		ReverseMs: 1,
+	})
+	suite.Equal(uint(1), d.Dispense(context.Background(), 1))
+
+	// 2ms and then the remaining 1ms forward.
+	suite.Equal([]string{cw, stop, ccw, stop, cw, stop, ccw, stop}, suite.sc.movements)
+}
+
+func (suite *DispenserSuite) TestDispense_Unjam() {
+	d := NewDispenser(suite.sc, 1, config.DispenseConfig{
+		UnjamEvery:   2,
+		UnjamWiggles: 1,
+		UnjamMs:      1,
+	})
+	suite.Equal(uint(4), d.Dispense(context.Background(), 4))
+
+	// No wiggle after the last portion.
+	suite.Equal([]string{
+		cw, stop, cw, stop,
+		ccw, stop, cw, stop,
+		cw, stop, cw, stop,
+	}, suite.sc.movements)
+}
+
+func (suite *DispenserSuite) TestDispense_Cancelled() {
+	d := NewDispenser(suite.sc, 20, config.DispenseConfig{})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	served := d.Dispense(ctx, 100)
+	suite.Less(served, uint(100))
+
+	// The servo is stopped after being cancelled.
+	suite.Equal(stop, suite.sc.movements[len(suite.sc.movements)-1])
+}
+
+func TestDispenserSuite(t *testing.T) {
+	suite.Run(t, new(DispenserSuite))
+}
@@ -1,8 +1,28 @@
 package servo
 
+import (
+	"github.com/imilchev/rpi-feeder/pkg/feeder/config"
+	"github.com/imilchev/rpi-feeder/pkg/feeder/gpio"
+)
+
 type ServoController interface {
 	RotateClockwise()
 	RotateCounterClockwise()
 	Stop()
 	Close()
 }
+
+// NewServoController creates a servo controller using the driver selected in
+// the config. The pin is used by the gpio driver only. The GPIO is shared with
+// other devices of the feeder, so it is not closed together with the
+// controller.
+func NewServoController(g gpio.Gpio, pinNumber uint8, cfg config.ServoConfig) (ServoController, error) {
+	switch cfg.Driver {
+	case config.PwmServoDriver:
+		return newPwmServoController(defaultPwmSysfsPath, cfg)
+	case config.StepperServoDriver:
+		return newStepperController(g, *cfg.Stepper), nil
+	default:
+		return newGpioServoController(g, pinNumber), nil
+	}
+}
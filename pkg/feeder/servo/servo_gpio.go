@@ -0,0 +1,81 @@
+package servo
+
+import (
+	"time"
+
+	"github.com/imilchev/rpi-feeder/pkg/feeder/gpio"
+	"go.uber.org/zap"
+)
+
+// The pulses for each direction of a continuous rotation servo. The pulse
+// width determines the direction.
+const (
+	clockwisePulse        = 2 * time.Millisecond
+	clockwiseRest         = 17 * time.Millisecond
+	counterClockwisePulse = 1 * time.Millisecond
+	counterClockwiseRest  = 19 * time.Millisecond
+)
+
+// servoController generates the servo pulses by toggling a GPIO pin.
+type servoController struct {
+	pin         gpio.Pin
+	stopChan    chan struct{}
+	stoppedChan chan struct{}
+	isRotating  bool
+}
+
+func newGpioServoController(g gpio.Gpio, pinNumber uint8) ServoController {
+	pin := g.Pin(pinNumber)
+	pin.Mode(gpio.Output)
+	zap.S().Infof("Using pin %d to control the servo.", pinNumber)
+	return &servoController{
+		pin:         pin,
+		stopChan:    make(chan struct{}),
+		stoppedChan: make(chan struct{}),
+	}
+}
+
+func (sc *servoController) RotateClockwise() {
+	zap.S().Debug("Rotating servo clockwise...")
+	sc.rotate(clockwisePulse, clockwiseRest)
+}
+
+func (sc *servoController) RotateCounterClockwise() {
+	zap.S().Debug("Rotating servo counter-clockwise...")
+	sc.rotate(counterClockwisePulse, counterClockwiseRest)
+}
+
+func (sc *servoController) Stop() {
+	if sc.isRotating {
+		sc.stopChan <- struct{}{}
+		<-sc.stoppedChan
+		sc.isRotating = false
+	}
+}
+
+func (sc *servoController) Close() {
+	if sc.isRotating {
+		sc.Stop()
+	}
+	close(sc.stopChan)
+	close(sc.stoppedChan)
+}
+
+func (sc *servoController) rotate(pulse, rest time.Duration) {
+	sc.isRotating = true
+	go func() {
+		for {
+			select {
+			default:
+				sc.pin.High()
+				time.Sleep(pulse)
+				sc.pin.Low()
+				time.Sleep(rest)
+			case <-sc.stopChan:
+				zap.S().Debug("Servo rotation stopped.")
+				sc.stoppedChan <- struct{}{}
+				return
+			}
+		}
+	}()
+}
@@ -0,0 +1,81 @@
+package servo
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/imilchev/rpi-feeder/pkg/feeder/config"
+	"github.com/imilchev/rpi-feeder/pkg/feeder/gpio"
+	"github.com/stretchr/testify/suite"
+)
+
+// pulseTolerance is how much longer than requested a pulse may take, since
+// sleeping is not exact.
+const pulseTolerance = 10 * time.Millisecond
+
+type GpioServoControllerSuite struct {
+	suite.Suite
+	gpio *gpio.Memory
+	sc   ServoController
+}
+
+func (suite *GpioServoControllerSuite) SetupTest() {
+	suite.gpio = gpio.NewRecording()
+	suite.sc = newGpioServoController(suite.gpio, 17)
+}
+
+func (suite *GpioServoControllerSuite) TestNewGpioServoController() {
+	suite.Equal(gpio.Output, suite.gpio.PinMode(17))
+}
+
+func (suite *GpioServoControllerSuite) TestRotateClockwise() {
+	suite.sc.RotateClockwise()
+	time.Sleep(100 * time.Millisecond)
+	suite.sc.Stop()
+	suite.assertPulses(clockwisePulse, clockwiseRest)
+}
+
+func (suite *GpioServoControllerSuite) TestRotateCounterClockwise() {
+	suite.sc.RotateCounterClockwise()
+	time.Sleep(100 * time.Millisecond)
+	suite.sc.Stop()
+	suite.assertPulses(counterClockwisePulse, counterClockwiseRest)
+}
+
+func (suite *GpioServoControllerSuite) TestDispense() {
+	d := NewDispenser(suite.sc, 1, config.DispenseConfig{
+		Strategy:     config.ReverseDispense,
+		ForwardMs:    1,
+		ReverseMs:    1,
+		UnjamEvery:   1,
+		UnjamWiggles: 2,
+		UnjamMs:      1,
+	})
+	suite.Equal(uint(3), d.Dispense(context.Background(), 3))
+	suite.NotEmpty(suite.gpio.Events())
+}
+
+// assertPulses checks that the pin alternates between high for the pulse and
+// low for the rest of the period.
+func (suite *GpioServoControllerSuite) assertPulses(pulse, rest time.Duration) {
+	events := suite.gpio.Events()
+	suite.Require().Greater(len(events), 4)
+	suite.Equal(gpio.High, events[0].State)
+
+	for i := 1; i < len(events); i++ {
+		suite.Equal(uint8(17), events[i].Pin)
+		expected := rest
+		if events[i].State == gpio.Low {
+			expected = pulse
+		}
+		suite.NotEqual(events[i-1].State, events[i].State)
+		d := events[i].At.Sub(events[i-1].At)
+		suite.GreaterOrEqual(d, expected)
+		suite.Less(d, expected+pulseTolerance)
+	}
+}
+
+func TestGpioServoControllerSuite(t *testing.T) {
+	suite.Run(t, new(GpioServoControllerSuite))
+}
@@ -0,0 +1,126 @@
+package servo
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+
+	"github.com/imilchev/rpi-feeder/pkg/feeder/config"
+	"go.uber.org/zap"
+)
+
+const (
+	defaultPwmSysfsPath = "/sys/class/pwm"
+
+	// exportTimeout is how long to wait for the channel to appear in sysfs
+	// after exporting it.
+	exportTimeout = time.Second
+)
+
+// pwmServoController drives the servo with the hardware PWM through the Linux
+// sysfs interface. The pulses are generated by the hardware, so the timing is
+// not affected by the load of the system.
+type pwmServoController struct {
+	chipPath           string
+	channelPath        string
+	channel            uint
+	clockwiseNs        uint64
+	counterClockwiseNs uint64
+}
+
+func newPwmServoController(sysfsPath string, cfg config.ServoConfig) (ServoController, error) {
+	chipPath := filepath.Join(sysfsPath, fmt.Sprintf("pwmchip%d", cfg.PwmChip))
+	sc := &pwmServoController{
+		chipPath:           chipPath,
+		channelPath:        filepath.Join(chipPath, fmt.Sprintf("pwm%d", cfg.PwmChannel)),
+		channel:            cfg.PwmChannel,
+		clockwiseNs:        cfg.ClockwisePulseUs * 1000,
+		counterClockwiseNs: cfg.CounterClockwisePulseUs * 1000,
+	}
+
+	if err := sc.export(); err != nil {
+		zap.S().Errorf("Could not export PWM channel %s. %+v", sc.channelPath, err)
+		return nil, err
+	}
+
+	// The duty cycle must not exceed the period, so it is reset before the
+	// period is set.
+	if err := sc.write("enable", 0); err != nil {
+		return nil, err
+	}
+	if err := sc.write("duty_cycle", 0); err != nil {
+		return nil, err
+	}
+	if err := sc.write("period", cfg.PeriodUs*1000); err != nil {
+		return nil, err
+	}
+
+	zap.S().Infof("Initialized PWM. Using %s to control the servo.", sc.channelPath)
+	return sc, nil
+}
+
+func (sc *pwmServoController) RotateClockwise() {
+	zap.S().Debug("Rotating servo clockwise...")
+	sc.rotate(sc.clockwiseNs)
+}
+
+func (sc *pwmServoController) RotateCounterClockwise() {
+	zap.S().Debug("Rotating servo counter-clockwise...")
+	sc.rotate(sc.counterClockwiseNs)
+}
+
+func (sc *pwmServoController) Stop() {
+	if err := sc.write("enable", 0); err != nil {
+		zap.S().Errorf("Failed to stop servo. %+v", err)
+		return
+	}
+	zap.S().Debug("Servo rotation stopped.")
+}
+
+func (sc *pwmServoController) Close() {
+	sc.Stop()
+	if err := writeSysfs(filepath.Join(sc.chipPath, "unexport"), uint64(sc.channel)); err != nil {
+		zap.S().Errorf("Failed to unexport PWM channel %s. %+v", sc.channelPath, err)
+	}
+	zap.S().Info("PWM closed.")
+}
+
+func (sc *pwmServoController) rotate(pulseNs uint64) {
+	if err := sc.write("duty_cycle", pulseNs); err != nil {
+		zap.S().Errorf("Failed to set servo pulse width. %+v", err)
+		return
+	}
+	if err := sc.write("enable", 1); err != nil {
+		zap.S().Errorf("Failed to enable PWM. %+v", err)
+	}
+}
+
+// export makes the channel available in sysfs, unless it already is.
+func (sc *pwmServoController) export() error {
+	if _, err := os.Stat(sc.channelPath); err == nil {
+		return nil
+	}
+	if err := writeSysfs(filepath.Join(sc.chipPath, "export"), uint64(sc.channel)); err != nil {
+		return err
+	}
+
+	// The channel directory is created asynchronously by the kernel.
+	deadline := time.Now().Add(exportTimeout)
+	for {
+		_, err := os.Stat(sc.channelPath)
+		if err == nil || time.Now().After(deadline) {
+			return err
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func (sc *pwmServoController) write(attr string, value uint64) error {
+	return writeSysfs(filepath.Join(sc.channelPath, attr), value)
+}
+
+func writeSysfs(path string, value uint64) error {
+	return os.WriteFile(path, []byte(strconv.FormatUint(value, 10)), 0644)
+}
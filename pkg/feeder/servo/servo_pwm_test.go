@@ -0,0 +1,95 @@
+package servo
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/imilchev/rpi-feeder/pkg/feeder/config"
+	"github.com/stretchr/testify/suite"
+)
+
+type PwmServoControllerSuite struct {
+	suite.Suite
+	sysfsPath   string
+	chipPath    string
+	channelPath string
+	cfg         config.ServoConfig
+}
+
+func (suite *PwmServoControllerSuite) SetupTest() {
+	suite.sysfsPath = suite.T().TempDir()
+	suite.chipPath = filepath.Join(suite.sysfsPath, "pwmchip0")
+	suite.channelPath = filepath.Join(suite.chipPath, "pwm1")
+	suite.Require().NoError(os.MkdirAll(suite.chipPath, 0755))
+	suite.cfg = config.ServoConfig{
+		Driver:                  config.PwmServoDriver,
+		PwmChip:                 0,
+		PwmChannel:              1,
+		PeriodUs:                20000,
+		ClockwisePulseUs:        2000,
+		CounterClockwisePulseUs: 1000,
+	}
+}
+
+func (suite *PwmServoControllerSuite) TestNewPwmServoController() {
+	suite.Require().NoError(os.MkdirAll(suite.channelPath, 0755))
+
+	_, err := newPwmServoController(suite.sysfsPath, suite.cfg)
+	suite.Require().NoError(err)
+	suite.Equal("20000000", suite.read("pwm1/period"))
+	suite.Equal("0", suite.read("pwm1/duty_cycle"))
+	suite.Equal("0", suite.read("pwm1/enable"))
+
+	// The channel was exported already.
+	suite.NoFileExists(filepath.Join(suite.chipPath, "export"))
+}
+
+func (suite *PwmServoControllerSuite) TestNewPwmServoController_Export() {
+	go func() {
+		time.Sleep(20 * time.Millisecond)
+		suite.NoError(os.MkdirAll(suite.channelPath, 0755))
+	}()
+
+	_, err := newPwmServoController(suite.sysfsPath, suite.cfg)
+	suite.Require().NoError(err)
+	suite.Equal("1", suite.read("export"))
+	suite.Equal("20000000", suite.read("pwm1/period"))
+}
+
+func (suite *PwmServoControllerSuite) TestNewPwmServoController_ExportFailed() {
+	_, err := newPwmServoController(suite.sysfsPath, suite.cfg)
+	suite.Error(err)
+}
+
+func (suite *PwmServoControllerSuite) TestRotate() {
+	suite.Require().NoError(os.MkdirAll(suite.channelPath, 0755))
+	sc, err := newPwmServoController(suite.sysfsPath, suite.cfg)
+	suite.Require().NoError(err)
+
+	sc.RotateClockwise()
+	suite.Equal("2000000", suite.read("pwm1/duty_cycle"))
+	suite.Equal("1", suite.read("pwm1/enable"))
+
+	sc.Stop()
+	suite.Equal("0", suite.read("pwm1/enable"))
+
+	sc.RotateCounterClockwise()
+	suite.Equal("1000000", suite.read("pwm1/duty_cycle"))
+	suite.Equal("1", suite.read("pwm1/enable"))
+
+	sc.Close()
+	suite.Equal("0", suite.read("pwm1/enable"))
+	suite.Equal("1", suite.read("unexport"))
+}
+
+func (suite *PwmServoControllerSuite) read(path string) string {
+	data, err := os.ReadFile(filepath.Join(suite.chipPath, path))
+	suite.Require().NoError(err)
+	return string(data)
+}
+
+func TestPwmServoControllerSuite(t *testing.T) {
+	suite.Run(t, new(PwmServoControllerSuite))
+}
@@ -0,0 +1,177 @@
+package servo
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/imilchev/rpi-feeder/pkg/feeder/config"
+	"github.com/imilchev/rpi-feeder/pkg/feeder/gpio"
+	"go.uber.org/zap"
+)
+
+// The coil states for each step, in the order of the IN1-IN4 pins.
+var (
+	fullStepSequence = [][4]bool{
+		{true, true, false, false},
+		{false, true, true, false},
+		{false, false, true, true},
+		{true, false, false, true},
+	}
+	halfStepSequence = [][4]bool{
+		{true, false, false, false},
+		{true, true, false, false},
+		{false, true, false, false},
+		{false, true, true, false},
+		{false, false, true, false},
+		{false, false, true, true},
+		{false, false, false, true},
+		{true, false, false, true},
+	}
+)
+
+// Stepper is a ServoController for stepper motors, which can also be moved by
+// an exact amount of steps.
+type Stepper interface {
+	ServoController
+
+	// Step moves the motor by the specified amount of steps, clockwise if
+	// steps is positive. Returns false if ctx was done before all steps were
+	// made.
+	Step(ctx context.Context, steps int) bool
+
+	// StepsPerPortion returns the amount of steps to drop 1 portion of food.
+	StepsPerPortion() uint
+}
+
+type stepperController struct {
+	pins            []gpio.Pin
+	sequence        [][4]bool
+	stepDelay       time.Duration
+	stepsPerPortion uint
+
+	mu          sync.Mutex
+	position    int
+	cancel      context.CancelFunc
+	stoppedChan chan struct{}
+}
+
+func newStepperController(g gpio.Gpio, cfg config.StepperConfig) Stepper {
+	var pins []gpio.Pin
+	for _, n := range cfg.Pins {
+		p := g.Pin(n)
+		p.Mode(gpio.Output)
+		pins = append(pins, p)
+	}
+	zap.S().Infof("Using pins %v to control the stepper.", cfg.Pins)
+
+	sequence := fullStepSequence
+	if cfg.HalfStep {
+		sequence = halfStepSequence
+	}
+	return &stepperController{
+		pins:            pins,
+		sequence:        sequence,
+		stepDelay:       time.Duration(cfg.StepDelayUs) * time.Microsecond,
+		stepsPerPortion: cfg.StepsPerPortion,
+	}
+}
+
+func (sc *stepperController) RotateClockwise() {
+	zap.S().Debug("Rotating stepper clockwise...")
+	sc.rotate(1)
+}
+
+func (sc *stepperController) RotateCounterClockwise() {
+	zap.S().Debug("Rotating stepper counter-clockwise...")
+	sc.rotate(-1)
+}
+
+func (sc *stepperController) Stop() {
+	sc.mu.Lock()
+	defer sc.mu.Unlock()
+
+	if sc.cancel != nil {
+		sc.cancel()
+		<-sc.stoppedChan
+		sc.cancel = nil
+		zap.S().Debug("Stepper rotation stopped.")
+	}
+}
+
+func (sc *stepperController) Close() {
+	sc.Stop()
+}
+
+func (sc *stepperController) Step(ctx context.Context, steps int) bool {
+	direction := 1
+	if steps < 0 {
+		direction, steps = -1, -steps
+	}
+	defer sc.release()
+
+	for i := 0; i < steps; i++ {
+		if !sc.step(ctx, direction) {
+			return false
+		}
+	}
+	return true
+}
+
+func (sc *stepperController) StepsPerPortion() uint {
+	return sc.stepsPerPortion
+}
+
+// rotate steps in the direction until stopped.
+func (sc *stepperController) rotate(direction int) {
+	sc.Stop()
+
+	sc.mu.Lock()
+	defer sc.mu.Unlock()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	sc.cancel = cancel
+	sc.stoppedChan = make(chan struct{})
+	go func() {
+		for sc.step(ctx, direction) {
+		}
+		sc.release()
+		close(sc.stoppedChan)
+	}()
+}
+
+// step makes a single step in the direction and waits for the step delay.
+func (sc *stepperController) step(ctx context.Context, direction int) bool {
+	if ctx.Err() != nil {
+		return false
+	}
+
+	n := len(sc.sequence)
+	sc.position = ((sc.position+direction)%n + n) % n
+	sc.apply(sc.sequence[sc.position])
+
+	timer := time.NewTimer(sc.stepDelay)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
+
+// release turns all coils off, such that the motor does not heat up while
+// idle.
+func (sc *stepperController) release() {
+	sc.apply([4]bool{})
+}
+
+func (sc *stepperController) apply(state [4]bool) {
+	for i, p := range sc.pins {
+		if state[i] {
+			p.High()
+		} else {
+			p.Low()
+		}
+	}
+}
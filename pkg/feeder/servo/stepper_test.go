@@ -0,0 +1,111 @@
+package servo
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/imilchev/rpi-feeder/pkg/feeder/config"
+	"github.com/imilchev/rpi-feeder/pkg/feeder/gpio"
+	"github.com/stretchr/testify/suite"
+)
+
+// stepStates reconstructs the coil states of each step from the recorded pin
+// changes. Steppers set all pins for every step, in the order of the pins.
+func stepStates(g *gpio.Memory) [][4]bool {
+	var states [][4]bool
+	var state [4]bool
+	for i, e := range g.Events() {
+		state[i%4] = e.State == gpio.High
+		if i%4 == 3 {
+			states = append(states, state)
+		}
+	}
+	return states
+}
+
+type StepperSuite struct {
+	suite.Suite
+	gpio *gpio.Memory
+	cfg  config.StepperConfig
+}
+
+func (suite *StepperSuite) SetupTest() {
+	suite.gpio = gpio.NewRecording()
+	suite.cfg = config.StepperConfig{
+		Pins:            [4]uint8{5, 6, 13, 19},
+		StepDelayUs:     10,
+		StepsPerPortion: 4,
+	}
+}
+
+func (suite *StepperSuite) TestNewStepperController() {
+	newStepperController(suite.gpio, suite.cfg)
+	for _, p := range suite.cfg.Pins {
+		suite.Equal(gpio.Output, suite.gpio.PinMode(p))
+	}
+}
+
+func (suite *StepperSuite) TestStep_FullStep() {
+	sc := newStepperController(suite.gpio, suite.cfg)
+	suite.True(sc.Step(context.Background(), 5))
+
+	suite.Equal([][4]bool{
+		{false, true, true, false},
+		{false, false, true, true},
+		{true, false, false, true},
+		{true, true, false, false},
+		{false, true, true, false},
+		// Released after stepping.
+		{false, false, false, false},
+	}, stepStates(suite.gpio))
+}
+
+func (suite *StepperSuite) TestStep_HalfStepCounterClockwise() {
+	suite.cfg.HalfStep = true
+	sc := newStepperController(suite.gpio, suite.cfg)
+	suite.True(sc.Step(context.Background(), -3))
+
+	suite.Equal([][4]bool{
+		{true, false, false, true},
+		{false, false, false, true},
+		{false, false, true, true},
+		{false, false, false, false},
+	}, stepStates(suite.gpio))
+}
+
+func (suite *StepperSuite) TestStep_Cancelled() {
+	suite.cfg.StepDelayUs = 10000
+	sc := newStepperController(suite.gpio, suite.cfg)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 25*time.Millisecond)
+	defer cancel()
+	suite.False(sc.Step(ctx, 100))
+	states := stepStates(suite.gpio)
+	suite.Less(len(states), 100)
+	suite.Equal([4]bool{}, states[len(states)-1])
+}
+
+func (suite *StepperSuite) TestRotate() {
+	sc := newStepperController(suite.gpio, suite.cfg)
+	sc.RotateClockwise()
+	time.Sleep(5 * time.Millisecond)
+	sc.Stop()
+
+	states := stepStates(suite.gpio)
+	suite.Greater(len(states), 1)
+	suite.Equal([4]bool{}, states[len(states)-1])
+}
+
+func (suite *StepperSuite) TestDispense() {
+	sc := newStepperController(suite.gpio, suite.cfg)
+	d := NewDispenser(sc, 0, config.DispenseConfig{})
+	suite.Equal(uint(2), d.Dispense(context.Background(), 2))
+
+	// 4 steps and releasing the coils for each portion.
+	suite.Equal(10, len(stepStates(suite.gpio)))
+}
+
+func TestStepperSuite(t *testing.T) {
+	suite.Run(t, new(StepperSuite))
+}
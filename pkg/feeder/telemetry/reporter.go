@@ -0,0 +1,121 @@
+package telemetry
+
+import (
+	"sync"
+	"time"
+
+	"github.com/imilchev/rpi-feeder/pkg/feeder/config"
+	"github.com/imilchev/rpi-feeder/pkg/mqtt/model"
+	"go.uber.org/zap"
+)
+
+// Sources provide the parts of the telemetry that are tracked elsewhere in
+// the feeder.
+type Sources struct {
+	// UnflushedFeedLogs returns the amount of feed logs that were not sent to
+	// the service yet.
+	UnflushedFeedLogs func() (int, error)
+
+	// MqttReconnects returns the amount of times the feeder reconnected to
+	// the broker.
+	MqttReconnects func() uint
+}
+
+// SendFunc is called with every telemetry message collected by the reporter.
+type SendFunc func(msg model.TelemetryMessage)
+
+// Reporter periodically collects the telemetry of the feeder.
+type Reporter interface {
+	Start()
+	Stop()
+}
+
+type reporter struct {
+	interval    time.Duration
+	dbPath      string
+	thermalPath string
+	src         Sources
+	send        SendFunc
+	startedAt   time.Time
+	now         func() time.Time
+
+	stopOnce    sync.Once
+	stopChan    chan struct{}
+	stoppedChan chan struct{}
+}
+
+func NewReporter(cfg config.Config, src Sources, send SendFunc) Reporter {
+	return newReporter(
+		time.Duration(cfg.TelemetryIntervalSeconds)*time.Second, cfg.DbPath, src, send)
+}
+
+func newReporter(interval time.Duration, dbPath string, src Sources, send SendFunc) *reporter {
+	return &reporter{
+		interval:    interval,
+		dbPath:      dbPath,
+		thermalPath: defaultThermalPath,
+		src:         src,
+		send:        send,
+		startedAt:   time.Now(),
+		now:         time.Now,
+		stopChan:    make(chan struct{}),
+		stoppedChan: make(chan struct{}),
+	}
+}
+
+func (r *reporter) Start() {
+	go r.run()
+	zap.S().Infof("Sending telemetry every %s.", r.interval)
+}
+
+func (r *reporter) Stop() {
+	r.stopOnce.Do(func() {
+		close(r.stopChan)
+		<-r.stoppedChan
+	})
+}
+
+func (r *reporter) run() {
+	defer close(r.stoppedChan)
+
+	ticker := time.NewTicker(r.interval)
+	defer ticker.Stop()
+	for {
+		r.send(r.collect())
+		select {
+		case <-ticker.C:
+		case <-r.stopChan:
+			return
+		}
+	}
+}
+
+// collect gathers the telemetry. Values that cannot be read are left empty,
+// such that a single failing source does not stop the heartbeat.
+func (r *reporter) collect() model.TelemetryMessage {
+	now := r.now()
+	msg := model.TelemetryMessage{
+		UptimeSeconds:  uint64(now.Sub(r.startedAt) / time.Second),
+		MqttReconnects: r.src.MqttReconnects(),
+		Timestamp:      now.UTC(),
+	}
+
+	if t, err := ReadCpuTemperature(r.thermalPath); err != nil {
+		zap.S().Debugf("Failed to read CPU temperature. %v", err)
+	} else {
+		msg.CpuTemperature = &t
+	}
+
+	if free, err := FreeDiskBytes(r.dbPath); err != nil {
+		zap.S().Warnf("Failed to read free disk space of %s. %v", r.dbPath, err)
+	} else {
+		msg.FreeDiskBytes = free
+	}
+
+	if n, err := r.src.UnflushedFeedLogs(); err != nil {
+		zap.S().Warnf("Failed to count unflushed feed logs. %v", err)
+	} else {
+		msg.UnflushedFeedLogs = uint(n)
+	}
+	return msg
+}
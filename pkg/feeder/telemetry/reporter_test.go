@@ -0,0 +1,93 @@
+package telemetry
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/imilchev/rpi-feeder/pkg/mqtt/model"
+	"github.com/stretchr/testify/suite"
+)
+
+type ReporterSuite struct {
+	suite.Suite
+	dir string
+	src Sources
+
+	mu   sync.Mutex
+	sent []model.TelemetryMessage
+}
+
+func (suite *ReporterSuite) SetupTest() {
+	suite.dir = suite.T().TempDir()
+	suite.src = Sources{
+		UnflushedFeedLogs: func() (int, error) { return 3, nil },
+		MqttReconnects:    func() uint { return 2 },
+	}
+	suite.sent = nil
+}
+
+func (suite *ReporterSuite) TestCollect() {
+	thermalPath := filepath.Join(suite.dir, "temp")
+	suite.Require().NoError(os.WriteFile(thermalPath, []byte("51000\n"), 0600))
+
+	r := suite.newReporter(time.Minute)
+	r.thermalPath = thermalPath
+	now := r.startedAt.Add(90 * time.Second)
+	r.now = func() time.Time { return now }
+
+	msg := r.collect()
+	suite.Equal(uint64(90), msg.UptimeSeconds)
+	suite.Require().NotNil(msg.CpuTemperature)
+	suite.InDelta(51.0, *msg.CpuTemperature, 0.0001)
+	suite.Greater(msg.FreeDiskBytes, uint64(0))
+	suite.Equal(uint(3), msg.UnflushedFeedLogs)
+	suite.Equal(uint(2), msg.MqttReconnects)
+	suite.Equal(now.UTC(), msg.Timestamp)
+}
+
+func (suite *ReporterSuite) TestCollect_SourcesFail() {
+	suite.src.UnflushedFeedLogs = func() (int, error) { return 0, errors.New("error") }
+	r := suite.newReporter(time.Minute)
+	r.thermalPath = filepath.Join(suite.dir, "missing")
+	r.dbPath = filepath.Join(suite.dir, "missing")
+
+	msg := r.collect()
+	suite.Nil(msg.CpuTemperature)
+	suite.Equal(uint64(0), msg.FreeDiskBytes)
+	suite.Equal(uint(0), msg.UnflushedFeedLogs)
+	suite.Equal(uint(2), msg.MqttReconnects)
+}
+
+func (suite *ReporterSuite) TestStart_SendsPeriodically() {
+	r := suite.newReporter(10 * time.Millisecond)
+	r.Start()
+	time.Sleep(25 * time.Millisecond)
+	r.Stop()
+
+	suite.mu.Lock()
+	defer suite.mu.Unlock()
+	suite.GreaterOrEqual(len(suite.sent), 2)
+}
+
+func (suite *ReporterSuite) TestStop_Twice() {
+	r := suite.newReporter(time.Minute)
+	r.Start()
+	r.Stop()
+	r.Stop()
+}
+
+func (suite *ReporterSuite) newReporter(interval time.Duration) *reporter {
+	return newReporter(interval, suite.dir, suite.src, func(msg model.TelemetryMessage) {
+		suite.mu.Lock()
+		defer suite.mu.Unlock()
+		suite.sent = append(suite.sent, msg)
+	})
+}
+
+func TestReporterSuite(t *testing.T) {
+	suite.Run(t, new(ReporterSuite))
+}
@@ -0,0 +1,37 @@
+package telemetry
+
+import (
+	"os"
+	"strconv"
+	"strings"
+	"syscall"
+)
+
+// defaultThermalPath is where the kernel exposes the CPU temperature of the
+// Raspberry Pi in millidegrees Celsius.
+const defaultThermalPath = "/sys/class/thermal/thermal_zone0/temp"
+
+// ReadCpuTemperature reads the temperature in °C from a sysfs thermal zone
+// file.
+func ReadCpuTemperature(path string) (float64, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return 0, err
+	}
+
+	milli, err := strconv.ParseInt(strings.TrimSpace(string(data)), 10, 64)
+	if err != nil {
+		return 0, err
+	}
+	return float64(milli) / 1000, nil
+}
+
+// FreeDiskBytes returns the bytes available to unprivileged users on the
+// filesystem holding path.
+func FreeDiskBytes(path string) (uint64, error) {
+	var st syscall.Statfs_t
+	if err := syscall.Statfs(path, &st); err != nil {
+		return 0, err
+	}
+	return uint64(st.Bavail) * uint64(st.Bsize), nil
+}
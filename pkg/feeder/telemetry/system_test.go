@@ -0,0 +1,50 @@
+package telemetry
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+)
+
+type SystemSuite struct {
+	suite.Suite
+}
+
+func (suite *SystemSuite) TestReadCpuTemperature() {
+	path := filepath.Join(suite.T().TempDir(), "temp")
+	suite.Require().NoError(os.WriteFile(path, []byte("48312\n"), 0600))
+
+	t, err := ReadCpuTemperature(path)
+	suite.NoError(err)
+	suite.InDelta(48.312, t, 0.0001)
+}
+
+func (suite *SystemSuite) TestReadCpuTemperature_Invalid() {
+	path := filepath.Join(suite.T().TempDir(), "temp")
+	suite.Require().NoError(os.WriteFile(path, []byte("hot"), 0600))
+
+	_, err := ReadCpuTemperature(path)
+	suite.Error(err)
+}
+
+func (suite *SystemSuite) TestReadCpuTemperature_Missing() {
+	_, err := ReadCpuTemperature(filepath.Join(suite.T().TempDir(), "temp"))
+	suite.Error(err)
+}
+
+func (suite *SystemSuite) TestFreeDiskBytes() {
+	free, err := FreeDiskBytes(suite.T().TempDir())
+	suite.NoError(err)
+	suite.Greater(free, uint64(0))
+}
+
+func (suite *SystemSuite) TestFreeDiskBytes_Missing() {
+	_, err := FreeDiskBytes(filepath.Join(suite.T().TempDir(), "missing"))
+	suite.Error(err)
+}
+
+func TestSystemSuite(t *testing.T) {
+	suite.Run(t, new(SystemSuite))
+}
@@ -0,0 +1,420 @@
+package update
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/imilchev/rpi-feeder/pkg/mqtt/model"
+	"github.com/imilchev/rpi-feeder/pkg/version"
+	"go.uber.org/zap"
+)
+
+const (
+	// confirmTimeout is how long a new version has to connect to the broker
+	// before the previous version is restored.
+	confirmTimeout = 2 * time.Minute
+
+	// maxBoots is how many times a new version is started without connecting
+	// to the broker before the previous version is restored, e.g. because it
+	// crashes on start.
+	maxBoots = 3
+
+	downloadTimeout = 10 * time.Minute
+
+	// stateFile keeps track of an update across restarts. It is stored next to
+	// the database of the feeder.
+	stateFile = "update.json"
+)
+
+// Updater installs other versions of the feeder binary. A new version is
+// started by replacing the running process, after which it has to confirm
+// that it works by connecting to the broker. Otherwise the previous version
+// is restored and started again.
+type Updater interface {
+	// Resume must be called on start, before connecting to the broker. If a
+	// new version was just installed, it is rolled back unless Confirm is
+	// called in time. Returns the result of a rolled back update, which is to
+	// be reported once connected, or nil.
+	Resume() (*model.UpdateResultMessage, error)
+
+	// Confirm marks the new version as working. Returns the result of the
+	// update to report, or nil if no update was pending.
+	Confirm() (*model.UpdateResultMessage, error)
+
+	// Install downloads the binary, verifies its checksum and replaces the
+	// executable with it. The previous executable is kept for rolling back.
+	// The new version runs once the feeder is restarted.
+	Install(msg model.UpdateMessage) error
+
+	// Restart replaces the running process with the executable, which is
+	// either the installed version or the restored previous one. It only
+	// returns if that failed.
+	Restart() error
+
+	// RolledBack is closed once the previous version was restored because
+	// the update was not confirmed in time. The feeder has to be restarted to
+	// run it.
+	RolledBack() <-chan struct{}
+}
+
+// state is the update in progress, as persisted in the state file.
+type state struct {
+	Version         string `json:"version"`
+	PreviousVersion string `json:"previousVersion"`
+
+	// How many times the new version was started.
+	Boots uint `json:"boots"`
+
+	// Set once the previous version was restored, together with the reason.
+	RolledBack bool   `json:"rolledBack,omitempty"`
+	Error      string `json:"error,omitempty"`
+}
+
+type updater struct {
+	exePath        string
+	statePath      string
+	version        string
+	client         *http.Client
+	confirmTimeout time.Duration
+	exec           func(path string) error
+
+	// pending is the update that awaits confirmation, or nil. Confirmation
+	// races against the rollback timer.
+	mu      sync.Mutex
+	pending *state
+	timer   *time.Timer
+
+	// rolledBackChan is closed once the pending update was rolled back.
+	rolledBackChan chan struct{}
+
+	// installed is the version installed by this process, which runs once the
+	// feeder is restarted.
+	installed string
+}
+
+// NewUpdater creates an updater for the running executable. The state of
+// updates is kept in dbPath.
+func NewUpdater(dbPath string) (Updater, error) {
+	exe, err := os.Executable()
+	if err != nil {
+		return nil, err
+	}
+	// Resolve symlinks, such that the actual binary is replaced rather than
+	// the link.
+	exe, err = filepath.EvalSymlinks(exe)
+	if err != nil {
+		return nil, err
+	}
+	return newUpdater(
+		exe, filepath.Join(dbPath, stateFile), version.Version, confirmTimeout, execSelf), nil
+}
+
+func newUpdater(
+	exePath, statePath, version string,
+	confirmTimeout time.Duration,
+	exec func(path string) error) *updater {
+	return &updater{
+		exePath:        exePath,
+		statePath:      statePath,
+		version:        version,
+		client:         &http.Client{Timeout: downloadTimeout},
+		confirmTimeout: confirmTimeout,
+		exec:           exec,
+		rolledBackChan: make(chan struct{}),
+	}
+}
+
+// execSelf replaces the running process with the executable at path, keeping
+// the arguments and the environment. The process id stays the same, so
+// service managers do not notice the restart.
+func execSelf(path string) error {
+	return syscall.Exec(path, os.Args, os.Environ())
+}
+
+func (u *updater) Resume() (*model.UpdateResultMessage, error) {
+	s, err := u.readState()
+	if err != nil || s == nil {
+		return nil, err
+	}
+
+	switch {
+	case s.RolledBack:
+		zap.S().Warnf("Update to %s was rolled back. %s", s.Version, s.Error)
+		if err := u.clear(); err != nil {
+			return nil, err
+		}
+		return &model.UpdateResultMessage{
+			Version:    s.Version,
+			Error:      s.Error,
+			RolledBack: true,
+			Timestamp:  time.Now().UTC(),
+		}, nil
+	case s.Version != u.version:
+		// The installed binary does not report the version it was released
+		// as. It runs nevertheless, so there is nothing to roll back to.
+		if err := u.clear(); err != nil {
+			return nil, err
+		}
+		return &model.UpdateResultMessage{
+			Version:   s.Version,
+			Error:     fmt.Sprintf("expected version %s to run, but %s is running", s.Version, u.version),
+			Timestamp: time.Now().UTC(),
+		}, nil
+	}
+
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	s.Boots++
+	if s.Boots > maxBoots {
+		// Nothing was started yet, so the previous version is started right
+		// away.
+		if err := u.rollback(s, fmt.Sprintf(
+			"Version %s was started %d times without connecting.", s.Version, maxBoots)); err != nil {
+			return nil, err
+		}
+		return nil, u.Restart()
+	}
+	if err := u.writeState(*s); err != nil {
+		return nil, err
+	}
+
+	zap.S().Infof("Running version %s, awaiting connection to confirm the update.", s.Version)
+	u.pending = s
+	u.timer = time.AfterFunc(u.confirmTimeout, u.rollbackPending)
+	return nil, nil
+}
+
+func (u *updater) Confirm() (*model.UpdateResultMessage, error) {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	if u.pending == nil {
+		return nil, nil
+	}
+	u.timer.Stop()
+	s := u.pending
+	u.pending = nil
+
+	zap.S().Infof("Update from %s to %s confirmed.", s.PreviousVersion, s.Version)
+	if err := u.clear(); err != nil {
+		return nil, err
+	}
+	return &model.UpdateResultMessage{
+		Version:   s.Version,
+		Success:   true,
+		Timestamp: time.Now().UTC(),
+	}, nil
+}
+
+func (u *updater) Install(msg model.UpdateMessage) error {
+	if msg.Version == u.version {
+		return fmt.Errorf("version %s is running already", msg.Version)
+	}
+
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	if u.pending != nil {
+		return fmt.Errorf("update to %s is not confirmed yet", u.pending.Version)
+	}
+	// Installing again would replace the backup of the running version.
+	if u.installed != "" {
+		return fmt.Errorf("version %s is installed already and awaits a restart", u.installed)
+	}
+
+	// The binary is downloaded next to the executable, such that it can be
+	// moved in place atomically.
+	newPath := u.exePath + ".new"
+	zap.S().Infof("Downloading version %s from %s...", msg.Version, msg.Url)
+	if err := u.download(msg.Url, msg.Sha256, newPath); err != nil {
+		os.Remove(newPath) //nolint
+		return err
+	}
+
+	if err := copyFile(u.exePath, u.backupPath()); err != nil {
+		os.Remove(newPath) //nolint
+		return err
+	}
+
+	if err := u.writeState(state{Version: msg.Version, PreviousVersion: u.version}); err != nil {
+		os.Remove(newPath) //nolint
+		return err
+	}
+
+	if err := os.Rename(newPath, u.exePath); err != nil {
+		os.Remove(newPath)     //nolint
+		os.Remove(u.statePath) //nolint
+		return err
+	}
+	u.installed = msg.Version
+	zap.S().Infof("Installed version %s.", msg.Version)
+	return nil
+}
+
+func (u *updater) Restart() error {
+	zap.S().Infof("Restarting %s...", u.exePath)
+	return u.exec(u.exePath)
+}
+
+func (u *updater) RolledBack() <-chan struct{} {
+	return u.rolledBackChan
+}
+
+// rollbackPending restores the previous version if the pending update was
+// not confirmed in time. The feeder is restarted by its owner, such that it
+// is shut down first.
+func (u *updater) rollbackPending() {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	s := u.pending
+	if s == nil {
+		return
+	}
+	reason := fmt.Sprintf("Version %s did not connect within %s.", s.Version, u.confirmTimeout)
+	if err := u.rollback(s, reason); err != nil {
+		zap.S().Errorf("Failed to roll back to version %s. %v", s.PreviousVersion, err)
+		return
+	}
+	// The previous version awaits the restart, so it must not be replaced.
+	u.installed = s.PreviousVersion
+	close(u.rolledBackChan)
+}
+
+// rollback restores the previous executable, which runs once the feeder is
+// restarted. Must be called with mu held.
+func (u *updater) rollback(s *state, reason string) error {
+	zap.S().Errorf("Rolling back to version %s. %s", s.PreviousVersion, reason)
+	if err := os.Rename(u.backupPath(), u.exePath); err != nil {
+		return err
+	}
+
+	s.RolledBack = true
+	s.Error = reason
+	if err := u.writeState(*s); err != nil {
+		return err
+	}
+	u.pending = nil
+	return nil
+}
+
+func (u *updater) download(url, expectedSha256, path string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), downloadTimeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := u.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("downloading %s failed with status %s", url, resp.Status)
+	}
+
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0755)
+	if err != nil {
+		return err
+	}
+	h := sha256.New()
+	_, err = io.Copy(io.MultiWriter(f, h), resp.Body)
+	if err == nil {
+		err = f.Sync()
+	}
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return err
+	}
+
+	if sum := hex.EncodeToString(h.Sum(nil)); !strings.EqualFold(sum, expectedSha256) {
+		return fmt.Errorf("checksum mismatch, expected %s, but downloaded %s", expectedSha256, sum)
+	}
+	return nil
+}
+
+func (u *updater) backupPath() string {
+	return u.exePath + ".old"
+}
+
+// readState returns the persisted update, or nil if there is none.
+func (u *updater) readState() (*state, error) {
+	data, err := os.ReadFile(u.statePath)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	s := &state{}
+	if err := json.Unmarshal(data, s); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// writeState persists the update. The file is replaced atomically, such that
+// a power loss does not leave a corrupt state behind.
+func (u *updater) writeState(s state) error {
+	data, err := json.Marshal(s)
+	if err != nil {
+		return err
+	}
+
+	tmpPath := u.statePath + ".tmp"
+	if err := os.WriteFile(tmpPath, data, 0600); err != nil {
+		return err
+	}
+	return os.Rename(tmpPath, u.statePath)
+}
+
+// clear removes the state of the update and the previous executable.
+func (u *updater) clear() error {
+	for _, p := range []string{u.statePath, u.backupPath()} {
+		if err := os.Remove(p); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return err
+		}
+	}
+	return nil
+}
+
+func copyFile(src, dst string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	info, err := in.Stat()
+	if err != nil {
+		return err
+	}
+	out, err := os.OpenFile(dst, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, info.Mode())
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(out, in)
+	if err == nil {
+		err = out.Sync()
+	}
+	if closeErr := out.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
@@ -0,0 +1,252 @@
+package update
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/imilchev/rpi-feeder/pkg/mqtt/model"
+	"github.com/stretchr/testify/suite"
+)
+
+const (
+	oldVersion = "v1.0.0"
+	newVersion = "v1.1.0"
+)
+
+var (
+	oldBinary = []byte("old binary")
+	newBinary = []byte("new binary")
+)
+
+type UpdaterSuite struct {
+	suite.Suite
+	dir       string
+	exePath   string
+	statePath string
+	server    *httptest.Server
+
+	mu    sync.Mutex
+	execs []string
+}
+
+func (suite *UpdaterSuite) SetupTest() {
+	suite.dir = suite.T().TempDir()
+	suite.exePath = filepath.Join(suite.dir, "rpi-feeder")
+	suite.statePath = filepath.Join(suite.dir, stateFile)
+	suite.Require().NoError(os.WriteFile(suite.exePath, oldBinary, 0755))
+
+	suite.server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/binary" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Write(newBinary) //nolint
+	}))
+	suite.execs = nil
+}
+
+func (suite *UpdaterSuite) TearDownTest() {
+	suite.server.Close()
+}
+
+func (suite *UpdaterSuite) TestInstall() {
+	u := suite.newUpdater(oldVersion, time.Minute)
+	suite.NoError(u.Install(suite.updateMessage()))
+
+	suite.fileEquals(newBinary, suite.exePath)
+	suite.fileEquals(oldBinary, u.backupPath())
+	info, err := os.Stat(suite.exePath)
+	suite.NoError(err)
+	suite.Equal(os.FileMode(0755), info.Mode().Perm())
+
+	s, err := u.readState()
+	suite.NoError(err)
+	suite.Equal(&state{Version: newVersion, PreviousVersion: oldVersion}, s)
+
+	suite.NoError(u.Restart())
+	suite.Equal([]string{suite.exePath}, suite.execs)
+}
+
+func (suite *UpdaterSuite) TestInstall_AwaitingRestart() {
+	u := suite.newUpdater(oldVersion, time.Minute)
+	suite.NoError(u.Install(suite.updateMessage()))
+
+	// The backup of the running version is not replaced.
+	suite.Error(u.Install(suite.updateMessage()))
+	suite.fileEquals(oldBinary, u.backupPath())
+}
+
+func (suite *UpdaterSuite) TestInstall_ChecksumMismatch() {
+	u := suite.newUpdater(oldVersion, time.Minute)
+	msg := suite.updateMessage()
+	msg.Sha256 = hex.EncodeToString(make([]byte, sha256.Size))
+	suite.Error(u.Install(msg))
+	suite.notInstalled()
+}
+
+func (suite *UpdaterSuite) TestInstall_DownloadFails() {
+	u := suite.newUpdater(oldVersion, time.Minute)
+	msg := suite.updateMessage()
+	msg.Url = suite.server.URL + "/missing"
+	suite.Error(u.Install(msg))
+	suite.notInstalled()
+}
+
+func (suite *UpdaterSuite) TestInstall_SameVersion() {
+	u := suite.newUpdater(newVersion, time.Minute)
+	suite.Error(u.Install(suite.updateMessage()))
+	suite.notInstalled()
+}
+
+func (suite *UpdaterSuite) TestResume_NoUpdate() {
+	u := suite.newUpdater(oldVersion, time.Minute)
+	result, err := u.Resume()
+	suite.NoError(err)
+	suite.Nil(result)
+
+	result, err = u.Confirm()
+	suite.NoError(err)
+	suite.Nil(result)
+}
+
+func (suite *UpdaterSuite) TestConfirm() {
+	suite.NoError(suite.newUpdater(oldVersion, time.Minute).Install(suite.updateMessage()))
+
+	// The new version is started.
+	u := suite.newUpdater(newVersion, 50*time.Millisecond)
+	result, err := u.Resume()
+	suite.NoError(err)
+	suite.Nil(result)
+
+	result, err = u.Confirm()
+	suite.NoError(err)
+	suite.Require().NotNil(result)
+	suite.Equal(newVersion, result.Version)
+	suite.True(result.Success)
+
+	// Confirmed updates are not rolled back.
+	time.Sleep(100 * time.Millisecond)
+	suite.Empty(suite.getExecs())
+	suite.fileEquals(newBinary, suite.exePath)
+	suite.NoFileExists(suite.statePath)
+	suite.NoFileExists(u.backupPath())
+}
+
+func (suite *UpdaterSuite) TestResume_NotConfirmed() {
+	suite.NoError(suite.newUpdater(oldVersion, time.Minute).Install(suite.updateMessage()))
+
+	u := suite.newUpdater(newVersion, 50*time.Millisecond)
+	_, err := u.Resume()
+	suite.NoError(err)
+
+	select {
+	case <-u.RolledBack():
+	case <-time.After(time.Second):
+		suite.FailNow("update was not rolled back")
+	}
+	suite.fileEquals(oldBinary, suite.exePath)
+
+	// The feeder is restarted by its owner.
+	suite.Empty(suite.getExecs())
+
+	// Confirming after the rollback has no effect.
+	result, err := u.Confirm()
+	suite.NoError(err)
+	suite.Nil(result)
+
+	// Installing would replace the restored version before it runs.
+	suite.Error(u.Install(suite.updateMessage()))
+	suite.fileEquals(oldBinary, suite.exePath)
+
+	// The previous version reports the rollback.
+	suite.assertRolledBack(suite.newUpdater(oldVersion, time.Minute))
+}
+
+func (suite *UpdaterSuite) TestResume_TooManyBoots() {
+	suite.NoError(suite.newUpdater(oldVersion, time.Minute).Install(suite.updateMessage()))
+
+	// The new version crashes before it connects.
+	for i := 0; i < maxBoots; i++ {
+		_, err := suite.newUpdater(newVersion, time.Minute).Resume()
+		suite.NoError(err)
+	}
+	suite.Empty(suite.getExecs())
+
+	_, err := suite.newUpdater(newVersion, time.Minute).Resume()
+	suite.NoError(err)
+	suite.Equal([]string{suite.exePath}, suite.getExecs())
+	suite.fileEquals(oldBinary, suite.exePath)
+
+	suite.assertRolledBack(suite.newUpdater(oldVersion, time.Minute))
+}
+
+func (suite *UpdaterSuite) TestResume_UnexpectedVersion() {
+	suite.NoError(suite.newUpdater(oldVersion, time.Minute).Install(suite.updateMessage()))
+
+	u := suite.newUpdater("dev", time.Minute)
+	result, err := u.Resume()
+	suite.NoError(err)
+	suite.Require().NotNil(result)
+	suite.False(result.Success)
+	suite.False(result.RolledBack)
+	suite.NotEmpty(result.Error)
+	suite.NoFileExists(suite.statePath)
+}
+
+func (suite *UpdaterSuite) assertRolledBack(u *updater) {
+	result, err := u.Resume()
+	suite.NoError(err)
+	suite.Require().NotNil(result)
+	suite.Equal(newVersion, result.Version)
+	suite.False(result.Success)
+	suite.True(result.RolledBack)
+	suite.NotEmpty(result.Error)
+	suite.NoFileExists(suite.statePath)
+}
+
+func (suite *UpdaterSuite) notInstalled() {
+	suite.fileEquals(oldBinary, suite.exePath)
+	suite.NoFileExists(suite.statePath)
+	suite.NoFileExists(suite.exePath + ".new")
+}
+
+func (suite *UpdaterSuite) fileEquals(expected []byte, path string) {
+	data, err := os.ReadFile(path)
+	suite.NoError(err)
+	suite.Equal(expected, data)
+}
+
+func (suite *UpdaterSuite) updateMessage() model.UpdateMessage {
+	sum := sha256.Sum256(newBinary)
+	return model.UpdateMessage{
+		Version: newVersion,
+		Url:     suite.server.URL + "/binary",
+		Sha256:  hex.EncodeToString(sum[:]),
+	}
+}
+
+func (suite *UpdaterSuite) newUpdater(version string, timeout time.Duration) *updater {
+	return newUpdater(suite.exePath, suite.statePath, version, timeout, func(path string) error {
+		suite.mu.Lock()
+		defer suite.mu.Unlock()
+		suite.execs = append(suite.execs, path)
+		return nil
+	})
+}
+
+func (suite *UpdaterSuite) getExecs() []string {
+	suite.mu.Lock()
+	defer suite.mu.Unlock()
+	return append([]string(nil), suite.execs...)
+}
+
+func TestUpdaterSuite(t *testing.T) {
+	suite.Run(t, new(UpdaterSuite))
+}
@@ -0,0 +1,92 @@
+package limits
+
+import (
+	"fmt"
+	"math"
+	"time"
+)
+
+// Window is the rolling period over which MaxPortionsPerDay is enforced.
+const Window = 24 * time.Hour
+
+// Limits protect the pet from overfeeding. A limit that is not set (zero) is
+// not enforced.
+type Limits struct {
+	// The maximum amount of portions served by a single feeding.
+	MaxPortionsPerFeeding uint `json:"maxPortionsPerFeeding"`
+
+	// The maximum amount of portions served in any 24 hour period.
+	MaxPortionsPerDay uint `json:"maxPortionsPerDay"`
+
+	// The minimum amount of seconds between two feedings.
+	MinIntervalSeconds uint `json:"minIntervalSeconds"`
+}
+
+// Feeding is a feeding that was served in the past.
+type Feeding struct {
+	Portions uint
+	At       time.Time
+}
+
+// Check returns an error describing the violated limit if serving portions
+// at now would exceed any of the limits. Feedings older than Window are
+// ignored.
+func (l Limits) Check(portions uint, history []Feeding, now time.Time) error {
+	if l.MaxPortionsPerFeeding > 0 && portions > l.MaxPortionsPerFeeding {
+		return fmt.Errorf(
+			"%d portions exceed the limit of %d portions per feeding",
+			portions, l.MaxPortionsPerFeeding)
+	}
+
+	served, last := recent(history, now)
+	if l.MaxPortionsPerDay > 0 && served+portions > l.MaxPortionsPerDay {
+		return fmt.Errorf(
+			"%d portions exceed the limit of %d portions per day, %d portions were served in the last 24 hours",
+			portions, l.MaxPortionsPerDay, served)
+	}
+
+	minInterval := time.Duration(l.MinIntervalSeconds) * time.Second
+	if minInterval > 0 && !last.IsZero() && now.Sub(last) < minInterval {
+		return fmt.Errorf(
+			"the last feeding was %s ago, feedings must be at least %s apart",
+			now.Sub(last).Round(time.Second), minInterval)
+	}
+	return nil
+}
+
+// Remaining returns the maximum amount of portions a feeding at now may serve
+// without exceeding MaxPortionsPerFeeding or MaxPortionsPerDay, or
+// math.MaxUint if neither is set.
+func (l Limits) Remaining(history []Feeding, now time.Time) uint {
+	remaining := uint(math.MaxUint)
+	if l.MaxPortionsPerFeeding > 0 {
+		remaining = l.MaxPortionsPerFeeding
+	}
+	if l.MaxPortionsPerDay > 0 {
+		served, _ := recent(history, now)
+		if served >= l.MaxPortionsPerDay {
+			return 0
+		}
+		if l.MaxPortionsPerDay-served < remaining {
+			remaining = l.MaxPortionsPerDay - served
+		}
+	}
+	return remaining
+}
+
+// recent returns the portions served within Window before now and the time
+// of the last feeding.
+func recent(history []Feeding, now time.Time) (uint, time.Time) {
+	var served uint
+	var last time.Time
+	for _, f := range history {
+		if now.Sub(f.At) >= Window {
+			continue
+		}
+		served += f.Portions
+		if f.At.After(last) {
+			last = f.At
+		}
+	}
+	return served, last
+}
@@ -0,0 +1,74 @@
+package limits
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/suite"
+)
+
+type LimitsSuite struct {
+	suite.Suite
+	now time.Time
+}
+
+func (suite *LimitsSuite) SetupTest() {
+	suite.now = time.Date(2022, 1, 10, 12, 0, 0, 0, time.UTC)
+}
+
+func (suite *LimitsSuite) TestCheck_NoLimits() {
+	l := Limits{}
+	history := []Feeding{{Portions: 100, At: suite.now.Add(-time.Second)}}
+	suite.NoError(l.Check(1000, history, suite.now))
+}
+
+func (suite *LimitsSuite) TestCheck_MaxPortionsPerFeeding() {
+	l := Limits{MaxPortionsPerFeeding: 3}
+	suite.NoError(l.Check(3, nil, suite.now))
+	suite.Error(l.Check(4, nil, suite.now))
+}
+
+func (suite *LimitsSuite) TestCheck_MaxPortionsPerDay() {
+	l := Limits{MaxPortionsPerDay: 5}
+	history := []Feeding{
+		{Portions: 2, At: suite.now.Add(-23 * time.Hour)},
+		{Portions: 2, At: suite.now.Add(-time.Hour)},
+
+		// Outside of the window.
+		{Portions: 10, At: suite.now.Add(-25 * time.Hour)},
+	}
+	suite.NoError(l.Check(1, history, suite.now))
+	suite.Error(l.Check(2, history, suite.now))
+}
+
+func (suite *LimitsSuite) TestCheck_MinInterval() {
+	l := Limits{MinIntervalSeconds: 3600}
+	history := []Feeding{
+		{Portions: 1, At: suite.now.Add(-3 * time.Hour)},
+		{Portions: 1, At: suite.now.Add(-30 * time.Minute)},
+	}
+	suite.Error(l.Check(1, history, suite.now))
+	suite.NoError(l.Check(1, history, suite.now.Add(30*time.Minute)))
+	suite.NoError(l.Check(1, nil, suite.now))
+}
+
+func (suite *LimitsSuite) TestRemaining() {
+	history := []Feeding{
+		{Portions: 2, At: suite.now.Add(-23 * time.Hour)},
+		{Portions: 2, At: suite.now.Add(-time.Hour)},
+
+		// Outside of the window.
+		{Portions: 10, At: suite.now.Add(-25 * time.Hour)},
+	}
+	suite.Equal(uint(math.MaxUint), Limits{}.Remaining(history, suite.now))
+	suite.Equal(uint(3), Limits{MaxPortionsPerFeeding: 3}.Remaining(history, suite.now))
+	suite.Equal(uint(2), Limits{MaxPortionsPerDay: 6}.Remaining(history, suite.now))
+	suite.Equal(uint(2), Limits{MaxPortionsPerFeeding: 3, MaxPortionsPerDay: 6}.Remaining(history, suite.now))
+	suite.Equal(uint(3), Limits{MaxPortionsPerFeeding: 3, MaxPortionsPerDay: 10}.Remaining(history, suite.now))
+	suite.Equal(uint(0), Limits{MaxPortionsPerDay: 3}.Remaining(history, suite.now))
+}
+
+func TestLimitsSuite(t *testing.T) {
+	suite.Run(t, new(LimitsSuite))
+}
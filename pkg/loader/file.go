@@ -0,0 +1,61 @@
+package loader
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v3"
+)
+
+// ReadFile reads the config file at path into a tree of its settings. The
+// format is determined by the extension of the file, which is one of .json,
+// .yaml, .yml and .toml.
+func ReadFile(path string) (map[string]interface{}, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	tree := map[string]interface{}{}
+	switch ext := strings.ToLower(filepath.Ext(path)); ext {
+	case ".json":
+		// Numbers are kept as they are, such that large integers do not lose
+		// precision.
+		d := json.NewDecoder(bytes.NewReader(data))
+		d.UseNumber()
+		err = d.Decode(&tree)
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(data, &tree)
+	case ".toml":
+		err = toml.Unmarshal(data, &tree)
+	default:
+		return nil, fmt.Errorf("unsupported config file format %q", ext)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+	return tree, nil
+}
+
+// Encode encodes tree in the format of the config file at path, see ReadFile.
+func Encode(path string, tree map[string]interface{}) ([]byte, error) {
+	switch ext := strings.ToLower(filepath.Ext(path)); ext {
+	case ".json":
+		return json.MarshalIndent(tree, "", "    ")
+	case ".yaml", ".yml":
+		return yaml.Marshal(tree)
+	case ".toml":
+		var buf bytes.Buffer
+		if err := toml.NewEncoder(&buf).Encode(tree); err != nil {
+			return nil, err
+		}
+		return buf.Bytes(), nil
+	default:
+		return nil, fmt.Errorf("unsupported config file format %q", ext)
+	}
+}
@@ -0,0 +1,358 @@
+package loader
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"reflect"
+	"sort"
+	"strings"
+	"unicode"
+
+	"go.uber.org/zap"
+)
+
+// EnvPrefix is the prefix of the environment variables overriding settings.
+// The rest of the name is the key of the setting in upper snake case, e.g.
+// RPI_FEEDER_MQTT_CLIENT_ID for mqtt.clientId.
+const EnvPrefix = "RPI_FEEDER_"
+
+// DefaultSource is the source of the settings that were not overridden.
+const DefaultSource = "default"
+
+// Sources holds where each setting was loaded from by its key, e.g.
+// "mqtt.password": "env RPI_FEEDER_MQTT_PASSWORD".
+type Sources map[string]string
+
+// Source returns where the setting with the given key was loaded from. Keys
+// in a section that was set as a whole have the source of the section.
+func (s Sources) Source(key string) string {
+	for {
+		if src, ok := s[key]; ok {
+			return src
+		}
+		i := strings.LastIndex(key, ".")
+		if i < 0 {
+			return DefaultSource
+		}
+		key = key[:i]
+	}
+}
+
+// Load fills cfg, which must be a pointer to a struct with json tags, from
+// the following layers, each one overriding the settings of the previous
+// ones:
+//  1. The values of cfg, i.e. the defaults.
+//  2. The config file at path, if not empty. See ReadFile for the formats.
+//  3. The environment variables starting with EnvPrefix.
+//  4. The overrides in the key=value format, e.g. mqtt.keepAlive=30. They
+//     are passed to the commands with --set.
+//
+// Values from the environment and overrides are taken as they are for
+// strings and in JSON otherwise, e.g. [5, 6, 13, 19] for a list. Returns where
+// each setting was loaded from.
+func Load(cfg interface{}, path string, overrides []string) (Sources, error) {
+	v := reflect.ValueOf(cfg)
+	if v.Kind() != reflect.Ptr || v.Elem().Kind() != reflect.Struct {
+		return nil, errors.New("config must be a pointer to a struct")
+	}
+	t := v.Elem().Type()
+
+	defaults, err := toTree(cfg)
+	if err != nil {
+		return nil, err
+	}
+	l := &layers{tree: map[string]interface{}{}, sources: Sources{}}
+	l.merge(nil, defaults, t, DefaultSource)
+
+	if path != "" {
+		file, err := ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		l.merge(nil, file, t, "file "+path)
+	}
+
+	var envErr error
+	walkLeaves(t, nil, func(key []string, ft reflect.Type) {
+		name := envName(key)
+		raw, ok := os.LookupEnv(name)
+		if !ok || envErr != nil {
+			return
+		}
+		value, err := parseValue(raw, ft)
+		if err != nil {
+			envErr = fmt.Errorf("invalid value of %s: %w", name, err)
+			return
+		}
+		l.set(key, value, "env "+name)
+	})
+	if envErr != nil {
+		return nil, envErr
+	}
+
+	for _, o := range overrides {
+		parts := strings.SplitN(o, "=", 2)
+		if len(parts) != 2 {
+			return nil, fmt.Errorf("override %q must be in the key=value format", o)
+		}
+		key, ft, err := resolve(t, parts[0])
+		if err != nil {
+			return nil, err
+		}
+		value, err := parseValue(parts[1], ft)
+		if err != nil {
+			return nil, fmt.Errorf("invalid value of %s: %w", parts[0], err)
+		}
+		l.set(key, value, "flag --set")
+	}
+
+	data, err := json.Marshal(l.tree)
+	if err != nil {
+		return nil, err
+	}
+	v.Elem().Set(reflect.Zero(t))
+	if err := json.Unmarshal(data, cfg); err != nil {
+		return nil, err
+	}
+
+	keys := make([]string, 0, len(l.sources))
+	for k := range l.sources {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		zap.S().Debugf("Config %s is set by %s.", k, l.sources[k])
+	}
+	return l.sources, nil
+}
+
+// layers holds the settings loaded so far as a tree of their JSON values,
+// together with their sources.
+type layers struct {
+	tree    map[string]interface{}
+	sources Sources
+}
+
+// merge sets the settings of tree under key. The keys of the tree are matched
+// case-insensitively against the fields of t, like when decoding JSON.
+// Sections are merged with the ones set already, other values replace them.
+func (l *layers) merge(key []string, tree map[string]interface{}, t reflect.Type, src string) {
+	for k, v := range tree {
+		name := k
+		var ft reflect.Type
+		if f, ok := lookup(t, k); ok {
+			name = f.name
+			ft = f.typ
+		}
+		fieldKey := append(append([]string{}, key...), name)
+
+		m, isMap := v.(map[string]interface{})
+		if isMap && ft != nil && isSection(ft) {
+			if _, ok := l.get(fieldKey).(map[string]interface{}); !ok {
+				l.set(fieldKey, map[string]interface{}{}, src)
+			}
+			l.merge(fieldKey, m, ft, src)
+			continue
+		}
+		l.set(fieldKey, v, src)
+	}
+}
+
+func (l *layers) get(key []string) interface{} {
+	var v interface{} = l.tree
+	for _, k := range key {
+		m, ok := v.(map[string]interface{})
+		if !ok {
+			return nil
+		}
+		v = m[k]
+	}
+	return v
+}
+
+// set replaces the value under key, creating the sections leading to it.
+func (l *layers) set(key []string, value interface{}, src string) {
+	m := l.tree
+	for _, k := range key[:len(key)-1] {
+		next, ok := m[k].(map[string]interface{})
+		if !ok {
+			next = map[string]interface{}{}
+			m[k] = next
+		}
+		m = next
+	}
+	m[key[len(key)-1]] = value
+
+	// The sections leading to the value and the settings under it were
+	// replaced.
+	for i := 1; i < len(key); i++ {
+		delete(l.sources, strings.Join(key[:i], "."))
+	}
+	joined := strings.Join(key, ".")
+	for k := range l.sources {
+		if k == joined || strings.HasPrefix(k, joined+".") {
+			delete(l.sources, k)
+		}
+	}
+	l.setSources(joined, value, src)
+}
+
+func (l *layers) setSources(key string, value interface{}, src string) {
+	m, ok := value.(map[string]interface{})
+	if !ok || len(m) == 0 {
+		l.sources[key] = src
+		return
+	}
+	for k, v := range m {
+		l.setSources(key+"."+k, v, src)
+	}
+}
+
+// field is a setting of a config struct.
+type field struct {
+	// name is the JSON key of the setting.
+	name   string
+	typ    reflect.Type
+	secret bool
+}
+
+var unmarshalerType = reflect.TypeOf((*json.Unmarshaler)(nil)).Elem()
+
+// fields returns the settings of the struct type t in the order of its fields.
+func fields(t reflect.Type) []field {
+	var fs []field
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if f.PkgPath != "" {
+			continue
+		}
+		name := strings.Split(f.Tag.Get("json"), ",")[0]
+		if name == "-" {
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+		fs = append(fs, field{name: name, typ: f.Type, secret: f.Tag.Get("secret") == "true"})
+	}
+	return fs
+}
+
+// lookup returns the setting of t with the given key. An exact match is
+// preferred over a case-insensitive one.
+func lookup(t reflect.Type, key string) (field, bool) {
+	t = deref(t)
+	if t.Kind() != reflect.Struct {
+		return field{}, false
+	}
+	var match *field
+	for _, f := range fields(t) {
+		if f.name == key {
+			return f, true
+		}
+		if match == nil && strings.EqualFold(f.name, key) {
+			f := f
+			match = &f
+		}
+	}
+	if match == nil {
+		return field{}, false
+	}
+	return *match, true
+}
+
+// resolve returns the key of the setting named by the dotted key and its type.
+func resolve(t reflect.Type, key string) ([]string, reflect.Type, error) {
+	var resolved []string
+	for _, k := range strings.Split(key, ".") {
+		f, ok := lookup(t, k)
+		if !ok {
+			return nil, nil, fmt.Errorf("unknown setting %s", key)
+		}
+		resolved = append(resolved, f.name)
+		t = f.typ
+	}
+	return resolved, t, nil
+}
+
+// isSection returns whether t is a struct whose settings are set one by one.
+// Structs decoding themselves from JSON, like time.Time, are single values.
+func isSection(t reflect.Type) bool {
+	t = deref(t)
+	return t.Kind() == reflect.Struct && !reflect.PtrTo(t).Implements(unmarshalerType)
+}
+
+func deref(t reflect.Type) reflect.Type {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	return t
+}
+
+// walkLeaves calls fn for every setting of t that is not a section.
+func walkLeaves(t reflect.Type, key []string, fn func(key []string, t reflect.Type)) {
+	for _, f := range fields(deref(t)) {
+		fieldKey := append(append([]string{}, key...), f.name)
+		if isSection(f.typ) {
+			walkLeaves(f.typ, fieldKey, fn)
+			continue
+		}
+		fn(fieldKey, f.typ)
+	}
+}
+
+// envName returns the environment variable of the setting with the given key.
+func envName(key []string) string {
+	var b strings.Builder
+	b.WriteString(EnvPrefix)
+	for i, k := range key {
+		if i > 0 {
+			b.WriteByte('_')
+		}
+		for j, r := range k {
+			if j > 0 && unicode.IsUpper(r) && !unicode.IsUpper(rune(k[j-1])) {
+				b.WriteByte('_')
+			}
+			b.WriteRune(unicode.ToUpper(r))
+		}
+	}
+	return b.String()
+}
+
+// parseValue parses raw as a value of type t. Strings are taken as they are,
+// other values are parsed as JSON.
+func parseValue(raw string, t reflect.Type) (interface{}, error) {
+	if deref(t).Kind() == reflect.String {
+		return raw, nil
+	}
+
+	data := []byte(raw)
+	if err := json.Unmarshal(data, reflect.New(t).Interface()); err != nil {
+		// Values decoding themselves from JSON strings, like time.Time.
+		quoted, qErr := json.Marshal(raw)
+		if qErr != nil || json.Unmarshal(quoted, reflect.New(t).Interface()) != nil {
+			return nil, err
+		}
+		return raw, nil
+	}
+	var value interface{}
+	if err := json.Unmarshal(data, &value); err != nil {
+		return nil, err
+	}
+	return value, nil
+}
+
+// toTree returns the settings of cfg as a tree of their JSON values.
+func toTree(cfg interface{}) (map[string]interface{}, error) {
+	data, err := json.Marshal(cfg)
+	if err != nil {
+		return nil, err
+	}
+	tree := map[string]interface{}{}
+	if err := json.Unmarshal(data, &tree); err != nil {
+		return nil, err
+	}
+	return tree, nil
+}
@@ -0,0 +1,227 @@
+package loader
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/suite"
+)
+
+type testMqtt struct {
+	Server    string `json:"server"`
+	Password  string `json:"password" secret:"true"`
+	ClientId  string `json:"clientId"`
+	KeepAlive uint16 `json:"keepAlive"`
+}
+
+type testScale struct {
+	DataPin  uint8 `json:"dataPin"`
+	ClockPin uint8 `json:"clockPin"`
+}
+
+type testConfig struct {
+	DbPath    string     `json:"dbPath"`
+	PortionMs uint64     `json:"portionMs"`
+	Pins      []uint8    `json:"pins"`
+	Scale     *testScale `json:"scale"`
+	Mqtt      testMqtt   `json:"mqtt"`
+	UpdatedAt time.Time  `json:"updatedAt"`
+}
+
+type LoaderSuite struct {
+	suite.Suite
+	dir string
+	cfg testConfig
+}
+
+func (suite *LoaderSuite) SetupTest() {
+	suite.dir = suite.T().TempDir()
+	suite.cfg = testConfig{
+		DbPath: "./output",
+		Mqtt:   testMqtt{KeepAlive: 60},
+	}
+}
+
+func (suite *LoaderSuite) writeFile(name, content string) string {
+	path := filepath.Join(suite.dir, name)
+	suite.Require().NoError(os.WriteFile(path, []byte(content), 0600))
+	return path
+}
+
+func (suite *LoaderSuite) TestLoad_Formats() {
+	for _, path := range []string{
+		suite.writeFile("config.json", `{
+    "portionMs": 1000,
+    "scale": {"dataPin": 5},
+    "mqtt": {"server": "mqtt://localhost:1883", "clientId": "dev1"}
+}`),
+		suite.writeFile("config.yaml", `
+portionMs: 1000
+scale:
+    dataPin: 5
+mqtt:
+    server: mqtt://localhost:1883
+    clientId: dev1
+`),
+		suite.writeFile("config.toml", `
+portionMs = 1000
+
+[scale]
+dataPin = 5
+
+[mqtt]
+server = "mqtt://localhost:1883"
+clientId = "dev1"
+`),
+	} {
+		cfg := suite.cfg
+		sources, err := Load(&cfg, path, nil)
+		suite.NoError(err, path)
+		suite.Equal(testConfig{
+			DbPath:    "./output",
+			PortionMs: 1000,
+			Scale:     &testScale{DataPin: 5},
+			Mqtt:      testMqtt{Server: "mqtt://localhost:1883", ClientId: "dev1", KeepAlive: 60},
+		}, cfg, path)
+
+		suite.Equal(DefaultSource, sources.Source("dbPath"))
+		suite.Equal(DefaultSource, sources.Source("mqtt.keepAlive"))
+		suite.Equal("file "+path, sources.Source("portionMs"))
+		suite.Equal("file "+path, sources.Source("scale.dataPin"))
+		suite.Equal("file "+path, sources.Source("mqtt.server"))
+	}
+}
+
+func (suite *LoaderSuite) TestLoad_KeysCaseInsensitive() {
+	path := suite.writeFile("config.yaml", `
+PortionMs: 1000
+MQTT:
+    ClientID: dev1
+`)
+	sources, err := Load(&suite.cfg, path, []string{"mqtt.clientId=dev2"})
+	suite.NoError(err)
+	suite.Equal(uint64(1000), suite.cfg.PortionMs)
+	suite.Equal("dev2", suite.cfg.Mqtt.ClientId)
+	suite.Equal(uint16(60), suite.cfg.Mqtt.KeepAlive)
+	suite.Equal("flag --set", sources.Source("mqtt.clientId"))
+}
+
+func (suite *LoaderSuite) TestLoad_Env() {
+	path := suite.writeFile("config.json", `{"portionMs": 1000, "mqtt": {"password": "file"}}`)
+	suite.T().Setenv("RPI_FEEDER_PORTION_MS", "800")
+	suite.T().Setenv("RPI_FEEDER_MQTT_PASSWORD", "secret")
+	suite.T().Setenv("RPI_FEEDER_MQTT_CLIENT_ID", "dev1")
+	suite.T().Setenv("RPI_FEEDER_PINS", "[5, 6]")
+	suite.T().Setenv("RPI_FEEDER_SCALE_CLOCK_PIN", "6")
+	suite.T().Setenv("RPI_FEEDER_UPDATED_AT", "2022-02-01T08:00:00Z")
+
+	sources, err := Load(&suite.cfg, path, nil)
+	suite.NoError(err)
+	suite.Equal(testConfig{
+		DbPath:    "./output",
+		PortionMs: 800,
+		Pins:      []uint8{5, 6},
+		Scale:     &testScale{ClockPin: 6},
+		Mqtt:      testMqtt{Password: "secret", ClientId: "dev1", KeepAlive: 60},
+		UpdatedAt: time.Date(2022, 2, 1, 8, 0, 0, 0, time.UTC),
+	}, suite.cfg)
+	suite.Equal("env RPI_FEEDER_PORTION_MS", sources.Source("portionMs"))
+	suite.Equal("env RPI_FEEDER_MQTT_PASSWORD", sources.Source("mqtt.password"))
+	suite.Equal("env RPI_FEEDER_SCALE_CLOCK_PIN", sources.Source("scale.clockPin"))
+}
+
+func (suite *LoaderSuite) TestLoad_EnvInvalid() {
+	suite.T().Setenv("RPI_FEEDER_PORTION_MS", "many")
+	_, err := Load(&suite.cfg, "", nil)
+	suite.Error(err)
+}
+
+func (suite *LoaderSuite) TestLoad_Overrides() {
+	suite.T().Setenv("RPI_FEEDER_PORTION_MS", "800")
+	sources, err := Load(&suite.cfg, "", []string{
+		"portionMs=900",
+		"scale={\"dataPin\": 5, \"clockPin\": 6}",
+		"mqtt.password=a=b",
+	})
+	suite.NoError(err)
+	suite.Equal(uint64(900), suite.cfg.PortionMs)
+	suite.Equal(&testScale{DataPin: 5, ClockPin: 6}, suite.cfg.Scale)
+	suite.Equal("a=b", suite.cfg.Mqtt.Password)
+	suite.Equal("flag --set", sources.Source("portionMs"))
+	suite.Equal("flag --set", sources.Source("scale.dataPin"))
+}
+
+func (suite *LoaderSuite) TestLoad_OverridesInvalid() {
+	for _, o := range []string{
+		"portionMs",
+		"unknown=1",
+		"mqtt.unknown=1",
+		"portionMs=-1",
+		"pins=5",
+	} {
+		_, err := Load(&suite.cfg, "", []string{o})
+		suite.Error(err, o)
+	}
+}
+
+func (suite *LoaderSuite) TestLoad_FileErrors() {
+	_, err := Load(&suite.cfg, filepath.Join(suite.dir, "missing.json"), nil)
+	suite.Error(err)
+
+	_, err = Load(&suite.cfg, suite.writeFile("config.ini", "portionMs=1000"), nil)
+	suite.Error(err)
+
+	_, err = Load(&suite.cfg, suite.writeFile("config.yaml", "portionMs: [1000"), nil)
+	suite.Error(err)
+}
+
+func (suite *LoaderSuite) TestLoad_NotStruct() {
+	_, err := Load(suite.cfg, "", nil)
+	suite.Error(err)
+}
+
+func (suite *LoaderSuite) TestPrint() {
+	suite.T().Setenv("RPI_FEEDER_MQTT_PASSWORD", "secret")
+	sources, err := Load(&suite.cfg, "", []string{"portionMs=900"})
+	suite.Require().NoError(err)
+
+	var buf bytes.Buffer
+	suite.NoError(Print(&buf, &suite.cfg, sources, true))
+	out := buf.String()
+	suite.Regexp(`portionMs\s+900\s+flag --set\n`, out)
+	suite.Regexp(`mqtt\.password\s+<redacted>\s+env RPI_FEEDER_MQTT_PASSWORD\n`, out)
+	suite.Regexp(`mqtt\.clientId\s+""\s+default\n`, out)
+	suite.NotContains(out, "secret")
+
+	buf.Reset()
+	suite.NoError(Print(&buf, &suite.cfg, sources, false))
+	suite.Regexp(`mqtt\.password\s+"secret"\s+env RPI_FEEDER_MQTT_PASSWORD\n`, buf.String())
+}
+
+func (suite *LoaderSuite) TestEncode() {
+	tree := map[string]interface{}{
+		"portionMs": 1000,
+		"mqtt":      map[string]interface{}{"clientId": "dev1"},
+	}
+	for _, name := range []string{"config.json", "config.yml", "config.toml"} {
+		data, err := Encode(name, tree)
+		suite.NoError(err, name)
+		path := suite.writeFile(name, string(data))
+
+		cfg := suite.cfg
+		_, err = Load(&cfg, path, nil)
+		suite.NoError(err, name)
+		suite.Equal(uint64(1000), cfg.PortionMs, name)
+		suite.Equal("dev1", cfg.Mqtt.ClientId, name)
+	}
+
+	_, err := Encode("config.ini", tree)
+	suite.Error(err)
+}
+
+func TestLoaderSuite(t *testing.T) {
+	suite.Run(t, new(LoaderSuite))
+}
@@ -0,0 +1,65 @@
+package loader
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+	"text/tabwriter"
+)
+
+// Redacted is printed instead of the values of secret settings, which are the
+// fields tagged with secret:"true".
+const Redacted = "<redacted>"
+
+// Print writes the settings of cfg to w, one per line, together with where
+// they were loaded from. The values of secret settings that are set are
+// replaced by Redacted if redacted is true.
+func Print(w io.Writer, cfg interface{}, sources Sources, redacted bool) error {
+	tree, err := toTree(cfg)
+	if err != nil {
+		return err
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "KEY\tVALUE\tSOURCE")
+	if err := printTree(tw, nil, tree, reflect.TypeOf(cfg), sources, redacted); err != nil {
+		return err
+	}
+	return tw.Flush()
+}
+
+func printTree(
+	w io.Writer,
+	key []string,
+	tree map[string]interface{},
+	t reflect.Type,
+	sources Sources,
+	redacted bool) error {
+	for _, f := range fields(deref(t)) {
+		v, ok := tree[f.name]
+		if !ok {
+			continue
+		}
+		fieldKey := append(append([]string{}, key...), f.name)
+		if m, isMap := v.(map[string]interface{}); isMap && isSection(f.typ) {
+			if err := printTree(w, fieldKey, m, f.typ, sources, redacted); err != nil {
+				return err
+			}
+			continue
+		}
+
+		value := Redacted
+		if !redacted || !f.secret || v == nil || v == "" {
+			data, err := json.Marshal(v)
+			if err != nil {
+				return err
+			}
+			value = string(data)
+		}
+		joined := strings.Join(fieldKey, ".")
+		fmt.Fprintf(w, "%s\t%s\t%s\n", joined, value, sources.Source(joined))
+	}
+	return nil
+}
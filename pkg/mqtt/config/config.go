@@ -3,8 +3,16 @@ package config
 type MqttConfig struct {
 	Server            string `json:"server" validate:"required"`
 	Username          string `json:"username"`
-	Password          string `json:"password"`
+	Password          string `json:"password" secret:"true"`
 	ClientId          string `json:"clientId" validate:"required"`
 	KeepAlive         uint16 `json:"keepAlive" validate:"required,gt=0"`
 	ConnectRetryDelay uint16 `json:"connectRetryDelay" validate:"required,gt=0"`
+
+	// The TLS settings used with mqtts:// servers. The broker certificate is
+	// verified with the CAs of the system if CaFile is not set. The client
+	// certificate is only sent if CertFile and KeyFile are set.
+	CaFile             string `json:"caFile" validate:"omitempty,file"`
+	CertFile           string `json:"certFile" validate:"required_with=KeyFile,omitempty,file"`
+	KeyFile            string `json:"keyFile" validate:"required_with=CertFile,omitempty,file"`
+	InsecureSkipVerify bool   `json:"insecureSkipVerify"`
 }
@@ -0,0 +1,39 @@
+package config
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"io/ioutil"
+)
+
+// TlsConfig returns the TLS config for connecting to the broker, which is used
+// with mqtts:// servers.
+func (c MqttConfig) TlsConfig() (*tls.Config, error) {
+	cfg := &tls.Config{
+		MinVersion: tls.VersionTLS12,
+		// Verifying the broker certificate is disabled on request only, e.g.
+		// while testing with a self-signed certificate.
+		InsecureSkipVerify: c.InsecureSkipVerify, //nolint
+	}
+
+	if c.CaFile != "" {
+		ca, err := ioutil.ReadFile(c.CaFile)
+		if err != nil {
+			return nil, err
+		}
+		cfg.RootCAs = x509.NewCertPool()
+		if !cfg.RootCAs.AppendCertsFromPEM(ca) {
+			return nil, fmt.Errorf("no certificates found in %s", c.CaFile)
+		}
+	}
+
+	if c.CertFile != "" || c.KeyFile != "" {
+		cert, err := tls.LoadX509KeyPair(c.CertFile, c.KeyFile)
+		if err != nil {
+			return nil, err
+		}
+		cfg.Certificates = []tls.Certificate{cert}
+	}
+	return cfg, nil
+}
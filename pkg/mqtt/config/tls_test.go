@@ -0,0 +1,104 @@
+package config
+
+import (
+	"crypto/tls"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/imilchev/rpi-feeder/tests/utils/broker"
+	"github.com/stretchr/testify/suite"
+)
+
+type TlsSuite struct {
+	suite.Suite
+	certs  broker.Certs
+	broker *broker.Broker
+}
+
+func (suite *TlsSuite) SetupTest() {
+	var err error
+	suite.certs, err = broker.GenerateCerts(suite.T().TempDir(), "dev1")
+	suite.Require().NoError(err)
+	suite.broker, err = broker.NewBroker(suite.certs)
+	suite.Require().NoError(err)
+}
+
+func (suite *TlsSuite) TearDownTest() {
+	suite.broker.Close()
+}
+
+func (suite *TlsSuite) handshake(c MqttConfig) error {
+	cfg, err := c.TlsConfig()
+	suite.Require().NoError(err)
+	conn, err := tls.Dial("tcp", strings.TrimPrefix(suite.broker.Url(), "mqtts://"), cfg)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	return conn.Handshake()
+}
+
+func (suite *TlsSuite) TestTlsConfig() {
+	cfg, err := MqttConfig{
+		CaFile:   suite.certs.CaFile,
+		CertFile: suite.certs.ClientCertFile,
+		KeyFile:  suite.certs.ClientKeyFile,
+	}.TlsConfig()
+	suite.NoError(err)
+	suite.NotNil(cfg.RootCAs)
+	suite.Len(cfg.Certificates, 1)
+	suite.False(cfg.InsecureSkipVerify)
+
+	suite.NoError(suite.handshake(MqttConfig{
+		CaFile:   suite.certs.CaFile,
+		CertFile: suite.certs.ClientCertFile,
+		KeyFile:  suite.certs.ClientKeyFile,
+	}))
+}
+
+func (suite *TlsSuite) TestTlsConfig_Defaults() {
+	cfg, err := MqttConfig{}.TlsConfig()
+	suite.NoError(err)
+	suite.Nil(cfg.RootCAs)
+	suite.Empty(cfg.Certificates)
+}
+
+func (suite *TlsSuite) TestTlsConfig_UnknownCa() {
+	other, err := broker.GenerateCerts(suite.T().TempDir(), "dev1")
+	suite.Require().NoError(err)
+
+	suite.Error(suite.handshake(MqttConfig{
+		CaFile:   other.CaFile,
+		CertFile: suite.certs.ClientCertFile,
+		KeyFile:  suite.certs.ClientKeyFile,
+	}))
+	suite.NoError(suite.handshake(MqttConfig{
+		CaFile:             other.CaFile,
+		CertFile:           suite.certs.ClientCertFile,
+		KeyFile:            suite.certs.ClientKeyFile,
+		InsecureSkipVerify: true,
+	}))
+}
+
+func (suite *TlsSuite) TestTlsConfig_InvalidFiles() {
+	dir := suite.T().TempDir()
+	notPem := filepath.Join(dir, "ca.pem")
+	suite.Require().NoError(os.WriteFile(notPem, []byte("not a certificate"), 0600))
+
+	for _, c := range []MqttConfig{
+		{CaFile: filepath.Join(dir, "missing.pem")},
+		{CaFile: notPem},
+		{CertFile: suite.certs.ClientCertFile},
+		{KeyFile: suite.certs.ClientKeyFile},
+		{CertFile: suite.certs.ClientCertFile, KeyFile: suite.certs.ServerKeyFile},
+	} {
+		_, err := c.TlsConfig()
+		suite.Error(err, c)
+	}
+}
+
+func TestTlsSuite(t *testing.T) {
+	suite.Run(t, new(TlsSuite))
+}
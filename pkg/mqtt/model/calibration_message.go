@@ -0,0 +1,32 @@
+package model
+
+import "time"
+
+// CalibrateMessage requests the feeder to calibrate the duration of a portion
+// using its load cell.
+type CalibrateMessage struct {
+	// The durations to run the servo for. The feeder defaults are used if not
+	// set.
+	DurationsMs []uint64 `json:"durationsMs,omitempty"`
+
+	// The weight of 1 portion to calibrate for. The weight configured for the
+	// scale of the feeder is used if not set.
+	GramsPerPortion float64 `json:"gramsPerPortion,omitempty"`
+}
+
+type CalibrationMessage struct {
+	MsPerGram       float64                    `json:"msPerGram"`
+	OffsetMs        float64                    `json:"offsetMs"`
+	GramsPerPortion float64                    `json:"gramsPerPortion"`
+	PortionMs       uint64                     `json:"portionMs"`
+	Samples         []CalibrationSampleMessage `json:"samples"`
+	Timestamp       time.Time                  `json:"timestamp"`
+
+	// Why the calibration failed. No other fields are set if it failed.
+	Error string `json:"error,omitempty"`
+}
+
+type CalibrationSampleMessage struct {
+	DurationMs uint64  `json:"durationMs"`
+	Grams      float64 `json:"grams"`
+}
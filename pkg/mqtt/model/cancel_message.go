@@ -0,0 +1,4 @@
+package model
+
+// CancelMessage requests the feeder to stop the feeding in progress.
+type CancelMessage struct{}
@@ -0,0 +1,12 @@
+package model
+
+import "encoding/json"
+
+// ConfigMessage holds the settings of a feeder managed by the service, in the
+// format of the config file of the feeder. They are applied over the config
+// file and replace any config the feeder has received before.
+type ConfigMessage struct {
+	// Increases with every change of the config of the feeder.
+	Revision int             `json:"revision"`
+	Config   json.RawMessage `json:"config"`
+}
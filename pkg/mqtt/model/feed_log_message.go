@@ -7,6 +7,10 @@ type FeedLogCollectionMessage struct {
 }
 
 type FeedLogMessage struct {
-	Portions  uint      `json:"portions"`
+	Portions uint `json:"portions"`
+
+	// The weight of the served food in grams. Not set if the feeding was not
+	// weighed.
+	Grams     float64   `json:"grams,omitempty"`
 	Timestamp time.Time `json:"timestamp"`
 }
@@ -1,5 +1,33 @@
 package model
 
+import "time"
+
 type FeedMessage struct {
+	// Identifies the command. The feeder reports the outcome of the command
+	// with a FeedResultMessage carrying the same id.
+	CommandId string `json:"commandId,omitempty"`
+	Portions  uint   `json:"portions"`
+}
+
+type FeedResultMessage struct {
+	CommandId string `json:"commandId"`
+	Success   bool   `json:"success"`
+
+	// The amount of portions that were actually served.
 	Portions uint `json:"portions"`
+
+	// The reason for the failure. Only set if Success is false.
+	Error string `json:"error,omitempty"`
+
+	// Whether the feeder refused the command because it would exceed its
+	// feeding limits.
+	Rejected bool `json:"rejected,omitempty"`
+
+	// Whether the feeding was cancelled before all portions were served.
+	Cancelled bool `json:"cancelled,omitempty"`
+
+	// When the feeding was scheduled. Only set for failed scheduled and
+	// caught up feedings, which have no command id.
+	ScheduledAt *time.Time `json:"scheduledAt,omitempty"`
+	Timestamp   time.Time  `json:"timestamp"`
 }
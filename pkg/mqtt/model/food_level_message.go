@@ -0,0 +1,12 @@
+package model
+
+import "time"
+
+type FoodLevelMessage struct {
+	// The fill level of the hopper in percent.
+	Level float64 `json:"level"`
+
+	// The distance in cm measured by the sensor.
+	DistanceCm float64   `json:"distanceCm"`
+	Timestamp  time.Time `json:"timestamp"`
+}
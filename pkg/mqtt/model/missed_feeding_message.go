@@ -0,0 +1,22 @@
+package model
+
+import "time"
+
+type MissedFeedingCollectionMessage struct {
+	// The most recent missed feedings.
+	Value []MissedFeedingMessage `json:"value"`
+
+	// The amount of missed feedings, including the ones older than the ones
+	// in Value.
+	Total uint `json:"total"`
+}
+
+// MissedFeedingMessage describes a scheduled feeding that was due while the
+// feeder was not running.
+type MissedFeedingMessage struct {
+	Portions    uint      `json:"portions"`
+	ScheduledAt time.Time `json:"scheduledAt"`
+
+	// Whether the feeding was served late, after the feeder started again.
+	CaughtUp bool `json:"caughtUp"`
+}
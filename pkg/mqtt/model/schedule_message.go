@@ -0,0 +1,13 @@
+package model
+
+// ScheduleMessage holds the complete feeding schedule of a feeder. It
+// replaces any schedule the feeder has received before.
+type ScheduleMessage struct {
+	Value []ScheduleEntryMessage `json:"value"`
+}
+
+type ScheduleEntryMessage struct {
+	Cron     string `json:"cron,omitempty"`
+	Time     string `json:"time,omitempty"`
+	Portions uint   `json:"portions"`
+}
@@ -10,4 +10,30 @@ const (
 type StatusMessage struct {
 	SoftwareVersion string `json:"softwareVersion"`
 	Status          Status `json:"status"`
+
+	// The amount of feed commands waiting or being executed on the feeder.
+	QueueDepth uint `json:"queueDepth"`
+
+	// The latest food level in percent. Only set if the feeder has a food
+	// level sensor and it has been read at least once.
+	FoodLevel *float64 `json:"foodLevel,omitempty"`
+
+	ConfigStatus
+}
+
+// ConfigStatus is the state of the config received from the service, as
+// reported in the status of the feeder.
+type ConfigStatus struct {
+	// The revision of the config received from the service that is in use,
+	// or 0 if the feeder only uses its config file. If ConfigPendingRestart is
+	// set, some settings of the revision only take effect once the feeder is
+	// restarted.
+	ConfigRevision       int  `json:"configRevision,omitempty"`
+	ConfigPendingRestart bool `json:"configPendingRestart,omitempty"`
+
+	// The latest revision of the config received from the service that the
+	// feeder rejected, together with the reason. Only set if it is newer than
+	// ConfigRevision. Rejected revisions are not applied again.
+	ConfigRejectedRevision int    `json:"configRejectedRevision,omitempty"`
+	ConfigError            string `json:"configError,omitempty"`
 }
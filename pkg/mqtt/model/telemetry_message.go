@@ -0,0 +1,25 @@
+package model
+
+import "time"
+
+// TelemetryMessage is sent periodically by the feeder and doubles as its
+// heartbeat.
+type TelemetryMessage struct {
+	// The seconds since the feeder was started.
+	UptimeSeconds uint64 `json:"uptimeSeconds"`
+
+	// The CPU temperature in °C. Not set if it could not be read.
+	CpuTemperature *float64 `json:"cpuTemperature,omitempty"`
+
+	// The free disk space in bytes of the partition holding the database.
+	FreeDiskBytes uint64 `json:"freeDiskBytes"`
+
+	// The amount of feed logs stored on the feeder, which have not been sent
+	// to the service yet.
+	UnflushedFeedLogs uint `json:"unflushedFeedLogs"`
+
+	// The amount of times the feeder reconnected to the broker since it was
+	// started.
+	MqttReconnects uint      `json:"mqttReconnects"`
+	Timestamp      time.Time `json:"timestamp"`
+}
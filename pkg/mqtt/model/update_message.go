@@ -0,0 +1,30 @@
+package model
+
+import "time"
+
+// UpdateMessage requests the feeder to install another version of its
+// software.
+type UpdateMessage struct {
+	Version string `json:"version"`
+
+	// The URL to download the binary from and its hex encoded SHA-256
+	// checksum.
+	Url    string `json:"url"`
+	Sha256 string `json:"sha256"`
+}
+
+// UpdateResultMessage reports the outcome of an update. It is sent once the
+// new version is connected to the broker, or once the update failed.
+type UpdateResultMessage struct {
+	// The version the update was requested for.
+	Version string `json:"version"`
+	Success bool   `json:"success"`
+
+	// Why the update failed. Only set if it failed.
+	Error string `json:"error,omitempty"`
+
+	// True if the new version was installed, but failed to connect and the
+	// previous version was restored.
+	RolledBack bool      `json:"rolledBack,omitempty"`
+	Timestamp  time.Time `json:"timestamp"`
+}
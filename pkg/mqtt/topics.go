@@ -17,12 +17,81 @@ func FeedTopic(clientId *string) string {
 	return fmt.Sprintf("feeder/%s/feed", wildcardOrClientId(clientId))
 }
 
+// FeedResultTopic gives the feed result topic for the specified clientId. If
+// clientId is nil, then a wildcard topic for all clients is returned.
+func FeedResultTopic(clientId *string) string {
+	return fmt.Sprintf("feeder/%s/feed_result", wildcardOrClientId(clientId))
+}
+
+// CancelTopic gives the cancel topic for the specified clientId. If clientId
+// is nil, then a wildcard topic for all clients is returned.
+func CancelTopic(clientId *string) string {
+	return fmt.Sprintf("feeder/%s/cancel", wildcardOrClientId(clientId))
+}
+
 // FeedLogTopic gives the feed log topic for the specified clientId. If clientId
 // is nil, then a wildcard topic for all clients is returned.
 func FeedLogTopic(clientId *string) string {
 	return fmt.Sprintf("feeder/%s/feed_log", wildcardOrClientId(clientId))
 }
 
+// ScheduleTopic gives the schedule topic for the specified clientId. If
+// clientId is nil, then a wildcard topic for all clients is returned.
+func ScheduleTopic(clientId *string) string {
+	return fmt.Sprintf("feeder/%s/schedule", wildcardOrClientId(clientId))
+}
+
+// MissedFeedingTopic gives the missed feeding topic for the specified
+// clientId. If clientId is nil, then a wildcard topic for all clients is
+// returned.
+func MissedFeedingTopic(clientId *string) string {
+	return fmt.Sprintf("feeder/%s/missed_feeding", wildcardOrClientId(clientId))
+}
+
+// CalibrateTopic gives the calibrate topic for the specified clientId. If
+// clientId is nil, then a wildcard topic for all clients is returned.
+func CalibrateTopic(clientId *string) string {
+	return fmt.Sprintf("feeder/%s/calibrate", wildcardOrClientId(clientId))
+}
+
+// CalibrationTopic gives the calibration result topic for the specified
+// clientId. If clientId is nil, then a wildcard topic for all clients is
+// returned.
+func CalibrationTopic(clientId *string) string {
+	return fmt.Sprintf("feeder/%s/calibration", wildcardOrClientId(clientId))
+}
+
+// FoodLevelTopic gives the food level topic for the specified clientId. If
+// clientId is nil, then a wildcard topic for all clients is returned.
+func FoodLevelTopic(clientId *string) string {
+	return fmt.Sprintf("feeder/%s/food_level", wildcardOrClientId(clientId))
+}
+
+// TelemetryTopic gives the telemetry topic for the specified clientId. If
+// clientId is nil, then a wildcard topic for all clients is returned.
+func TelemetryTopic(clientId *string) string {
+	return fmt.Sprintf("feeder/%s/telemetry", wildcardOrClientId(clientId))
+}
+
+// UpdateTopic gives the software update topic for the specified clientId. If
+// clientId is nil, then a wildcard topic for all clients is returned.
+func UpdateTopic(clientId *string) string {
+	return fmt.Sprintf("feeder/%s/update", wildcardOrClientId(clientId))
+}
+
+// UpdateResultTopic gives the software update result topic for the specified
+// clientId. If clientId is nil, then a wildcard topic for all clients is
+// returned.
+func UpdateResultTopic(clientId *string) string {
+	return fmt.Sprintf("feeder/%s/update_result", wildcardOrClientId(clientId))
+}
+
+// ConfigTopic gives the config topic for the specified clientId. If clientId
+// is nil, then a wildcard topic for all clients is returned.
+func ConfigTopic(clientId *string) string {
+	return fmt.Sprintf("feeder/%s/config", wildcardOrClientId(clientId))
+}
+
 // ClientIdFromTopic extracts the clientId from a topic. Panics if the topic
 // format is invalid.
 func ClientIdFromTopic(topic string) string {
@@ -0,0 +1,146 @@
+package alerts
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/imilchev/rpi-feeder/pkg/mqtt/model"
+	"github.com/imilchev/rpi-feeder/pkg/service/config"
+	"github.com/imilchev/rpi-feeder/pkg/service/db/repos"
+	"github.com/imilchev/rpi-feeder/pkg/service/models"
+	"go.uber.org/zap"
+)
+
+// Alerter raises alerts based on the messages received from the feeders.
+type Alerter interface {
+	// EvaluateStatus raises a low food or empty hopper alert if the food level
+	// in the status is at or below the configured thresholds. Alerts for
+	// levels that are no longer reached are resolved.
+	EvaluateStatus(clientId string, msg model.StatusMessage) error
+
+	// EvaluateFeedResult raises an alert if the feeder failed to feed, whether
+	// the feeding was commanded or scheduled. Rejected and cancelled feedings
+	// do not raise alerts.
+	EvaluateFeedResult(clientId string, msg model.FeedResultMessage) error
+
+	// RaiseStale raises an alert for a feeder that was last seen at lastSeen
+	// and has stopped reporting since. lastSeen is the zero time if it is not
+	// known when the feeder was last seen.
+	RaiseStale(clientId string, lastSeen time.Time) error
+
+	// ResolveStale resolves the stale alert of a feeder that is reporting
+	// again.
+	ResolveStale(clientId string) error
+
+	// EvaluateUpdateResult raises an alert if the feeder failed to install a
+	// new version. A successful update resolves the alerts of earlier failed
+	// updates.
+	EvaluateUpdateResult(clientId string, msg model.UpdateResultMessage) error
+}
+
+type alerter struct {
+	repo repos.AlertsRepository
+	cfg  config.Alerts
+	now  func() time.Time
+}
+
+func NewAlerter(repo repos.AlertsRepository, cfg config.Alerts) Alerter {
+	return &alerter{repo: repo, cfg: cfg, now: time.Now}
+}
+
+func (a *alerter) EvaluateStatus(clientId string, msg model.StatusMessage) error {
+	if msg.FoodLevel == nil {
+		return nil
+	}
+
+	level := *msg.FoodLevel
+	var raised models.AlertType
+	switch {
+	case level <= a.cfg.EmptyFoodLevel:
+		raised = models.EmptyHopperAlertType
+	case level <= a.cfg.LowFoodLevel:
+		raised = models.LowFoodAlertType
+	}
+
+	// A refill from empty to low resolves the empty hopper alert, but still
+	// raises a low food alert.
+	now := a.now().UTC().Unix()
+	for _, t := range []models.AlertType{models.LowFoodAlertType, models.EmptyHopperAlertType} {
+		if t == raised {
+			continue
+		}
+		if err := a.repo.ResolveAlerts(clientId, t, now); err != nil {
+			return err
+		}
+	}
+	if raised == "" {
+		return nil
+	}
+
+	// The level is reported with every status, so the alert is only raised
+	// once until it is resolved.
+	if _, err := a.repo.GetUnresolvedAlert(clientId, raised); err == nil {
+		return nil
+	} else if e, ok := err.(*models.ApiError); !ok || e.Code() != http.StatusNotFound {
+		return err
+	}
+	message := fmt.Sprintf("Food level of feeder %s is %.1f%%.", clientId, level)
+	if raised == models.EmptyHopperAlertType {
+		message = fmt.Sprintf("The hopper of feeder %s is empty (%.1f%%).", clientId, level)
+	}
+	return a.raise(clientId, raised, message, now)
+}
+
+func (a *alerter) EvaluateFeedResult(clientId string, msg model.FeedResultMessage) error {
+	if msg.Success || msg.Rejected || msg.Cancelled {
+		return nil
+	}
+
+	message := fmt.Sprintf(
+		"Feeder %s failed to feed after serving %d portions. %s", clientId, msg.Portions, msg.Error)
+	if msg.ScheduledAt != nil {
+		message = fmt.Sprintf(
+			"Feeder %s failed to serve the feeding scheduled at %s after serving %d portions. %s",
+			clientId, msg.ScheduledAt.UTC().Format(time.RFC3339), msg.Portions, msg.Error)
+	}
+	return a.raise(clientId, models.FeedFailedAlertType, message, a.now().UTC().Unix())
+}
+
+func (a *alerter) RaiseStale(clientId string, lastSeen time.Time) error {
+	message := fmt.Sprintf("Feeder %s has stopped reporting.", clientId)
+	if !lastSeen.IsZero() {
+		message = fmt.Sprintf(
+			"Feeder %s has not reported since %s.", clientId, lastSeen.UTC().Format(time.RFC3339))
+	}
+	return a.raise(clientId, models.StaleAlertType, message, a.now().UTC().Unix())
+}
+
+func (a *alerter) ResolveStale(clientId string) error {
+	return a.repo.ResolveAlerts(clientId, models.StaleAlertType, a.now().UTC().Unix())
+}
+
+func (a *alerter) EvaluateUpdateResult(clientId string, msg model.UpdateResultMessage) error {
+	now := a.now().UTC().Unix()
+	if msg.Success {
+		return a.repo.ResolveAlerts(clientId, models.UpdateFailedAlertType, now)
+	}
+
+	message := fmt.Sprintf("Feeder %s failed to update to %s. %s", clientId, msg.Version, msg.Error)
+	if msg.RolledBack {
+		message = fmt.Sprintf(
+			"Feeder %s was rolled back after updating to %s. %s", clientId, msg.Version, msg.Error)
+	}
+	return a.raise(clientId, models.UpdateFailedAlertType, message, now)
+}
+
+func (a *alerter) raise(clientId string, t models.AlertType, message string, now int64) error {
+	zap.S().Warnf("Raising %s alert. %s", t, message)
+	_, err := a.repo.CreateAlert(models.Alert{
+		ClientId:  clientId,
+		Type:      t,
+		Message:   message,
+		CreatedAt: now,
+	})
+	return err
+}
@@ -0,0 +1,193 @@
+package alerts
+
+import (
+	"fmt"
+	"math/rand"
+	"testing"
+	"time"
+
+	"github.com/imilchev/rpi-feeder/pkg/mqtt/model"
+	"github.com/imilchev/rpi-feeder/pkg/service/config"
+	"github.com/imilchev/rpi-feeder/pkg/service/models"
+	fake "github.com/imilchev/rpi-feeder/tests/fake/repos"
+	"github.com/imilchev/rpi-feeder/tests/utils"
+	"github.com/stretchr/testify/suite"
+)
+
+type AlerterSuite struct {
+	suite.Suite
+	repo     *fake.FakeAlertsRepository
+	a        *alerter
+	clientId string
+	now      time.Time
+}
+
+func (suite *AlerterSuite) SetupTest() {
+	suite.repo = &fake.FakeAlertsRepository{}
+	suite.clientId = utils.RandString(10)
+	suite.now = time.Now().UTC()
+	suite.a = &alerter{
+		repo: suite.repo,
+		cfg:  config.Alerts{LowFoodLevel: 20, EmptyFoodLevel: 5},
+		now:  func() time.Time { return suite.now },
+	}
+}
+
+func (suite *AlerterSuite) TestEvaluateStatus_NoFoodLevel() {
+	suite.NoError(suite.a.EvaluateStatus(suite.clientId, model.StatusMessage{Status: model.OnlineStatus}))
+	suite.Empty(suite.repo.Alerts)
+}
+
+func (suite *AlerterSuite) TestEvaluateStatus_LevelOk() {
+	suite.NoError(suite.a.EvaluateStatus(suite.clientId, status(50)))
+	suite.Empty(suite.repo.Alerts)
+}
+
+func (suite *AlerterSuite) TestEvaluateStatus_LowFood() {
+	suite.NoError(suite.a.EvaluateStatus(suite.clientId, status(15)))
+
+	suite.Equal(1, len(suite.repo.Alerts))
+	a := suite.repo.Alerts[0]
+	suite.Equal(suite.clientId, a.ClientId)
+	suite.Equal(models.LowFoodAlertType, a.Type)
+	suite.Equal(suite.now.Unix(), a.CreatedAt)
+	suite.True(a.Active())
+}
+
+func (suite *AlerterSuite) TestEvaluateStatus_LowFoodRaisedOnce() {
+	suite.NoError(suite.a.EvaluateStatus(suite.clientId, status(15)))
+	suite.NoError(suite.a.EvaluateStatus(suite.clientId, status(12)))
+
+	suite.Equal(1, len(suite.repo.Alerts))
+}
+
+func (suite *AlerterSuite) TestEvaluateStatus_EmptyHopper() {
+	suite.NoError(suite.a.EvaluateStatus(suite.clientId, status(15)))
+	suite.NoError(suite.a.EvaluateStatus(suite.clientId, status(3)))
+
+	suite.Equal(2, len(suite.repo.Alerts))
+	suite.Equal(models.LowFoodAlertType, suite.repo.Alerts[0].Type)
+	suite.NotNil(suite.repo.Alerts[0].ResolvedAt)
+	suite.Equal(models.EmptyHopperAlertType, suite.repo.Alerts[1].Type)
+	suite.True(suite.repo.Alerts[1].Active())
+}
+
+func (suite *AlerterSuite) TestEvaluateStatus_Refilled() {
+	suite.NoError(suite.a.EvaluateStatus(suite.clientId, status(3)))
+	suite.NoError(suite.a.EvaluateStatus(suite.clientId, status(90)))
+
+	suite.Equal(1, len(suite.repo.Alerts))
+	suite.Equal(suite.now.Unix(), *suite.repo.Alerts[0].ResolvedAt)
+}
+
+func (suite *AlerterSuite) TestEvaluateStatus_Error() {
+	suite.repo.Error = fmt.Errorf("error")
+	suite.Error(suite.a.EvaluateStatus(suite.clientId, status(3)))
+}
+
+func (suite *AlerterSuite) TestEvaluateStatus_LookupFailed() {
+	suite.a.repo = &failingLookupRepository{suite.repo}
+	suite.Error(suite.a.EvaluateStatus(suite.clientId, status(15)))
+	suite.Empty(suite.repo.Alerts)
+}
+
+func (suite *AlerterSuite) TestEvaluateFeedResult_Failed() {
+	msg := model.FeedResultMessage{Portions: 1, Error: utils.RandString(10)}
+	suite.NoError(suite.a.EvaluateFeedResult(suite.clientId, msg))
+
+	suite.Equal(1, len(suite.repo.Alerts))
+	suite.Equal(models.FeedFailedAlertType, suite.repo.Alerts[0].Type)
+	suite.Contains(suite.repo.Alerts[0].Message, msg.Error)
+}
+
+func (suite *AlerterSuite) TestEvaluateFeedResult_ScheduledFailed() {
+	scheduledAt := time.Date(2022, 1, 10, 8, 0, 0, 0, time.UTC)
+	msg := model.FeedResultMessage{Error: utils.RandString(10), ScheduledAt: &scheduledAt}
+	suite.NoError(suite.a.EvaluateFeedResult(suite.clientId, msg))
+
+	suite.Equal(1, len(suite.repo.Alerts))
+	suite.Equal(models.FeedFailedAlertType, suite.repo.Alerts[0].Type)
+	suite.Contains(suite.repo.Alerts[0].Message, "2022-01-10T08:00:00Z")
+	suite.Contains(suite.repo.Alerts[0].Message, msg.Error)
+}
+
+func (suite *AlerterSuite) TestEvaluateFeedResult_NotFailed() {
+	for _, msg := range []model.FeedResultMessage{
+		{Success: true},
+		{Rejected: true},
+		{Cancelled: true},
+	} {
+		suite.NoError(suite.a.EvaluateFeedResult(suite.clientId, msg))
+	}
+	suite.Empty(suite.repo.Alerts)
+}
+
+func (suite *AlerterSuite) TestRaiseStale() {
+	lastSeen := suite.now.Add(-10 * time.Minute)
+	suite.NoError(suite.a.RaiseStale(suite.clientId, lastSeen))
+
+	suite.Equal(1, len(suite.repo.Alerts))
+	suite.Equal(models.StaleAlertType, suite.repo.Alerts[0].Type)
+	suite.Contains(suite.repo.Alerts[0].Message, lastSeen.Format(time.RFC3339))
+}
+
+func (suite *AlerterSuite) TestRaiseStale_LastSeenUnknown() {
+	suite.NoError(suite.a.RaiseStale(suite.clientId, time.Time{}))
+
+	suite.Equal(1, len(suite.repo.Alerts))
+	suite.NotContains(suite.repo.Alerts[0].Message, "since")
+}
+
+func (suite *AlerterSuite) TestResolveStale() {
+	suite.NoError(suite.a.RaiseStale(suite.clientId, suite.now.Add(-10*time.Minute)))
+	suite.NoError(suite.a.ResolveStale(suite.clientId))
+
+	suite.Equal(suite.now.Unix(), *suite.repo.Alerts[0].ResolvedAt)
+}
+
+func (suite *AlerterSuite) TestEvaluateUpdateResult_Failed() {
+	msg := model.UpdateResultMessage{Version: "v1.2.0", Error: utils.RandString(10)}
+	suite.NoError(suite.a.EvaluateUpdateResult(suite.clientId, msg))
+
+	suite.Equal(1, len(suite.repo.Alerts))
+	suite.Equal(models.UpdateFailedAlertType, suite.repo.Alerts[0].Type)
+	suite.Contains(suite.repo.Alerts[0].Message, msg.Version)
+	suite.Contains(suite.repo.Alerts[0].Message, msg.Error)
+}
+
+func (suite *AlerterSuite) TestEvaluateUpdateResult_RolledBack() {
+	msg := model.UpdateResultMessage{Version: "v1.2.0", RolledBack: true}
+	suite.NoError(suite.a.EvaluateUpdateResult(suite.clientId, msg))
+
+	suite.Equal(1, len(suite.repo.Alerts))
+	suite.Contains(suite.repo.Alerts[0].Message, "rolled back")
+}
+
+func (suite *AlerterSuite) TestEvaluateUpdateResult_Success() {
+	failed := model.UpdateResultMessage{Version: "v1.2.0", Error: utils.RandString(10)}
+	suite.NoError(suite.a.EvaluateUpdateResult(suite.clientId, failed))
+	suite.NoError(suite.a.EvaluateUpdateResult(
+		suite.clientId, model.UpdateResultMessage{Version: "v1.2.1", Success: true}))
+
+	suite.Equal(1, len(suite.repo.Alerts))
+	suite.Equal(suite.now.Unix(), *suite.repo.Alerts[0].ResolvedAt)
+}
+
+// failingLookupRepository fails to look up the unresolved alerts, while the
+// other functions succeed.
+type failingLookupRepository struct {
+	*fake.FakeAlertsRepository
+}
+
+func (r *failingLookupRepository) GetUnresolvedAlert(clientId string, t models.AlertType) (models.Alert, error) {
+	return models.Alert{}, fmt.Errorf("error")
+}
+
+func status(level float64) model.StatusMessage {
+	return model.StatusMessage{Status: model.OnlineStatus, FoodLevel: &level}
+}
+
+func TestAlerterSuite(t *testing.T) {
+	rand.Seed(time.Now().UnixNano())
+	suite.Run(t, new(AlerterSuite))
+}
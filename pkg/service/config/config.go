@@ -1,12 +1,28 @@
 package config
 
-import "github.com/imilchev/rpi-feeder/pkg/mqtt/config"
+import (
+	"github.com/imilchev/rpi-feeder/pkg/limits"
+	"github.com/imilchev/rpi-feeder/pkg/mqtt/config"
+)
 
 type Config struct {
 	Server   Server   `json:"server" validate:"required"`
 	Database Database `json:"database" validate:"required"`
 	//Jwt      Jwt      `json:"jwt" validate:"required"`
 	Mqtt config.MqttConfig `json:"mqtt" validate:"required"`
+
+	// Feeding limits checked before sending feed commands. These should match
+	// the limits configured on the feeders, which enforce them regardless.
+	Limits limits.Limits `json:"limits"`
+
+	// Thresholds for the alerts raised for the food level of the feeders.
+	Alerts Alerts `json:"alerts"`
+
+	// Detection of feeders that stopped reporting without going offline.
+	Watchdog Watchdog `json:"watchdog"`
+
+	// Storage of the software releases installed on the feeders.
+	Releases Releases `json:"releases"`
 }
 
 type Server struct {
@@ -15,8 +31,30 @@ type Server struct {
 	ReadTimeout uint   `json:"readTimeout" validate:"gt=0"`
 }
 
+// Alerts holds the food levels in percent at or below which alerts are raised.
+type Alerts struct {
+	LowFoodLevel   float64 `json:"lowFoodLevel" validate:"gte=0,lte=100,gtfield=EmptyFoodLevel"`
+	EmptyFoodLevel float64 `json:"emptyFoodLevel" validate:"gte=0,lte=100"`
+}
+
+// Watchdog holds the seconds without a heartbeat after which an online feeder
+// is marked offline.
+type Watchdog struct {
+	TimeoutSeconds uint `json:"timeoutSeconds" validate:"gt=0"`
+}
+
+// Releases holds the directory in which the uploaded binaries are stored and
+// the URL of the service as reachable by the feeders, which they download
+// the binaries from. Feeders cannot be updated without PublicUrl. Uploads are
+// limited to MaxSizeMb.
+type Releases struct {
+	Path      string `json:"path" validate:"required"`
+	PublicUrl string `json:"publicUrl" validate:"omitempty,url"`
+	MaxSizeMb uint   `json:"maxSizeMb" validate:"gt=0"`
+}
+
 type Database struct {
-	ConnectionString string `json:"connectionString" validate:"required"`
+	ConnectionString string `json:"connectionString" validate:"required" secret:"true"`
 }
 
 type Jwt struct {
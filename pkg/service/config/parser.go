@@ -1,19 +1,45 @@
 package config
 
 import (
-	"encoding/json"
-	"io/ioutil"
+	"github.com/imilchev/rpi-feeder/pkg/loader"
 )
 
-func ReadConfig(configPath string) (*Config, error) {
-	configFile, err := ioutil.ReadFile(configPath)
-	if err != nil {
-		return nil, err
-	}
+const (
+	defaultLowFoodLevel   = 20
+	defaultEmptyFoodLevel = 5
+
+	// The feeders send telemetry every minute by default, so a few of them
+	// can be lost before a feeder is considered stale.
+	defaultWatchdogTimeoutSeconds = 300
+
+	defaultReleasesPath      = "releases"
+	defaultReleasesMaxSizeMb = 64
+)
 
-	config := &Config{}
-	if err := json.Unmarshal(configFile, config); err != nil {
-		return nil, err
+// ReadConfig reads the config file at configPath, with the settings from the
+// environment and the overrides applied over it. See loader.Load.
+func ReadConfig(configPath string, overrides []string) (*Config, error) {
+	config, _, err := LoadConfig(configPath, overrides)
+	return config, err
+}
+
+// LoadConfig reads the config like ReadConfig and also returns where each
+// setting was loaded from.
+func LoadConfig(configPath string, overrides []string) (*Config, loader.Sources, error) {
+	config := &Config{
+		Alerts: Alerts{
+			LowFoodLevel:   defaultLowFoodLevel,
+			EmptyFoodLevel: defaultEmptyFoodLevel,
+		},
+		Watchdog: Watchdog{TimeoutSeconds: defaultWatchdogTimeoutSeconds},
+		Releases: Releases{
+			Path:      defaultReleasesPath,
+			MaxSizeMb: defaultReleasesMaxSizeMb,
+		},
+	}
+	sources, err := loader.Load(config, configPath, overrides)
+	if err != nil {
+		return nil, nil, err
 	}
-	return config, nil
+	return config, sources, nil
 }
@@ -0,0 +1,59 @@
+package v1
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/imilchev/rpi-feeder/pkg/service/db/repos"
+	"github.com/imilchev/rpi-feeder/pkg/service/models"
+	"gorm.io/gorm"
+)
+
+type AlertsController struct {
+	alertsRepo repos.AlertsRepository
+}
+
+func NewAlertsController(db *gorm.DB) *AlertsController {
+	return &AlertsController{
+		alertsRepo: repos.NewAlertsRepository(db),
+	}
+}
+
+func (c *AlertsController) RegisterHandlers(a *fiber.App) {
+	route := a.Group(apiGroup)
+	route.Get("/alerts", c.GetAlerts)
+	route.Post("/alerts/:id/ack", c.AcknowledgeAlert)
+}
+
+// GetAlerts returns the alerts of all feeders. Passing active=true only
+// returns the alerts that were neither acknowledged nor resolved.
+func (c *AlertsController) GetAlerts(ctx *fiber.Ctx) error {
+	activeOnly := false
+	if active := ctx.Query("active"); active != "" {
+		var err error
+		if activeOnly, err = strconv.ParseBool(active); err != nil {
+			return models.NewValidationError("Invalid active filter.")
+		}
+	}
+
+	alerts, err := c.alertsRepo.GetAlerts(activeOnly)
+	if err != nil {
+		return err
+	}
+	return ctx.Status(http.StatusOK).JSON(alerts)
+}
+
+func (c *AlertsController) AcknowledgeAlert(ctx *fiber.Ctx) error {
+	id, err := strconv.Atoi(ctx.Params("id"))
+	if err != nil {
+		return models.NewValidationError("Invalid alert id.")
+	}
+
+	alert, err := c.alertsRepo.AcknowledgeAlert(id, time.Now().UTC().Unix())
+	if err != nil {
+		return err
+	}
+	return ctx.Status(http.StatusOK).JSON(alert)
+}
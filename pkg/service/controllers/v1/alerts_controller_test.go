@@ -0,0 +1,125 @@
+package v1
+
+import (
+	"fmt"
+	"math/rand"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/imilchev/rpi-feeder/pkg/service/middleware"
+	"github.com/imilchev/rpi-feeder/pkg/service/models"
+	fake "github.com/imilchev/rpi-feeder/tests/fake/repos"
+	"github.com/imilchev/rpi-feeder/tests/utils"
+	modelUtils "github.com/imilchev/rpi-feeder/tests/utils/models"
+	"github.com/stretchr/testify/suite"
+)
+
+type AlertsControllerSuite struct {
+	suite.Suite
+	app    *fiber.App
+	alerts *fake.FakeAlertsRepository
+}
+
+func (suite *AlertsControllerSuite) SetupTest() {
+	suite.app = fiber.New(fiber.Config{
+		ErrorHandler: middleware.ErrorHandler,
+	})
+	suite.alerts = &fake.FakeAlertsRepository{}
+	c := AlertsController{alertsRepo: suite.alerts}
+	c.RegisterHandlers(suite.app)
+}
+
+func (suite *AlertsControllerSuite) TestGetAlerts() {
+	suite.seedAlerts()
+
+	req := httptest.NewRequest(http.MethodGet, "/v1/alerts", nil)
+	resp, err := suite.app.Test(req)
+	suite.NoError(err)
+	suite.Equal(http.StatusOK, resp.StatusCode)
+
+	var rA []models.Alert
+	suite.NoError(utils.ParseResponse(&rA, resp))
+	suite.ElementsMatch(suite.alerts.Alerts, rA)
+}
+
+func (suite *AlertsControllerSuite) TestGetAlerts_Active() {
+	suite.seedAlerts()
+
+	req := httptest.NewRequest(http.MethodGet, "/v1/alerts?active=true", nil)
+	resp, err := suite.app.Test(req)
+	suite.NoError(err)
+	suite.Equal(http.StatusOK, resp.StatusCode)
+
+	expected := make([]models.Alert, 0)
+	for _, a := range suite.alerts.Alerts {
+		if a.Active() {
+			expected = append(expected, a)
+		}
+	}
+	var rA []models.Alert
+	suite.NoError(utils.ParseResponse(&rA, resp))
+	suite.ElementsMatch(expected, rA)
+}
+
+func (suite *AlertsControllerSuite) TestGetAlerts_InvalidActive() {
+	req := httptest.NewRequest(http.MethodGet, "/v1/alerts?active=abc", nil)
+	resp, err := suite.app.Test(req)
+	suite.NoError(err)
+	suite.Equal(http.StatusBadRequest, resp.StatusCode)
+}
+
+func (suite *AlertsControllerSuite) TestGetAlerts_Error() {
+	suite.alerts.Error = fmt.Errorf("error")
+
+	req := httptest.NewRequest(http.MethodGet, "/v1/alerts", nil)
+	resp, err := suite.app.Test(req)
+	suite.NoError(err)
+	suite.Equal(http.StatusInternalServerError, resp.StatusCode)
+}
+
+func (suite *AlertsControllerSuite) TestAcknowledgeAlert() {
+	a, err := suite.alerts.CreateAlert(modelUtils.RandomAlertForFeeder(utils.RandString(10)))
+	suite.NoError(err)
+
+	req := httptest.NewRequest(http.MethodPost, fmt.Sprintf("/v1/alerts/%d/ack", a.Id), nil)
+	resp, err := suite.app.Test(req)
+	suite.NoError(err)
+	suite.Equal(http.StatusOK, resp.StatusCode)
+
+	rA := models.Alert{}
+	suite.NoError(utils.ParseResponse(&rA, resp))
+	suite.NotNil(rA.AcknowledgedAt)
+	suite.Equal(suite.alerts.Alerts[0], rA)
+}
+
+func (suite *AlertsControllerSuite) TestAcknowledgeAlert_DoesNotExist() {
+	req := httptest.NewRequest(http.MethodPost, "/v1/alerts/1/ack", nil)
+	resp, err := suite.app.Test(req)
+	suite.NoError(err)
+	suite.Equal(http.StatusInternalServerError, resp.StatusCode)
+}
+
+func (suite *AlertsControllerSuite) TestAcknowledgeAlert_InvalidId() {
+	req := httptest.NewRequest(http.MethodPost, "/v1/alerts/abc/ack", nil)
+	resp, err := suite.app.Test(req)
+	suite.NoError(err)
+	suite.Equal(http.StatusBadRequest, resp.StatusCode)
+}
+
+func (suite *AlertsControllerSuite) seedAlerts() {
+	count := rand.Intn(5) + 1
+	for i := 0; i < count; i++ {
+		for _, a := range modelUtils.RandomAlertsForFeeder(utils.RandString(10)) {
+			_, err := suite.alerts.CreateAlert(a)
+			suite.NoError(err)
+		}
+	}
+}
+
+func TestAlertsControllerSuite(t *testing.T) {
+	rand.Seed(time.Now().UnixNano())
+	suite.Run(t, new(AlertsControllerSuite))
+}
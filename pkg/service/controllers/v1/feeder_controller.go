@@ -1,29 +1,63 @@
 package v1
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"net/http"
+	"strconv"
+	"time"
 
 	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/imilchev/rpi-feeder/pkg/feeder/config"
+	"github.com/imilchev/rpi-feeder/pkg/limits"
 	"github.com/imilchev/rpi-feeder/pkg/mqtt/model"
 	"github.com/imilchev/rpi-feeder/pkg/service/db/repos"
 	"github.com/imilchev/rpi-feeder/pkg/service/models"
 	"github.com/imilchev/rpi-feeder/pkg/service/mqtt"
 	"github.com/imilchev/rpi-feeder/pkg/utils"
+	"github.com/imilchev/rpi-feeder/pkg/version"
+	"go.uber.org/zap"
 	"gorm.io/gorm"
 )
 
+// idempotencyKeyHeader is the header in which clients can pass a key to make
+// feed requests idempotent. The key is used as the id of the feed command.
+const idempotencyKeyHeader = "Idempotency-Key"
+
+// maxFeedWait is the longest a client can wait for a feeder to confirm a feed
+// command.
+const maxFeedWait = time.Minute
+
 type FeederController struct {
-	feedersRepo  repos.FeedersRepository
-	feedLogsRepo repos.FeedLogsRepository
-	mqtt         mqtt.MqttManager
+	feedersRepo   repos.FeedersRepository
+	feedLogsRepo  repos.FeedLogsRepository
+	schedulesRepo repos.SchedulesRepository
+	missedRepo    repos.MissedFeedingsRepository
+	commandsRepo  repos.FeedCommandsRepository
+	levelsRepo    repos.FoodLevelsRepository
+	calibRepo     repos.CalibrationsRepository
+	telemRepo     repos.TelemetryRepository
+	configsRepo   repos.FeederConfigsRepository
+	mqtt          mqtt.MqttManager
+	limits        limits.Limits
 }
 
-func NewFeederController(db *gorm.DB, mqtt mqtt.MqttManager) *FeederController {
+func NewFeederController(
+	db *gorm.DB, mqtt mqtt.MqttManager, limits limits.Limits) *FeederController {
 	return &FeederController{
-		mqtt:         mqtt,
-		feedersRepo:  repos.NewFeedersRepository(db),
-		feedLogsRepo: repos.NewFeedLogsRepository(db),
+		mqtt:          mqtt,
+		limits:        limits,
+		feedersRepo:   repos.NewFeedersRepository(db),
+		feedLogsRepo:  repos.NewFeedLogsRepository(db),
+		schedulesRepo: repos.NewSchedulesRepository(db),
+		missedRepo:    repos.NewMissedFeedingsRepository(db),
+		commandsRepo:  repos.NewFeedCommandsRepository(db),
+		levelsRepo:    repos.NewFoodLevelsRepository(db),
+		calibRepo:     repos.NewCalibrationsRepository(db),
+		telemRepo:     repos.NewTelemetryRepository(db),
+		configsRepo:   repos.NewFeederConfigsRepository(db),
 	}
 }
 
@@ -31,15 +65,47 @@ func (c *FeederController) RegisterHandlers(a *fiber.App) {
 	route := a.Group(apiGroup)
 	route.Get("/feeders", c.GetFeeders)
 	route.Get("/feeders/:clientId/logs", c.GetFeedLogsForFeeder)
+	route.Get("/feeders/:clientId/missed-feedings", c.GetMissedFeedingsForFeeder)
+	route.Get("/feeders/:clientId/food-level", c.GetFoodLevelsForFeeder)
+	route.Get("/feeders/:clientId/telemetry", c.GetTelemetryForFeeder)
 	route.Post("/feeders/:clientId/feed", c.FeedPortions)
+	route.Post("/feeders/:clientId/feed/cancel", c.CancelFeeding)
+	route.Get("/feeders/:clientId/commands/:id", c.GetFeedCommand)
+	route.Get("/feeders/:clientId/calibrations", c.GetCalibrations)
+	route.Post("/feeders/:clientId/calibrate", c.Calibrate)
+	route.Get("/feeders/:clientId/config", c.GetConfig)
+	route.Put("/feeders/:clientId/config", c.UpdateConfig)
+	route.Get("/feeders/:clientId/schedules", c.GetSchedules)
+	route.Post("/feeders/:clientId/schedules", c.CreateSchedule)
+	route.Get("/feeders/:clientId/schedules/:id", c.GetSchedule)
+	route.Put("/feeders/:clientId/schedules/:id", c.UpdateSchedule)
+	route.Delete("/feeders/:clientId/schedules/:id", c.DeleteSchedule)
 }
 
+// GetFeeders returns all feeders. Passing outdated=true only returns the
+// feeders running older software than the service.
 func (c *FeederController) GetFeeders(ctx *fiber.Ctx) error {
+	outdatedOnly := false
+	if outdated := ctx.Query("outdated"); outdated != "" {
+		var err error
+		if outdatedOnly, err = strconv.ParseBool(outdated); err != nil {
+			return models.NewValidationError("Invalid outdated filter.")
+		}
+	}
+
 	feeders, err := c.feedersRepo.GetFeeders()
 	if err != nil {
 		return err
 	}
-	return ctx.Status(http.StatusOK).JSON(feeders)
+
+	result := make([]models.Feeder, 0, len(feeders))
+	for _, f := range feeders {
+		f.Outdated = version.IsOlder(f.SoftwareVersion, version.Version)
+		if !outdatedOnly || f.Outdated {
+			result = append(result, f)
+		}
+	}
+	return ctx.Status(http.StatusOK).JSON(result)
 }
 
 func (c *FeederController) GetFeedLogsForFeeder(ctx *fiber.Ctx) error {
@@ -60,6 +126,60 @@ func (c *FeederController) GetFeedLogsForFeeder(ctx *fiber.Ctx) error {
 	return ctx.Status(http.StatusOK).JSON(feedLogs)
 }
 
+func (c *FeederController) GetMissedFeedingsForFeeder(ctx *fiber.Ctx) error {
+	clientId := ctx.Params("clientId")
+	if clientId == "" {
+		return models.NewValidationError("Missing clientId.")
+	}
+
+	_, err := c.feedersRepo.GetFeederByClientId(clientId)
+	if err != nil {
+		return err
+	}
+
+	missed, err := c.missedRepo.GetMissedFeedingsForFeeder(clientId)
+	if err != nil {
+		return err
+	}
+	return ctx.Status(http.StatusOK).JSON(missed)
+}
+
+func (c *FeederController) GetFoodLevelsForFeeder(ctx *fiber.Ctx) error {
+	clientId := ctx.Params("clientId")
+	if clientId == "" {
+		return models.NewValidationError("Missing clientId.")
+	}
+
+	_, err := c.feedersRepo.GetFeederByClientId(clientId)
+	if err != nil {
+		return err
+	}
+
+	levels, err := c.levelsRepo.GetFoodLevelsForFeeder(clientId)
+	if err != nil {
+		return err
+	}
+	return ctx.Status(http.StatusOK).JSON(levels)
+}
+
+func (c *FeederController) GetTelemetryForFeeder(ctx *fiber.Ctx) error {
+	clientId := ctx.Params("clientId")
+	if clientId == "" {
+		return models.NewValidationError("Missing clientId.")
+	}
+
+	_, err := c.feedersRepo.GetFeederByClientId(clientId)
+	if err != nil {
+		return err
+	}
+
+	telemetry, err := c.telemRepo.GetTelemetryForFeeder(clientId)
+	if err != nil {
+		return err
+	}
+	return ctx.Status(http.StatusOK).JSON(telemetry)
+}
+
 func (c *FeederController) FeedPortions(ctx *fiber.Ctx) error {
 	clientId := ctx.Params("clientId")
 	if clientId == "" {
@@ -71,6 +191,26 @@ func (c *FeederController) FeedPortions(ctx *fiber.Ctx) error {
 		return err
 	}
 
+	commandId := uuid.NewString()
+	key := ctx.Get(idempotencyKeyHeader)
+	if key != "" {
+		if len(key) > 64 {
+			return models.NewValidationError(
+				fmt.Sprintf("%s must be at most 64 characters long.", idempotencyKeyHeader))
+		}
+
+		// A repeated request returns the command created by the original one
+		// instead of feeding again.
+		command, err := c.commandsRepo.GetFeedCommand(clientId, key)
+		if err == nil {
+			return ctx.Status(http.StatusOK).JSON(command)
+		}
+		if e, ok := err.(*models.ApiError); !ok || e.Code() != http.StatusNotFound {
+			return err
+		}
+		commandId = key
+	}
+
 	if feeder.Status != model.OnlineStatus {
 		return models.NewValidationError(
 			fmt.Sprintf("Feeder %s is not online.", feeder.ClientId))
@@ -85,11 +225,385 @@ func (c *FeederController) FeedPortions(ctx *fiber.Ctx) error {
 		return models.NewValidationError(err.Error())
 	}
 
+	var wait time.Duration
+	if w := ctx.Query("wait"); w != "" {
+		wait, err = time.ParseDuration(w)
+		if err != nil || wait <= 0 || wait > maxFeedWait {
+			return models.NewValidationError(fmt.Sprintf(
+				"Invalid wait duration %s. It must be positive and at most %s.", w, maxFeedWait))
+		}
+	}
+
+	if err := c.checkLimits(clientId, request.Portions); err != nil {
+		return err
+	}
+
+	command, err := c.commandsRepo.CreateFeedCommand(models.FeedCommand{
+		Id:        commandId,
+		ClientId:  clientId,
+		Portions:  request.Portions,
+		Status:    models.PendingCommandStatus,
+		CreatedAt: time.Now().UTC().Unix(),
+	})
+	if e, ok := err.(*models.ApiError); ok && e.Code() == http.StatusConflict && key != "" {
+		// A concurrent request with the same key created the command first.
+		existing, err := c.commandsRepo.GetFeedCommand(clientId, commandId)
+		if err != nil {
+			return err
+		}
+		return ctx.Status(http.StatusOK).JSON(existing)
+	}
+	if err != nil {
+		return err
+	}
+
 	msg := model.FeedMessage{
+		CommandId: command.Id,
+		Portions:  request.Portions,
+	}
+	if wait == 0 {
+		if err := c.mqtt.SendFeedCommand(clientId, msg); err != nil {
+			c.failFeedCommand(command, err)
+			return err
+		}
+		return ctx.Status(http.StatusAccepted).JSON(command)
+	}
+
+	waitCtx, cancel := context.WithTimeout(context.Background(), wait)
+	defer cancel()
+	result, err := c.mqtt.SendFeedCommandAndWait(waitCtx, clientId, msg)
+	if err != nil {
+		if errors.Is(err, context.DeadlineExceeded) {
+			return models.NewTimeoutError(fmt.Sprintf(
+				"Feeder %s did not confirm command %s within %s.", clientId, command.Id, wait))
+		}
+		c.failFeedCommand(command, err)
+		return err
+	}
+
+	command.ApplyResult(result)
+	return ctx.Status(http.StatusOK).JSON(command)
+}
+
+// checkLimits returns a validation error if feeding the portions would exceed
+// the feeding limits, based on the feed logs reported by the feeder.
+func (c *FeederController) checkLimits(clientId string, portions uint) error {
+	now := time.Now().UTC()
+	logs, err := c.feedLogsRepo.GetLogsForFeederSince(clientId, now.Add(-limits.Window).Unix())
+	if err != nil {
+		return err
+	}
+
+	var feedings []limits.Feeding
+	for _, l := range logs {
+		feedings = append(feedings, limits.Feeding{Portions: l.Portions, At: time.Unix(l.Timestamp, 0)})
+	}
+	if err := c.limits.Check(portions, feedings, now); err != nil {
+		return models.NewValidationError(fmt.Sprintf("Feeding rejected: %v.", err))
+	}
+	return nil
+}
+
+// failFeedCommand marks a command that never reached the feeder as failed,
+// since the feeder will not report a result for it.
+func (c *FeederController) failFeedCommand(command models.FeedCommand, err error) {
+	t := time.Now().UTC().Unix()
+	command.Status = models.FailedCommandStatus
+	command.Error = err.Error()
+	command.CompletedAt = &t
+	if _, uErr := c.commandsRepo.UpdateFeedCommand(command); uErr != nil {
+		zap.S().Errorf("Failed to update command %s. %v", command.Id, uErr)
+	}
+}
+
+func (c *FeederController) CancelFeeding(ctx *fiber.Ctx) error {
+	clientId := ctx.Params("clientId")
+	if clientId == "" {
+		return models.NewValidationError("Missing clientId.")
+	}
+
+	feeder, err := c.feedersRepo.GetFeederByClientId(clientId)
+	if err != nil {
+		return err
+	}
+
+	if feeder.Status != model.OnlineStatus {
+		return models.NewValidationError(
+			fmt.Sprintf("Feeder %s is not online.", feeder.ClientId))
+	}
+
+	if err := c.mqtt.SendCancel(clientId, model.CancelMessage{}); err != nil {
+		return err
+	}
+	return ctx.SendStatus(http.StatusAccepted)
+}
+
+func (c *FeederController) GetFeedCommand(ctx *fiber.Ctx) error {
+	clientId := ctx.Params("clientId")
+	if clientId == "" {
+		return models.NewValidationError("Missing clientId.")
+	}
+
+	command, err := c.commandsRepo.GetFeedCommand(clientId, ctx.Params("id"))
+	if err != nil {
+		return err
+	}
+	return ctx.Status(http.StatusOK).JSON(command)
+}
+
+func (c *FeederController) GetCalibrations(ctx *fiber.Ctx) error {
+	clientId := ctx.Params("clientId")
+	if clientId == "" {
+		return models.NewValidationError("Missing clientId.")
+	}
+
+	_, err := c.feedersRepo.GetFeederByClientId(clientId)
+	if err != nil {
+		return err
+	}
+
+	calibrations, err := c.calibRepo.GetCalibrationsForFeeder(clientId)
+	if err != nil {
+		return err
+	}
+	return ctx.Status(http.StatusOK).JSON(calibrations)
+}
+
+func (c *FeederController) Calibrate(ctx *fiber.Ctx) error {
+	clientId := ctx.Params("clientId")
+	if clientId == "" {
+		return models.NewValidationError("Missing clientId.")
+	}
+
+	feeder, err := c.feedersRepo.GetFeederByClientId(clientId)
+	if err != nil {
+		return err
+	}
+
+	if feeder.Status != model.OnlineStatus {
+		return models.NewValidationError(
+			fmt.Sprintf("Feeder %s is not online.", feeder.ClientId))
+	}
+
+	request := models.CalibrateRequest{}
+	if err := ctx.BodyParser(&request); err != nil {
+		return models.NewValidationError(fmt.Sprintf("Cannot parse request body. %v", err))
+	}
+
+	if err := utils.Validate.Struct(request); err != nil {
+		return models.NewValidationError(err.Error())
+	}
+
+	msg := model.CalibrateMessage{
+		DurationsMs:     request.DurationsMs,
+		GramsPerPortion: request.GramsPerPortion,
+	}
+	if err := c.mqtt.SendCalibrate(clientId, msg); err != nil {
+		return err
+	}
+	return ctx.SendStatus(http.StatusAccepted)
+}
+
+func (c *FeederController) GetConfig(ctx *fiber.Ctx) error {
+	clientId := ctx.Params("clientId")
+	if clientId == "" {
+		return models.NewValidationError("Missing clientId.")
+	}
+
+	_, err := c.feedersRepo.GetFeederByClientId(clientId)
+	if err != nil {
+		return err
+	}
+
+	cfg, err := c.configsRepo.GetFeederConfig(clientId)
+	if err != nil {
+		return err
+	}
+	return ctx.Status(http.StatusOK).JSON(cfg)
+}
+
+// UpdateConfig replaces the config of the feeder with the request body. The
+// body is in the format of the config file of the feeder and only holds the
+// settings to override. The feeder reports the revision it uses with its
+// status.
+func (c *FeederController) UpdateConfig(ctx *fiber.Ctx) error {
+	clientId := ctx.Params("clientId")
+	if clientId == "" {
+		return models.NewValidationError("Missing clientId.")
+	}
+
+	_, err := c.feedersRepo.GetFeederByClientId(clientId)
+	if err != nil {
+		return err
+	}
+
+	body := ctx.Body()
+	if err := config.CheckRemote(body); err != nil {
+		return models.NewValidationError(fmt.Sprintf("Invalid config. %v", err))
+	}
+
+	cfg, err := c.configsRepo.SetFeederConfig(models.FeederConfig{
+		ClientId:  clientId,
+		Config:    append([]byte(nil), body...),
+		UpdatedAt: time.Now().UTC().Unix(),
+	})
+	if err != nil {
+		return err
+	}
+
+	msg := model.ConfigMessage{Revision: cfg.Revision, Config: cfg.Config}
+	if err := c.mqtt.SendConfig(clientId, msg); err != nil {
+		return err
+	}
+	return ctx.Status(http.StatusOK).JSON(cfg)
+}
+
+func (c *FeederController) GetSchedules(ctx *fiber.Ctx) error {
+	clientId := ctx.Params("clientId")
+	if clientId == "" {
+		return models.NewValidationError("Missing clientId.")
+	}
+
+	_, err := c.feedersRepo.GetFeederByClientId(clientId)
+	if err != nil {
+		return err
+	}
+
+	schedules, err := c.schedulesRepo.GetSchedulesForFeeder(clientId)
+	if err != nil {
+		return err
+	}
+	return ctx.Status(http.StatusOK).JSON(schedules)
+}
+
+func (c *FeederController) CreateSchedule(ctx *fiber.Ctx) error {
+	clientId := ctx.Params("clientId")
+	if clientId == "" {
+		return models.NewValidationError("Missing clientId.")
+	}
+
+	_, err := c.feedersRepo.GetFeederByClientId(clientId)
+	if err != nil {
+		return err
+	}
+
+	request := models.ScheduleRequest{}
+	if err := ctx.BodyParser(&request); err != nil {
+		return models.NewValidationError(fmt.Sprintf("Cannot parse request body. %v", err))
+	}
+
+	schedule := models.Schedule{
+		ClientId: clientId,
+		Cron:     request.Cron,
+		Time:     request.Time,
 		Portions: request.Portions,
 	}
-	if err := c.mqtt.SendFeedCommand(clientId, msg); err != nil {
+	if err := utils.Validate.Struct(schedule); err != nil {
+		return models.NewValidationError(err.Error())
+	}
+
+	schedule, err = c.schedulesRepo.CreateSchedule(schedule)
+	if err != nil {
+		return err
+	}
+
+	if err := c.publishSchedule(clientId); err != nil {
+		return err
+	}
+	return ctx.Status(http.StatusCreated).JSON(schedule)
+}
+
+func (c *FeederController) GetSchedule(ctx *fiber.Ctx) error {
+	clientId := ctx.Params("clientId")
+	if clientId == "" {
+		return models.NewValidationError("Missing clientId.")
+	}
+
+	id, err := strconv.Atoi(ctx.Params("id"))
+	if err != nil {
+		return models.NewValidationError("Invalid schedule id.")
+	}
+
+	schedule, err := c.schedulesRepo.GetSchedule(clientId, id)
+	if err != nil {
+		return err
+	}
+	return ctx.Status(http.StatusOK).JSON(schedule)
+}
+
+func (c *FeederController) UpdateSchedule(ctx *fiber.Ctx) error {
+	clientId := ctx.Params("clientId")
+	if clientId == "" {
+		return models.NewValidationError("Missing clientId.")
+	}
+
+	id, err := strconv.Atoi(ctx.Params("id"))
+	if err != nil {
+		return models.NewValidationError("Invalid schedule id.")
+	}
+
+	request := models.ScheduleRequest{}
+	if err := ctx.BodyParser(&request); err != nil {
+		return models.NewValidationError(fmt.Sprintf("Cannot parse request body. %v", err))
+	}
+
+	schedule := models.Schedule{
+		Id:       id,
+		ClientId: clientId,
+		Cron:     request.Cron,
+		Time:     request.Time,
+		Portions: request.Portions,
+	}
+	if err := utils.Validate.Struct(schedule); err != nil {
+		return models.NewValidationError(err.Error())
+	}
+
+	schedule, err = c.schedulesRepo.UpdateSchedule(schedule)
+	if err != nil {
+		return err
+	}
+
+	if err := c.publishSchedule(clientId); err != nil {
+		return err
+	}
+	return ctx.Status(http.StatusOK).JSON(schedule)
+}
+
+func (c *FeederController) DeleteSchedule(ctx *fiber.Ctx) error {
+	clientId := ctx.Params("clientId")
+	if clientId == "" {
+		return models.NewValidationError("Missing clientId.")
+	}
+
+	id, err := strconv.Atoi(ctx.Params("id"))
+	if err != nil {
+		return models.NewValidationError("Invalid schedule id.")
+	}
+
+	if err := c.schedulesRepo.DeleteSchedule(clientId, id); err != nil {
+		return err
+	}
+
+	if err := c.publishSchedule(clientId); err != nil {
 		return err
 	}
 	return ctx.Status(http.StatusNoContent).JSON(fiber.Map{})
 }
+
+// publishSchedule sends the complete schedule of the feeder to the feeder.
+func (c *FeederController) publishSchedule(clientId string) error {
+	schedules, err := c.schedulesRepo.GetSchedulesForFeeder(clientId)
+	if err != nil {
+		return err
+	}
+
+	msg := model.ScheduleMessage{Value: make([]model.ScheduleEntryMessage, 0)}
+	for _, s := range schedules {
+		msg.Value = append(msg.Value, model.ScheduleEntryMessage{
+			Cron:     s.Cron,
+			Time:     s.Time,
+			Portions: s.Portions,
+		})
+	}
+	return c.mqtt.SendSchedule(clientId, msg)
+}
@@ -9,9 +9,11 @@ import (
 	"time"
 
 	"github.com/gofiber/fiber/v2"
+	"github.com/imilchev/rpi-feeder/pkg/limits"
 	"github.com/imilchev/rpi-feeder/pkg/mqtt/model"
 	"github.com/imilchev/rpi-feeder/pkg/service/middleware"
 	"github.com/imilchev/rpi-feeder/pkg/service/models"
+	"github.com/imilchev/rpi-feeder/pkg/version"
 	"github.com/imilchev/rpi-feeder/tests/fake/mqtt"
 	fake "github.com/imilchev/rpi-feeder/tests/fake/repos"
 	"github.com/imilchev/rpi-feeder/tests/utils"
@@ -21,10 +23,17 @@ import (
 
 type FeederControllerSuite struct {
 	suite.Suite
-	app      *fiber.App
-	feeders  *fake.FakeFeedersRepository
-	feedLogs *fake.FakeFeedLogsRepository
-	mqtt     *mqtt.FakeServiceMqttManager
+	app       *fiber.App
+	feeders   *fake.FakeFeedersRepository
+	feedLogs  *fake.FakeFeedLogsRepository
+	schedules *fake.FakeSchedulesRepository
+	missed    *fake.FakeMissedFeedingsRepository
+	commands  *fake.FakeFeedCommandsRepository
+	levels    *fake.FakeFoodLevelsRepository
+	calibs    *fake.FakeCalibrationsRepository
+	telemetry *fake.FakeTelemetryRepository
+	configs   *fake.FakeFeederConfigsRepository
+	mqtt      *mqtt.FakeServiceMqttManager
 }
 
 func (suite *FeederControllerSuite) SetupTest() {
@@ -33,11 +42,30 @@ func (suite *FeederControllerSuite) SetupTest() {
 	})
 	suite.feeders = &fake.FakeFeedersRepository{}
 	suite.feedLogs = &fake.FakeFeedLogsRepository{}
+	suite.schedules = &fake.FakeSchedulesRepository{}
+	suite.missed = &fake.FakeMissedFeedingsRepository{}
+	suite.commands = &fake.FakeFeedCommandsRepository{}
+	suite.levels = &fake.FakeFoodLevelsRepository{}
+	suite.calibs = &fake.FakeCalibrationsRepository{}
+	suite.telemetry = &fake.FakeTelemetryRepository{}
+	suite.configs = &fake.FakeFeederConfigsRepository{}
 	suite.mqtt = &mqtt.FakeServiceMqttManager{}
 	c := FeederController{
-		feedersRepo:  suite.feeders,
-		feedLogsRepo: suite.feedLogs,
-		mqtt:         suite.mqtt}
+		feedersRepo:   suite.feeders,
+		feedLogsRepo:  suite.feedLogs,
+		schedulesRepo: suite.schedules,
+		missedRepo:    suite.missed,
+		commandsRepo:  suite.commands,
+		levelsRepo:    suite.levels,
+		calibRepo:     suite.calibs,
+		telemRepo:     suite.telemetry,
+		configsRepo:   suite.configs,
+		mqtt:          suite.mqtt,
+		limits: limits.Limits{
+			MaxPortionsPerFeeding: 10,
+			MaxPortionsPerDay:     20,
+			MinIntervalSeconds:    600,
+		}}
 	c.RegisterHandlers(suite.app)
 }
 
@@ -55,6 +83,32 @@ func (suite *FeederControllerSuite) TestGetFeeders() {
 	suite.ElementsMatch(fs, rFs)
 }
 
+func (suite *FeederControllerSuite) TestGetFeeders_Outdated() {
+	version.Version = "v1.2.0"
+	defer func() { version.Version = "dev" }()
+
+	fs := modelUtils.RandomFeeders()
+	fs[0].SoftwareVersion = "v1.1.0"
+	fs[0].Outdated = true
+	suite.feeders.Feeders = fs
+
+	req := httptest.NewRequest(http.MethodGet, "/v1/feeders?outdated=true", nil)
+	resp, err := suite.app.Test(req)
+	suite.NoError(err)
+	suite.Equal(http.StatusOK, resp.StatusCode)
+
+	var rFs []models.Feeder
+	suite.NoError(utils.ParseResponse(&rFs, resp))
+	suite.Equal([]models.Feeder{fs[0]}, rFs)
+}
+
+func (suite *FeederControllerSuite) TestGetFeeders_InvalidOutdated() {
+	req := httptest.NewRequest(http.MethodGet, "/v1/feeders?outdated=abc", nil)
+	resp, err := suite.app.Test(req)
+	suite.NoError(err)
+	suite.Equal(http.StatusBadRequest, resp.StatusCode)
+}
+
 func (suite *FeederControllerSuite) TestGetFeeders_Error() {
 	suite.feeders.Error = fmt.Errorf("error")
 	req := httptest.NewRequest(http.MethodGet, "/v1/feeders", nil)
@@ -94,6 +148,90 @@ func (suite *FeederControllerSuite) TestGetFeedLogsForFeeder_FeederDoesNotExist(
 	suite.Equal(http.StatusInternalServerError, resp.StatusCode)
 }
 
+func (suite *FeederControllerSuite) TestGetMissedFeedingsForFeeder() {
+	fs := modelUtils.RandomFeeders()
+	suite.feeders.Feeders = fs
+
+	f := fs[len(fs)/2]
+	ms := modelUtils.RandomMissedFeedingsForFeeder(f.ClientId)
+	suite.missed.MissedFeedings = append(
+		ms, modelUtils.RandomMissedFeedingsForFeeder(utils.RandString(10))...)
+
+	req := httptest.NewRequest(
+		http.MethodGet, fmt.Sprintf("/v1/feeders/%s/missed-feedings", f.ClientId), nil)
+	resp, err := suite.app.Test(req)
+	suite.NoError(err)
+	suite.Equal(http.StatusOK, resp.StatusCode)
+
+	var rMs []models.MissedFeeding
+	suite.NoError(utils.ParseResponse(&rMs, resp))
+	suite.ElementsMatch(ms, rMs)
+}
+
+func (suite *FeederControllerSuite) TestGetMissedFeedingsForFeeder_FeederDoesNotExist() {
+	req := httptest.NewRequest(
+		http.MethodGet, fmt.Sprintf("/v1/feeders/%s/missed-feedings", utils.RandString(10)), nil)
+	resp, err := suite.app.Test(req)
+	suite.NoError(err)
+	suite.Equal(http.StatusInternalServerError, resp.StatusCode)
+}
+
+func (suite *FeederControllerSuite) TestGetFoodLevelsForFeeder() {
+	fs := modelUtils.RandomFeeders()
+	suite.feeders.Feeders = fs
+
+	f := fs[len(fs)/2]
+	ls := modelUtils.RandomFoodLevelsForFeeder(f.ClientId)
+	suite.levels.FoodLevels = append(
+		ls, modelUtils.RandomFoodLevelsForFeeder(utils.RandString(10))...)
+
+	req := httptest.NewRequest(
+		http.MethodGet, fmt.Sprintf("/v1/feeders/%s/food-level", f.ClientId), nil)
+	resp, err := suite.app.Test(req)
+	suite.NoError(err)
+	suite.Equal(http.StatusOK, resp.StatusCode)
+
+	var rLs []models.FoodLevel
+	suite.NoError(utils.ParseResponse(&rLs, resp))
+	suite.ElementsMatch(ls, rLs)
+}
+
+func (suite *FeederControllerSuite) TestGetFoodLevelsForFeeder_FeederDoesNotExist() {
+	req := httptest.NewRequest(
+		http.MethodGet, fmt.Sprintf("/v1/feeders/%s/food-level", utils.RandString(10)), nil)
+	resp, err := suite.app.Test(req)
+	suite.NoError(err)
+	suite.Equal(http.StatusInternalServerError, resp.StatusCode)
+}
+
+func (suite *FeederControllerSuite) TestGetTelemetryForFeeder() {
+	fs := modelUtils.RandomFeeders()
+	suite.feeders.Feeders = fs
+
+	f := fs[len(fs)/2]
+	ts := modelUtils.RandomTelemetrySeriesForFeeder(f.ClientId)
+	suite.telemetry.Telemetry = append(
+		ts, modelUtils.RandomTelemetrySeriesForFeeder(utils.RandString(10))...)
+
+	req := httptest.NewRequest(
+		http.MethodGet, fmt.Sprintf("/v1/feeders/%s/telemetry", f.ClientId), nil)
+	resp, err := suite.app.Test(req)
+	suite.NoError(err)
+	suite.Equal(http.StatusOK, resp.StatusCode)
+
+	var rTs []models.Telemetry
+	suite.NoError(utils.ParseResponse(&rTs, resp))
+	suite.ElementsMatch(ts, rTs)
+}
+
+func (suite *FeederControllerSuite) TestGetTelemetryForFeeder_FeederDoesNotExist() {
+	req := httptest.NewRequest(
+		http.MethodGet, fmt.Sprintf("/v1/feeders/%s/telemetry", utils.RandString(10)), nil)
+	resp, err := suite.app.Test(req)
+	suite.NoError(err)
+	suite.Equal(http.StatusInternalServerError, resp.StatusCode)
+}
+
 func (suite *FeederControllerSuite) TestFeedPortions() {
 	f := modelUtils.RandomFeeder()
 	f.Status = model.OnlineStatus
@@ -103,13 +241,215 @@ func (suite *FeederControllerSuite) TestFeedPortions() {
 	req := utils.PostJsonRequest(fmt.Sprintf("/v1/feeders/%s/feed", f.ClientId), m)
 	resp, err := suite.app.Test(req)
 	suite.NoError(err)
-	suite.Equal(http.StatusNoContent, resp.StatusCode)
+	suite.Equal(http.StatusAccepted, resp.StatusCode)
+
+	c := models.FeedCommand{}
+	suite.NoError(utils.ParseResponse(&c, resp))
+	suite.NotEmpty(c.Id)
+	suite.Equal(f.ClientId, c.ClientId)
+	suite.Equal(m.Portions, c.Portions)
+	suite.Equal(models.PendingCommandStatus, c.Status)
+	suite.Equal([]models.FeedCommand{c}, suite.commands.FeedCommands)
 
 	suite.Equal(1, len(suite.mqtt.Feeds))
 	suite.Equal(f.ClientId, suite.mqtt.Feeds[0].ClientId)
+	suite.Equal(c.Id, suite.mqtt.Feeds[0].Msg.CommandId)
 	suite.Equal(m.Portions, suite.mqtt.Feeds[0].Msg.Portions)
 }
 
+func (suite *FeederControllerSuite) TestFeedPortions_Wait() {
+	f := modelUtils.RandomFeeder()
+	f.Status = model.OnlineStatus
+	suite.feeders.Feeders = append(suite.feeders.Feeders, f)
+
+	m := models.FeedRequest{Portions: uint(rand.Intn(10) + 1)}
+	suite.mqtt.FeedResult = &model.FeedResultMessage{
+		Success:   true,
+		Portions:  m.Portions,
+		Timestamp: time.Now().UTC(),
+	}
+	req := utils.PostJsonRequest(
+		fmt.Sprintf("/v1/feeders/%s/feed?wait=10s", f.ClientId), m)
+	resp, err := suite.app.Test(req)
+	suite.NoError(err)
+	suite.Equal(http.StatusOK, resp.StatusCode)
+
+	c := models.FeedCommand{}
+	suite.NoError(utils.ParseResponse(&c, resp))
+	suite.Equal(models.SucceededCommandStatus, c.Status)
+	suite.Equal(m.Portions, c.ServedPortions)
+	suite.NotNil(c.CompletedAt)
+
+	suite.Equal(1, len(suite.mqtt.Feeds))
+	suite.Equal(c.Id, suite.mqtt.Feeds[0].Msg.CommandId)
+}
+
+func (suite *FeederControllerSuite) TestFeedPortions_WaitTimeout() {
+	f := modelUtils.RandomFeeder()
+	f.Status = model.OnlineStatus
+	suite.feeders.Feeders = append(suite.feeders.Feeders, f)
+
+	m := models.FeedRequest{Portions: uint(rand.Intn(10) + 1)}
+	req := utils.PostJsonRequest(
+		fmt.Sprintf("/v1/feeders/%s/feed?wait=50ms", f.ClientId), m)
+	resp, err := suite.app.Test(req)
+	suite.NoError(err)
+	suite.Equal(http.StatusGatewayTimeout, resp.StatusCode)
+
+	e := models.ApiError{}
+	suite.NoError(utils.ParseResponse(&e, resp))
+	suite.Equal(
+		fmt.Sprintf("Feeder %s did not confirm command %s within 50ms.",
+			f.ClientId, suite.mqtt.Feeds[0].Msg.CommandId),
+		e.Message)
+
+	// The feeder may still execute the command, so it must remain pending.
+	suite.Equal(models.PendingCommandStatus, suite.commands.FeedCommands[0].Status)
+}
+
+func (suite *FeederControllerSuite) TestFeedPortions_InvalidWait() {
+	f := modelUtils.RandomFeeder()
+	f.Status = model.OnlineStatus
+	suite.feeders.Feeders = append(suite.feeders.Feeders, f)
+
+	for _, w := range []string{"abc", "-1s", "2h"} {
+		m := models.FeedRequest{Portions: uint(rand.Intn(10) + 1)}
+		req := utils.PostJsonRequest(
+			fmt.Sprintf("/v1/feeders/%s/feed?wait=%s", f.ClientId, w), m)
+		resp, err := suite.app.Test(req)
+		suite.NoError(err)
+		suite.Equal(http.StatusBadRequest, resp.StatusCode)
+	}
+	suite.Empty(suite.commands.FeedCommands)
+	suite.Empty(suite.mqtt.Feeds)
+}
+
+func (suite *FeederControllerSuite) TestFeedPortions_IdempotencyKey() {
+	f := modelUtils.RandomFeeder()
+	f.Status = model.OnlineStatus
+	suite.feeders.Feeders = append(suite.feeders.Feeders, f)
+
+	key := utils.RandString(20)
+	m := models.FeedRequest{Portions: uint(rand.Intn(10) + 1)}
+	req := utils.PostJsonRequest(fmt.Sprintf("/v1/feeders/%s/feed", f.ClientId), m)
+	req.Header.Add("Idempotency-Key", key)
+	resp, err := suite.app.Test(req)
+	suite.NoError(err)
+	suite.Equal(http.StatusAccepted, resp.StatusCode)
+
+	c := models.FeedCommand{}
+	suite.NoError(utils.ParseResponse(&c, resp))
+	suite.Equal(key, c.Id)
+	suite.Equal(1, len(suite.mqtt.Feeds))
+	suite.Equal(key, suite.mqtt.Feeds[0].Msg.CommandId)
+
+	// Repeating the request returns the original command without feeding.
+	req = utils.PostJsonRequest(fmt.Sprintf("/v1/feeders/%s/feed", f.ClientId), m)
+	req.Header.Add("Idempotency-Key", key)
+	resp, err = suite.app.Test(req)
+	suite.NoError(err)
+	suite.Equal(http.StatusOK, resp.StatusCode)
+
+	c2 := models.FeedCommand{}
+	suite.NoError(utils.ParseResponse(&c2, resp))
+	suite.Equal(c, c2)
+	suite.Equal(1, len(suite.mqtt.Feeds))
+	suite.Equal(1, len(suite.commands.FeedCommands))
+}
+
+// racingCommandsRepository stores the command of a concurrent request with
+// the same id right before a command is created.
+type racingCommandsRepository struct {
+	*fake.FakeFeedCommandsRepository
+	concurrent models.FeedCommand
+}
+
+func (r *racingCommandsRepository) CreateFeedCommand(c models.FeedCommand) (models.FeedCommand, error) {
+	r.FeedCommands = append(r.FeedCommands, r.concurrent)
+	return r.FakeFeedCommandsRepository.CreateFeedCommand(c)
+}
+
+func (suite *FeederControllerSuite) TestFeedPortions_IdempotencyKeyConcurrent() {
+	f := modelUtils.RandomFeeder()
+	f.Status = model.OnlineStatus
+	suite.feeders.Feeders = append(suite.feeders.Feeders, f)
+
+	key := utils.RandString(20)
+	concurrent := modelUtils.RandomFeedCommandForFeeder(f.ClientId)
+	concurrent.Id = key
+	app := fiber.New(fiber.Config{ErrorHandler: middleware.ErrorHandler})
+	c := FeederController{
+		feedersRepo:  suite.feeders,
+		feedLogsRepo: suite.feedLogs,
+		commandsRepo: &racingCommandsRepository{
+			FakeFeedCommandsRepository: suite.commands,
+			concurrent:                 concurrent,
+		},
+		mqtt: suite.mqtt,
+	}
+	c.RegisterHandlers(app)
+
+	m := models.FeedRequest{Portions: uint(rand.Intn(10) + 1)}
+	req := utils.PostJsonRequest(fmt.Sprintf("/v1/feeders/%s/feed", f.ClientId), m)
+	req.Header.Add("Idempotency-Key", key)
+	resp, err := app.Test(req)
+	suite.NoError(err)
+	suite.Equal(http.StatusOK, resp.StatusCode)
+
+	rC := models.FeedCommand{}
+	suite.NoError(utils.ParseResponse(&rC, resp))
+	suite.Equal(concurrent, rC)
+	suite.Empty(suite.mqtt.Feeds)
+	suite.Equal(1, len(suite.commands.FeedCommands))
+}
+
+func (suite *FeederControllerSuite) TestFeedPortions_IdempotencyKeyLookupFailed() {
+	f := modelUtils.RandomFeeder()
+	f.Status = model.OnlineStatus
+	suite.feeders.Feeders = append(suite.feeders.Feeders, f)
+	suite.commands.Error = fmt.Errorf("error")
+
+	m := models.FeedRequest{Portions: uint(rand.Intn(10) + 1)}
+	req := utils.PostJsonRequest(fmt.Sprintf("/v1/feeders/%s/feed", f.ClientId), m)
+	req.Header.Add("Idempotency-Key", utils.RandString(20))
+	resp, err := suite.app.Test(req)
+	suite.NoError(err)
+	suite.Equal(http.StatusInternalServerError, resp.StatusCode)
+	suite.Empty(suite.mqtt.Feeds)
+}
+
+func (suite *FeederControllerSuite) TestFeedPortions_IdempotencyKeyTooLong() {
+	f := modelUtils.RandomFeeder()
+	f.Status = model.OnlineStatus
+	suite.feeders.Feeders = append(suite.feeders.Feeders, f)
+
+	m := models.FeedRequest{Portions: uint(rand.Intn(10) + 1)}
+	req := utils.PostJsonRequest(fmt.Sprintf("/v1/feeders/%s/feed", f.ClientId), m)
+	req.Header.Add("Idempotency-Key", utils.RandString(65))
+	resp, err := suite.app.Test(req)
+	suite.NoError(err)
+	suite.Equal(http.StatusBadRequest, resp.StatusCode)
+	suite.Empty(suite.mqtt.Feeds)
+}
+
+func (suite *FeederControllerSuite) TestFeedPortions_SendFailed() {
+	f := modelUtils.RandomFeeder()
+	f.Status = model.OnlineStatus
+	suite.feeders.Feeders = append(suite.feeders.Feeders, f)
+	suite.mqtt.Error = fmt.Errorf("error")
+
+	m := models.FeedRequest{Portions: uint(rand.Intn(10) + 1)}
+	req := utils.PostJsonRequest(fmt.Sprintf("/v1/feeders/%s/feed", f.ClientId), m)
+	resp, err := suite.app.Test(req)
+	suite.NoError(err)
+	suite.Equal(http.StatusInternalServerError, resp.StatusCode)
+
+	suite.Equal(1, len(suite.commands.FeedCommands))
+	suite.Equal(models.FailedCommandStatus, suite.commands.FeedCommands[0].Status)
+	suite.Equal("error", suite.commands.FeedCommands[0].Error)
+	suite.NotNil(suite.commands.FeedCommands[0].CompletedAt)
+}
+
 func (suite *FeederControllerSuite) TestFeedPortions_PortionsMissing() {
 	f := modelUtils.RandomFeeder()
 	f.Status = model.OfflineStatus
@@ -123,6 +463,162 @@ func (suite *FeederControllerSuite) TestFeedPortions_PortionsMissing() {
 	suite.Empty(suite.mqtt.Feeds)
 }
 
+func (suite *FeederControllerSuite) TestFeedPortions_MaxPortionsPerFeeding() {
+	f := modelUtils.RandomFeeder()
+	f.Status = model.OnlineStatus
+	suite.feeders.Feeders = append(suite.feeders.Feeders, f)
+
+	m := models.FeedRequest{Portions: 11}
+	req := utils.PostJsonRequest(fmt.Sprintf("/v1/feeders/%s/feed", f.ClientId), m)
+	resp, err := suite.app.Test(req)
+	suite.NoError(err)
+	suite.Equal(http.StatusBadRequest, resp.StatusCode)
+	suite.Empty(suite.commands.FeedCommands)
+	suite.Empty(suite.mqtt.Feeds)
+}
+
+func (suite *FeederControllerSuite) TestFeedPortions_MaxPortionsPerDay() {
+	f := modelUtils.RandomFeeder()
+	f.Status = model.OnlineStatus
+	suite.feeders.Feeders = append(suite.feeders.Feeders, f)
+
+	l := modelUtils.RandomFeedLogForFeeder(f.ClientId)
+	l.Portions = 15
+	l.Timestamp = time.Now().UTC().Add(-2 * time.Hour).Unix()
+	suite.feedLogs.FeedLogs = append(suite.feedLogs.FeedLogs, l)
+
+	m := models.FeedRequest{Portions: 6}
+	req := utils.PostJsonRequest(fmt.Sprintf("/v1/feeders/%s/feed", f.ClientId), m)
+	resp, err := suite.app.Test(req)
+	suite.NoError(err)
+	suite.Equal(http.StatusBadRequest, resp.StatusCode)
+	suite.Empty(suite.mqtt.Feeds)
+
+	// Feedings of other feeders and older than a day are not counted.
+	l.ClientId = utils.RandString(10)
+	l.Timestamp = time.Now().UTC().Add(-25 * time.Hour).Unix()
+	suite.feedLogs.FeedLogs = []models.FeedLog{l}
+	resp, err = suite.app.Test(
+		utils.PostJsonRequest(fmt.Sprintf("/v1/feeders/%s/feed", f.ClientId), m))
+	suite.NoError(err)
+	suite.Equal(http.StatusAccepted, resp.StatusCode)
+}
+
+func (suite *FeederControllerSuite) TestFeedPortions_MinInterval() {
+	f := modelUtils.RandomFeeder()
+	f.Status = model.OnlineStatus
+	suite.feeders.Feeders = append(suite.feeders.Feeders, f)
+
+	l := modelUtils.RandomFeedLogForFeeder(f.ClientId)
+	l.Portions = 1
+	l.Timestamp = time.Now().UTC().Add(-time.Minute).Unix()
+	suite.feedLogs.FeedLogs = append(suite.feedLogs.FeedLogs, l)
+
+	m := models.FeedRequest{Portions: 1}
+	req := utils.PostJsonRequest(fmt.Sprintf("/v1/feeders/%s/feed", f.ClientId), m)
+	resp, err := suite.app.Test(req)
+	suite.NoError(err)
+	suite.Equal(http.StatusBadRequest, resp.StatusCode)
+	suite.Empty(suite.mqtt.Feeds)
+}
+
+func (suite *FeederControllerSuite) TestCancelFeeding() {
+	f := modelUtils.RandomFeeder()
+	f.Status = model.OnlineStatus
+	suite.feeders.Feeders = append(suite.feeders.Feeders, f)
+
+	req := httptest.NewRequest(
+		http.MethodPost, fmt.Sprintf("/v1/feeders/%s/feed/cancel", f.ClientId), nil)
+	resp, err := suite.app.Test(req)
+	suite.NoError(err)
+	suite.Equal(http.StatusAccepted, resp.StatusCode)
+	suite.Equal(1, len(suite.mqtt.Cancels))
+	suite.Equal(f.ClientId, suite.mqtt.Cancels[0].ClientId)
+}
+
+func (suite *FeederControllerSuite) TestCancelFeeding_FeederOffline() {
+	f := modelUtils.RandomFeeder()
+	f.Status = model.OfflineStatus
+	suite.feeders.Feeders = append(suite.feeders.Feeders, f)
+
+	req := httptest.NewRequest(
+		http.MethodPost, fmt.Sprintf("/v1/feeders/%s/feed/cancel", f.ClientId), nil)
+	resp, err := suite.app.Test(req)
+	suite.NoError(err)
+	suite.Equal(http.StatusBadRequest, resp.StatusCode)
+	suite.Empty(suite.mqtt.Cancels)
+}
+
+func (suite *FeederControllerSuite) TestGetCalibrations() {
+	fs := modelUtils.RandomFeeders()
+	suite.feeders.Feeders = fs
+
+	f := fs[len(fs)/2]
+	cs := modelUtils.RandomCalibrationsForFeeder(f.ClientId)
+	suite.calibs.Calibrations = append(
+		cs, modelUtils.RandomCalibrationsForFeeder(utils.RandString(10))...)
+
+	req := httptest.NewRequest(
+		http.MethodGet, fmt.Sprintf("/v1/feeders/%s/calibrations", f.ClientId), nil)
+	resp, err := suite.app.Test(req)
+	suite.NoError(err)
+	suite.Equal(http.StatusOK, resp.StatusCode)
+
+	var rCs []models.Calibration
+	suite.NoError(utils.ParseResponse(&rCs, resp))
+	suite.ElementsMatch(cs, rCs)
+}
+
+func (suite *FeederControllerSuite) TestGetCalibrations_FeederDoesNotExist() {
+	req := httptest.NewRequest(
+		http.MethodGet, fmt.Sprintf("/v1/feeders/%s/calibrations", utils.RandString(10)), nil)
+	resp, err := suite.app.Test(req)
+	suite.NoError(err)
+	suite.Equal(http.StatusInternalServerError, resp.StatusCode)
+}
+
+func (suite *FeederControllerSuite) TestCalibrate() {
+	f := modelUtils.RandomFeeder()
+	f.Status = model.OnlineStatus
+	suite.feeders.Feeders = append(suite.feeders.Feeders, f)
+
+	m := models.CalibrateRequest{DurationsMs: []uint64{500, 1000, 1500}, GramsPerPortion: 10}
+	req := utils.PostJsonRequest(fmt.Sprintf("/v1/feeders/%s/calibrate", f.ClientId), m)
+	resp, err := suite.app.Test(req)
+	suite.NoError(err)
+	suite.Equal(http.StatusAccepted, resp.StatusCode)
+	suite.Equal(1, len(suite.mqtt.Calibrates))
+	suite.Equal(f.ClientId, suite.mqtt.Calibrates[0].ClientId)
+	suite.Equal(m.DurationsMs, suite.mqtt.Calibrates[0].Msg.DurationsMs)
+	suite.Equal(m.GramsPerPortion, suite.mqtt.Calibrates[0].Msg.GramsPerPortion)
+}
+
+func (suite *FeederControllerSuite) TestCalibrate_InvalidDurations() {
+	f := modelUtils.RandomFeeder()
+	f.Status = model.OnlineStatus
+	suite.feeders.Feeders = append(suite.feeders.Feeders, f)
+
+	m := models.CalibrateRequest{DurationsMs: []uint64{1000}}
+	req := utils.PostJsonRequest(fmt.Sprintf("/v1/feeders/%s/calibrate", f.ClientId), m)
+	resp, err := suite.app.Test(req)
+	suite.NoError(err)
+	suite.Equal(http.StatusBadRequest, resp.StatusCode)
+	suite.Empty(suite.mqtt.Calibrates)
+}
+
+func (suite *FeederControllerSuite) TestCalibrate_FeederOffline() {
+	f := modelUtils.RandomFeeder()
+	f.Status = model.OfflineStatus
+	suite.feeders.Feeders = append(suite.feeders.Feeders, f)
+
+	req := utils.PostJsonRequest(
+		fmt.Sprintf("/v1/feeders/%s/calibrate", f.ClientId), models.CalibrateRequest{})
+	resp, err := suite.app.Test(req)
+	suite.NoError(err)
+	suite.Equal(http.StatusBadRequest, resp.StatusCode)
+	suite.Empty(suite.mqtt.Calibrates)
+}
+
 func (suite *FeederControllerSuite) TestFeedPortions_FeederOffline() {
 	f := modelUtils.RandomFeeder()
 	f.Status = model.OfflineStatus
@@ -140,6 +636,253 @@ func (suite *FeederControllerSuite) TestFeedPortions_FeederOffline() {
 	suite.Empty(suite.mqtt.Feeds)
 }
 
+func (suite *FeederControllerSuite) TestGetFeedCommand() {
+	f := modelUtils.RandomFeeder()
+	suite.feeders.Feeders = append(suite.feeders.Feeders, f)
+	c := modelUtils.RandomFeedCommandForFeeder(f.ClientId)
+	suite.commands.FeedCommands = append(
+		suite.commands.FeedCommands,
+		modelUtils.RandomFeedCommandForFeeder(f.ClientId),
+		c)
+
+	req := httptest.NewRequest(
+		http.MethodGet, fmt.Sprintf("/v1/feeders/%s/commands/%s", f.ClientId, c.Id), nil)
+	resp, err := suite.app.Test(req)
+	suite.NoError(err)
+	suite.Equal(http.StatusOK, resp.StatusCode)
+
+	rC := models.FeedCommand{}
+	suite.NoError(utils.ParseResponse(&rC, resp))
+	suite.Equal(c, rC)
+}
+
+func (suite *FeederControllerSuite) TestGetFeedCommand_DoesNotExist() {
+	f := modelUtils.RandomFeeder()
+	suite.feeders.Feeders = append(suite.feeders.Feeders, f)
+
+	req := httptest.NewRequest(
+		http.MethodGet, fmt.Sprintf("/v1/feeders/%s/commands/%s", f.ClientId, utils.RandString(10)), nil)
+	resp, err := suite.app.Test(req)
+	suite.NoError(err)
+	suite.Equal(http.StatusNotFound, resp.StatusCode)
+}
+
+func (suite *FeederControllerSuite) TestGetConfig() {
+	f := modelUtils.RandomFeeder()
+	suite.feeders.Feeders = append(suite.feeders.Feeders, f)
+	c := modelUtils.RandomFeederConfigForFeeder(f.ClientId)
+	suite.configs.Configs = append(suite.configs.Configs, c)
+
+	req := httptest.NewRequest(http.MethodGet, fmt.Sprintf("/v1/feeders/%s/config", f.ClientId), nil)
+	resp, err := suite.app.Test(req)
+	suite.NoError(err)
+	suite.Equal(http.StatusOK, resp.StatusCode)
+
+	var rC models.FeederConfig
+	suite.NoError(utils.ParseResponse(&rC, resp))
+	suite.JSONEq(string(c.Config), string(rC.Config))
+	c.Config, rC.Config = nil, nil
+	suite.Equal(c, rC)
+}
+
+func (suite *FeederControllerSuite) TestGetConfig_NotSet() {
+	f := modelUtils.RandomFeeder()
+	suite.feeders.Feeders = append(suite.feeders.Feeders, f)
+
+	req := httptest.NewRequest(http.MethodGet, fmt.Sprintf("/v1/feeders/%s/config", f.ClientId), nil)
+	resp, err := suite.app.Test(req)
+	suite.NoError(err)
+	suite.Equal(http.StatusInternalServerError, resp.StatusCode)
+}
+
+func (suite *FeederControllerSuite) TestUpdateConfig() {
+	f := modelUtils.RandomFeeder()
+	suite.feeders.Feeders = append(suite.feeders.Feeders, f)
+
+	for revision := 1; revision <= 2; revision++ {
+		m := map[string]interface{}{"portionMs": 800 + revision}
+		req := utils.PutJsonRequest(fmt.Sprintf("/v1/feeders/%s/config", f.ClientId), m)
+		resp, err := suite.app.Test(req)
+		suite.NoError(err)
+		suite.Equal(http.StatusOK, resp.StatusCode)
+
+		var rC models.FeederConfig
+		suite.NoError(utils.ParseResponse(&rC, resp))
+		suite.Equal(f.ClientId, rC.ClientId)
+		suite.Equal(revision, rC.Revision)
+		suite.JSONEq(fmt.Sprintf(`{"portionMs": %d}`, 800+revision), string(rC.Config))
+
+		suite.Equal(revision, len(suite.mqtt.Configs))
+		sent := suite.mqtt.Configs[revision-1]
+		suite.Equal(f.ClientId, sent.ClientId)
+		suite.Equal(revision, sent.Msg.Revision)
+		suite.JSONEq(string(rC.Config), string(sent.Msg.Config))
+	}
+}
+
+func (suite *FeederControllerSuite) TestUpdateConfig_Invalid() {
+	f := modelUtils.RandomFeeder()
+	suite.feeders.Feeders = append(suite.feeders.Feeders, f)
+
+	for _, m := range []interface{}{
+		[]int{1},
+		map[string]interface{}{"unknown": 1},
+		map[string]interface{}{"dbPath": "/tmp"},
+		map[string]interface{}{"mqtt": map[string]interface{}{"clientId": "other"}},
+	} {
+		req := utils.PutJsonRequest(fmt.Sprintf("/v1/feeders/%s/config", f.ClientId), m)
+		resp, err := suite.app.Test(req)
+		suite.NoError(err)
+		suite.Equal(http.StatusBadRequest, resp.StatusCode)
+	}
+	suite.Empty(suite.configs.Configs)
+	suite.Empty(suite.mqtt.Configs)
+}
+
+func (suite *FeederControllerSuite) TestUpdateConfig_FeederDoesNotExist() {
+	req := utils.PutJsonRequest("/v1/feeders/missing/config", map[string]interface{}{"portionMs": 800})
+	resp, err := suite.app.Test(req)
+	suite.NoError(err)
+	suite.Equal(http.StatusInternalServerError, resp.StatusCode)
+	suite.Empty(suite.mqtt.Configs)
+}
+
+func (suite *FeederControllerSuite) TestGetSchedules() {
+	f := modelUtils.RandomFeeder()
+	suite.feeders.Feeders = append(suite.feeders.Feeders, f)
+	for _, s := range modelUtils.RandomSchedulesForFeeder(f.ClientId) {
+		_, err := suite.schedules.CreateSchedule(s)
+		suite.NoError(err)
+	}
+	_, err := suite.schedules.CreateSchedule(modelUtils.RandomScheduleForFeeder(utils.RandString(10)))
+	suite.NoError(err)
+
+	req := httptest.NewRequest(
+		http.MethodGet, fmt.Sprintf("/v1/feeders/%s/schedules", f.ClientId), nil)
+	resp, err := suite.app.Test(req)
+	suite.NoError(err)
+	suite.Equal(http.StatusOK, resp.StatusCode)
+
+	var rSs []models.Schedule
+	suite.NoError(utils.ParseResponse(&rSs, resp))
+	expected, _ := suite.schedules.GetSchedulesForFeeder(f.ClientId)
+	suite.ElementsMatch(expected, rSs)
+}
+
+func (suite *FeederControllerSuite) TestCreateSchedule() {
+	f := modelUtils.RandomFeeder()
+	suite.feeders.Feeders = append(suite.feeders.Feeders, f)
+
+	m := models.ScheduleRequest{Time: "08:30", Portions: uint(rand.Intn(10) + 1)}
+	req := utils.PostJsonRequest(fmt.Sprintf("/v1/feeders/%s/schedules", f.ClientId), m)
+	resp, err := suite.app.Test(req)
+	suite.NoError(err)
+	suite.Equal(http.StatusCreated, resp.StatusCode)
+
+	s := models.Schedule{}
+	suite.NoError(utils.ParseResponse(&s, resp))
+	suite.Equal(f.ClientId, s.ClientId)
+	suite.Equal(m.Time, s.Time)
+	suite.Equal(m.Portions, s.Portions)
+	suite.Equal([]models.Schedule{s}, suite.schedules.Schedules)
+
+	suite.Equal(1, len(suite.mqtt.Schedules))
+	suite.Equal(f.ClientId, suite.mqtt.Schedules[0].ClientId)
+	suite.Equal(
+		[]model.ScheduleEntryMessage{{Time: m.Time, Portions: m.Portions}},
+		suite.mqtt.Schedules[0].Msg.Value)
+}
+
+func (suite *FeederControllerSuite) TestCreateSchedule_CronAndTime() {
+	f := modelUtils.RandomFeeder()
+	suite.feeders.Feeders = append(suite.feeders.Feeders, f)
+
+	m := models.ScheduleRequest{Cron: "30 8 * * *", Time: "08:30", Portions: 1}
+	req := utils.PostJsonRequest(fmt.Sprintf("/v1/feeders/%s/schedules", f.ClientId), m)
+	resp, err := suite.app.Test(req)
+	suite.NoError(err)
+	suite.Equal(http.StatusBadRequest, resp.StatusCode)
+	suite.Empty(suite.schedules.Schedules)
+	suite.Empty(suite.mqtt.Schedules)
+}
+
+func (suite *FeederControllerSuite) TestCreateSchedule_InvalidCron() {
+	f := modelUtils.RandomFeeder()
+	suite.feeders.Feeders = append(suite.feeders.Feeders, f)
+
+	m := models.ScheduleRequest{Cron: "every day", Portions: 1}
+	req := utils.PostJsonRequest(fmt.Sprintf("/v1/feeders/%s/schedules", f.ClientId), m)
+	resp, err := suite.app.Test(req)
+	suite.NoError(err)
+	suite.Equal(http.StatusBadRequest, resp.StatusCode)
+	suite.Empty(suite.schedules.Schedules)
+	suite.Empty(suite.mqtt.Schedules)
+}
+
+func (suite *FeederControllerSuite) TestCreateSchedule_CronNeverMatches() {
+	f := modelUtils.RandomFeeder()
+	suite.feeders.Feeders = append(suite.feeders.Feeders, f)
+
+	m := models.ScheduleRequest{Cron: "0 0 30 2 *", Portions: 1}
+	req := utils.PostJsonRequest(fmt.Sprintf("/v1/feeders/%s/schedules", f.ClientId), m)
+	resp, err := suite.app.Test(req)
+	suite.NoError(err)
+	suite.Equal(http.StatusBadRequest, resp.StatusCode)
+	suite.Empty(suite.schedules.Schedules)
+	suite.Empty(suite.mqtt.Schedules)
+}
+
+func (suite *FeederControllerSuite) TestUpdateSchedule() {
+	f := modelUtils.RandomFeeder()
+	suite.feeders.Feeders = append(suite.feeders.Feeders, f)
+	s, err := suite.schedules.CreateSchedule(modelUtils.RandomScheduleForFeeder(f.ClientId))
+	suite.NoError(err)
+
+	m := models.ScheduleRequest{Cron: "0 7 * * 1-5", Portions: s.Portions + 1}
+	req := utils.PutJsonRequest(
+		fmt.Sprintf("/v1/feeders/%s/schedules/%d", f.ClientId, s.Id), m)
+	resp, err := suite.app.Test(req)
+	suite.NoError(err)
+	suite.Equal(http.StatusOK, resp.StatusCode)
+
+	expected := models.Schedule{
+		Id: s.Id, ClientId: f.ClientId, Cron: m.Cron, Portions: m.Portions}
+	suite.Equal([]models.Schedule{expected}, suite.schedules.Schedules)
+	suite.Equal(1, len(suite.mqtt.Schedules))
+	suite.Equal(
+		[]model.ScheduleEntryMessage{{Cron: m.Cron, Portions: m.Portions}},
+		suite.mqtt.Schedules[0].Msg.Value)
+}
+
+func (suite *FeederControllerSuite) TestDeleteSchedule() {
+	f := modelUtils.RandomFeeder()
+	suite.feeders.Feeders = append(suite.feeders.Feeders, f)
+	s, err := suite.schedules.CreateSchedule(modelUtils.RandomScheduleForFeeder(f.ClientId))
+	suite.NoError(err)
+
+	req := httptest.NewRequest(
+		http.MethodDelete, fmt.Sprintf("/v1/feeders/%s/schedules/%d", f.ClientId, s.Id), nil)
+	resp, err := suite.app.Test(req)
+	suite.NoError(err)
+	suite.Equal(http.StatusNoContent, resp.StatusCode)
+
+	suite.Empty(suite.schedules.Schedules)
+	suite.Equal(1, len(suite.mqtt.Schedules))
+	suite.Empty(suite.mqtt.Schedules[0].Msg.Value)
+}
+
+func (suite *FeederControllerSuite) TestDeleteSchedule_InvalidId() {
+	f := modelUtils.RandomFeeder()
+	suite.feeders.Feeders = append(suite.feeders.Feeders, f)
+
+	req := httptest.NewRequest(
+		http.MethodDelete, fmt.Sprintf("/v1/feeders/%s/schedules/abc", f.ClientId), nil)
+	resp, err := suite.app.Test(req)
+	suite.NoError(err)
+	suite.Equal(http.StatusBadRequest, resp.StatusCode)
+	suite.Empty(suite.mqtt.Schedules)
+}
+
 func TestFeederControllerSuite(t *testing.T) {
 	rand.Seed(time.Now().UnixNano())
 	suite.Run(t, new(FeederControllerSuite))
@@ -0,0 +1,201 @@
+package v1
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/imilchev/rpi-feeder/pkg/mqtt/model"
+	"github.com/imilchev/rpi-feeder/pkg/service/config"
+	"github.com/imilchev/rpi-feeder/pkg/service/db/repos"
+	"github.com/imilchev/rpi-feeder/pkg/service/models"
+	"github.com/imilchev/rpi-feeder/pkg/service/mqtt"
+	"github.com/imilchev/rpi-feeder/pkg/service/releases"
+	"github.com/imilchev/rpi-feeder/pkg/utils"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+type ReleasesController struct {
+	feedersRepo  repos.FeedersRepository
+	releasesRepo repos.ReleasesRepository
+	store        releases.Store
+	mqtt         mqtt.MqttManager
+
+	// publicUrl is the URL of the service as reachable by the feeders. It is
+	// empty if it is not configured, in which case feeders cannot be updated.
+	publicUrl string
+}
+
+func NewReleasesController(
+	db *gorm.DB, mqtt mqtt.MqttManager, store releases.Store, cfg config.Releases) *ReleasesController {
+	if cfg.PublicUrl == "" {
+		zap.S().Warn("The publicUrl of the releases is not configured, feeders cannot be updated.")
+	}
+	return &ReleasesController{
+		feedersRepo:  repos.NewFeedersRepository(db),
+		releasesRepo: repos.NewReleasesRepository(db),
+		store:        store,
+		mqtt:         mqtt,
+		publicUrl:    strings.TrimSuffix(cfg.PublicUrl, "/"),
+	}
+}
+
+func (c *ReleasesController) RegisterHandlers(a *fiber.App) {
+	route := a.Group(apiGroup)
+	route.Get("/releases", c.GetReleases)
+	route.Post("/releases", c.CreateRelease)
+	route.Get("/releases/:version/binary", c.GetReleaseBinary)
+	route.Post("/feeders/:clientId/update", c.UpdateFeeder)
+}
+
+func (c *ReleasesController) GetReleases(ctx *fiber.Ctx) error {
+	releases, err := c.releasesRepo.GetReleases()
+	if err != nil {
+		return err
+	}
+	return ctx.Status(http.StatusOK).JSON(releases)
+}
+
+// CreateRelease stores the binary uploaded as the binary field of a multipart
+// form, under the version in the version field. If the form has a sha256
+// field, the upload is rejected unless the checksum of the binary matches it.
+func (c *ReleasesController) CreateRelease(ctx *fiber.Ctx) error {
+	version := ctx.FormValue("version")
+	if err := utils.Validate.Var(version, "required,max=60,semver"); err != nil {
+		return models.NewValidationError(fmt.Sprintf("Invalid version %q.", version))
+	}
+
+	if _, err := c.releasesRepo.GetRelease(version); err == nil {
+		return models.NewAlreadyExistsError("Release", "Version", version)
+	}
+
+	fh, err := ctx.FormFile("binary")
+	if err != nil {
+		return models.NewValidationError(fmt.Sprintf("Missing binary. %v", err))
+	}
+	f, err := fh.Open()
+	if err != nil {
+		return err
+	}
+	defer f.Close() //nolint
+
+	sum, size, err := c.store.Save(f, ctx.FormValue("sha256"))
+	var mismatch *releases.ChecksumMismatchError
+	if errors.As(err, &mismatch) {
+		return models.NewValidationError(fmt.Sprintf(
+			"Checksum mismatch. Expected %s, but the uploaded binary has %s.",
+			mismatch.Expected, mismatch.Actual))
+	}
+	if err != nil {
+		return err
+	}
+
+	release, err := c.releasesRepo.CreateRelease(models.Release{
+		Version:   version,
+		Sha256:    sum,
+		Size:      size,
+		CreatedAt: time.Now().UTC().Unix(),
+	})
+	if err != nil {
+		c.deleteUnusedBinary(sum)
+		return err
+	}
+	zap.S().Infof("Stored release %s with checksum %s.", release.Version, release.Sha256)
+	return ctx.Status(http.StatusCreated).JSON(release)
+}
+
+// GetReleaseBinary serves the binary of a release. This is where the feeders
+// download updates from.
+func (c *ReleasesController) GetReleaseBinary(ctx *fiber.Ctx) error {
+	version, err := url.PathUnescape(ctx.Params("version"))
+	if err != nil {
+		return models.NewValidationError("Invalid version.")
+	}
+
+	release, err := c.releasesRepo.GetRelease(version)
+	if err != nil {
+		return err
+	}
+
+	ctx.Set(fiber.HeaderContentType, "application/octet-stream")
+	return ctx.SendFile(c.store.Path(release.Sha256))
+}
+
+// UpdateFeeder requests the feeder to install a release. The feeder reports
+// the outcome on its own, once the new version is running or the update
+// failed.
+func (c *ReleasesController) UpdateFeeder(ctx *fiber.Ctx) error {
+	clientId := ctx.Params("clientId")
+	if clientId == "" {
+		return models.NewValidationError("Missing clientId.")
+	}
+
+	if c.publicUrl == "" {
+		return models.NewApiError(http.StatusServiceUnavailable,
+			"Feeders cannot be updated, since the publicUrl of the releases is not configured.")
+	}
+
+	feeder, err := c.feedersRepo.GetFeederByClientId(clientId)
+	if err != nil {
+		return err
+	}
+
+	if feeder.Status != model.OnlineStatus {
+		return models.NewValidationError(
+			fmt.Sprintf("Feeder %s is not online.", feeder.ClientId))
+	}
+
+	request := models.UpdateRequest{}
+	if err := ctx.BodyParser(&request); err != nil {
+		return models.NewValidationError(fmt.Sprintf("Cannot parse request body. %v", err))
+	}
+
+	if err := utils.Validate.Struct(request); err != nil {
+		return models.NewValidationError(err.Error())
+	}
+
+	if feeder.SoftwareVersion == request.Version {
+		return models.NewValidationError(
+			fmt.Sprintf("Feeder %s already runs %s.", feeder.ClientId, request.Version))
+	}
+
+	release, err := c.releasesRepo.GetRelease(request.Version)
+	if err != nil {
+		return err
+	}
+
+	msg := model.UpdateMessage{
+		Version: release.Version,
+		Url: fmt.Sprintf(
+			"%s/%s/releases/%s/binary", c.publicUrl, apiGroup, url.PathEscape(release.Version)),
+		Sha256: release.Sha256,
+	}
+	if err := c.mqtt.SendUpdate(clientId, msg); err != nil {
+		return err
+	}
+	return ctx.Status(http.StatusAccepted).JSON(release)
+}
+
+// deleteUnusedBinary deletes the binary unless a release uses it. Binaries are
+// stored by their checksum, so releases with the same binary share it.
+func (c *ReleasesController) deleteUnusedBinary(sha256 string) {
+	releases, err := c.releasesRepo.GetReleases()
+	if err != nil {
+		zap.S().Errorf("Failed to check whether binary %s is used. %v", sha256, err)
+		return
+	}
+	for _, r := range releases {
+		if r.Sha256 == sha256 {
+			return
+		}
+	}
+
+	if err := c.store.Delete(sha256); err != nil {
+		zap.S().Errorf("Failed to delete binary %s. %v", sha256, err)
+	}
+}
@@ -0,0 +1,361 @@
+package v1
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io/ioutil"
+	"math/rand"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/imilchev/rpi-feeder/pkg/mqtt/model"
+	"github.com/imilchev/rpi-feeder/pkg/service/db/repos"
+	"github.com/imilchev/rpi-feeder/pkg/service/middleware"
+	"github.com/imilchev/rpi-feeder/pkg/service/models"
+	"github.com/imilchev/rpi-feeder/pkg/service/releases"
+	"github.com/imilchev/rpi-feeder/tests/fake/mqtt"
+	fake "github.com/imilchev/rpi-feeder/tests/fake/repos"
+	"github.com/imilchev/rpi-feeder/tests/utils"
+	modelUtils "github.com/imilchev/rpi-feeder/tests/utils/models"
+	"github.com/stretchr/testify/suite"
+)
+
+const testPublicUrl = "http://feeder-service:8080"
+
+type ReleasesControllerSuite struct {
+	suite.Suite
+	app      *fiber.App
+	dir      string
+	store    releases.Store
+	feeders  *fake.FakeFeedersRepository
+	releases *fake.FakeReleasesRepository
+	mqtt     *mqtt.FakeServiceMqttManager
+}
+
+func (suite *ReleasesControllerSuite) SetupTest() {
+	var err error
+	suite.dir, err = ioutil.TempDir("", "releases-")
+	suite.Require().NoError(err)
+	suite.store, err = releases.NewStore(suite.dir)
+	suite.Require().NoError(err)
+
+	suite.feeders = &fake.FakeFeedersRepository{}
+	suite.releases = &fake.FakeReleasesRepository{}
+	suite.mqtt = &mqtt.FakeServiceMqttManager{}
+	suite.useReleasesRepo(suite.releases)
+}
+
+func (suite *ReleasesControllerSuite) TearDownTest() {
+	suite.NoError(os.RemoveAll(suite.dir))
+}
+
+func (suite *ReleasesControllerSuite) TestGetReleases() {
+	suite.releases.Releases = modelUtils.RandomReleases()
+
+	req := httptest.NewRequest(http.MethodGet, "/v1/releases", nil)
+	resp, err := suite.app.Test(req)
+	suite.NoError(err)
+	suite.Equal(http.StatusOK, resp.StatusCode)
+
+	var rR []models.Release
+	suite.NoError(utils.ParseResponse(&rR, resp))
+	suite.ElementsMatch(suite.releases.Releases, rR)
+}
+
+func (suite *ReleasesControllerSuite) TestGetReleases_Error() {
+	suite.releases.Error = fmt.Errorf("error")
+
+	req := httptest.NewRequest(http.MethodGet, "/v1/releases", nil)
+	resp, err := suite.app.Test(req)
+	suite.NoError(err)
+	suite.Equal(http.StatusInternalServerError, resp.StatusCode)
+}
+
+func (suite *ReleasesControllerSuite) TestCreateRelease() {
+	binary := []byte(utils.RandString(100))
+	sum := sha256.Sum256(binary)
+
+	req := utils.PostMultipartRequest(
+		"/v1/releases",
+		map[string]string{"version": "v1.2.0", "sha256": hex.EncodeToString(sum[:])},
+		map[string][]byte{"binary": binary})
+	resp, err := suite.app.Test(req)
+	suite.NoError(err)
+	suite.Equal(http.StatusCreated, resp.StatusCode)
+
+	rR := models.Release{}
+	suite.NoError(utils.ParseResponse(&rR, resp))
+	suite.Equal(suite.releases.Releases[0], rR)
+	suite.Equal("v1.2.0", rR.Version)
+	suite.Equal(hex.EncodeToString(sum[:]), rR.Sha256)
+	suite.Equal(int64(len(binary)), rR.Size)
+
+	stored, err := ioutil.ReadFile(suite.store.Path(rR.Sha256))
+	suite.NoError(err)
+	suite.Equal(binary, stored)
+}
+
+func (suite *ReleasesControllerSuite) TestCreateRelease_ChecksumMismatch() {
+	sum := sha256.Sum256([]byte(utils.RandString(10)))
+
+	req := utils.PostMultipartRequest(
+		"/v1/releases",
+		map[string]string{"version": "v1.2.0", "sha256": hex.EncodeToString(sum[:])},
+		map[string][]byte{"binary": []byte(utils.RandString(100))})
+	resp, err := suite.app.Test(req)
+	suite.NoError(err)
+	suite.Equal(http.StatusBadRequest, resp.StatusCode)
+	suite.Empty(suite.releases.Releases)
+
+	// The rejected binary is not kept.
+	entries, err := ioutil.ReadDir(suite.dir)
+	suite.NoError(err)
+	suite.Empty(entries)
+}
+
+func (suite *ReleasesControllerSuite) TestCreateRelease_ChecksumMismatchBinaryInUse() {
+	binary := []byte(utils.RandString(100))
+	r := suite.storedRelease(binary)
+
+	sum := sha256.Sum256([]byte(utils.RandString(10)))
+	req := utils.PostMultipartRequest(
+		"/v1/releases",
+		map[string]string{"version": "v1.2.0", "sha256": hex.EncodeToString(sum[:])},
+		map[string][]byte{"binary": binary})
+	resp, err := suite.app.Test(req)
+	suite.NoError(err)
+	suite.Equal(http.StatusBadRequest, resp.StatusCode)
+
+	// The binary of the existing release is kept.
+	suite.FileExists(suite.store.Path(r.Sha256))
+}
+
+func (suite *ReleasesControllerSuite) TestCreateRelease_Error() {
+	suite.useReleasesRepo(&failingCreateReleasesRepository{suite.releases})
+
+	binary := []byte(utils.RandString(100))
+	req := utils.PostMultipartRequest(
+		"/v1/releases", map[string]string{"version": "v1.2.0"}, map[string][]byte{"binary": binary})
+	resp, err := suite.app.Test(req)
+	suite.NoError(err)
+	suite.Equal(http.StatusInternalServerError, resp.StatusCode)
+
+	// The binary is not kept.
+	entries, err := ioutil.ReadDir(suite.dir)
+	suite.NoError(err)
+	suite.Empty(entries)
+}
+
+func (suite *ReleasesControllerSuite) TestCreateRelease_ErrorBinaryInUse() {
+	binary := []byte(utils.RandString(100))
+	r := suite.storedRelease(binary)
+	suite.useReleasesRepo(&failingCreateReleasesRepository{suite.releases})
+
+	req := utils.PostMultipartRequest(
+		"/v1/releases", map[string]string{"version": "v1.2.0"}, map[string][]byte{"binary": binary})
+	resp, err := suite.app.Test(req)
+	suite.NoError(err)
+	suite.Equal(http.StatusInternalServerError, resp.StatusCode)
+
+	// The binary of the existing release is kept.
+	suite.FileExists(suite.store.Path(r.Sha256))
+}
+
+func (suite *ReleasesControllerSuite) TestCreateRelease_InvalidVersion() {
+	req := utils.PostMultipartRequest(
+		"/v1/releases",
+		map[string]string{"version": "dev"},
+		map[string][]byte{"binary": []byte(utils.RandString(100))})
+	resp, err := suite.app.Test(req)
+	suite.NoError(err)
+	suite.Equal(http.StatusBadRequest, resp.StatusCode)
+	suite.Empty(suite.releases.Releases)
+}
+
+func (suite *ReleasesControllerSuite) TestCreateRelease_MissingBinary() {
+	req := utils.PostMultipartRequest(
+		"/v1/releases", map[string]string{"version": "v1.2.0"}, nil)
+	resp, err := suite.app.Test(req)
+	suite.NoError(err)
+	suite.Equal(http.StatusBadRequest, resp.StatusCode)
+	suite.Empty(suite.releases.Releases)
+}
+
+func (suite *ReleasesControllerSuite) TestCreateRelease_AlreadyExists() {
+	r := modelUtils.RandomRelease()
+	suite.releases.Releases = append(suite.releases.Releases, r)
+
+	req := utils.PostMultipartRequest(
+		"/v1/releases",
+		map[string]string{"version": r.Version},
+		map[string][]byte{"binary": []byte(utils.RandString(100))})
+	resp, err := suite.app.Test(req)
+	suite.NoError(err)
+	suite.Equal(http.StatusConflict, resp.StatusCode)
+	suite.Equal(1, len(suite.releases.Releases))
+}
+
+func (suite *ReleasesControllerSuite) TestGetReleaseBinary() {
+	binary := []byte(utils.RandString(100))
+	req := utils.PostMultipartRequest(
+		"/v1/releases",
+		map[string]string{"version": "v1.2.0"},
+		map[string][]byte{"binary": binary})
+	resp, err := suite.app.Test(req)
+	suite.NoError(err)
+	suite.Equal(http.StatusCreated, resp.StatusCode)
+
+	req = httptest.NewRequest(http.MethodGet, "/v1/releases/v1.2.0/binary", nil)
+	resp, err = suite.app.Test(req)
+	suite.NoError(err)
+	suite.Equal(http.StatusOK, resp.StatusCode)
+
+	body, err := ioutil.ReadAll(resp.Body)
+	suite.NoError(err)
+	suite.Equal(binary, body)
+}
+
+func (suite *ReleasesControllerSuite) TestGetReleaseBinary_DoesNotExist() {
+	req := httptest.NewRequest(http.MethodGet, "/v1/releases/v1.2.0/binary", nil)
+	resp, err := suite.app.Test(req)
+	suite.NoError(err)
+	suite.Equal(http.StatusInternalServerError, resp.StatusCode)
+}
+
+func (suite *ReleasesControllerSuite) TestUpdateFeeder() {
+	f := modelUtils.RandomFeeder()
+	f.Status = model.OnlineStatus
+	suite.feeders.Feeders = append(suite.feeders.Feeders, f)
+	r := modelUtils.RandomRelease()
+	suite.releases.Releases = append(suite.releases.Releases, r)
+
+	req := utils.PostJsonRequest(
+		fmt.Sprintf("/v1/feeders/%s/update", f.ClientId), models.UpdateRequest{Version: r.Version})
+	resp, err := suite.app.Test(req)
+	suite.NoError(err)
+	suite.Equal(http.StatusAccepted, resp.StatusCode)
+
+	suite.Equal(1, len(suite.mqtt.Updates))
+	suite.Equal(f.ClientId, suite.mqtt.Updates[0].ClientId)
+	suite.Equal(model.UpdateMessage{
+		Version: r.Version,
+		Url:     fmt.Sprintf("%s/v1/releases/%s/binary", testPublicUrl, r.Version),
+		Sha256:  r.Sha256,
+	}, suite.mqtt.Updates[0].Msg)
+}
+
+func (suite *ReleasesControllerSuite) TestUpdateFeeder_NoPublicUrl() {
+	suite.registerController(ReleasesController{
+		feedersRepo:  suite.feeders,
+		releasesRepo: suite.releases,
+		store:        suite.store,
+		mqtt:         suite.mqtt,
+	})
+
+	f := modelUtils.RandomFeeder()
+	f.Status = model.OnlineStatus
+	suite.feeders.Feeders = append(suite.feeders.Feeders, f)
+	r := modelUtils.RandomRelease()
+	suite.releases.Releases = append(suite.releases.Releases, r)
+
+	req := utils.PostJsonRequest(
+		fmt.Sprintf("/v1/feeders/%s/update", f.ClientId), models.UpdateRequest{Version: r.Version})
+	resp, err := suite.app.Test(req)
+	suite.NoError(err)
+	suite.Equal(http.StatusServiceUnavailable, resp.StatusCode)
+	suite.Empty(suite.mqtt.Updates)
+}
+
+func (suite *ReleasesControllerSuite) TestUpdateFeeder_FeederOffline() {
+	f := modelUtils.RandomFeeder()
+	f.Status = model.OfflineStatus
+	suite.feeders.Feeders = append(suite.feeders.Feeders, f)
+	r := modelUtils.RandomRelease()
+	suite.releases.Releases = append(suite.releases.Releases, r)
+
+	req := utils.PostJsonRequest(
+		fmt.Sprintf("/v1/feeders/%s/update", f.ClientId), models.UpdateRequest{Version: r.Version})
+	resp, err := suite.app.Test(req)
+	suite.NoError(err)
+	suite.Equal(http.StatusBadRequest, resp.StatusCode)
+	suite.Empty(suite.mqtt.Updates)
+}
+
+func (suite *ReleasesControllerSuite) TestUpdateFeeder_AlreadyRunning() {
+	r := modelUtils.RandomRelease()
+	suite.releases.Releases = append(suite.releases.Releases, r)
+	f := modelUtils.RandomFeeder()
+	f.Status = model.OnlineStatus
+	f.SoftwareVersion = r.Version
+	suite.feeders.Feeders = append(suite.feeders.Feeders, f)
+
+	req := utils.PostJsonRequest(
+		fmt.Sprintf("/v1/feeders/%s/update", f.ClientId), models.UpdateRequest{Version: r.Version})
+	resp, err := suite.app.Test(req)
+	suite.NoError(err)
+	suite.Equal(http.StatusBadRequest, resp.StatusCode)
+	suite.Empty(suite.mqtt.Updates)
+}
+
+func (suite *ReleasesControllerSuite) TestUpdateFeeder_ReleaseDoesNotExist() {
+	f := modelUtils.RandomFeeder()
+	f.Status = model.OnlineStatus
+	suite.feeders.Feeders = append(suite.feeders.Feeders, f)
+
+	req := utils.PostJsonRequest(
+		fmt.Sprintf("/v1/feeders/%s/update", f.ClientId), models.UpdateRequest{Version: "v1.2.0"})
+	resp, err := suite.app.Test(req)
+	suite.NoError(err)
+	suite.Equal(http.StatusInternalServerError, resp.StatusCode)
+	suite.Empty(suite.mqtt.Updates)
+}
+
+// useReleasesRepo registers a controller that uses the releases repository.
+func (suite *ReleasesControllerSuite) useReleasesRepo(r repos.ReleasesRepository) {
+	suite.registerController(ReleasesController{
+		feedersRepo:  suite.feeders,
+		releasesRepo: r,
+		store:        suite.store,
+		mqtt:         suite.mqtt,
+		publicUrl:    testPublicUrl,
+	})
+}
+
+func (suite *ReleasesControllerSuite) registerController(c ReleasesController) {
+	suite.app = fiber.New(fiber.Config{
+		ErrorHandler: middleware.ErrorHandler,
+	})
+	c.RegisterHandlers(suite.app)
+}
+
+// storedRelease stores the binary and adds a release for it.
+func (suite *ReleasesControllerSuite) storedRelease(binary []byte) models.Release {
+	sum, size, err := suite.store.Save(bytes.NewReader(binary), "")
+	suite.Require().NoError(err)
+	r := modelUtils.RandomRelease()
+	r.Sha256 = sum
+	r.Size = size
+	suite.releases.Releases = append(suite.releases.Releases, r)
+	return r
+}
+
+// failingCreateReleasesRepository fails to create releases, while the other
+// functions succeed.
+type failingCreateReleasesRepository struct {
+	*fake.FakeReleasesRepository
+}
+
+func (r *failingCreateReleasesRepository) CreateRelease(m models.Release) (models.Release, error) {
+	return models.Release{}, fmt.Errorf("error")
+}
+
+func TestReleasesControllerSuite(t *testing.T) {
+	rand.Seed(time.Now().UnixNano())
+	suite.Run(t, new(ReleasesControllerSuite))
+}
@@ -0,0 +1,24 @@
+package v1
+
+import (
+	"net/http"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/imilchev/rpi-feeder/pkg/version"
+)
+
+type VersionController struct{}
+
+func NewVersionController() *VersionController {
+	return &VersionController{}
+}
+
+func (c *VersionController) RegisterHandlers(a *fiber.App) {
+	route := a.Group(apiGroup)
+	route.Get("/version", c.GetVersion)
+}
+
+// GetVersion returns the build information of the service.
+func (c *VersionController) GetVersion(ctx *fiber.Ctx) error {
+	return ctx.Status(http.StatusOK).JSON(version.Get())
+}
@@ -0,0 +1,40 @@
+package v1
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/imilchev/rpi-feeder/pkg/service/middleware"
+	"github.com/imilchev/rpi-feeder/pkg/version"
+	"github.com/imilchev/rpi-feeder/tests/utils"
+	"github.com/stretchr/testify/suite"
+)
+
+type VersionControllerSuite struct {
+	suite.Suite
+	app *fiber.App
+}
+
+func (suite *VersionControllerSuite) SetupTest() {
+	suite.app = fiber.New(fiber.Config{
+		ErrorHandler: middleware.ErrorHandler,
+	})
+	NewVersionController().RegisterHandlers(suite.app)
+}
+
+func (suite *VersionControllerSuite) TestGetVersion() {
+	req := httptest.NewRequest(http.MethodGet, "/v1/version", nil)
+	resp, err := suite.app.Test(req)
+	suite.NoError(err)
+	suite.Equal(http.StatusOK, resp.StatusCode)
+
+	rV := version.Info{}
+	suite.NoError(utils.ParseResponse(&rV, resp))
+	suite.Equal(version.Get(), rV)
+}
+
+func TestVersionControllerSuite(t *testing.T) {
+	suite.Run(t, new(VersionControllerSuite))
+}
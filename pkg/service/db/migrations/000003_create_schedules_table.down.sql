@@ -0,0 +1 @@
+DROP TABLE IF EXISTS schedules;
\ No newline at end of file
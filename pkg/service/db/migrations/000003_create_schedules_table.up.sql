@@ -0,0 +1,10 @@
+CREATE TABLE IF NOT EXISTS schedules(
+    id SERIAL PRIMARY KEY,
+    client_id VARCHAR (60) NOT NULL,
+    cron VARCHAR (60) NOT NULL DEFAULT '',
+    time VARCHAR (5) NOT NULL DEFAULT '',
+    portions SMALLINT NOT NULL,
+    CONSTRAINT fk_feeder
+      FOREIGN KEY(client_id) 
+	  REFERENCES feeders(client_id)
+);
\ No newline at end of file
@@ -0,0 +1 @@
+DROP TABLE IF EXISTS missed_feedings;
\ No newline at end of file
@@ -0,0 +1,10 @@
+CREATE TABLE IF NOT EXISTS missed_feedings(
+    id SERIAL PRIMARY KEY,
+    client_id VARCHAR (60) NOT NULL,
+    portions SMALLINT NOT NULL,
+    scheduled_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    caught_up BOOLEAN NOT NULL DEFAULT FALSE,
+    CONSTRAINT fk_feeder
+      FOREIGN KEY(client_id) 
+	  REFERENCES feeders(client_id)
+);
\ No newline at end of file
@@ -0,0 +1 @@
+DROP TABLE IF EXISTS feed_commands;
\ No newline at end of file
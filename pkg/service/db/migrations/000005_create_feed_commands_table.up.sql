@@ -0,0 +1,14 @@
+CREATE TABLE IF NOT EXISTS feed_commands(
+    id VARCHAR (64) NOT NULL,
+    client_id VARCHAR (60) NOT NULL,
+    portions SMALLINT NOT NULL,
+    status VARCHAR (9) NOT NULL,
+    served_portions SMALLINT NOT NULL DEFAULT 0,
+    error TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    completed_at TIMESTAMP WITH TIME ZONE DEFAULT NULL,
+    PRIMARY KEY(client_id, id),
+    CONSTRAINT fk_feeder
+      FOREIGN KEY(client_id) 
+	  REFERENCES feeders(client_id)
+);
\ No newline at end of file
@@ -0,0 +1 @@
+DROP TABLE IF EXISTS food_levels;
\ No newline at end of file
@@ -0,0 +1,10 @@
+CREATE TABLE IF NOT EXISTS food_levels(
+    id SERIAL PRIMARY KEY,
+    client_id VARCHAR (60) NOT NULL,
+    level REAL NOT NULL,
+    distance REAL NOT NULL,
+    timestamp TIMESTAMP WITH TIME ZONE NOT NULL,
+    CONSTRAINT fk_feeder
+      FOREIGN KEY(client_id) 
+	  REFERENCES feeders(client_id)
+);
\ No newline at end of file
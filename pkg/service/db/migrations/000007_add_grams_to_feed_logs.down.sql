@@ -0,0 +1 @@
+ALTER TABLE feed_logs DROP COLUMN IF EXISTS grams;
\ No newline at end of file
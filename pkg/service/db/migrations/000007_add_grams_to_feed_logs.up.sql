@@ -0,0 +1 @@
+ALTER TABLE feed_logs ADD COLUMN IF NOT EXISTS grams REAL NOT NULL DEFAULT 0;
\ No newline at end of file
@@ -0,0 +1 @@
+DROP TABLE IF EXISTS calibrations;
\ No newline at end of file
@@ -0,0 +1,12 @@
+CREATE TABLE IF NOT EXISTS calibrations(
+    id SERIAL PRIMARY KEY,
+    client_id VARCHAR (60) NOT NULL,
+    ms_per_gram REAL NOT NULL,
+    offset_ms REAL NOT NULL,
+    grams_per_portion REAL NOT NULL,
+    portion_ms INTEGER NOT NULL,
+    timestamp TIMESTAMP WITH TIME ZONE NOT NULL,
+    CONSTRAINT fk_feeder
+      FOREIGN KEY(client_id) 
+	  REFERENCES feeders(client_id)
+);
\ No newline at end of file
@@ -0,0 +1,12 @@
+CREATE TABLE IF NOT EXISTS alerts(
+    id SERIAL PRIMARY KEY,
+    client_id VARCHAR (60) NOT NULL,
+    type VARCHAR (20) NOT NULL,
+    message TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    acknowledged_at TIMESTAMP WITH TIME ZONE DEFAULT NULL,
+    resolved_at TIMESTAMP WITH TIME ZONE DEFAULT NULL,
+    CONSTRAINT fk_feeder
+      FOREIGN KEY(client_id) 
+	  REFERENCES feeders(client_id)
+);
\ No newline at end of file
@@ -0,0 +1 @@
+DROP TABLE IF EXISTS telemetry;
\ No newline at end of file
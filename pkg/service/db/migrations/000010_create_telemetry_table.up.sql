@@ -0,0 +1,13 @@
+CREATE TABLE IF NOT EXISTS telemetry(
+    id SERIAL PRIMARY KEY,
+    client_id VARCHAR (60) NOT NULL,
+    uptime_seconds BIGINT NOT NULL,
+    cpu_temperature REAL DEFAULT NULL,
+    free_disk_bytes BIGINT NOT NULL,
+    unflushed_feed_logs INTEGER NOT NULL,
+    mqtt_reconnects INTEGER NOT NULL,
+    timestamp TIMESTAMP WITH TIME ZONE NOT NULL,
+    CONSTRAINT fk_feeder
+      FOREIGN KEY(client_id) 
+	  REFERENCES feeders(client_id)
+);
\ No newline at end of file
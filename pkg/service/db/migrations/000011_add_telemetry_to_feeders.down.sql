@@ -0,0 +1,7 @@
+ALTER TABLE feeders
+    DROP COLUMN IF EXISTS uptime_seconds,
+    DROP COLUMN IF EXISTS cpu_temperature,
+    DROP COLUMN IF EXISTS free_disk_bytes,
+    DROP COLUMN IF EXISTS unflushed_feed_logs,
+    DROP COLUMN IF EXISTS mqtt_reconnects,
+    DROP COLUMN IF EXISTS telemetry_at;
\ No newline at end of file
@@ -0,0 +1,7 @@
+ALTER TABLE feeders
+    ADD COLUMN IF NOT EXISTS uptime_seconds BIGINT NOT NULL DEFAULT 0,
+    ADD COLUMN IF NOT EXISTS cpu_temperature REAL DEFAULT NULL,
+    ADD COLUMN IF NOT EXISTS free_disk_bytes BIGINT NOT NULL DEFAULT 0,
+    ADD COLUMN IF NOT EXISTS unflushed_feed_logs INTEGER NOT NULL DEFAULT 0,
+    ADD COLUMN IF NOT EXISTS mqtt_reconnects INTEGER NOT NULL DEFAULT 0,
+    ADD COLUMN IF NOT EXISTS telemetry_at TIMESTAMP WITH TIME ZONE DEFAULT NULL;
\ No newline at end of file
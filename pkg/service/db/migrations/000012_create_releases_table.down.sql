@@ -0,0 +1 @@
+DROP TABLE IF EXISTS releases;
\ No newline at end of file
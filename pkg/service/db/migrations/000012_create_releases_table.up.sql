@@ -0,0 +1,7 @@
+CREATE TABLE IF NOT EXISTS releases(
+    id SERIAL PRIMARY KEY,
+    version VARCHAR (60) UNIQUE NOT NULL,
+    sha256 CHAR (64) NOT NULL,
+    size BIGINT NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL
+);
\ No newline at end of file
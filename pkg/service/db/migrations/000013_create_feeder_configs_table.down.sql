@@ -0,0 +1 @@
+DROP TABLE IF EXISTS feeder_configs;
\ No newline at end of file
@@ -0,0 +1,9 @@
+CREATE TABLE IF NOT EXISTS feeder_configs(
+    client_id VARCHAR (60) PRIMARY KEY,
+    revision INTEGER NOT NULL,
+    config JSONB NOT NULL,
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    CONSTRAINT fk_feeder
+      FOREIGN KEY(client_id) 
+	  REFERENCES feeders(client_id)
+);
\ No newline at end of file
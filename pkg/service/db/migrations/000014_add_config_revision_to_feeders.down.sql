@@ -0,0 +1,3 @@
+ALTER TABLE feeders
+    DROP COLUMN IF EXISTS config_revision,
+    DROP COLUMN IF EXISTS config_pending_restart;
\ No newline at end of file
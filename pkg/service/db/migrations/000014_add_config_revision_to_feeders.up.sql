@@ -0,0 +1,3 @@
+ALTER TABLE feeders
+    ADD COLUMN IF NOT EXISTS config_revision INTEGER NOT NULL DEFAULT 0,
+    ADD COLUMN IF NOT EXISTS config_pending_restart BOOLEAN NOT NULL DEFAULT FALSE;
\ No newline at end of file
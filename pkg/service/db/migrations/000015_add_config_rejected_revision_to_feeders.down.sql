@@ -0,0 +1,3 @@
+ALTER TABLE feeders
+    DROP COLUMN IF EXISTS config_rejected_revision,
+    DROP COLUMN IF EXISTS config_error;
\ No newline at end of file
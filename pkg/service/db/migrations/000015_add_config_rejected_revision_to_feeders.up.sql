@@ -0,0 +1,3 @@
+ALTER TABLE feeders
+    ADD COLUMN IF NOT EXISTS config_rejected_revision INTEGER NOT NULL DEFAULT 0,
+    ADD COLUMN IF NOT EXISTS config_error TEXT NOT NULL DEFAULT '';
\ No newline at end of file
@@ -0,0 +1,53 @@
+package models
+
+import (
+	"time"
+
+	"github.com/imilchev/rpi-feeder/pkg/service/models"
+)
+
+type Alert struct {
+	Id             int `gorm:"primaryKey"`
+	ClientId       string
+	Type           string
+	Message        string
+	CreatedAt      time.Time
+	AcknowledgedAt *time.Time
+	ResolvedAt     *time.Time
+}
+
+func (a Alert) ToApi(m *models.Alert) {
+	m.Id = a.Id
+	m.ClientId = a.ClientId
+	m.Type = models.AlertType(a.Type)
+	m.Message = a.Message
+	m.CreatedAt = a.CreatedAt.UTC().Unix()
+	m.AcknowledgedAt = toUnix(a.AcknowledgedAt)
+	m.ResolvedAt = toUnix(a.ResolvedAt)
+}
+
+func (a *Alert) FromApi(m models.Alert) {
+	a.Id = m.Id
+	a.ClientId = m.ClientId
+	a.Type = string(m.Type)
+	a.Message = m.Message
+	a.CreatedAt = time.Unix(m.CreatedAt, 0)
+	a.AcknowledgedAt = fromUnix(m.AcknowledgedAt)
+	a.ResolvedAt = fromUnix(m.ResolvedAt)
+}
+
+func toUnix(t *time.Time) *int64 {
+	if t == nil {
+		return nil
+	}
+	u := t.UTC().Unix()
+	return &u
+}
+
+func fromUnix(u *int64) *time.Time {
+	if u == nil {
+		return nil
+	}
+	t := time.Unix(*u, 0)
+	return &t
+}
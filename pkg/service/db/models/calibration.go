@@ -0,0 +1,37 @@
+package models
+
+import (
+	"time"
+
+	"github.com/imilchev/rpi-feeder/pkg/service/models"
+)
+
+type Calibration struct {
+	Id              int `gorm:"primaryKey"`
+	ClientId        string
+	MsPerGram       float64
+	OffsetMs        float64
+	GramsPerPortion float64
+	PortionMs       uint64
+	Timestamp       time.Time
+}
+
+func (c Calibration) ToApi(m *models.Calibration) {
+	m.Id = c.Id
+	m.ClientId = c.ClientId
+	m.MsPerGram = c.MsPerGram
+	m.OffsetMs = c.OffsetMs
+	m.GramsPerPortion = c.GramsPerPortion
+	m.PortionMs = c.PortionMs
+	m.Timestamp = c.Timestamp.UTC().Unix()
+}
+
+func (c *Calibration) FromApi(m models.Calibration) {
+	c.Id = m.Id
+	c.ClientId = m.ClientId
+	c.MsPerGram = m.MsPerGram
+	c.OffsetMs = m.OffsetMs
+	c.GramsPerPortion = m.GramsPerPortion
+	c.PortionMs = m.PortionMs
+	c.Timestamp = time.Unix(m.Timestamp, 0)
+}
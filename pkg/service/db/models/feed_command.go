@@ -0,0 +1,48 @@
+package models
+
+import (
+	"time"
+
+	"github.com/imilchev/rpi-feeder/pkg/service/models"
+)
+
+type FeedCommand struct {
+	Id             string `gorm:"primaryKey"`
+	ClientId       string `gorm:"primaryKey"`
+	Portions       uint
+	Status         string
+	ServedPortions uint
+	Error          string
+	CreatedAt      time.Time
+	CompletedAt    *time.Time
+}
+
+func (c FeedCommand) ToApi(m *models.FeedCommand) {
+	m.Id = c.Id
+	m.ClientId = c.ClientId
+	m.Portions = c.Portions
+	m.Status = models.CommandStatus(c.Status)
+	m.ServedPortions = c.ServedPortions
+	m.Error = c.Error
+	m.CreatedAt = c.CreatedAt.UTC().Unix()
+	m.CompletedAt = nil
+	if c.CompletedAt != nil {
+		t := c.CompletedAt.UTC().Unix()
+		m.CompletedAt = &t
+	}
+}
+
+func (c *FeedCommand) FromApi(m models.FeedCommand) {
+	c.Id = m.Id
+	c.ClientId = m.ClientId
+	c.Portions = m.Portions
+	c.Status = string(m.Status)
+	c.ServedPortions = m.ServedPortions
+	c.Error = m.Error
+	c.CreatedAt = time.Unix(m.CreatedAt, 0)
+	c.CompletedAt = nil
+	if m.CompletedAt != nil {
+		t := time.Unix(*m.CompletedAt, 0)
+		c.CompletedAt = &t
+	}
+}
@@ -10,6 +10,7 @@ type FeedLog struct {
 	Id        int `gorm:"primaryKey"`
 	ClientId  string
 	Portions  uint
+	Grams     float64
 	Timestamp time.Time
 }
 
@@ -17,6 +18,7 @@ func (f FeedLog) ToApi(m *models.FeedLog) {
 	m.Id = f.Id
 	m.ClientId = f.ClientId
 	m.Portions = f.Portions
+	m.Grams = f.Grams
 	m.Timestamp = f.Timestamp.UTC().Unix()
 }
 
@@ -24,5 +26,6 @@ func (f *FeedLog) FromApi(m models.FeedLog) {
 	f.Id = m.Id
 	f.ClientId = m.ClientId
 	f.Portions = m.Portions
+	f.Grams = m.Grams
 	f.Timestamp = time.Unix(m.Timestamp, 0)
 }
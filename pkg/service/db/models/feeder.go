@@ -15,26 +15,79 @@ type Feeder struct {
 	// The timestamp of when the feeder was last observed to be online.
 	// Only set if the feeder is offline.
 	LastOnline *time.Time
+
+	ConfigRevision         int
+	ConfigPendingRestart   bool
+	ConfigRejectedRevision int
+	ConfigError            string
+
+	// The latest telemetry of the feeder. TelemetryAt is only set if the
+	// feeder sent telemetry.
+	UptimeSeconds     uint64
+	CpuTemperature    *float64
+	FreeDiskBytes     uint64
+	UnflushedFeedLogs uint
+	MqttReconnects    uint
+	TelemetryAt       *time.Time
 }
 
 func (f Feeder) ToApi(m *models.Feeder) {
 	m.ClientId = f.ClientId
 	m.SoftwareVersion = f.SoftwareVersion
 	m.Status = model.Status(f.Status)
+	m.ConfigRevision = f.ConfigRevision
+	m.ConfigPendingRestart = f.ConfigPendingRestart
+	m.ConfigRejectedRevision = f.ConfigRejectedRevision
+	m.ConfigError = f.ConfigError
 	m.LastOnline = nil
 	if f.LastOnline != nil {
 		t := f.LastOnline.UTC().Unix()
 		m.LastOnline = &t
 	}
+	m.Telemetry = nil
+	if f.TelemetryAt != nil {
+		m.Telemetry = &models.Telemetry{
+			ClientId:          f.ClientId,
+			UptimeSeconds:     f.UptimeSeconds,
+			CpuTemperature:    f.CpuTemperature,
+			FreeDiskBytes:     f.FreeDiskBytes,
+			UnflushedFeedLogs: f.UnflushedFeedLogs,
+			MqttReconnects:    f.MqttReconnects,
+			Timestamp:         f.TelemetryAt.UTC().Unix(),
+		}
+	}
 }
 
 func (f *Feeder) FromApi(m models.Feeder) {
 	f.ClientId = m.ClientId
 	f.SoftwareVersion = m.SoftwareVersion
 	f.Status = string(m.Status)
+	f.ConfigRevision = m.ConfigRevision
+	f.ConfigPendingRestart = m.ConfigPendingRestart
+	f.ConfigRejectedRevision = m.ConfigRejectedRevision
+	f.ConfigError = m.ConfigError
 	f.LastOnline = nil
 	if m.LastOnline != nil {
 		t := time.Unix(*m.LastOnline, 0)
 		f.LastOnline = &t
 	}
+	f.SetTelemetry(m.Telemetry)
+}
+
+// SetTelemetry sets the latest telemetry of the feeder. A nil telemetry
+// clears it.
+func (f *Feeder) SetTelemetry(t *models.Telemetry) {
+	f.UptimeSeconds, f.CpuTemperature, f.FreeDiskBytes = 0, nil, 0
+	f.UnflushedFeedLogs, f.MqttReconnects, f.TelemetryAt = 0, 0, nil
+	if t == nil {
+		return
+	}
+
+	f.UptimeSeconds = t.UptimeSeconds
+	f.CpuTemperature = t.CpuTemperature
+	f.FreeDiskBytes = t.FreeDiskBytes
+	f.UnflushedFeedLogs = t.UnflushedFeedLogs
+	f.MqttReconnects = t.MqttReconnects
+	at := time.Unix(t.Timestamp, 0)
+	f.TelemetryAt = &at
 }
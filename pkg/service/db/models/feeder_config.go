@@ -0,0 +1,29 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/imilchev/rpi-feeder/pkg/service/models"
+)
+
+type FeederConfig struct {
+	ClientId  string `gorm:"primaryKey"`
+	Revision  int
+	Config    string
+	UpdatedAt time.Time
+}
+
+func (c FeederConfig) ToApi(m *models.FeederConfig) {
+	m.ClientId = c.ClientId
+	m.Revision = c.Revision
+	m.Config = json.RawMessage(c.Config)
+	m.UpdatedAt = c.UpdatedAt.UTC().Unix()
+}
+
+func (c *FeederConfig) FromApi(m models.FeederConfig) {
+	c.ClientId = m.ClientId
+	c.Revision = m.Revision
+	c.Config = string(m.Config)
+	c.UpdatedAt = time.Unix(m.UpdatedAt, 0)
+}
@@ -0,0 +1,31 @@
+package models
+
+import (
+	"time"
+
+	"github.com/imilchev/rpi-feeder/pkg/service/models"
+)
+
+type FoodLevel struct {
+	Id        int `gorm:"primaryKey"`
+	ClientId  string
+	Level     float64
+	Distance  float64
+	Timestamp time.Time
+}
+
+func (f FoodLevel) ToApi(m *models.FoodLevel) {
+	m.Id = f.Id
+	m.ClientId = f.ClientId
+	m.Level = f.Level
+	m.Distance = f.Distance
+	m.Timestamp = f.Timestamp.UTC().Unix()
+}
+
+func (f *FoodLevel) FromApi(m models.FoodLevel) {
+	f.Id = m.Id
+	f.ClientId = m.ClientId
+	f.Level = m.Level
+	f.Distance = m.Distance
+	f.Timestamp = time.Unix(m.Timestamp, 0)
+}
@@ -0,0 +1,31 @@
+package models
+
+import (
+	"time"
+
+	"github.com/imilchev/rpi-feeder/pkg/service/models"
+)
+
+type MissedFeeding struct {
+	Id          int `gorm:"primaryKey"`
+	ClientId    string
+	Portions    uint
+	ScheduledAt time.Time
+	CaughtUp    bool
+}
+
+func (f MissedFeeding) ToApi(m *models.MissedFeeding) {
+	m.Id = f.Id
+	m.ClientId = f.ClientId
+	m.Portions = f.Portions
+	m.ScheduledAt = f.ScheduledAt.UTC().Unix()
+	m.CaughtUp = f.CaughtUp
+}
+
+func (f *MissedFeeding) FromApi(m models.MissedFeeding) {
+	f.Id = m.Id
+	f.ClientId = m.ClientId
+	f.Portions = m.Portions
+	f.ScheduledAt = time.Unix(m.ScheduledAt, 0)
+	f.CaughtUp = m.CaughtUp
+}
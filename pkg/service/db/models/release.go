@@ -0,0 +1,31 @@
+package models
+
+import (
+	"time"
+
+	"github.com/imilchev/rpi-feeder/pkg/service/models"
+)
+
+type Release struct {
+	Id        int `gorm:"primaryKey"`
+	Version   string
+	Sha256    string
+	Size      int64
+	CreatedAt time.Time
+}
+
+func (r Release) ToApi(m *models.Release) {
+	m.Id = r.Id
+	m.Version = r.Version
+	m.Sha256 = r.Sha256
+	m.Size = r.Size
+	m.CreatedAt = r.CreatedAt.UTC().Unix()
+}
+
+func (r *Release) FromApi(m models.Release) {
+	r.Id = m.Id
+	r.Version = m.Version
+	r.Sha256 = m.Sha256
+	r.Size = m.Size
+	r.CreatedAt = time.Unix(m.CreatedAt, 0)
+}
@@ -0,0 +1,27 @@
+package models
+
+import "github.com/imilchev/rpi-feeder/pkg/service/models"
+
+type Schedule struct {
+	Id       int `gorm:"primaryKey"`
+	ClientId string
+	Cron     string
+	Time     string
+	Portions uint
+}
+
+func (s Schedule) ToApi(m *models.Schedule) {
+	m.Id = s.Id
+	m.ClientId = s.ClientId
+	m.Cron = s.Cron
+	m.Time = s.Time
+	m.Portions = s.Portions
+}
+
+func (s *Schedule) FromApi(m models.Schedule) {
+	s.Id = m.Id
+	s.ClientId = m.ClientId
+	s.Cron = m.Cron
+	s.Time = m.Time
+	s.Portions = m.Portions
+}
@@ -0,0 +1,45 @@
+package models
+
+import (
+	"time"
+
+	"github.com/imilchev/rpi-feeder/pkg/service/models"
+)
+
+type Telemetry struct {
+	Id                int `gorm:"primaryKey"`
+	ClientId          string
+	UptimeSeconds     uint64
+	CpuTemperature    *float64
+	FreeDiskBytes     uint64
+	UnflushedFeedLogs uint
+	MqttReconnects    uint
+	Timestamp         time.Time
+}
+
+// TableName overrides the pluralized table name used by gorm.
+func (Telemetry) TableName() string {
+	return "telemetry"
+}
+
+func (t Telemetry) ToApi(m *models.Telemetry) {
+	m.Id = t.Id
+	m.ClientId = t.ClientId
+	m.UptimeSeconds = t.UptimeSeconds
+	m.CpuTemperature = t.CpuTemperature
+	m.FreeDiskBytes = t.FreeDiskBytes
+	m.UnflushedFeedLogs = t.UnflushedFeedLogs
+	m.MqttReconnects = t.MqttReconnects
+	m.Timestamp = t.Timestamp.UTC().Unix()
+}
+
+func (t *Telemetry) FromApi(m models.Telemetry) {
+	t.Id = m.Id
+	t.ClientId = m.ClientId
+	t.UptimeSeconds = m.UptimeSeconds
+	t.CpuTemperature = m.CpuTemperature
+	t.FreeDiskBytes = m.FreeDiskBytes
+	t.UnflushedFeedLogs = m.UnflushedFeedLogs
+	t.MqttReconnects = m.MqttReconnects
+	t.Timestamp = time.Unix(m.Timestamp, 0)
+}
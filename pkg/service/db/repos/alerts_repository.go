@@ -0,0 +1,117 @@
+package repos
+
+import (
+	"strconv"
+	"time"
+
+	dbm "github.com/imilchev/rpi-feeder/pkg/service/db/models"
+	"github.com/imilchev/rpi-feeder/pkg/service/models"
+	"github.com/imilchev/rpi-feeder/pkg/utils"
+	"gorm.io/gorm"
+)
+
+type AlertsRepository interface {
+	CreateAlert(a models.Alert) (models.Alert, error)
+
+	// GetAlerts returns the alerts of all feeders. If activeOnly is set, only
+	// alerts that were neither acknowledged nor resolved are returned.
+	GetAlerts(activeOnly bool) ([]models.Alert, error)
+
+	// GetUnresolvedAlert returns the alert of the type for the feeder that was
+	// not resolved yet, regardless of whether it was acknowledged.
+	GetUnresolvedAlert(clientId string, t models.AlertType) (models.Alert, error)
+
+	// AcknowledgeAlert marks the alert as acknowledged at the UNIX timestamp.
+	// Alerts that were already acknowledged are not changed.
+	AcknowledgeAlert(id int, at int64) (models.Alert, error)
+
+	// ResolveAlerts marks all unresolved alerts of the type for the feeder as
+	// resolved at the UNIX timestamp.
+	ResolveAlerts(clientId string, t models.AlertType, at int64) error
+}
+
+type alertsRepository struct {
+	db *gorm.DB
+}
+
+func NewAlertsRepository(db *gorm.DB) AlertsRepository {
+	return &alertsRepository{db: db}
+}
+
+func (r *alertsRepository) CreateAlert(a models.Alert) (models.Alert, error) {
+	if err := utils.Validate.Struct(a); err != nil {
+		return models.Alert{}, models.NewValidationError(err.Error())
+	}
+
+	dbModel := &dbm.Alert{}
+	dbModel.FromApi(a)
+	dbModel.Id = 0
+	if res := r.db.Create(dbModel); res.Error != nil {
+		return models.Alert{}, res.Error
+	}
+	created := models.Alert{}
+	dbModel.ToApi(&created)
+	return created, nil
+}
+
+func (r *alertsRepository) GetAlerts(activeOnly bool) (a []models.Alert, err error) {
+	query := r.db
+	if activeOnly {
+		query = query.Where("acknowledged_at IS NULL AND resolved_at IS NULL")
+	}
+
+	var alerts []dbm.Alert
+	if res := query.Order("created_at").Find(&alerts); res.Error != nil {
+		return a, res.Error
+	}
+
+	a = make([]models.Alert, 0, len(alerts))
+	apiAlert := &models.Alert{}
+	for _, m := range alerts {
+		m.ToApi(apiAlert)
+		a = append(a, *apiAlert)
+	}
+	return a, nil
+}
+
+func (r *alertsRepository) GetUnresolvedAlert(clientId string, t models.AlertType) (models.Alert, error) {
+	a := dbm.Alert{}
+	res := r.db.Where("client_id = ? AND type = ? AND resolved_at IS NULL", clientId, string(t)).
+		Order("created_at DESC").Limit(1).Find(&a)
+	if res.Error != nil {
+		return models.Alert{}, res.Error
+	}
+	if res.RowsAffected == 0 {
+		return models.Alert{}, models.NewDoesNotExistError("Alert", "Type", string(t))
+	}
+
+	aApi := models.Alert{}
+	a.ToApi(&aApi)
+	return aApi, nil
+}
+
+func (r *alertsRepository) AcknowledgeAlert(id int, at int64) (models.Alert, error) {
+	dbModel := &dbm.Alert{}
+	if res := r.db.Where("id = ?", id).Find(dbModel); res.RowsAffected == 0 {
+		return models.Alert{}, models.NewDoesNotExistError("Alert", "Id", strconv.Itoa(id))
+	}
+
+	if dbModel.AcknowledgedAt == nil {
+		t := time.Unix(at, 0)
+		dbModel.AcknowledgedAt = &t
+		if res := r.db.Model(dbModel).Where("id = ?", id).
+			Select("acknowledged_at").Updates(dbModel); res.Error != nil {
+			return models.Alert{}, res.Error
+		}
+	}
+
+	a := models.Alert{}
+	dbModel.ToApi(&a)
+	return a, nil
+}
+
+func (r *alertsRepository) ResolveAlerts(clientId string, t models.AlertType, at int64) error {
+	return r.db.Model(&dbm.Alert{}).
+		Where("client_id = ? AND type = ? AND resolved_at IS NULL", clientId, string(t)).
+		Update("resolved_at", time.Unix(at, 0)).Error
+}
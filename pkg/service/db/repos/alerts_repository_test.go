@@ -0,0 +1,194 @@
+package repos
+
+import (
+	"math/rand"
+	"net/http"
+	"testing"
+	"time"
+
+	dbm "github.com/imilchev/rpi-feeder/pkg/service/db/models"
+	"github.com/imilchev/rpi-feeder/pkg/service/models"
+	"github.com/imilchev/rpi-feeder/tests/utils"
+	modelUtils "github.com/imilchev/rpi-feeder/tests/utils/models"
+	"github.com/stretchr/testify/suite"
+)
+
+type AlertsRepositorySuite struct {
+	suite.Suite
+	r *alertsRepository
+}
+
+func (suite *AlertsRepositorySuite) SetupTest() {
+	suite.Require().NoError(utils.InitTestDb())
+	db, err := utils.GetTestDb()
+	suite.Require().NoError(err)
+	suite.r = &alertsRepository{db: db}
+}
+
+func (suite *AlertsRepositorySuite) AfterTest(suiteName, testName string) {
+	suite.Require().NoError(utils.CleanupDb(suite.r.db))
+	db, err := suite.r.db.DB()
+	suite.Require().NoError(err)
+	db.Close()
+}
+
+func (suite *AlertsRepositorySuite) TestCreateAlert() {
+	f := modelUtils.RandomDbFeeder()
+	suite.NoError(suite.r.db.Create(&f).Error)
+
+	a := modelUtils.RandomAlertForFeeder(f.ClientId)
+	created, err := suite.r.CreateAlert(a)
+	suite.NoError(err)
+
+	// Do not compare IDs since they were generated by the database.
+	created.Id = 0
+	suite.Equal(a, created)
+}
+
+func (suite *AlertsRepositorySuite) TestCreateAlert_InvalidType() {
+	f := modelUtils.RandomDbFeeder()
+	suite.NoError(suite.r.db.Create(&f).Error)
+
+	a := modelUtils.RandomAlertForFeeder(f.ClientId)
+	a.Type = models.AlertType(utils.RandString(10))
+	_, err := suite.r.CreateAlert(a)
+	suite.Error(err)
+	apiErr, ok := err.(*models.ApiError)
+	suite.True(ok)
+	suite.Equal(http.StatusBadRequest, apiErr.Code())
+}
+
+func (suite *AlertsRepositorySuite) TestGetAlerts() {
+	_, alerts := suite.seedAlerts()
+
+	var expected []models.Alert
+	apiAlert := &models.Alert{}
+	for _, a := range alerts {
+		a.ToApi(apiAlert)
+		expected = append(expected, *apiAlert)
+	}
+
+	aa, err := suite.r.GetAlerts(false)
+	suite.NoError(err)
+	suite.ElementsMatch(expected, aa)
+}
+
+func (suite *AlertsRepositorySuite) TestGetAlerts_ActiveOnly() {
+	_, alerts := suite.seedAlerts()
+
+	var expected []models.Alert
+	apiAlert := &models.Alert{}
+	for _, a := range alerts {
+		a.ToApi(apiAlert)
+		if apiAlert.Active() {
+			expected = append(expected, *apiAlert)
+		}
+	}
+
+	aa, err := suite.r.GetAlerts(true)
+	suite.NoError(err)
+	suite.ElementsMatch(expected, aa)
+}
+
+func (suite *AlertsRepositorySuite) TestGetUnresolvedAlert() {
+	f := modelUtils.RandomDbFeeder()
+	suite.NoError(suite.r.db.Create(&f).Error)
+
+	a := modelUtils.RandomAlertForFeeder(f.ClientId)
+	created, err := suite.r.CreateAlert(a)
+	suite.NoError(err)
+
+	found, err := suite.r.GetUnresolvedAlert(f.ClientId, a.Type)
+	suite.NoError(err)
+	suite.Equal(created, found)
+}
+
+func (suite *AlertsRepositorySuite) TestGetUnresolvedAlert_Resolved() {
+	f := modelUtils.RandomDbFeeder()
+	suite.NoError(suite.r.db.Create(&f).Error)
+
+	a := modelUtils.RandomAlertForFeeder(f.ClientId)
+	_, err := suite.r.CreateAlert(a)
+	suite.NoError(err)
+	suite.NoError(suite.r.ResolveAlerts(f.ClientId, a.Type, time.Now().UTC().Unix()))
+
+	_, err = suite.r.GetUnresolvedAlert(f.ClientId, a.Type)
+	suite.Error(err)
+	apiErr, ok := err.(*models.ApiError)
+	suite.True(ok)
+	suite.Equal(http.StatusNotFound, apiErr.Code())
+}
+
+func (suite *AlertsRepositorySuite) TestAcknowledgeAlert() {
+	f := modelUtils.RandomDbFeeder()
+	suite.NoError(suite.r.db.Create(&f).Error)
+
+	created, err := suite.r.CreateAlert(modelUtils.RandomAlertForFeeder(f.ClientId))
+	suite.NoError(err)
+
+	at := time.Now().UTC().Unix()
+	acked, err := suite.r.AcknowledgeAlert(created.Id, at)
+	suite.NoError(err)
+	suite.Equal(at, *acked.AcknowledgedAt)
+
+	// Acknowledging again keeps the original timestamp.
+	acked, err = suite.r.AcknowledgeAlert(created.Id, at+10)
+	suite.NoError(err)
+	suite.Equal(at, *acked.AcknowledgedAt)
+}
+
+func (suite *AlertsRepositorySuite) TestAcknowledgeAlert_DoesNotExist() {
+	_, err := suite.r.AcknowledgeAlert(rand.Intn(1000)+1, time.Now().UTC().Unix())
+	suite.Error(err)
+	apiErr, ok := err.(*models.ApiError)
+	suite.True(ok)
+	suite.Equal(http.StatusNotFound, apiErr.Code())
+}
+
+func (suite *AlertsRepositorySuite) TestResolveAlerts() {
+	f := modelUtils.RandomDbFeeder()
+	suite.NoError(suite.r.db.Create(&f).Error)
+
+	low := modelUtils.RandomAlertForFeeder(f.ClientId)
+	low.Type = models.LowFoodAlertType
+	_, err := suite.r.CreateAlert(low)
+	suite.NoError(err)
+	failed := modelUtils.RandomAlertForFeeder(f.ClientId)
+	failed.Type = models.FeedFailedAlertType
+	_, err = suite.r.CreateAlert(failed)
+	suite.NoError(err)
+
+	at := time.Now().UTC().Unix()
+	suite.NoError(suite.r.ResolveAlerts(f.ClientId, models.LowFoodAlertType, at))
+
+	aa, err := suite.r.GetAlerts(false)
+	suite.NoError(err)
+	suite.Equal(2, len(aa))
+	for _, a := range aa {
+		if a.Type == models.LowFoodAlertType {
+			suite.Equal(at, *a.ResolvedAt)
+		} else {
+			suite.Nil(a.ResolvedAt)
+		}
+	}
+}
+
+func (suite *AlertsRepositorySuite) seedAlerts() (
+	feeders []dbm.Feeder, alerts []dbm.Alert) {
+	count := rand.Intn(10) + 1
+	for i := 0; i < count; i++ {
+		feeder := modelUtils.RandomDbFeeder()
+		feeders = append(feeders, feeder)
+		suite.NoError(suite.r.db.Create(&feeder).Error)
+
+		seed := modelUtils.RandomDbAlertsForFeeder(feeder.ClientId)
+		suite.NoError(suite.r.db.Create(&seed).Error)
+		alerts = append(alerts, seed...)
+	}
+	return feeders, alerts
+}
+
+func TestAlertsRepositorySuite(t *testing.T) {
+	rand.Seed(time.Now().UnixNano())
+	suite.Run(t, new(AlertsRepositorySuite))
+}
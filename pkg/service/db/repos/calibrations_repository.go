@@ -0,0 +1,53 @@
+package repos
+
+import (
+	dbm "github.com/imilchev/rpi-feeder/pkg/service/db/models"
+	"github.com/imilchev/rpi-feeder/pkg/service/models"
+	"github.com/imilchev/rpi-feeder/pkg/utils"
+	"gorm.io/gorm"
+)
+
+type CalibrationsRepository interface {
+	CreateCalibration(c models.Calibration) (models.Calibration, error)
+	GetCalibrationsForFeeder(clientId string) ([]models.Calibration, error)
+}
+
+type calibrationsRepository struct {
+	db *gorm.DB
+}
+
+func NewCalibrationsRepository(db *gorm.DB) CalibrationsRepository {
+	return &calibrationsRepository{db: db}
+}
+
+func (r *calibrationsRepository) CreateCalibration(c models.Calibration) (models.Calibration, error) {
+	if err := utils.Validate.Struct(c); err != nil {
+		return models.Calibration{}, models.NewValidationError(err.Error())
+	}
+
+	dbModel := &dbm.Calibration{}
+	dbModel.FromApi(c)
+	dbModel.Id = 0
+	if res := r.db.Create(dbModel); res.Error != nil {
+		return models.Calibration{}, res.Error
+	}
+	created := models.Calibration{}
+	dbModel.ToApi(&created)
+	return created, nil
+}
+
+func (r *calibrationsRepository) GetCalibrationsForFeeder(clientId string) (c []models.Calibration, err error) {
+	var calibrations []dbm.Calibration
+	if res := r.db.Where("client_id = ?", clientId).
+		Order("timestamp").Find(&calibrations); res.Error != nil {
+		return c, res.Error
+	}
+
+	c = make([]models.Calibration, 0, len(calibrations))
+	apiCalibration := &models.Calibration{}
+	for _, m := range calibrations {
+		m.ToApi(apiCalibration)
+		c = append(c, *apiCalibration)
+	}
+	return c, nil
+}
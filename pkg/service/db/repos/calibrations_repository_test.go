@@ -0,0 +1,103 @@
+package repos
+
+import (
+	"math/rand"
+	"net/http"
+	"testing"
+	"time"
+
+	dbm "github.com/imilchev/rpi-feeder/pkg/service/db/models"
+	"github.com/imilchev/rpi-feeder/pkg/service/models"
+	"github.com/imilchev/rpi-feeder/tests/utils"
+	modelUtils "github.com/imilchev/rpi-feeder/tests/utils/models"
+	"github.com/stretchr/testify/suite"
+)
+
+type CalibrationsRepositorySuite struct {
+	suite.Suite
+	r *calibrationsRepository
+}
+
+func (suite *CalibrationsRepositorySuite) SetupTest() {
+	suite.Require().NoError(utils.InitTestDb())
+	db, err := utils.GetTestDb()
+	suite.Require().NoError(err)
+	suite.r = &calibrationsRepository{db: db}
+}
+
+func (suite *CalibrationsRepositorySuite) AfterTest(suiteName, testName string) {
+	suite.Require().NoError(utils.CleanupDb(suite.r.db))
+	db, err := suite.r.db.DB()
+	suite.Require().NoError(err)
+	db.Close()
+}
+
+func (suite *CalibrationsRepositorySuite) TestCreateCalibration() {
+	f := modelUtils.RandomDbFeeder()
+	suite.NoError(suite.r.db.Create(&f).Error)
+
+	c := modelUtils.RandomCalibrationForFeeder(f.ClientId)
+	created, err := suite.r.CreateCalibration(c)
+	suite.NoError(err)
+
+	// Do not compare IDs since they were generated by the database.
+	created.Id = 0
+	suite.Equal(c, created)
+}
+
+func (suite *CalibrationsRepositorySuite) TestCreateCalibration_PortionMsMissing() {
+	f := modelUtils.RandomDbFeeder()
+	suite.NoError(suite.r.db.Create(&f).Error)
+
+	c := modelUtils.RandomCalibrationForFeeder(f.ClientId)
+	c.PortionMs = 0
+	_, err := suite.r.CreateCalibration(c)
+	suite.Error(err)
+	apiErr, ok := err.(*models.ApiError)
+	suite.True(ok)
+	suite.Equal(http.StatusBadRequest, apiErr.Code())
+}
+
+func (suite *CalibrationsRepositorySuite) TestGetCalibrationsForFeeder() {
+	feeders, calibrations := suite.seedCalibrations()
+	randFeeder := feeders[len(feeders)/2]
+
+	var expected []models.Calibration
+	apiCalibration := &models.Calibration{}
+	for _, c := range calibrations {
+		if c.ClientId == randFeeder.ClientId {
+			c.ToApi(apiCalibration)
+			expected = append(expected, *apiCalibration)
+		}
+	}
+
+	cc, err := suite.r.GetCalibrationsForFeeder(randFeeder.ClientId)
+	suite.NoError(err)
+	suite.ElementsMatch(expected, cc)
+}
+
+func (suite *CalibrationsRepositorySuite) TestGetCalibrationsForFeeder_NoCalibrations() {
+	cc, err := suite.r.GetCalibrationsForFeeder(utils.RandString(10))
+	suite.NoError(err)
+	suite.Equal(0, len(cc))
+}
+
+func (suite *CalibrationsRepositorySuite) seedCalibrations() (
+	feeders []dbm.Feeder, calibrations []dbm.Calibration) {
+	count := rand.Intn(10) + 1
+	for i := 0; i < count; i++ {
+		feeder := modelUtils.RandomDbFeeder()
+		feeders = append(feeders, feeder)
+		suite.NoError(suite.r.db.Create(&feeder).Error)
+
+		seed := modelUtils.RandomDbCalibrationsForFeeder(feeder.ClientId)
+		suite.NoError(suite.r.db.Create(&seed).Error)
+		calibrations = append(calibrations, seed...)
+	}
+	return feeders, calibrations
+}
+
+func TestCalibrationsRepositorySuite(t *testing.T) {
+	rand.Seed(time.Now().UnixNano())
+	suite.Run(t, new(CalibrationsRepositorySuite))
+}
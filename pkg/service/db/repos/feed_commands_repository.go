@@ -0,0 +1,93 @@
+package repos
+
+import (
+	"errors"
+
+	dbm "github.com/imilchev/rpi-feeder/pkg/service/db/models"
+	"github.com/imilchev/rpi-feeder/pkg/service/models"
+	"github.com/imilchev/rpi-feeder/pkg/utils"
+	"github.com/jackc/pgconn"
+	"gorm.io/gorm"
+)
+
+type FeedCommandsRepository interface {
+	CreateFeedCommand(c models.FeedCommand) (models.FeedCommand, error)
+	GetFeedCommand(clientId, id string) (models.FeedCommand, error)
+	UpdateFeedCommand(c models.FeedCommand) (models.FeedCommand, error)
+}
+
+type feedCommandsRepository struct {
+	db *gorm.DB
+}
+
+func NewFeedCommandsRepository(db *gorm.DB) FeedCommandsRepository {
+	return &feedCommandsRepository{db: db}
+}
+
+func (r *feedCommandsRepository) CreateFeedCommand(c models.FeedCommand) (models.FeedCommand, error) {
+	if err := utils.Validate.Struct(c); err != nil {
+		return models.FeedCommand{}, models.NewValidationError(err.Error())
+	}
+
+	if res := r.db.Where("client_id = ? AND id = ?", c.ClientId, c.Id).
+		Find(&dbm.FeedCommand{}); res.RowsAffected > 0 {
+		return models.FeedCommand{}, models.NewAlreadyExistsError("Command", "Id", c.Id)
+	}
+
+	dbModel := &dbm.FeedCommand{}
+	dbModel.FromApi(c)
+	if res := r.db.Create(dbModel); res.Error != nil {
+		// A concurrent request created the same command in the meantime.
+		if isUniqueViolation(res.Error) {
+			return models.FeedCommand{}, models.NewAlreadyExistsError("Command", "Id", c.Id)
+		}
+		return models.FeedCommand{}, res.Error
+	}
+	createdCommand := models.FeedCommand{}
+	dbModel.ToApi(&createdCommand)
+	return createdCommand, nil
+}
+
+// uniqueViolationCode is the Postgres error code for violating a unique
+// constraint, such as a primary key.
+const uniqueViolationCode = "23505"
+
+func isUniqueViolation(err error) bool {
+	var pgErr *pgconn.PgError
+	return errors.As(err, &pgErr) && pgErr.Code == uniqueViolationCode
+}
+
+func (r *feedCommandsRepository) GetFeedCommand(clientId, id string) (models.FeedCommand, error) {
+	c := dbm.FeedCommand{}
+	res := r.db.Where("client_id = ? AND id = ?", clientId, id).Find(&c)
+	if res.Error != nil {
+		return models.FeedCommand{}, res.Error
+	}
+	if res.RowsAffected == 0 {
+		return models.FeedCommand{}, models.NewDoesNotExistError("Command", "Id", id)
+	}
+
+	cApi := models.FeedCommand{}
+	c.ToApi(&cApi)
+	return cApi, nil
+}
+
+func (r *feedCommandsRepository) UpdateFeedCommand(c models.FeedCommand) (models.FeedCommand, error) {
+	if err := utils.Validate.Struct(c); err != nil {
+		return models.FeedCommand{}, models.NewValidationError(err.Error())
+	}
+
+	dbModel := &dbm.FeedCommand{}
+	if res := r.db.Where("client_id = ? AND id = ?", c.ClientId, c.Id).
+		Find(dbModel); res.RowsAffected == 0 {
+		return models.FeedCommand{}, models.NewDoesNotExistError("Command", "Id", c.Id)
+	}
+
+	dbModel.FromApi(c)
+	if res := r.db.Model(dbModel).Where("client_id = ? AND id = ?", c.ClientId, c.Id).
+		Select("status", "served_portions", "error", "completed_at").
+		Updates(dbModel); res.Error != nil {
+		return models.FeedCommand{}, res.Error
+	}
+	return c, nil
+}
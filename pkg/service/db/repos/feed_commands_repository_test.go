@@ -0,0 +1,144 @@
+package repos
+
+import (
+	"fmt"
+	"math/rand"
+	"net/http"
+	"testing"
+	"time"
+
+	dbm "github.com/imilchev/rpi-feeder/pkg/service/db/models"
+	"github.com/imilchev/rpi-feeder/pkg/service/models"
+	"github.com/imilchev/rpi-feeder/tests/utils"
+	modelUtils "github.com/imilchev/rpi-feeder/tests/utils/models"
+	"github.com/stretchr/testify/suite"
+)
+
+type FeedCommandsRepositorySuite struct {
+	suite.Suite
+	r *feedCommandsRepository
+}
+
+func (suite *FeedCommandsRepositorySuite) SetupTest() {
+	suite.Require().NoError(utils.InitTestDb())
+	db, err := utils.GetTestDb()
+	suite.Require().NoError(err)
+	suite.r = &feedCommandsRepository{db: db}
+}
+
+func (suite *FeedCommandsRepositorySuite) AfterTest(suiteName, testName string) {
+	suite.Require().NoError(utils.CleanupDb(suite.r.db))
+	db, err := suite.r.db.DB()
+	suite.Require().NoError(err)
+	db.Close()
+}
+
+func (suite *FeedCommandsRepositorySuite) TestCreateFeedCommand() {
+	f := modelUtils.RandomDbFeeder()
+	suite.NoError(suite.r.db.Create(&f).Error)
+
+	c := modelUtils.RandomFeedCommandForFeeder(f.ClientId)
+	cc, err := suite.r.CreateFeedCommand(c)
+	suite.NoError(err)
+	suite.Equal(c, cc)
+
+	cDb := &dbm.FeedCommand{}
+	suite.NoError(suite.r.db.First(cDb, "id = ?", c.Id).Error)
+	cDb.ToApi(&cc)
+	suite.Equal(c, cc)
+}
+
+func (suite *FeedCommandsRepositorySuite) TestCreateFeedCommand_AlreadyExists() {
+	f := modelUtils.RandomDbFeeder()
+	suite.NoError(suite.r.db.Create(&f).Error)
+	c := modelUtils.RandomDbFeedCommandForFeeder(f.ClientId)
+	suite.NoError(suite.r.db.Create(&c).Error)
+
+	c2 := models.FeedCommand{}
+	c.ToApi(&c2)
+	_, err := suite.r.CreateFeedCommand(c2)
+	suite.Error(err)
+	apiErr, ok := err.(*models.ApiError)
+	suite.True(ok)
+	suite.Equal(http.StatusConflict, apiErr.Code())
+	suite.Equal(fmt.Sprintf("Command with Id %s already exists.", c.Id), apiErr.Error())
+}
+
+func (suite *FeedCommandsRepositorySuite) TestCreateFeedCommand_InvalidStatus() {
+	f := modelUtils.RandomDbFeeder()
+	suite.NoError(suite.r.db.Create(&f).Error)
+
+	c := modelUtils.RandomFeedCommandForFeeder(f.ClientId)
+	c.Status = models.CommandStatus(utils.RandString(5))
+	_, err := suite.r.CreateFeedCommand(c)
+	suite.Error(err)
+	apiErr, ok := err.(*models.ApiError)
+	suite.True(ok)
+	suite.Equal(http.StatusBadRequest, apiErr.Code())
+}
+
+func (suite *FeedCommandsRepositorySuite) TestGetFeedCommand() {
+	f := modelUtils.RandomDbFeeder()
+	suite.NoError(suite.r.db.Create(&f).Error)
+	c := modelUtils.RandomDbFeedCommandForFeeder(f.ClientId)
+	suite.NoError(suite.r.db.Create(&c).Error)
+
+	expected := models.FeedCommand{}
+	c.ToApi(&expected)
+
+	cc, err := suite.r.GetFeedCommand(f.ClientId, c.Id)
+	suite.NoError(err)
+	suite.Equal(expected, cc)
+}
+
+func (suite *FeedCommandsRepositorySuite) TestGetFeedCommand_WrongFeeder() {
+	f := modelUtils.RandomDbFeeder()
+	suite.NoError(suite.r.db.Create(&f).Error)
+	c := modelUtils.RandomDbFeedCommandForFeeder(f.ClientId)
+	suite.NoError(suite.r.db.Create(&c).Error)
+
+	_, err := suite.r.GetFeedCommand(utils.RandString(10), c.Id)
+	suite.Error(err)
+	apiErr, ok := err.(*models.ApiError)
+	suite.True(ok)
+	suite.Equal(http.StatusNotFound, apiErr.Code())
+	suite.Equal(fmt.Sprintf("Command with Id %s does not exist.", c.Id), apiErr.Error())
+}
+
+func (suite *FeedCommandsRepositorySuite) TestUpdateFeedCommand() {
+	f := modelUtils.RandomDbFeeder()
+	suite.NoError(suite.r.db.Create(&f).Error)
+	c := modelUtils.RandomDbFeedCommandForFeeder(f.ClientId)
+	suite.NoError(suite.r.db.Create(&c).Error)
+
+	cApi := models.FeedCommand{}
+	c.ToApi(&cApi)
+	t := time.Now().UTC().Unix()
+	cApi.Status = models.FailedCommandStatus
+	cApi.Error = utils.RandString(20)
+	cApi.CompletedAt = &t
+
+	cc, err := suite.r.UpdateFeedCommand(cApi)
+	suite.NoError(err)
+	suite.Equal(cApi, cc)
+
+	cDb := &dbm.FeedCommand{}
+	suite.NoError(suite.r.db.First(cDb, "id = ?", c.Id).Error)
+	cDb.ToApi(&cc)
+	suite.Equal(cApi, cc)
+}
+
+func (suite *FeedCommandsRepositorySuite) TestUpdateFeedCommand_DoesNotExist() {
+	c := modelUtils.RandomFeedCommandForFeeder(utils.RandString(10))
+
+	_, err := suite.r.UpdateFeedCommand(c)
+	suite.Error(err)
+	apiErr, ok := err.(*models.ApiError)
+	suite.True(ok)
+	suite.Equal(http.StatusNotFound, apiErr.Code())
+}
+
+func TestFeedCommandsRepositorySuite(t *testing.T) {
+	rand.Seed(time.Now().UnixNano())
+	suite.Run(t, new(FeedCommandsRepositorySuite))
+}
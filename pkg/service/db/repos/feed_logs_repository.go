@@ -1,6 +1,8 @@
 package repos
 
 import (
+	"time"
+
 	dbm "github.com/imilchev/rpi-feeder/pkg/service/db/models"
 	"github.com/imilchev/rpi-feeder/pkg/service/models"
 	"github.com/imilchev/rpi-feeder/pkg/utils"
@@ -10,6 +12,10 @@ import (
 type FeedLogsRepository interface {
 	CreateFeedLogs(f []models.FeedLog) ([]models.FeedLog, error)
 	GetLogsForFeeder(clientId string) ([]models.FeedLog, error)
+
+	// GetLogsForFeederSince returns the logs of the feeder with a timestamp
+	// after the since UNIX timestamp.
+	GetLogsForFeederSince(clientId string, since int64) ([]models.FeedLog, error)
 }
 
 type feedLogsRepository struct {
@@ -60,3 +66,19 @@ func (r *feedLogsRepository) GetLogsForFeeder(clientId string) (f []models.FeedL
 	}
 	return f, nil
 }
+
+func (r *feedLogsRepository) GetLogsForFeederSince(clientId string, since int64) (f []models.FeedLog, err error) {
+	var feedLogs []dbm.FeedLog
+	if res := r.db.Where("client_id = ? AND timestamp > ?", clientId, time.Unix(since, 0)).
+		Find(&feedLogs); res.Error != nil {
+		return f, res.Error
+	}
+
+	f = make([]models.FeedLog, 0, len(feedLogs))
+	apiFeedLog := &models.FeedLog{}
+	for _, c := range feedLogs {
+		c.ToApi(apiFeedLog)
+		f = append(f, *apiFeedLog)
+	}
+	return f, nil
+}
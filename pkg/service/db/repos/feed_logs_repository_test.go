@@ -87,6 +87,19 @@ func (suite *FeedLogsRepositorySuite) TestCreateFeedLogs_PortionsMissing() {
 	suite.Equal(http.StatusBadRequest, apiErr.Code())
 }
 
+func (suite *FeedLogsRepositorySuite) TestCreateFeedLogs_GramsNegative() {
+	f := modelUtils.RandomDbFeeder()
+	suite.NoError(suite.r.db.Create(&f).Error)
+
+	l := modelUtils.RandomFeedLogForFeeder(f.ClientId)
+	l.Grams = -1
+	_, err := suite.r.CreateFeedLogs([]models.FeedLog{l})
+	suite.Error(err)
+	apiErr, ok := err.(*models.ApiError)
+	suite.True(ok)
+	suite.Equal(http.StatusBadRequest, apiErr.Code())
+}
+
 func (suite *FeedLogsRepositorySuite) TestCreateFeedLogs_TimestampMissing() {
 	f := modelUtils.RandomDbFeeder()
 	suite.NoError(suite.r.db.Create(&f).Error)
@@ -130,6 +143,31 @@ func (suite *FeedLogsRepositorySuite) TestGetFeedLogs_DoesNotExist() {
 		fmt.Sprintf("Feeder with ClientId %s does not exist.", clientId), apiErr.Error())
 }
 
+func (suite *FeedLogsRepositorySuite) TestGetFeedLogsSince() {
+	f := modelUtils.RandomDbFeeder()
+	suite.NoError(suite.r.db.Create(&f).Error)
+
+	now := time.Unix(time.Now().UTC().Unix(), 0)
+	logs := []dbm.FeedLog{
+		{ClientId: f.ClientId, Portions: 1, Timestamp: now.Add(-48 * time.Hour)},
+		{ClientId: f.ClientId, Portions: 2, Timestamp: now.Add(-time.Hour)},
+	}
+	suite.NoError(suite.r.db.Create(&logs).Error)
+
+	expected := models.FeedLog{}
+	logs[1].ToApi(&expected)
+
+	feedLogs, err := suite.r.GetLogsForFeederSince(f.ClientId, now.Add(-24*time.Hour).Unix())
+	suite.NoError(err)
+	suite.Equal([]models.FeedLog{expected}, feedLogs)
+}
+
+func (suite *FeedLogsRepositorySuite) TestGetFeedLogsSince_NoLogs() {
+	feedLogs, err := suite.r.GetLogsForFeederSince(utils.RandString(10), 0)
+	suite.NoError(err)
+	suite.Equal(0, len(feedLogs))
+}
+
 func (suite *FeedLogsRepositorySuite) seedFeedLogs() (feeders []dbm.Feeder, feedLogs []dbm.FeedLog) {
 	count := rand.Intn(10) + 1
 	for i := 0; i < count; i++ {
@@ -0,0 +1,68 @@
+package repos
+
+import (
+	dbm "github.com/imilchev/rpi-feeder/pkg/service/db/models"
+	"github.com/imilchev/rpi-feeder/pkg/service/models"
+	"github.com/imilchev/rpi-feeder/pkg/utils"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type FeederConfigsRepository interface {
+	GetFeederConfig(clientId string) (models.FeederConfig, error)
+
+	// SetFeederConfig stores the config of a feeder, replacing the previous
+	// one. The revision of c is ignored, the stored config gets the revision
+	// following the previous one.
+	SetFeederConfig(c models.FeederConfig) (models.FeederConfig, error)
+}
+
+type feederConfigsRepository struct {
+	db *gorm.DB
+}
+
+func NewFeederConfigsRepository(db *gorm.DB) FeederConfigsRepository {
+	return &feederConfigsRepository{db: db}
+}
+
+func (r *feederConfigsRepository) GetFeederConfig(clientId string) (models.FeederConfig, error) {
+	c := dbm.FeederConfig{}
+	if res := r.db.Where("client_id = ?", clientId).Find(&c); res.RowsAffected == 0 {
+		return models.FeederConfig{}, models.NewDoesNotExistError("FeederConfig", "ClientId", clientId)
+	}
+
+	cApi := models.FeederConfig{}
+	c.ToApi(&cApi)
+	return cApi, nil
+}
+
+func (r *feederConfigsRepository) SetFeederConfig(c models.FeederConfig) (models.FeederConfig, error) {
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		// Lock the feeder, such that concurrent changes do not get the same
+		// revision.
+		if res := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("client_id = ?", c.ClientId).Find(&dbm.Feeder{}); res.RowsAffected == 0 {
+			return models.NewDoesNotExistError("Feeder", "ClientId", c.ClientId)
+		}
+
+		previous := dbm.FeederConfig{}
+		exists := tx.Where("client_id = ?", c.ClientId).Find(&previous).RowsAffected > 0
+		c.Revision = previous.Revision + 1
+		if err := utils.Validate.Struct(c); err != nil {
+			return models.NewValidationError(err.Error())
+		}
+
+		dbModel := &dbm.FeederConfig{}
+		dbModel.FromApi(c)
+		if !exists {
+			return tx.Create(dbModel).Error
+		}
+		return tx.Model(dbModel).Where("client_id = ?", c.ClientId).
+			Select("revision", "config", "updated_at").
+			Updates(dbModel).Error
+	})
+	if err != nil {
+		return models.FeederConfig{}, err
+	}
+	return c, nil
+}
@@ -0,0 +1,108 @@
+package repos
+
+import (
+	"net/http"
+	"testing"
+
+	dbm "github.com/imilchev/rpi-feeder/pkg/service/db/models"
+	"github.com/imilchev/rpi-feeder/pkg/service/models"
+	"github.com/imilchev/rpi-feeder/tests/utils"
+	modelUtils "github.com/imilchev/rpi-feeder/tests/utils/models"
+	"github.com/stretchr/testify/suite"
+)
+
+type FeederConfigsRepositorySuite struct {
+	suite.Suite
+	r *feederConfigsRepository
+}
+
+func (suite *FeederConfigsRepositorySuite) SetupTest() {
+	suite.Require().NoError(utils.InitTestDb())
+	db, err := utils.GetTestDb()
+	suite.Require().NoError(err)
+	suite.r = &feederConfigsRepository{db: db}
+}
+
+func (suite *FeederConfigsRepositorySuite) AfterTest(suiteName, testName string) {
+	suite.Require().NoError(utils.CleanupDb(suite.r.db))
+	db, err := suite.r.db.DB()
+	suite.Require().NoError(err)
+	db.Close()
+}
+
+func (suite *FeederConfigsRepositorySuite) TestGetFeederConfig() {
+	f := modelUtils.RandomDbFeeder()
+	suite.NoError(suite.r.db.Create(&f).Error)
+	c := modelUtils.RandomDbFeederConfigForFeeder(f.ClientId)
+	suite.NoError(suite.r.db.Create(&c).Error)
+
+	expected := models.FeederConfig{}
+	c.ToApi(&expected)
+
+	cc, err := suite.r.GetFeederConfig(f.ClientId)
+	suite.NoError(err)
+	suite.JSONEq(string(expected.Config), string(cc.Config))
+	expected.Config, cc.Config = nil, nil
+	suite.Equal(expected, cc)
+}
+
+func (suite *FeederConfigsRepositorySuite) TestGetFeederConfig_DoesNotExist() {
+	f := modelUtils.RandomDbFeeder()
+	suite.NoError(suite.r.db.Create(&f).Error)
+
+	_, err := suite.r.GetFeederConfig(f.ClientId)
+	suite.Error(err)
+	apiErr, ok := err.(*models.ApiError)
+	suite.True(ok)
+	suite.Equal(http.StatusNotFound, apiErr.Code())
+}
+
+func (suite *FeederConfigsRepositorySuite) TestSetFeederConfig() {
+	f := modelUtils.RandomDbFeeder()
+	suite.NoError(suite.r.db.Create(&f).Error)
+
+	c := modelUtils.RandomFeederConfigForFeeder(f.ClientId)
+	cc, err := suite.r.SetFeederConfig(c)
+	suite.NoError(err)
+	c.Revision = 1
+	suite.Equal(c, cc)
+
+	// The revision increases with every change.
+	c = modelUtils.RandomFeederConfigForFeeder(f.ClientId)
+	cc, err = suite.r.SetFeederConfig(c)
+	suite.NoError(err)
+	c.Revision = 2
+	suite.Equal(c, cc)
+
+	cDb := &dbm.FeederConfig{}
+	suite.NoError(suite.r.db.First(cDb, "client_id = ?", f.ClientId).Error)
+	cDb.ToApi(&cc)
+	suite.JSONEq(string(c.Config), string(cc.Config))
+	suite.Equal(2, cc.Revision)
+}
+
+func (suite *FeederConfigsRepositorySuite) TestSetFeederConfig_FeederDoesNotExist() {
+	c := modelUtils.RandomFeederConfigForFeeder("missing")
+	_, err := suite.r.SetFeederConfig(c)
+	suite.Error(err)
+	apiErr, ok := err.(*models.ApiError)
+	suite.True(ok)
+	suite.Equal(http.StatusNotFound, apiErr.Code())
+}
+
+func (suite *FeederConfigsRepositorySuite) TestSetFeederConfig_Invalid() {
+	f := modelUtils.RandomDbFeeder()
+	suite.NoError(suite.r.db.Create(&f).Error)
+
+	c := modelUtils.RandomFeederConfigForFeeder(f.ClientId)
+	c.Config = nil
+	_, err := suite.r.SetFeederConfig(c)
+	suite.Error(err)
+	apiErr, ok := err.(*models.ApiError)
+	suite.True(ok)
+	suite.Equal(http.StatusBadRequest, apiErr.Code())
+}
+
+func TestFeederConfigsRepositorySuite(t *testing.T) {
+	suite.Run(t, new(FeederConfigsRepositorySuite))
+}
@@ -12,6 +12,9 @@ type FeedersRepository interface {
 	GetFeeders() ([]models.Feeder, error)
 	GetFeederByClientId(cId string) (models.Feeder, error)
 	UpdateFeeder(f models.Feeder) (models.Feeder, error)
+
+	// UpdateFeederTelemetry stores t as the latest telemetry of its feeder.
+	UpdateFeederTelemetry(t models.Telemetry) error
 }
 
 type feedersRepository struct {
@@ -76,9 +79,27 @@ func (r *feedersRepository) UpdateFeeder(f models.Feeder) (models.Feeder, error)
 
 	dbModel.FromApi(f)
 	if res := r.db.Model(dbModel).Where("client_id = ?", f.ClientId).
-		Select("status", "last_online", "software_version").
+		Select("status", "last_online", "software_version",
+			"config_revision", "config_pending_restart", "config_rejected_revision", "config_error").
 		Updates(dbModel); res.Error != nil {
 		return models.Feeder{}, res.Error
 	}
 	return f, nil
 }
+
+func (r *feedersRepository) UpdateFeederTelemetry(t models.Telemetry) error {
+	if err := utils.Validate.Struct(t); err != nil {
+		return models.NewValidationError(err.Error())
+	}
+
+	dbModel := &dbm.Feeder{}
+	if res := r.db.Where("client_id = ?", t.ClientId).Find(dbModel); res.RowsAffected == 0 {
+		return models.NewDoesNotExistError("Feeder", "ClientId", t.ClientId)
+	}
+
+	dbModel.SetTelemetry(&t)
+	return r.db.Model(dbModel).Where("client_id = ?", t.ClientId).
+		Select("uptime_seconds", "cpu_temperature", "free_disk_bytes",
+			"unflushed_feed_logs", "mqtt_reconnects", "telemetry_at").
+		Updates(dbModel).Error
+}
@@ -169,7 +169,9 @@ func (suite *FeedersRepositorySuite) TestUpdateFeeder_OnlineToOffline() {
 	f := modelUtils.RandomFeeder()
 	f.Status = model.OnlineStatus
 	f.LastOnline = nil
-	suite.NoError(suite.r.db.Create(f).Error)
+	created := dbm.Feeder{}
+	created.FromApi(f)
+	suite.NoError(suite.r.db.Create(&created).Error)
 
 	t := time.Now().UTC().Unix()
 	f.LastOnline = &t
@@ -207,6 +209,26 @@ func (suite *FeedersRepositorySuite) TestUpdateFeeder_OfflineToOnline() {
 	suite.Equal(fApi, ff)
 }
 
+func (suite *FeedersRepositorySuite) TestUpdateFeeder_ConfigRevision() {
+	f := modelUtils.RandomFeeder()
+	created := dbm.Feeder{}
+	created.FromApi(f)
+	suite.NoError(suite.r.db.Create(&created).Error)
+
+	f.ConfigRevision = 3
+	f.ConfigPendingRestart = true
+	f.ConfigRejectedRevision = 4
+	f.ConfigError = "failed to connect"
+	ff, err := suite.r.UpdateFeeder(f)
+	suite.NoError(err)
+	suite.Equal(f, ff)
+
+	fDb := &dbm.Feeder{}
+	suite.NoError(suite.r.db.First(fDb, "client_id = ?", f.ClientId).Error)
+	fDb.ToApi(&ff)
+	suite.Equal(f, ff)
+}
+
 func (suite *FeedersRepositorySuite) TestUpdateFeeder_DoesNotExist() {
 	f := modelUtils.RandomFeeder()
 
@@ -219,6 +241,29 @@ func (suite *FeedersRepositorySuite) TestUpdateFeeder_DoesNotExist() {
 		fmt.Sprintf("Feeder with ClientId %s does not exist.", f.ClientId), apiErr.Error())
 }
 
+func (suite *FeedersRepositorySuite) TestUpdateFeederTelemetry() {
+	f := modelUtils.RandomDbFeeder()
+	suite.NoError(suite.r.db.Create(&f).Error)
+
+	t := modelUtils.RandomTelemetryForFeeder(f.ClientId)
+	suite.NoError(suite.r.UpdateFeederTelemetry(t))
+
+	fDb := &dbm.Feeder{}
+	suite.NoError(suite.r.db.First(fDb, "client_id = ?", f.ClientId).Error)
+	ff := models.Feeder{}
+	fDb.ToApi(&ff)
+	suite.Equal(&t, ff.Telemetry)
+	suite.Equal(f.Status, string(ff.Status))
+}
+
+func (suite *FeedersRepositorySuite) TestUpdateFeederTelemetry_DoesNotExist() {
+	err := suite.r.UpdateFeederTelemetry(modelUtils.RandomTelemetryForFeeder(utils.RandString(10)))
+	suite.Error(err)
+	apiErr, ok := err.(*models.ApiError)
+	suite.True(ok)
+	suite.Equal(http.StatusNotFound, apiErr.Code())
+}
+
 func (suite *FeedersRepositorySuite) seedFeeders() (feeders []dbm.Feeder) {
 	count := rand.Intn(20) + 1
 	for i := 0; i < count; i++ {
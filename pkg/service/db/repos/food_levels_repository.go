@@ -0,0 +1,53 @@
+package repos
+
+import (
+	dbm "github.com/imilchev/rpi-feeder/pkg/service/db/models"
+	"github.com/imilchev/rpi-feeder/pkg/service/models"
+	"github.com/imilchev/rpi-feeder/pkg/utils"
+	"gorm.io/gorm"
+)
+
+type FoodLevelsRepository interface {
+	CreateFoodLevel(l models.FoodLevel) (models.FoodLevel, error)
+	GetFoodLevelsForFeeder(clientId string) ([]models.FoodLevel, error)
+}
+
+type foodLevelsRepository struct {
+	db *gorm.DB
+}
+
+func NewFoodLevelsRepository(db *gorm.DB) FoodLevelsRepository {
+	return &foodLevelsRepository{db: db}
+}
+
+func (r *foodLevelsRepository) CreateFoodLevel(l models.FoodLevel) (models.FoodLevel, error) {
+	if err := utils.Validate.Struct(l); err != nil {
+		return models.FoodLevel{}, models.NewValidationError(err.Error())
+	}
+
+	dbModel := &dbm.FoodLevel{}
+	dbModel.FromApi(l)
+	dbModel.Id = 0
+	if res := r.db.Create(dbModel); res.Error != nil {
+		return models.FoodLevel{}, res.Error
+	}
+	created := models.FoodLevel{}
+	dbModel.ToApi(&created)
+	return created, nil
+}
+
+func (r *foodLevelsRepository) GetFoodLevelsForFeeder(clientId string) (l []models.FoodLevel, err error) {
+	var levels []dbm.FoodLevel
+	if res := r.db.Where("client_id = ?", clientId).
+		Order("timestamp").Find(&levels); res.Error != nil {
+		return l, res.Error
+	}
+
+	l = make([]models.FoodLevel, 0, len(levels))
+	apiLevel := &models.FoodLevel{}
+	for _, c := range levels {
+		c.ToApi(apiLevel)
+		l = append(l, *apiLevel)
+	}
+	return l, nil
+}
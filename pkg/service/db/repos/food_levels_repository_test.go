@@ -0,0 +1,103 @@
+package repos
+
+import (
+	"math/rand"
+	"net/http"
+	"testing"
+	"time"
+
+	dbm "github.com/imilchev/rpi-feeder/pkg/service/db/models"
+	"github.com/imilchev/rpi-feeder/pkg/service/models"
+	"github.com/imilchev/rpi-feeder/tests/utils"
+	modelUtils "github.com/imilchev/rpi-feeder/tests/utils/models"
+	"github.com/stretchr/testify/suite"
+)
+
+type FoodLevelsRepositorySuite struct {
+	suite.Suite
+	r *foodLevelsRepository
+}
+
+func (suite *FoodLevelsRepositorySuite) SetupTest() {
+	suite.Require().NoError(utils.InitTestDb())
+	db, err := utils.GetTestDb()
+	suite.Require().NoError(err)
+	suite.r = &foodLevelsRepository{db: db}
+}
+
+func (suite *FoodLevelsRepositorySuite) AfterTest(suiteName, testName string) {
+	suite.Require().NoError(utils.CleanupDb(suite.r.db))
+	db, err := suite.r.db.DB()
+	suite.Require().NoError(err)
+	db.Close()
+}
+
+func (suite *FoodLevelsRepositorySuite) TestCreateFoodLevel() {
+	f := modelUtils.RandomDbFeeder()
+	suite.NoError(suite.r.db.Create(&f).Error)
+
+	l := modelUtils.RandomFoodLevelForFeeder(f.ClientId)
+	created, err := suite.r.CreateFoodLevel(l)
+	suite.NoError(err)
+
+	// Do not compare IDs since they were generated by the database.
+	created.Id = 0
+	suite.Equal(l, created)
+}
+
+func (suite *FoodLevelsRepositorySuite) TestCreateFoodLevel_LevelOutOfRange() {
+	f := modelUtils.RandomDbFeeder()
+	suite.NoError(suite.r.db.Create(&f).Error)
+
+	l := modelUtils.RandomFoodLevelForFeeder(f.ClientId)
+	l.Level = 101
+	_, err := suite.r.CreateFoodLevel(l)
+	suite.Error(err)
+	apiErr, ok := err.(*models.ApiError)
+	suite.True(ok)
+	suite.Equal(http.StatusBadRequest, apiErr.Code())
+}
+
+func (suite *FoodLevelsRepositorySuite) TestGetFoodLevelsForFeeder() {
+	feeders, levels := suite.seedFoodLevels()
+	randFeeder := feeders[len(feeders)/2]
+
+	var expected []models.FoodLevel
+	apiLevel := &models.FoodLevel{}
+	for _, l := range levels {
+		if l.ClientId == randFeeder.ClientId {
+			l.ToApi(apiLevel)
+			expected = append(expected, *apiLevel)
+		}
+	}
+
+	ll, err := suite.r.GetFoodLevelsForFeeder(randFeeder.ClientId)
+	suite.NoError(err)
+	suite.ElementsMatch(expected, ll)
+}
+
+func (suite *FoodLevelsRepositorySuite) TestGetFoodLevelsForFeeder_NoFoodLevels() {
+	ll, err := suite.r.GetFoodLevelsForFeeder(utils.RandString(10))
+	suite.NoError(err)
+	suite.Equal(0, len(ll))
+}
+
+func (suite *FoodLevelsRepositorySuite) seedFoodLevels() (
+	feeders []dbm.Feeder, levels []dbm.FoodLevel) {
+	count := rand.Intn(10) + 1
+	for i := 0; i < count; i++ {
+		feeder := modelUtils.RandomDbFeeder()
+		feeders = append(feeders, feeder)
+		suite.NoError(suite.r.db.Create(&feeder).Error)
+
+		seed := modelUtils.RandomDbFoodLevelsForFeeder(feeder.ClientId)
+		suite.NoError(suite.r.db.Create(&seed).Error)
+		levels = append(levels, seed...)
+	}
+	return feeders, levels
+}
+
+func TestFoodLevelsRepositorySuite(t *testing.T) {
+	rand.Seed(time.Now().UnixNano())
+	suite.Run(t, new(FoodLevelsRepositorySuite))
+}
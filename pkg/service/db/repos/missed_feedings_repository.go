@@ -0,0 +1,66 @@
+package repos
+
+import (
+	dbm "github.com/imilchev/rpi-feeder/pkg/service/db/models"
+	"github.com/imilchev/rpi-feeder/pkg/service/models"
+	"github.com/imilchev/rpi-feeder/pkg/utils"
+	"gorm.io/gorm"
+)
+
+type MissedFeedingsRepository interface {
+	CreateMissedFeedings(f []models.MissedFeeding) ([]models.MissedFeeding, error)
+	GetMissedFeedingsForFeeder(clientId string) ([]models.MissedFeeding, error)
+}
+
+type missedFeedingsRepository struct {
+	db *gorm.DB
+}
+
+func NewMissedFeedingsRepository(db *gorm.DB) MissedFeedingsRepository {
+	return &missedFeedingsRepository{db: db}
+}
+
+func (r *missedFeedingsRepository) CreateMissedFeedings(
+	f []models.MissedFeeding) ([]models.MissedFeeding, error) {
+	for _, v := range f {
+		if err := utils.Validate.Struct(v); err != nil {
+			return []models.MissedFeeding{}, models.NewValidationError(err.Error())
+		}
+	}
+
+	var dbModels []dbm.MissedFeeding
+	for _, m := range f {
+		dbm := &dbm.MissedFeeding{}
+		dbm.FromApi(m)
+		dbModels = append(dbModels, *dbm)
+	}
+
+	if res := r.db.CreateInBatches(dbModels, 100); res.Error != nil {
+		return []models.MissedFeeding{}, res.Error
+	}
+
+	f = make([]models.MissedFeeding, 0)
+	for _, m := range dbModels {
+		created := models.MissedFeeding{}
+		m.ToApi(&created)
+		f = append(f, created)
+	}
+	return f, nil
+}
+
+func (r *missedFeedingsRepository) GetMissedFeedingsForFeeder(
+	clientId string) (f []models.MissedFeeding, err error) {
+	var missed []dbm.MissedFeeding
+	if res := r.db.Where("client_id = ?", clientId).
+		Order("scheduled_at").Find(&missed); res.Error != nil {
+		return f, res.Error
+	}
+
+	f = make([]models.MissedFeeding, 0, len(missed))
+	apiMissed := &models.MissedFeeding{}
+	for _, c := range missed {
+		c.ToApi(apiMissed)
+		f = append(f, *apiMissed)
+	}
+	return f, nil
+}
@@ -0,0 +1,118 @@
+package repos
+
+import (
+	"math/rand"
+	"net/http"
+	"testing"
+	"time"
+
+	dbm "github.com/imilchev/rpi-feeder/pkg/service/db/models"
+	"github.com/imilchev/rpi-feeder/pkg/service/models"
+	"github.com/imilchev/rpi-feeder/tests/utils"
+	modelUtils "github.com/imilchev/rpi-feeder/tests/utils/models"
+	"github.com/stretchr/testify/suite"
+)
+
+type MissedFeedingsRepositorySuite struct {
+	suite.Suite
+	r *missedFeedingsRepository
+}
+
+func (suite *MissedFeedingsRepositorySuite) SetupTest() {
+	suite.Require().NoError(utils.InitTestDb())
+	db, err := utils.GetTestDb()
+	suite.Require().NoError(err)
+	suite.r = &missedFeedingsRepository{db: db}
+}
+
+func (suite *MissedFeedingsRepositorySuite) AfterTest(suiteName, testName string) {
+	suite.Require().NoError(utils.CleanupDb(suite.r.db))
+	db, err := suite.r.db.DB()
+	suite.Require().NoError(err)
+	db.Close()
+}
+
+func (suite *MissedFeedingsRepositorySuite) TestCreateMissedFeedings() {
+	f := modelUtils.RandomDbFeeder()
+	suite.NoError(suite.r.db.Create(&f).Error)
+
+	missed := modelUtils.RandomMissedFeedingsForFeeder(f.ClientId)
+	mm, err := suite.r.CreateMissedFeedings(missed)
+	suite.NoError(err)
+
+	// Do not compare IDs since they were generated by the database.
+	for i := range mm {
+		mm[i].Id = 0
+	}
+	suite.Equal(missed, mm)
+}
+
+func (suite *MissedFeedingsRepositorySuite) TestCreateMissedFeedings_PortionsMissing() {
+	f := modelUtils.RandomDbFeeder()
+	suite.NoError(suite.r.db.Create(&f).Error)
+
+	m := modelUtils.RandomMissedFeedingForFeeder(f.ClientId)
+	m.Portions = 0
+	_, err := suite.r.CreateMissedFeedings([]models.MissedFeeding{m})
+	suite.Error(err)
+	apiErr, ok := err.(*models.ApiError)
+	suite.True(ok)
+	suite.Equal(http.StatusBadRequest, apiErr.Code())
+}
+
+func (suite *MissedFeedingsRepositorySuite) TestCreateMissedFeedings_ScheduledAtMissing() {
+	f := modelUtils.RandomDbFeeder()
+	suite.NoError(suite.r.db.Create(&f).Error)
+
+	m := modelUtils.RandomMissedFeedingForFeeder(f.ClientId)
+	m.ScheduledAt = 0
+	_, err := suite.r.CreateMissedFeedings([]models.MissedFeeding{m})
+	suite.Error(err)
+	apiErr, ok := err.(*models.ApiError)
+	suite.True(ok)
+	suite.Equal(http.StatusBadRequest, apiErr.Code())
+}
+
+func (suite *MissedFeedingsRepositorySuite) TestGetMissedFeedingsForFeeder() {
+	feeders, missed := suite.seedMissedFeedings()
+	randFeeder := feeders[len(feeders)/2]
+
+	var expected []models.MissedFeeding
+	apiMissed := &models.MissedFeeding{}
+	for _, m := range missed {
+		if m.ClientId == randFeeder.ClientId {
+			m.ToApi(apiMissed)
+			expected = append(expected, *apiMissed)
+		}
+	}
+
+	mm, err := suite.r.GetMissedFeedingsForFeeder(randFeeder.ClientId)
+	suite.NoError(err)
+	suite.ElementsMatch(expected, mm)
+}
+
+func (suite *MissedFeedingsRepositorySuite) TestGetMissedFeedingsForFeeder_NoMissedFeedings() {
+	mm, err := suite.r.GetMissedFeedingsForFeeder(utils.RandString(10))
+	suite.NoError(err)
+	suite.Equal(0, len(mm))
+}
+
+func (suite *MissedFeedingsRepositorySuite) seedMissedFeedings() (
+	feeders []dbm.Feeder, missed []dbm.MissedFeeding) {
+	count := rand.Intn(10) + 1
+	for i := 0; i < count; i++ {
+		feeder := modelUtils.RandomDbFeeder()
+		feeders = append(feeders, feeder)
+		suite.NoError(suite.r.db.Create(&feeder).Error)
+
+		seed := modelUtils.RandomDbMissedFeedingsForFeeder(feeder.ClientId)
+		suite.NoError(suite.r.db.Create(&seed).Error)
+		missed = append(missed, seed...)
+	}
+	return feeders, missed
+}
+
+func TestMissedFeedingsRepositorySuite(t *testing.T) {
+	rand.Seed(time.Now().UnixNano())
+	suite.Run(t, new(MissedFeedingsRepositorySuite))
+}
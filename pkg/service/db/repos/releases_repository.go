@@ -0,0 +1,70 @@
+package repos
+
+import (
+	dbm "github.com/imilchev/rpi-feeder/pkg/service/db/models"
+	"github.com/imilchev/rpi-feeder/pkg/service/models"
+	"github.com/imilchev/rpi-feeder/pkg/utils"
+	"gorm.io/gorm"
+)
+
+type ReleasesRepository interface {
+	// CreateRelease stores the release. Every version can only be released
+	// once.
+	CreateRelease(r models.Release) (models.Release, error)
+	GetReleases() ([]models.Release, error)
+	GetRelease(version string) (models.Release, error)
+}
+
+type releasesRepository struct {
+	db *gorm.DB
+}
+
+func NewReleasesRepository(db *gorm.DB) ReleasesRepository {
+	return &releasesRepository{db: db}
+}
+
+func (r *releasesRepository) CreateRelease(m models.Release) (models.Release, error) {
+	if err := utils.Validate.Struct(m); err != nil {
+		return models.Release{}, models.NewValidationError(err.Error())
+	}
+
+	if _, err := r.GetRelease(m.Version); err == nil {
+		return models.Release{}, models.NewAlreadyExistsError("Release", "Version", m.Version)
+	}
+
+	dbModel := &dbm.Release{}
+	dbModel.FromApi(m)
+	dbModel.Id = 0
+	if res := r.db.Create(dbModel); res.Error != nil {
+		return models.Release{}, res.Error
+	}
+	created := models.Release{}
+	dbModel.ToApi(&created)
+	return created, nil
+}
+
+func (r *releasesRepository) GetReleases() (rs []models.Release, err error) {
+	var releases []dbm.Release
+	if res := r.db.Order("created_at").Find(&releases); res.Error != nil {
+		return rs, res.Error
+	}
+
+	rs = make([]models.Release, 0, len(releases))
+	apiRelease := &models.Release{}
+	for _, m := range releases {
+		m.ToApi(apiRelease)
+		rs = append(rs, *apiRelease)
+	}
+	return rs, nil
+}
+
+func (r *releasesRepository) GetRelease(version string) (models.Release, error) {
+	release := dbm.Release{}
+	if res := r.db.Where("version = ?", version).Find(&release); res.RowsAffected == 0 {
+		return models.Release{}, models.NewDoesNotExistError("Release", "Version", version)
+	}
+
+	rApi := models.Release{}
+	release.ToApi(&rApi)
+	return rApi, nil
+}
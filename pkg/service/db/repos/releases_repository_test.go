@@ -0,0 +1,102 @@
+package repos
+
+import (
+	"math/rand"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/imilchev/rpi-feeder/pkg/service/models"
+	"github.com/imilchev/rpi-feeder/tests/utils"
+	modelUtils "github.com/imilchev/rpi-feeder/tests/utils/models"
+	"github.com/stretchr/testify/suite"
+)
+
+type ReleasesRepositorySuite struct {
+	suite.Suite
+	r *releasesRepository
+}
+
+func (suite *ReleasesRepositorySuite) SetupTest() {
+	suite.Require().NoError(utils.InitTestDb())
+	db, err := utils.GetTestDb()
+	suite.Require().NoError(err)
+	suite.r = &releasesRepository{db: db}
+}
+
+func (suite *ReleasesRepositorySuite) AfterTest(suiteName, testName string) {
+	suite.Require().NoError(utils.CleanupDb(suite.r.db))
+	db, err := suite.r.db.DB()
+	suite.Require().NoError(err)
+	db.Close()
+}
+
+func (suite *ReleasesRepositorySuite) TestCreateRelease() {
+	r := modelUtils.RandomRelease()
+	created, err := suite.r.CreateRelease(r)
+	suite.NoError(err)
+
+	// Do not compare IDs since they were generated by the database.
+	created.Id = 0
+	suite.Equal(r, created)
+}
+
+func (suite *ReleasesRepositorySuite) TestCreateRelease_AlreadyExists() {
+	r := modelUtils.RandomRelease()
+	_, err := suite.r.CreateRelease(r)
+	suite.NoError(err)
+
+	_, err = suite.r.CreateRelease(r)
+	suite.Error(err)
+	apiErr, ok := err.(*models.ApiError)
+	suite.True(ok)
+	suite.Equal(http.StatusConflict, apiErr.Code())
+}
+
+func (suite *ReleasesRepositorySuite) TestCreateRelease_InvalidVersion() {
+	r := modelUtils.RandomRelease()
+	r.Version = "dev"
+	_, err := suite.r.CreateRelease(r)
+	suite.Error(err)
+	apiErr, ok := err.(*models.ApiError)
+	suite.True(ok)
+	suite.Equal(http.StatusBadRequest, apiErr.Code())
+}
+
+func (suite *ReleasesRepositorySuite) TestGetReleases() {
+	releases := modelUtils.RandomDbReleases()
+	suite.NoError(suite.r.db.Create(&releases).Error)
+
+	var expected []models.Release
+	apiRelease := &models.Release{}
+	for _, r := range releases {
+		r.ToApi(apiRelease)
+		expected = append(expected, *apiRelease)
+	}
+
+	rs, err := suite.r.GetReleases()
+	suite.NoError(err)
+	suite.ElementsMatch(expected, rs)
+}
+
+func (suite *ReleasesRepositorySuite) TestGetRelease() {
+	created, err := suite.r.CreateRelease(modelUtils.RandomRelease())
+	suite.NoError(err)
+
+	found, err := suite.r.GetRelease(created.Version)
+	suite.NoError(err)
+	suite.Equal(created, found)
+}
+
+func (suite *ReleasesRepositorySuite) TestGetRelease_DoesNotExist() {
+	_, err := suite.r.GetRelease(modelUtils.RandomRelease().Version)
+	suite.Error(err)
+	apiErr, ok := err.(*models.ApiError)
+	suite.True(ok)
+	suite.Equal(http.StatusNotFound, apiErr.Code())
+}
+
+func TestReleasesRepositorySuite(t *testing.T) {
+	rand.Seed(time.Now().UnixNano())
+	suite.Run(t, new(ReleasesRepositorySuite))
+}
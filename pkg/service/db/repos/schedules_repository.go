@@ -0,0 +1,98 @@
+package repos
+
+import (
+	"strconv"
+
+	dbm "github.com/imilchev/rpi-feeder/pkg/service/db/models"
+	"github.com/imilchev/rpi-feeder/pkg/service/models"
+	"github.com/imilchev/rpi-feeder/pkg/utils"
+	"gorm.io/gorm"
+)
+
+type SchedulesRepository interface {
+	CreateSchedule(s models.Schedule) (models.Schedule, error)
+	GetSchedulesForFeeder(clientId string) ([]models.Schedule, error)
+	GetSchedule(clientId string, id int) (models.Schedule, error)
+	UpdateSchedule(s models.Schedule) (models.Schedule, error)
+	DeleteSchedule(clientId string, id int) error
+}
+
+type schedulesRepository struct {
+	db *gorm.DB
+}
+
+func NewSchedulesRepository(db *gorm.DB) SchedulesRepository {
+	return &schedulesRepository{db: db}
+}
+
+func (r *schedulesRepository) CreateSchedule(s models.Schedule) (models.Schedule, error) {
+	if err := utils.Validate.Struct(s); err != nil {
+		return models.Schedule{}, models.NewValidationError(err.Error())
+	}
+
+	dbModel := &dbm.Schedule{}
+	dbModel.FromApi(s)
+	dbModel.Id = 0
+	if res := r.db.Create(dbModel); res.Error != nil {
+		return models.Schedule{}, res.Error
+	}
+	createdSchedule := models.Schedule{}
+	dbModel.ToApi(&createdSchedule)
+	return createdSchedule, nil
+}
+
+func (r *schedulesRepository) GetSchedulesForFeeder(clientId string) (s []models.Schedule, err error) {
+	var schedules []dbm.Schedule
+	if res := r.db.Where("client_id = ?", clientId).Order("id").Find(&schedules); res.Error != nil {
+		return s, res.Error
+	}
+
+	s = make([]models.Schedule, 0, len(schedules))
+	apiSchedule := &models.Schedule{}
+	for _, c := range schedules {
+		c.ToApi(apiSchedule)
+		s = append(s, *apiSchedule)
+	}
+	return s, nil
+}
+
+func (r *schedulesRepository) GetSchedule(clientId string, id int) (models.Schedule, error) {
+	s := dbm.Schedule{}
+	if res := r.db.Where("client_id = ? AND id = ?", clientId, id).Find(&s); res.RowsAffected == 0 {
+		return models.Schedule{}, models.NewDoesNotExistError("Schedule", "Id", strconv.Itoa(id))
+	}
+
+	sApi := models.Schedule{}
+	s.ToApi(&sApi)
+	return sApi, nil
+}
+
+func (r *schedulesRepository) UpdateSchedule(s models.Schedule) (models.Schedule, error) {
+	if err := utils.Validate.Struct(s); err != nil {
+		return models.Schedule{}, models.NewValidationError(err.Error())
+	}
+
+	dbModel := &dbm.Schedule{}
+	if res := r.db.Where("client_id = ? AND id = ?", s.ClientId, s.Id).Find(dbModel); res.RowsAffected == 0 {
+		return models.Schedule{}, models.NewDoesNotExistError("Schedule", "Id", strconv.Itoa(s.Id))
+	}
+
+	dbModel.FromApi(s)
+	if res := r.db.Model(dbModel).Where("id = ?", s.Id).
+		Select("cron", "time", "portions").
+		Updates(dbModel); res.Error != nil {
+		return models.Schedule{}, res.Error
+	}
+	return s, nil
+}
+
+func (r *schedulesRepository) DeleteSchedule(clientId string, id int) error {
+	res := r.db.Where("client_id = ? AND id = ?", clientId, id).Delete(&dbm.Schedule{})
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return models.NewDoesNotExistError("Schedule", "Id", strconv.Itoa(id))
+	}
+	return nil
+}
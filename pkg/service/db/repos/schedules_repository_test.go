@@ -0,0 +1,218 @@
+package repos
+
+import (
+	"fmt"
+	"math/rand"
+	"net/http"
+	"testing"
+	"time"
+
+	dbm "github.com/imilchev/rpi-feeder/pkg/service/db/models"
+	"github.com/imilchev/rpi-feeder/pkg/service/models"
+	"github.com/imilchev/rpi-feeder/tests/utils"
+	modelUtils "github.com/imilchev/rpi-feeder/tests/utils/models"
+	"github.com/stretchr/testify/suite"
+)
+
+type SchedulesRepositorySuite struct {
+	suite.Suite
+	r *schedulesRepository
+}
+
+func (suite *SchedulesRepositorySuite) SetupTest() {
+	suite.Require().NoError(utils.InitTestDb())
+	db, err := utils.GetTestDb()
+	suite.Require().NoError(err)
+	suite.r = &schedulesRepository{db: db}
+}
+
+func (suite *SchedulesRepositorySuite) AfterTest(suiteName, testName string) {
+	suite.Require().NoError(utils.CleanupDb(suite.r.db))
+	db, err := suite.r.db.DB()
+	suite.Require().NoError(err)
+	db.Close()
+}
+
+func (suite *SchedulesRepositorySuite) TestCreateSchedule() {
+	f := modelUtils.RandomDbFeeder()
+	suite.NoError(suite.r.db.Create(&f).Error)
+
+	s := modelUtils.RandomScheduleForFeeder(f.ClientId)
+	ss, err := suite.r.CreateSchedule(s)
+	suite.NoError(err)
+	suite.NotZero(ss.Id)
+
+	sDb := &dbm.Schedule{}
+	suite.NoError(suite.r.db.First(sDb, "id = ?", ss.Id).Error)
+	sDb.ToApi(&ss)
+	s.Id = ss.Id
+	suite.Equal(s, ss)
+}
+
+func (suite *SchedulesRepositorySuite) TestCreateSchedule_CronAndTime() {
+	f := modelUtils.RandomDbFeeder()
+	suite.NoError(suite.r.db.Create(&f).Error)
+
+	s := modelUtils.RandomScheduleForFeeder(f.ClientId)
+	s.Cron = "0 8 * * *"
+	s.Time = "08:00"
+	_, err := suite.r.CreateSchedule(s)
+	suite.Error(err)
+	apiErr, ok := err.(*models.ApiError)
+	suite.True(ok)
+	suite.Equal(http.StatusBadRequest, apiErr.Code())
+}
+
+func (suite *SchedulesRepositorySuite) TestCreateSchedule_InvalidCron() {
+	f := modelUtils.RandomDbFeeder()
+	suite.NoError(suite.r.db.Create(&f).Error)
+
+	s := modelUtils.RandomScheduleForFeeder(f.ClientId)
+	s.Cron = "invalid"
+	s.Time = ""
+	_, err := suite.r.CreateSchedule(s)
+	suite.Error(err)
+	apiErr, ok := err.(*models.ApiError)
+	suite.True(ok)
+	suite.Equal(http.StatusBadRequest, apiErr.Code())
+}
+
+func (suite *SchedulesRepositorySuite) TestCreateSchedule_InvalidTime() {
+	f := modelUtils.RandomDbFeeder()
+	suite.NoError(suite.r.db.Create(&f).Error)
+
+	s := modelUtils.RandomScheduleForFeeder(f.ClientId)
+	s.Cron = ""
+	s.Time = "24:61"
+	_, err := suite.r.CreateSchedule(s)
+	suite.Error(err)
+	apiErr, ok := err.(*models.ApiError)
+	suite.True(ok)
+	suite.Equal(http.StatusBadRequest, apiErr.Code())
+}
+
+func (suite *SchedulesRepositorySuite) TestCreateSchedule_PortionsMissing() {
+	f := modelUtils.RandomDbFeeder()
+	suite.NoError(suite.r.db.Create(&f).Error)
+
+	s := modelUtils.RandomScheduleForFeeder(f.ClientId)
+	s.Portions = 0
+	_, err := suite.r.CreateSchedule(s)
+	suite.Error(err)
+	apiErr, ok := err.(*models.ApiError)
+	suite.True(ok)
+	suite.Equal(http.StatusBadRequest, apiErr.Code())
+}
+
+func (suite *SchedulesRepositorySuite) TestGetSchedulesForFeeder() {
+	feeders, schedules := suite.seedSchedules()
+	randFeeder := feeders[len(feeders)/2]
+
+	var expected []models.Schedule
+	apiSchedule := &models.Schedule{}
+	for _, s := range schedules {
+		if s.ClientId == randFeeder.ClientId {
+			s.ToApi(apiSchedule)
+			expected = append(expected, *apiSchedule)
+		}
+	}
+
+	s, err := suite.r.GetSchedulesForFeeder(randFeeder.ClientId)
+	suite.NoError(err)
+	suite.ElementsMatch(expected, s)
+}
+
+func (suite *SchedulesRepositorySuite) TestGetSchedulesForFeeder_NoSchedules() {
+	s, err := suite.r.GetSchedulesForFeeder(utils.RandString(10))
+	suite.NoError(err)
+	suite.Equal(0, len(s))
+}
+
+func (suite *SchedulesRepositorySuite) TestGetSchedule() {
+	_, schedules := suite.seedSchedules()
+	expected := models.Schedule{}
+	schedules[len(schedules)/2].ToApi(&expected)
+
+	s, err := suite.r.GetSchedule(expected.ClientId, expected.Id)
+	suite.NoError(err)
+	suite.Equal(expected, s)
+}
+
+func (suite *SchedulesRepositorySuite) TestGetSchedule_WrongFeeder() {
+	_, schedules := suite.seedSchedules()
+	s := schedules[len(schedules)/2]
+
+	_, err := suite.r.GetSchedule(utils.RandString(10), s.Id)
+	suite.Error(err)
+	apiErr, ok := err.(*models.ApiError)
+	suite.True(ok)
+	suite.Equal(http.StatusNotFound, apiErr.Code())
+	suite.Equal(fmt.Sprintf("Schedule with Id %d does not exist.", s.Id), apiErr.Error())
+}
+
+func (suite *SchedulesRepositorySuite) TestUpdateSchedule() {
+	_, schedules := suite.seedSchedules()
+	s := models.Schedule{}
+	schedules[len(schedules)/2].ToApi(&s)
+
+	s.Cron = ""
+	s.Time = "12:34"
+	s.Portions = s.Portions + 1
+	ss, err := suite.r.UpdateSchedule(s)
+	suite.NoError(err)
+	suite.Equal(s, ss)
+
+	sDb := &dbm.Schedule{}
+	suite.NoError(suite.r.db.First(sDb, "id = ?", s.Id).Error)
+	sDb.ToApi(&ss)
+	suite.Equal(s, ss)
+}
+
+func (suite *SchedulesRepositorySuite) TestUpdateSchedule_DoesNotExist() {
+	s := modelUtils.RandomScheduleForFeeder(utils.RandString(10))
+	s.Id = rand.Intn(1000) + 1
+
+	_, err := suite.r.UpdateSchedule(s)
+	suite.Error(err)
+	apiErr, ok := err.(*models.ApiError)
+	suite.True(ok)
+	suite.Equal(http.StatusNotFound, apiErr.Code())
+}
+
+func (suite *SchedulesRepositorySuite) TestDeleteSchedule() {
+	_, schedules := suite.seedSchedules()
+	s := schedules[len(schedules)/2]
+
+	suite.NoError(suite.r.DeleteSchedule(s.ClientId, s.Id))
+
+	var count int64
+	suite.NoError(suite.r.db.Model(&dbm.Schedule{}).Where("id = ?", s.Id).Count(&count).Error)
+	suite.Equal(int64(0), count)
+}
+
+func (suite *SchedulesRepositorySuite) TestDeleteSchedule_DoesNotExist() {
+	err := suite.r.DeleteSchedule(utils.RandString(10), rand.Intn(1000)+1)
+	suite.Error(err)
+	apiErr, ok := err.(*models.ApiError)
+	suite.True(ok)
+	suite.Equal(http.StatusNotFound, apiErr.Code())
+}
+
+func (suite *SchedulesRepositorySuite) seedSchedules() (feeders []dbm.Feeder, schedules []dbm.Schedule) {
+	count := rand.Intn(10) + 1
+	for i := 0; i < count; i++ {
+		feeder := modelUtils.RandomDbFeeder()
+		feeders = append(feeders, feeder)
+		suite.NoError(suite.r.db.Create(&feeder).Error)
+
+		seed := modelUtils.RandomDbSchedulesForFeeder(feeder.ClientId)
+		suite.NoError(suite.r.db.Create(&seed).Error)
+		schedules = append(schedules, seed...)
+	}
+	return feeders, schedules
+}
+
+func TestSchedulesRepositorySuite(t *testing.T) {
+	rand.Seed(time.Now().UnixNano())
+	suite.Run(t, new(SchedulesRepositorySuite))
+}
@@ -0,0 +1,53 @@
+package repos
+
+import (
+	dbm "github.com/imilchev/rpi-feeder/pkg/service/db/models"
+	"github.com/imilchev/rpi-feeder/pkg/service/models"
+	"github.com/imilchev/rpi-feeder/pkg/utils"
+	"gorm.io/gorm"
+)
+
+type TelemetryRepository interface {
+	CreateTelemetry(t models.Telemetry) (models.Telemetry, error)
+	GetTelemetryForFeeder(clientId string) ([]models.Telemetry, error)
+}
+
+type telemetryRepository struct {
+	db *gorm.DB
+}
+
+func NewTelemetryRepository(db *gorm.DB) TelemetryRepository {
+	return &telemetryRepository{db: db}
+}
+
+func (r *telemetryRepository) CreateTelemetry(t models.Telemetry) (models.Telemetry, error) {
+	if err := utils.Validate.Struct(t); err != nil {
+		return models.Telemetry{}, models.NewValidationError(err.Error())
+	}
+
+	dbModel := &dbm.Telemetry{}
+	dbModel.FromApi(t)
+	dbModel.Id = 0
+	if res := r.db.Create(dbModel); res.Error != nil {
+		return models.Telemetry{}, res.Error
+	}
+	created := models.Telemetry{}
+	dbModel.ToApi(&created)
+	return created, nil
+}
+
+func (r *telemetryRepository) GetTelemetryForFeeder(clientId string) (t []models.Telemetry, err error) {
+	var telemetry []dbm.Telemetry
+	if res := r.db.Where("client_id = ?", clientId).
+		Order("timestamp").Find(&telemetry); res.Error != nil {
+		return t, res.Error
+	}
+
+	t = make([]models.Telemetry, 0, len(telemetry))
+	apiTelemetry := &models.Telemetry{}
+	for _, m := range telemetry {
+		m.ToApi(apiTelemetry)
+		t = append(t, *apiTelemetry)
+	}
+	return t, nil
+}
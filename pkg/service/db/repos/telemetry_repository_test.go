@@ -0,0 +1,103 @@
+package repos
+
+import (
+	"math/rand"
+	"net/http"
+	"testing"
+	"time"
+
+	dbm "github.com/imilchev/rpi-feeder/pkg/service/db/models"
+	"github.com/imilchev/rpi-feeder/pkg/service/models"
+	"github.com/imilchev/rpi-feeder/tests/utils"
+	modelUtils "github.com/imilchev/rpi-feeder/tests/utils/models"
+	"github.com/stretchr/testify/suite"
+)
+
+type TelemetryRepositorySuite struct {
+	suite.Suite
+	r *telemetryRepository
+}
+
+func (suite *TelemetryRepositorySuite) SetupTest() {
+	suite.Require().NoError(utils.InitTestDb())
+	db, err := utils.GetTestDb()
+	suite.Require().NoError(err)
+	suite.r = &telemetryRepository{db: db}
+}
+
+func (suite *TelemetryRepositorySuite) AfterTest(suiteName, testName string) {
+	suite.Require().NoError(utils.CleanupDb(suite.r.db))
+	db, err := suite.r.db.DB()
+	suite.Require().NoError(err)
+	db.Close()
+}
+
+func (suite *TelemetryRepositorySuite) TestCreateTelemetry() {
+	f := modelUtils.RandomDbFeeder()
+	suite.NoError(suite.r.db.Create(&f).Error)
+
+	t := modelUtils.RandomTelemetryForFeeder(f.ClientId)
+	created, err := suite.r.CreateTelemetry(t)
+	suite.NoError(err)
+
+	// Do not compare IDs since they were generated by the database.
+	created.Id = 0
+	suite.Equal(t, created)
+}
+
+func (suite *TelemetryRepositorySuite) TestCreateTelemetry_TimestampMissing() {
+	f := modelUtils.RandomDbFeeder()
+	suite.NoError(suite.r.db.Create(&f).Error)
+
+	t := modelUtils.RandomTelemetryForFeeder(f.ClientId)
+	t.Timestamp = 0
+	_, err := suite.r.CreateTelemetry(t)
+	suite.Error(err)
+	apiErr, ok := err.(*models.ApiError)
+	suite.True(ok)
+	suite.Equal(http.StatusBadRequest, apiErr.Code())
+}
+
+func (suite *TelemetryRepositorySuite) TestGetTelemetryForFeeder() {
+	feeders, telemetry := suite.seedTelemetry()
+	randFeeder := feeders[len(feeders)/2]
+
+	var expected []models.Telemetry
+	apiTelemetry := &models.Telemetry{}
+	for _, t := range telemetry {
+		if t.ClientId == randFeeder.ClientId {
+			t.ToApi(apiTelemetry)
+			expected = append(expected, *apiTelemetry)
+		}
+	}
+
+	tt, err := suite.r.GetTelemetryForFeeder(randFeeder.ClientId)
+	suite.NoError(err)
+	suite.ElementsMatch(expected, tt)
+}
+
+func (suite *TelemetryRepositorySuite) TestGetTelemetryForFeeder_NoTelemetry() {
+	tt, err := suite.r.GetTelemetryForFeeder(utils.RandString(10))
+	suite.NoError(err)
+	suite.Equal(0, len(tt))
+}
+
+func (suite *TelemetryRepositorySuite) seedTelemetry() (
+	feeders []dbm.Feeder, telemetry []dbm.Telemetry) {
+	count := rand.Intn(10) + 1
+	for i := 0; i < count; i++ {
+		feeder := modelUtils.RandomDbFeeder()
+		feeders = append(feeders, feeder)
+		suite.NoError(suite.r.db.Create(&feeder).Error)
+
+		seed := modelUtils.RandomDbTelemetrySeriesForFeeder(feeder.ClientId)
+		suite.NoError(suite.r.db.Create(&seed).Error)
+		telemetry = append(telemetry, seed...)
+	}
+	return feeders, telemetry
+}
+
+func TestTelemetryRepositorySuite(t *testing.T) {
+	rand.Seed(time.Now().UnixNano())
+	suite.Run(t, new(TelemetryRepositorySuite))
+}
@@ -74,6 +74,19 @@ func (suite *ErrorHandlerSuite) TestValidationErrorHandling() {
 	suite.Equal(msg, res.Message)
 }
 
+func (suite *ErrorHandlerSuite) TestTimeoutErrorHandling() {
+	msg := utils.RandString(10)
+	suite.err = models.NewTimeoutError(msg)
+
+	suite.app.Handler()(suite.ctx)
+
+	suite.Equal(http.StatusGatewayTimeout, suite.ctx.Response.Header.StatusCode())
+	res := &models.ApiError{}
+	suite.NoError(json.Unmarshal(suite.ctx.Response.Body(), res))
+
+	suite.Equal(msg, res.Message)
+}
+
 func (suite *ErrorHandlerSuite) TestRandomErrorHandling() {
 	msg := utils.RandString(10)
 	suite.err = fmt.Errorf("%s", msg)
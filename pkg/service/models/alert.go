@@ -0,0 +1,49 @@
+package models
+
+type AlertType string
+
+const (
+	// LowFoodAlertType is raised when the food level of a feeder drops to the
+	// configured low level.
+	LowFoodAlertType AlertType = "low_food"
+
+	// EmptyHopperAlertType is raised when the food level of a feeder drops to
+	// the configured empty level.
+	EmptyHopperAlertType AlertType = "empty_hopper"
+
+	// FeedFailedAlertType is raised when a feeder fails to execute a feed
+	// command.
+	FeedFailedAlertType AlertType = "feed_failed"
+
+	// StaleAlertType is raised when a feeder that is online stopped reporting
+	// without going offline, e.g. because the broker lost its last will.
+	StaleAlertType AlertType = "stale"
+
+	// UpdateFailedAlertType is raised when a feeder failed to install a new
+	// version of its software.
+	UpdateFailedAlertType AlertType = "update_failed"
+)
+
+type Alert struct {
+	Id       int
+	ClientId string    `validate:"required,max=60"`
+	Type     AlertType `validate:"required,oneof=low_food empty_hopper feed_failed stale update_failed"`
+	Message  string
+
+	// The UNIX timestamp of when the alert was raised.
+	CreatedAt int64 `validate:"required"`
+
+	// The UNIX timestamp of when the alert was acknowledged. Only set if the
+	// alert was acknowledged.
+	AcknowledgedAt *int64
+
+	// The UNIX timestamp of when the condition that raised the alert was
+	// over, e.g. because the hopper was refilled. Only set if the alert was
+	// resolved.
+	ResolvedAt *int64
+}
+
+// Active returns true if the alert was neither acknowledged nor resolved.
+func (a Alert) Active() bool {
+	return a.AcknowledgedAt == nil && a.ResolvedAt == nil
+}
@@ -0,0 +1,25 @@
+package models
+
+type Calibration struct {
+	Id       int
+	ClientId string `validate:"required,max=60"`
+
+	// The fitted ms of rotation per gram of food and the offset in ms.
+	MsPerGram float64 `validate:"gt=0"`
+	OffsetMs  float64
+
+	// The weight of 1 portion and the portionMs calibrated for it.
+	GramsPerPortion float64 `validate:"gt=0"`
+	PortionMs       uint64  `validate:"gt=0"`
+	Timestamp       int64   `validate:"required"`
+}
+
+type CalibrateRequest struct {
+	// The durations to run the servo for. The feeder defaults are used if not
+	// set.
+	DurationsMs []uint64 `validate:"omitempty,min=2,dive,gt=0"`
+
+	// The weight of 1 portion. The weight configured for the scale of the
+	// feeder is used if not set.
+	GramsPerPortion float64 `validate:"gte=0"`
+}
@@ -24,6 +24,10 @@ func NewValidationError(message string) *ApiError {
 	return NewApiError(http.StatusBadRequest, message)
 }
 
+func NewTimeoutError(message string) *ApiError {
+	return NewApiError(http.StatusGatewayTimeout, message)
+}
+
 func NewApiError(code int, message string) *ApiError {
 	return &ApiError{code: code, Message: message}
 }
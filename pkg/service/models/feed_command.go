@@ -0,0 +1,55 @@
+package models
+
+import "github.com/imilchev/rpi-feeder/pkg/mqtt/model"
+
+type CommandStatus string
+
+const (
+	PendingCommandStatus   CommandStatus = "pending"
+	SucceededCommandStatus CommandStatus = "succeeded"
+	FailedCommandStatus    CommandStatus = "failed"
+
+	// RejectedCommandStatus is set for commands the feeder refused to execute
+	// because they would exceed its feeding limits.
+	RejectedCommandStatus CommandStatus = "rejected"
+
+	// CancelledCommandStatus is set for commands that were cancelled while
+	// being executed. ServedPortions holds the portions served until then.
+	CancelledCommandStatus CommandStatus = "cancelled"
+)
+
+type FeedCommand struct {
+	Id       string        `validate:"required,max=64"`
+	ClientId string        `validate:"required,max=60"`
+	Portions uint          `validate:"required,gt=0"`
+	Status   CommandStatus `validate:"required,oneof=pending succeeded failed rejected cancelled"`
+
+	// The amount of portions the feeder reported as served.
+	ServedPortions uint
+
+	// The reason the command failed or was rejected.
+	Error string
+
+	// The UNIX timestamp of when the command was sent.
+	CreatedAt int64 `validate:"required"`
+
+	// The UNIX timestamp of when the command completed. Only set if the
+	// command is not pending.
+	CompletedAt *int64
+}
+
+// ApplyResult completes the command with the result reported by the feeder.
+func (c *FeedCommand) ApplyResult(msg model.FeedResultMessage) {
+	c.Status = SucceededCommandStatus
+	if msg.Rejected {
+		c.Status = RejectedCommandStatus
+	} else if msg.Cancelled {
+		c.Status = CancelledCommandStatus
+	} else if !msg.Success {
+		c.Status = FailedCommandStatus
+	}
+	c.ServedPortions = msg.Portions
+	c.Error = msg.Error
+	t := msg.Timestamp.UTC().Unix()
+	c.CompletedAt = &t
+}
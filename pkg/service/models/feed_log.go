@@ -1,8 +1,11 @@
 package models
 
 type FeedLog struct {
-	Id        int
-	ClientId  string `validate:"required,max=60"`
-	Portions  uint   `validate:"required,gt=0"`
-	Timestamp int64  `validate:"required"`
+	Id       int
+	ClientId string `validate:"required,max=60"`
+	Portions uint   `validate:"required,gt=0"`
+
+	// The weight of the served food. 0 if the feeding was not weighed.
+	Grams     float64 `validate:"gte=0"`
+	Timestamp int64   `validate:"required"`
 }
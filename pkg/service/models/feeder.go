@@ -12,6 +12,26 @@ type Feeder struct {
 	// The UNIX timestamp of when the feeder was last observed to be online.
 	// Only set if the feeder is offline.
 	LastOnline *int64
+
+	// The latest telemetry reported by the feeder. Only set if the feeder
+	// sent telemetry.
+	Telemetry *Telemetry
+
+	// The revision of the config received from the service that the feeder
+	// uses, or 0 if it only uses its config file. If ConfigPendingRestart is
+	// set, the feeder has to be restarted to apply the revision completely.
+	ConfigRevision       int
+	ConfigPendingRestart bool
+
+	// The latest revision of the config received from the service that the
+	// feeder rejected, together with the reason. Only set if it is newer than
+	// ConfigRevision. The feeder does not apply a rejected revision again.
+	ConfigRejectedRevision int
+	ConfigError            string
+
+	// Whether the software of the feeder is older than the one of the
+	// service. It is determined when the feeder is returned and not stored.
+	Outdated bool
 }
 
 type FeedRequest struct {
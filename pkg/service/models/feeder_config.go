@@ -0,0 +1,15 @@
+package models
+
+import "encoding/json"
+
+// FeederConfig holds the settings of a feeder managed by the service. They
+// are in the format of the config file of the feeder and applied over it.
+type FeederConfig struct {
+	ClientId string `validate:"required,max=60"`
+
+	// Increases with every change of the config. The feeder reports the
+	// revision it uses with its status.
+	Revision  int             `validate:"gt=0"`
+	Config    json.RawMessage `validate:"required"`
+	UpdatedAt int64           `validate:"required"`
+}
@@ -0,0 +1,13 @@
+package models
+
+type FoodLevel struct {
+	Id       int
+	ClientId string `validate:"required,max=60"`
+
+	// The fill level of the hopper in percent.
+	Level float64 `validate:"gte=0,lte=100"`
+
+	// The distance in cm measured by the sensor.
+	Distance  float64 `validate:"gte=0"`
+	Timestamp int64   `validate:"required"`
+}
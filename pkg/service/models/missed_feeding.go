@@ -0,0 +1,13 @@
+package models
+
+type MissedFeeding struct {
+	Id       int
+	ClientId string `validate:"required,max=60"`
+	Portions uint   `validate:"required,gt=0"`
+
+	// The UNIX timestamp of when the feeding was scheduled.
+	ScheduledAt int64 `validate:"required"`
+
+	// Whether the feeder served the feeding late, after it started again.
+	CaughtUp bool
+}
@@ -0,0 +1,18 @@
+package models
+
+// Release is a version of the feeder software that feeders can be updated to.
+type Release struct {
+	Id      int
+	Version string `validate:"required,max=60,semver"`
+
+	// The hex encoded SHA-256 checksum and the size in bytes of the binary.
+	Sha256 string `validate:"required,len=64,hexadecimal"`
+	Size   int64  `validate:"gt=0"`
+
+	// The UNIX timestamp of when the release was uploaded.
+	CreatedAt int64 `validate:"required"`
+}
+
+type UpdateRequest struct {
+	Version string `validate:"required"`
+}
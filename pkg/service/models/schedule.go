@@ -0,0 +1,20 @@
+package models
+
+type Schedule struct {
+	Id       int
+	ClientId string `validate:"required,max=60"`
+
+	// A standard 5-field cron expression. Exactly one of Cron and Time must
+	// be set.
+	Cron string `validate:"required_without=Time,excluded_with=Time,omitempty,max=60,cron"`
+
+	// A time of day in the 24-hour HH:MM format.
+	Time     string `validate:"required_without=Cron,excluded_with=Cron,omitempty,datetime=15:04"`
+	Portions uint   `validate:"required,gt=0"`
+}
+
+type ScheduleRequest struct {
+	Cron     string
+	Time     string
+	Portions uint
+}
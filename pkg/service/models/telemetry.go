@@ -0,0 +1,25 @@
+package models
+
+type Telemetry struct {
+	Id       int
+	ClientId string `validate:"required,max=60"`
+
+	// The seconds since the feeder was started.
+	UptimeSeconds uint64
+
+	// The CPU temperature in °C. Only set if the feeder could read it.
+	CpuTemperature *float64
+
+	// The free disk space in bytes of the partition holding the database of
+	// the feeder.
+	FreeDiskBytes uint64
+
+	// The amount of feed logs stored on the feeder, which were not sent to
+	// the service yet.
+	UnflushedFeedLogs uint
+
+	// The amount of times the feeder reconnected to the broker since it was
+	// started.
+	MqttReconnects uint
+	Timestamp      int64 `validate:"required"`
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"encoding/json"
 	"net/url"
+	"sync"
 	"time"
 
 	"github.com/eclipse/paho.golang/autopaho"
@@ -16,26 +17,75 @@ import (
 
 type FeederStatusHandler func(clientId string, msg model.StatusMessage) error
 type FeederLogsHandler func(clientId string, msg model.FeedLogCollectionMessage) error
+type MissedFeedingsHandler func(clientId string, msg model.MissedFeedingCollectionMessage) error
+type FeedResultHandler func(clientId string, msg model.FeedResultMessage) error
+type FoodLevelHandler func(clientId string, msg model.FoodLevelMessage) error
+type CalibrationHandler func(clientId string, msg model.CalibrationMessage) error
+type TelemetryHandler func(clientId string, msg model.TelemetryMessage) error
+type UpdateResultHandler func(clientId string, msg model.UpdateResultMessage) error
 
 type MqttManager interface {
 	SendFeedCommand(clientId string, msg model.FeedMessage) error
+
+	// SendFeedCommandAndWait sends a feed command and blocks until the feeder
+	// reports its result or ctx is done. The result is correlated with the
+	// command using the MQTT 5 response topic and correlation data properties.
+	SendFeedCommandAndWait(
+		ctx context.Context, clientId string, msg model.FeedMessage) (model.FeedResultMessage, error)
+
+	// SendCancel requests the feeder to stop the feeding in progress.
+	SendCancel(clientId string, msg model.CancelMessage) error
+
+	// SendSchedule publishes the complete schedule of a feeder as a retained
+	// message, so the feeder receives it even if it is currently offline.
+	SendSchedule(clientId string, msg model.ScheduleMessage) error
+
+	// SendCalibrate requests the feeder to calibrate its portions with its
+	// load cell. The result is reported on the calibration topic.
+	SendCalibrate(clientId string, msg model.CalibrateMessage) error
+
+	// SendUpdate requests the feeder to install another version of its
+	// software. The result is reported on the update result topic.
+	SendUpdate(clientId string, msg model.UpdateMessage) error
+
+	// SendConfig publishes the config of a feeder as a retained message, so
+	// the feeder receives it even if it is currently offline.
+	SendConfig(clientId string, msg model.ConfigMessage) error
 	Stop() error
 }
 
 type mqttManager struct {
 	clientId string
 	c        *autopaho.ConnectionManager
+
+	// The feed commands awaiting a result, keyed by correlation data.
+	pendingMu sync.Mutex
+	pending   map[string]chan model.FeedResultMessage
 }
 
 func NewMqttManager(
 	cfg config.MqttConfig,
 	fsh FeederStatusHandler,
-	flh FeederLogsHandler) (MqttManager, error) {
+	flh FeederLogsHandler,
+	mfh MissedFeedingsHandler,
+	frh FeedResultHandler,
+	lvh FoodLevelHandler,
+	cah CalibrationHandler,
+	teh TelemetryHandler,
+	urh UpdateResultHandler) (MqttManager, error) {
 	serverUrl, err := url.Parse(cfg.Server)
 	if err != nil {
 		return nil, err
 	}
+	tlsCfg, err := cfg.TlsConfig()
+	if err != nil {
+		return nil, err
+	}
 
+	m := &mqttManager{
+		clientId: cfg.ClientId,
+		pending:  make(map[string]chan model.FeedResultMessage),
+	}
 	router := paho.NewStandardRouter()
 	router.RegisterHandler(
 		mqtt.StatusTopic(nil),
@@ -43,9 +93,28 @@ func NewMqttManager(
 	router.RegisterHandler(
 		mqtt.FeedLogTopic(nil),
 		func(p *paho.Publish) { internalFeedLogsHandler(p, flh) })
+	router.RegisterHandler(
+		mqtt.MissedFeedingTopic(nil),
+		func(p *paho.Publish) { internalMissedFeedingsHandler(p, mfh) })
+	router.RegisterHandler(
+		mqtt.FeedResultTopic(nil),
+		func(p *paho.Publish) { m.internalFeedResultHandler(p, frh) })
+	router.RegisterHandler(
+		mqtt.FoodLevelTopic(nil),
+		func(p *paho.Publish) { internalFoodLevelHandler(p, lvh) })
+	router.RegisterHandler(
+		mqtt.CalibrationTopic(nil),
+		func(p *paho.Publish) { internalCalibrationHandler(p, cah) })
+	router.RegisterHandler(
+		mqtt.TelemetryTopic(nil),
+		func(p *paho.Publish) { internalTelemetryHandler(p, teh) })
+	router.RegisterHandler(
+		mqtt.UpdateResultTopic(nil),
+		func(p *paho.Publish) { internalUpdateResultHandler(p, urh) })
 
 	pahoCfg := autopaho.ClientConfig{
 		BrokerUrls:        []*url.URL{serverUrl},
+		TlsCfg:            tlsCfg,
 		KeepAlive:         cfg.KeepAlive,
 		ConnectRetryDelay: time.Duration(cfg.ConnectRetryDelay) * time.Second,
 		OnConnectionUp: func(cm *autopaho.ConnectionManager, connAck *paho.Connack) {
@@ -53,8 +122,14 @@ func NewMqttManager(
 
 			if _, err := cm.Subscribe(context.Background(), &paho.Subscribe{
 				Subscriptions: map[string]paho.SubscribeOptions{
-					mqtt.StatusTopic(nil):  {QoS: byte(1)},
-					mqtt.FeedLogTopic(nil): {QoS: byte(2)},
+					mqtt.StatusTopic(nil):        {QoS: byte(1)},
+					mqtt.FeedLogTopic(nil):       {QoS: byte(2)},
+					mqtt.MissedFeedingTopic(nil): {QoS: byte(2)},
+					mqtt.FeedResultTopic(nil):    {QoS: byte(2)},
+					mqtt.FoodLevelTopic(nil):     {QoS: byte(1)},
+					mqtt.CalibrationTopic(nil):   {QoS: byte(1)},
+					mqtt.TelemetryTopic(nil):     {QoS: byte(1)},
+					mqtt.UpdateResultTopic(nil):  {QoS: byte(1)},
 				},
 			}); err != nil {
 				zap.S().Errorf("Failed to subscribe (%v). This is likely to mean no messages will be received.", err)
@@ -84,7 +159,7 @@ func NewMqttManager(
 		return nil, err
 	}
 
-	m := &mqttManager{clientId: cfg.ClientId, c: cm}
+	m.c = cm
 	if err := cm.AwaitConnection(context.Background()); err != nil {
 		return nil, err
 	}
@@ -117,6 +192,116 @@ func (m *mqttManager) SendFeedCommand(clientId string, msg model.FeedMessage) er
 	return err
 }
 
+func (m *mqttManager) SendFeedCommandAndWait(
+	ctx context.Context, clientId string, msg model.FeedMessage) (model.FeedResultMessage, error) {
+	data, err := json.Marshal(msg)
+	if err != nil {
+		return model.FeedResultMessage{}, err
+	}
+
+	resultChan := make(chan model.FeedResultMessage, 1)
+	m.pendingMu.Lock()
+	m.pending[msg.CommandId] = resultChan
+	m.pendingMu.Unlock()
+	defer func() {
+		m.pendingMu.Lock()
+		delete(m.pending, msg.CommandId)
+		m.pendingMu.Unlock()
+	}()
+
+	_, err = m.c.Publish(ctx, &paho.Publish{
+		Topic:   mqtt.FeedTopic(&clientId),
+		QoS:     byte(2),
+		Payload: data,
+		Properties: &paho.PublishProperties{
+			ResponseTopic:   mqtt.FeedResultTopic(&clientId),
+			CorrelationData: []byte(msg.CommandId),
+		},
+	})
+	if err != nil {
+		return model.FeedResultMessage{}, err
+	}
+
+	select {
+	case result := <-resultChan:
+		return result, nil
+	case <-ctx.Done():
+		return model.FeedResultMessage{}, ctx.Err()
+	}
+}
+
+func (m *mqttManager) SendCancel(clientId string, msg model.CancelMessage) error {
+	data, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+
+	_, err = m.c.Publish(context.Background(), &paho.Publish{
+		Topic:   mqtt.CancelTopic(&clientId),
+		QoS:     byte(1),
+		Payload: data,
+	})
+	return err
+}
+
+func (m *mqttManager) SendSchedule(clientId string, msg model.ScheduleMessage) error {
+	data, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+
+	_, err = m.c.Publish(context.Background(), &paho.Publish{
+		Topic:   mqtt.ScheduleTopic(&clientId),
+		QoS:     byte(1),
+		Retain:  true,
+		Payload: data,
+	})
+	return err
+}
+
+func (m *mqttManager) SendConfig(clientId string, msg model.ConfigMessage) error {
+	data, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+
+	_, err = m.c.Publish(context.Background(), &paho.Publish{
+		Topic:   mqtt.ConfigTopic(&clientId),
+		QoS:     byte(1),
+		Retain:  true,
+		Payload: data,
+	})
+	return err
+}
+
+func (m *mqttManager) SendCalibrate(clientId string, msg model.CalibrateMessage) error {
+	data, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+
+	_, err = m.c.Publish(context.Background(), &paho.Publish{
+		Topic:   mqtt.CalibrateTopic(&clientId),
+		QoS:     byte(1),
+		Payload: data,
+	})
+	return err
+}
+
+func (m *mqttManager) SendUpdate(clientId string, msg model.UpdateMessage) error {
+	data, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+
+	_, err = m.c.Publish(context.Background(), &paho.Publish{
+		Topic:   mqtt.UpdateTopic(&clientId),
+		QoS:     byte(1),
+		Payload: data,
+	})
+	return err
+}
+
 func internalStatusHandler(p *paho.Publish, fsh FeederStatusHandler) {
 	msg := model.StatusMessage{}
 	if err := json.Unmarshal(p.Payload, &msg); err != nil {
@@ -144,3 +329,114 @@ func internalFeedLogsHandler(p *paho.Publish, flh FeederLogsHandler) {
 	}
 	zap.S().Infof("Processed %d feed logs for feeder %s.", len(msg.Value), clientId)
 }
+
+func internalMissedFeedingsHandler(p *paho.Publish, mfh MissedFeedingsHandler) {
+	msg := model.MissedFeedingCollectionMessage{}
+	if err := json.Unmarshal(p.Payload, &msg); err != nil {
+		zap.S().Errorf("Failed to deserialize message %s. %v", string(p.Payload), err)
+		return
+	}
+	clientId := mqtt.ClientIdFromTopic(p.Topic)
+	if err := mfh(clientId, msg); err != nil {
+		zap.S().Errorf(
+			"Failed to process %d missed feedings for feeder %s. %v", len(msg.Value), clientId, err)
+		return
+	}
+	zap.S().Infof("Processed %d missed feedings for feeder %s.", len(msg.Value), clientId)
+}
+
+func internalFoodLevelHandler(p *paho.Publish, lvh FoodLevelHandler) {
+	msg := model.FoodLevelMessage{}
+	if err := json.Unmarshal(p.Payload, &msg); err != nil {
+		zap.S().Errorf("Failed to deserialize message %s. %v", string(p.Payload), err)
+		return
+	}
+	clientId := mqtt.ClientIdFromTopic(p.Topic)
+	if err := lvh(clientId, msg); err != nil {
+		zap.S().Errorf("Failed to store food level for feeder %s. %v", clientId, err)
+		return
+	}
+	zap.S().Debugf("Food level of feeder %s is %.1f%%.", clientId, msg.Level)
+}
+
+func internalTelemetryHandler(p *paho.Publish, teh TelemetryHandler) {
+	msg := model.TelemetryMessage{}
+	if err := json.Unmarshal(p.Payload, &msg); err != nil {
+		zap.S().Errorf("Failed to deserialize message %s. %v", string(p.Payload), err)
+		return
+	}
+	clientId := mqtt.ClientIdFromTopic(p.Topic)
+	if err := teh(clientId, msg); err != nil {
+		zap.S().Errorf("Failed to store telemetry for feeder %s. %v", clientId, err)
+		return
+	}
+	zap.S().Debugf("Received telemetry from feeder %s.", clientId)
+}
+
+func internalCalibrationHandler(p *paho.Publish, cah CalibrationHandler) {
+	msg := model.CalibrationMessage{}
+	if err := json.Unmarshal(p.Payload, &msg); err != nil {
+		zap.S().Errorf("Failed to deserialize message %s. %v", string(p.Payload), err)
+		return
+	}
+	clientId := mqtt.ClientIdFromTopic(p.Topic)
+	if err := cah(clientId, msg); err != nil {
+		zap.S().Errorf("Failed to store calibration for feeder %s. %v", clientId, err)
+		return
+	}
+	zap.S().Infof("Processed calibration for feeder %s.", clientId)
+}
+
+func internalUpdateResultHandler(p *paho.Publish, urh UpdateResultHandler) {
+	msg := model.UpdateResultMessage{}
+	if err := json.Unmarshal(p.Payload, &msg); err != nil {
+		zap.S().Errorf("Failed to deserialize message %s. %v", string(p.Payload), err)
+		return
+	}
+	clientId := mqtt.ClientIdFromTopic(p.Topic)
+	if err := urh(clientId, msg); err != nil {
+		zap.S().Errorf("Failed to process update result for feeder %s. %v", clientId, err)
+		return
+	}
+	zap.S().Infof("Processed update result for feeder %s.", clientId)
+}
+
+func (m *mqttManager) internalFeedResultHandler(p *paho.Publish, frh FeedResultHandler) {
+	msg := model.FeedResultMessage{}
+	if err := json.Unmarshal(p.Payload, &msg); err != nil {
+		zap.S().Errorf("Failed to deserialize message %s. %v", string(p.Payload), err)
+		return
+	}
+	clientId := mqtt.ClientIdFromTopic(p.Topic)
+	if msg.CommandId == "" {
+		if err := frh(clientId, msg); err != nil {
+			zap.S().Errorf("Failed to process result of scheduled feeding for feeder %s. %v", clientId, err)
+		} else {
+			zap.S().Infof("Processed result of scheduled feeding for feeder %s.", clientId)
+		}
+		return
+	}
+
+	if err := frh(clientId, msg); err != nil {
+		zap.S().Errorf(
+			"Failed to process result of command %s for feeder %s. %v", msg.CommandId, clientId, err)
+	} else {
+		zap.S().Infof("Processed result of command %s for feeder %s.", msg.CommandId, clientId)
+	}
+
+	// Notify the caller waiting for the result, if there is one. The result
+	// is processed first such that the caller observes the stored command.
+	correlationId := msg.CommandId
+	if p.Properties != nil && len(p.Properties.CorrelationData) > 0 {
+		correlationId = string(p.Properties.CorrelationData)
+	}
+	m.pendingMu.Lock()
+	resultChan, ok := m.pending[correlationId]
+	m.pendingMu.Unlock()
+	if ok {
+		select {
+		case resultChan <- msg:
+		default:
+		}
+	}
+}
@@ -0,0 +1,72 @@
+package mqtt
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/imilchev/rpi-feeder/pkg/mqtt"
+	"github.com/imilchev/rpi-feeder/pkg/mqtt/config"
+	"github.com/imilchev/rpi-feeder/pkg/mqtt/model"
+	"github.com/imilchev/rpi-feeder/tests/utils/broker"
+	"github.com/stretchr/testify/suite"
+)
+
+type MqttManagerSuite struct {
+	suite.Suite
+	certs  broker.Certs
+	broker *broker.Broker
+	cfg    config.MqttConfig
+}
+
+func (suite *MqttManagerSuite) SetupTest() {
+	var err error
+	suite.certs, err = broker.GenerateCerts(suite.T().TempDir(), "service")
+	suite.Require().NoError(err)
+	suite.broker, err = broker.NewBroker(suite.certs)
+	suite.Require().NoError(err)
+
+	suite.cfg = config.MqttConfig{
+		Server:            suite.broker.Url(),
+		ClientId:          "service",
+		KeepAlive:         30,
+		ConnectRetryDelay: 1,
+		CaFile:            suite.certs.CaFile,
+		CertFile:          suite.certs.ClientCertFile,
+		KeyFile:           suite.certs.ClientKeyFile,
+	}
+}
+
+func (suite *MqttManagerSuite) TearDownTest() {
+	suite.broker.Close()
+}
+
+func (suite *MqttManagerSuite) TestNewMqttManager_Tls() {
+	m, err := NewMqttManager(suite.cfg, nil, nil, nil, nil, nil, nil, nil, nil)
+	suite.Require().NoError(err)
+	defer m.Stop() //nolint
+
+	suite.Equal([]broker.Client{{ClientId: "service", CommonName: "service"}}, suite.broker.Clients())
+
+	clientId := "dev1"
+	suite.NoError(m.SendFeedCommand(clientId, model.FeedMessage{Portions: 2}))
+	suite.Eventually(func() bool {
+		return len(suite.broker.Published(mqtt.FeedTopic(&clientId))) == 1
+	}, 3*time.Second, 10*time.Millisecond)
+
+	var msg model.FeedMessage
+	suite.NoError(json.Unmarshal(suite.broker.Published(mqtt.FeedTopic(&clientId))[0].Payload, &msg))
+	suite.Equal(uint(2), msg.Portions)
+}
+
+func (suite *MqttManagerSuite) TestNewMqttManager_InvalidTls() {
+	suite.cfg.CertFile = suite.certs.ServerCertFile
+
+	_, err := NewMqttManager(suite.cfg, nil, nil, nil, nil, nil, nil, nil, nil)
+	suite.Error(err)
+	suite.Empty(suite.broker.Clients())
+}
+
+func TestMqttManagerSuite(t *testing.T) {
+	suite.Run(t, new(MqttManagerSuite))
+}
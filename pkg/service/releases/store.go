@@ -0,0 +1,89 @@
+package releases
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Store keeps the uploaded feeder binaries on disk. Binaries are stored by
+// their checksum, such that the versions uploaded by users never end up in
+// file paths.
+type Store interface {
+	// Save stores the binary read from r and returns its hex encoded SHA-256
+	// checksum and its size in bytes. If expectedSha256 is set and the
+	// checksum does not match it, a *ChecksumMismatchError is returned and
+	// nothing is stored.
+	Save(r io.Reader, expectedSha256 string) (string, int64, error)
+
+	// Path returns the path of the binary with the checksum.
+	Path(sha256 string) string
+	Delete(sha256 string) error
+}
+
+// ChecksumMismatchError is returned when a binary does not have the expected
+// checksum.
+type ChecksumMismatchError struct {
+	Expected string
+	Actual   string
+}
+
+func (e *ChecksumMismatchError) Error() string {
+	return fmt.Sprintf("checksum mismatch, expected %s, but the binary has %s", e.Expected, e.Actual)
+}
+
+type store struct {
+	dir string
+}
+
+func NewStore(dir string) (Store, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+	return &store{dir: dir}, nil
+}
+
+func (s *store) Save(r io.Reader, expectedSha256 string) (string, int64, error) {
+	// The binary is written to a temporary file first, since its name is only
+	// known once it was read completely. A binary with the same checksum may
+	// be stored already, so it is only replaced once it is verified.
+	f, err := ioutil.TempFile(s.dir, "upload-")
+	if err != nil {
+		return "", 0, err
+	}
+	defer os.Remove(f.Name()) //nolint
+
+	h := sha256.New()
+	size, err := io.Copy(io.MultiWriter(f, h), r)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return "", 0, err
+	}
+
+	sum := hex.EncodeToString(h.Sum(nil))
+	if expectedSha256 != "" && !strings.EqualFold(expectedSha256, sum) {
+		return "", 0, &ChecksumMismatchError{Expected: expectedSha256, Actual: sum}
+	}
+	if err := os.Rename(f.Name(), s.Path(sum)); err != nil {
+		return "", 0, err
+	}
+	return sum, size, nil
+}
+
+func (s *store) Path(sha256 string) string {
+	return filepath.Join(s.dir, sha256)
+}
+
+func (s *store) Delete(sha256 string) error {
+	if err := os.Remove(s.Path(sha256)); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,94 @@
+package releases
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/imilchev/rpi-feeder/tests/utils"
+	"github.com/stretchr/testify/suite"
+)
+
+type StoreSuite struct {
+	suite.Suite
+	dir string
+	s   Store
+}
+
+func (suite *StoreSuite) SetupTest() {
+	dir, err := ioutil.TempDir("", "releases-")
+	suite.Require().NoError(err)
+	suite.dir = filepath.Join(dir, "releases")
+
+	suite.s, err = NewStore(suite.dir)
+	suite.Require().NoError(err)
+}
+
+func (suite *StoreSuite) TearDownTest() {
+	suite.NoError(os.RemoveAll(filepath.Dir(suite.dir)))
+}
+
+func (suite *StoreSuite) TestSave() {
+	data := []byte(utils.RandString(100))
+	sum, size, err := suite.s.Save(bytes.NewReader(data), "")
+	suite.NoError(err)
+
+	expected := sha256.Sum256(data)
+	suite.Equal(hex.EncodeToString(expected[:]), sum)
+	suite.Equal(int64(len(data)), size)
+
+	stored, err := ioutil.ReadFile(suite.s.Path(sum))
+	suite.NoError(err)
+	suite.Equal(data, stored)
+
+	// No temporary files are left behind.
+	entries, err := ioutil.ReadDir(suite.dir)
+	suite.NoError(err)
+	suite.Equal(1, len(entries))
+}
+
+func (suite *StoreSuite) TestSave_ExpectedChecksum() {
+	data := []byte(utils.RandString(100))
+	expected := sha256.Sum256(data)
+
+	sum, _, err := suite.s.Save(bytes.NewReader(data), strings.ToUpper(hex.EncodeToString(expected[:])))
+	suite.NoError(err)
+	suite.Equal(hex.EncodeToString(expected[:]), sum)
+	suite.FileExists(suite.s.Path(sum))
+}
+
+func (suite *StoreSuite) TestSave_ChecksumMismatch() {
+	data := []byte(utils.RandString(100))
+	expected := sha256.Sum256([]byte(utils.RandString(10)))
+
+	_, _, err := suite.s.Save(bytes.NewReader(data), hex.EncodeToString(expected[:]))
+	var mismatch *ChecksumMismatchError
+	suite.Require().ErrorAs(err, &mismatch)
+	suite.Equal(hex.EncodeToString(expected[:]), mismatch.Expected)
+
+	// Nothing is stored.
+	entries, err := ioutil.ReadDir(suite.dir)
+	suite.NoError(err)
+	suite.Empty(entries)
+}
+
+func (suite *StoreSuite) TestDelete() {
+	sum, _, err := suite.s.Save(bytes.NewReader([]byte(utils.RandString(100))), "")
+	suite.NoError(err)
+
+	suite.NoError(suite.s.Delete(sum))
+	_, err = os.Stat(suite.s.Path(sum))
+	suite.True(os.IsNotExist(err))
+
+	// Deleting a missing binary is not an error.
+	suite.NoError(suite.s.Delete(sum))
+}
+
+func TestStoreSuite(t *testing.T) {
+	suite.Run(t, new(StoreSuite))
+}
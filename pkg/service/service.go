@@ -8,6 +8,7 @@ import (
 
 	"github.com/gofiber/fiber/v2"
 	"github.com/imilchev/rpi-feeder/pkg/mqtt/model"
+	"github.com/imilchev/rpi-feeder/pkg/service/alerts"
 	"github.com/imilchev/rpi-feeder/pkg/service/config"
 	"github.com/imilchev/rpi-feeder/pkg/service/controllers"
 	v1 "github.com/imilchev/rpi-feeder/pkg/service/controllers/v1"
@@ -16,7 +17,10 @@ import (
 	"github.com/imilchev/rpi-feeder/pkg/service/middleware"
 	"github.com/imilchev/rpi-feeder/pkg/service/models"
 	"github.com/imilchev/rpi-feeder/pkg/service/mqtt"
+	"github.com/imilchev/rpi-feeder/pkg/service/releases"
+	"github.com/imilchev/rpi-feeder/pkg/service/watchdog"
 	"github.com/imilchev/rpi-feeder/pkg/utils"
+	"github.com/imilchev/rpi-feeder/pkg/version"
 	"go.uber.org/zap"
 )
 
@@ -26,13 +30,20 @@ type Service struct {
 	db           *db.Database
 	feedersRepo  repos.FeedersRepository
 	feedLogsRepo repos.FeedLogsRepository
+	missedRepo   repos.MissedFeedingsRepository
+	commandsRepo repos.FeedCommandsRepository
+	levelsRepo   repos.FoodLevelsRepository
+	calibRepo    repos.CalibrationsRepository
+	telemRepo    repos.TelemetryRepository
+	alerter      alerts.Alerter
+	watchdog     watchdog.Watchdog
 	mqtt         mqtt.MqttManager
 	shutdownChan chan os.Signal
 	controllers  []controllers.Controller
 }
 
-func NewService(configPath string) (*Service, error) {
-	cfg, err := config.ReadConfig(configPath)
+func NewService(configPath string, overrides []string) (*Service, error) {
+	cfg, err := config.ReadConfig(configPath, overrides)
 	if err != nil {
 		return nil, err
 	}
@@ -45,6 +56,8 @@ func NewService(configPath string) (*Service, error) {
 	fCfg := fiber.Config{
 		ReadTimeout:  time.Duration(cfg.Server.ReadTimeout) * time.Second,
 		ErrorHandler: middleware.ErrorHandler,
+		// The body of release uploads is the feeder binary.
+		BodyLimit: int(cfg.Releases.MaxSizeMb) * 1024 * 1024,
 	}
 	db, err := db.NewDatabaseConnection(cfg.Database)
 	if err != nil {
@@ -57,16 +70,40 @@ func NewService(configPath string) (*Service, error) {
 		db:           db,
 		feedersRepo:  repos.NewFeedersRepository(db.DB),
 		feedLogsRepo: repos.NewFeedLogsRepository(db.DB),
+		missedRepo:   repos.NewMissedFeedingsRepository(db.DB),
+		commandsRepo: repos.NewFeedCommandsRepository(db.DB),
+		levelsRepo:   repos.NewFoodLevelsRepository(db.DB),
+		calibRepo:    repos.NewCalibrationsRepository(db.DB),
+		telemRepo:    repos.NewTelemetryRepository(db.DB),
+		alerter:      alerts.NewAlerter(repos.NewAlertsRepository(db.DB), cfg.Alerts),
 		shutdownChan: make(chan os.Signal, 1),
 	}
+	app.watchdog = watchdog.NewWatchdog(app.feedersRepo, app.alerter, cfg.Watchdog)
 
-	mqtt, err := mqtt.NewMqttManager(cfg.Mqtt, app.updateFeederStatus, app.storeFeedLogs)
+	store, err := releases.NewStore(cfg.Releases.Path)
+	if err != nil {
+		return nil, err
+	}
+
+	mqtt, err := mqtt.NewMqttManager(
+		cfg.Mqtt,
+		app.updateFeederStatus,
+		app.storeFeedLogs,
+		app.storeMissedFeedings,
+		app.storeFeedResult,
+		app.storeFoodLevel,
+		app.storeCalibration,
+		app.storeTelemetry,
+		app.storeUpdateResult)
 	if err != nil {
 		return nil, err
 	}
 	app.mqtt = mqtt
 	app.controllers = []controllers.Controller{
-		v1.NewFeederController(db.DB, mqtt),
+		v1.NewFeederController(db.DB, mqtt, cfg.Limits),
+		v1.NewAlertsController(db.DB),
+		v1.NewReleasesController(db.DB, mqtt, store, cfg.Releases),
+		v1.NewVersionController(),
 	}
 
 	signal.Notify(app.shutdownChan, os.Interrupt) // Catch OS signals.
@@ -77,7 +114,8 @@ func NewService(configPath string) (*Service, error) {
 // StartServerWithGracefulShutdown function for starting server with a graceful shutdown.
 func (s *Service) StartServerWithGracefulShutdown() {
 	defer zap.S().Sync() //nolint
-	zap.S().Info("Starting RPi feeder web service...")
+	zap.S().Infof("Starting RPi feeder web service %s...", version.Get())
+	s.watchdog.Start()
 	// Create channel for idle connections.
 	idleConnsClosed := make(chan struct{})
 
@@ -99,6 +137,7 @@ func (s *Service) StartServerWithGracefulShutdown() {
 
 	<-idleConnsClosed
 	zap.S().Info("Sucessfully closed all API connections.")
+	s.watchdog.Stop()
 
 	if err := s.db.Close(); err != nil {
 		return
@@ -130,25 +169,46 @@ func (a *Service) registerHandlers() {
 
 func (s *Service) updateFeederStatus(clientId string, msg model.StatusMessage) error {
 	m := models.Feeder{
-		ClientId:        clientId,
-		SoftwareVersion: msg.SoftwareVersion,
-		Status:          msg.Status,
+		ClientId:               clientId,
+		SoftwareVersion:        msg.SoftwareVersion,
+		Status:                 msg.Status,
+		ConfigRevision:         msg.ConfigRevision,
+		ConfigPendingRestart:   msg.ConfigPendingRestart,
+		ConfigRejectedRevision: msg.ConfigRejectedRevision,
+		ConfigError:            msg.ConfigError,
 	}
+
+	existing, err := s.feedersRepo.GetFeederByClientId(clientId)
 	if msg.Status == model.OfflineStatus {
 		t := time.Now().UTC().Unix()
 		m.LastOnline = &t
+
+		// The offline status does not report the config in use, which is
+		// still the one of the last online status.
+		m.ConfigRevision = existing.ConfigRevision
+		m.ConfigPendingRestart = existing.ConfigPendingRestart
+		m.ConfigRejectedRevision = existing.ConfigRejectedRevision
+		m.ConfigError = existing.ConfigError
 	}
 
-	_, err := s.feedersRepo.GetFeederByClientId(clientId)
 	if err != nil {
 		if _, err := s.feedersRepo.CreateFeeder(m); err != nil {
 			return err
 		}
-		return nil
+	} else if _, err := s.feedersRepo.UpdateFeeder(m); err != nil {
+		return err
 	}
 
-	_, err = s.feedersRepo.UpdateFeeder(m)
-	return err
+	if msg.Status == model.OnlineStatus {
+		if version.IsOlder(msg.SoftwareVersion, version.Version) {
+			zap.S().Warnf("Feeder %s runs version %s, which is older than %s.",
+				clientId, msg.SoftwareVersion, version.Version)
+		}
+		if err := s.watchdog.Seen(clientId); err != nil {
+			return err
+		}
+	}
+	return s.alerter.EvaluateStatus(clientId, msg)
 }
 
 func (s *Service) storeFeedLogs(clientId string, msg model.FeedLogCollectionMessage) error {
@@ -162,6 +222,7 @@ func (s *Service) storeFeedLogs(clientId string, msg model.FeedLogCollectionMess
 		f = append(f, models.FeedLog{
 			ClientId:  clientId,
 			Portions:  m.Portions,
+			Grams:     m.Grams,
 			Timestamp: m.Timestamp.UTC().Unix(),
 		})
 	}
@@ -169,3 +230,121 @@ func (s *Service) storeFeedLogs(clientId string, msg model.FeedLogCollectionMess
 	_, err = s.feedLogsRepo.CreateFeedLogs(f)
 	return err
 }
+
+func (s *Service) storeMissedFeedings(clientId string, msg model.MissedFeedingCollectionMessage) error {
+	_, err := s.feedersRepo.GetFeederByClientId(clientId)
+	if err != nil {
+		return err
+	}
+
+	var f []models.MissedFeeding
+	for _, m := range msg.Value {
+		f = append(f, models.MissedFeeding{
+			ClientId:    clientId,
+			Portions:    m.Portions,
+			ScheduledAt: m.ScheduledAt.UTC().Unix(),
+			CaughtUp:    m.CaughtUp,
+		})
+	}
+
+	_, err = s.missedRepo.CreateMissedFeedings(f)
+	return err
+}
+
+func (s *Service) storeFoodLevel(clientId string, msg model.FoodLevelMessage) error {
+	_, err := s.feedersRepo.GetFeederByClientId(clientId)
+	if err != nil {
+		return err
+	}
+
+	_, err = s.levelsRepo.CreateFoodLevel(models.FoodLevel{
+		ClientId:  clientId,
+		Level:     msg.Level,
+		Distance:  msg.DistanceCm,
+		Timestamp: msg.Timestamp.UTC().Unix(),
+	})
+	return err
+}
+
+func (s *Service) storeCalibration(clientId string, msg model.CalibrationMessage) error {
+	_, err := s.feedersRepo.GetFeederByClientId(clientId)
+	if err != nil {
+		return err
+	}
+
+	// Failed calibrations do not change the feeder, so there is nothing to
+	// store.
+	if msg.Error != "" {
+		zap.S().Warnf("Calibration of feeder %s failed. %s", clientId, msg.Error)
+		return nil
+	}
+
+	_, err = s.calibRepo.CreateCalibration(models.Calibration{
+		ClientId:        clientId,
+		MsPerGram:       msg.MsPerGram,
+		OffsetMs:        msg.OffsetMs,
+		GramsPerPortion: msg.GramsPerPortion,
+		PortionMs:       msg.PortionMs,
+		Timestamp:       msg.Timestamp.UTC().Unix(),
+	})
+	return err
+}
+
+func (s *Service) storeTelemetry(clientId string, msg model.TelemetryMessage) error {
+	_, err := s.feedersRepo.GetFeederByClientId(clientId)
+	if err != nil {
+		return err
+	}
+
+	t, err := s.telemRepo.CreateTelemetry(models.Telemetry{
+		ClientId:          clientId,
+		UptimeSeconds:     msg.UptimeSeconds,
+		CpuTemperature:    msg.CpuTemperature,
+		FreeDiskBytes:     msg.FreeDiskBytes,
+		UnflushedFeedLogs: msg.UnflushedFeedLogs,
+		MqttReconnects:    msg.MqttReconnects,
+		Timestamp:         msg.Timestamp.UTC().Unix(),
+	})
+	if err != nil {
+		return err
+	}
+	if err := s.feedersRepo.UpdateFeederTelemetry(t); err != nil {
+		return err
+	}
+
+	// The telemetry is the heartbeat of the feeder.
+	return s.watchdog.Seen(clientId)
+}
+
+func (s *Service) storeUpdateResult(clientId string, msg model.UpdateResultMessage) error {
+	_, err := s.feedersRepo.GetFeederByClientId(clientId)
+	if err != nil {
+		return err
+	}
+
+	// The new version itself is reported with the status of the feeder.
+	if msg.Success {
+		zap.S().Infof("Feeder %s was updated to %s.", clientId, msg.Version)
+	} else {
+		zap.S().Warnf("Feeder %s failed to update to %s. %s", clientId, msg.Version, msg.Error)
+	}
+	return s.alerter.EvaluateUpdateResult(clientId, msg)
+}
+
+func (s *Service) storeFeedResult(clientId string, msg model.FeedResultMessage) error {
+	// Scheduled feedings are not commands, only their failures are reported.
+	if msg.CommandId == "" {
+		return s.alerter.EvaluateFeedResult(clientId, msg)
+	}
+
+	c, err := s.commandsRepo.GetFeedCommand(clientId, msg.CommandId)
+	if err != nil {
+		return err
+	}
+
+	c.ApplyResult(msg)
+	if _, err := s.commandsRepo.UpdateFeedCommand(c); err != nil {
+		return err
+	}
+	return s.alerter.EvaluateFeedResult(clientId, msg)
+}
@@ -0,0 +1,188 @@
+package watchdog
+
+import (
+	"sync"
+	"time"
+
+	"github.com/imilchev/rpi-feeder/pkg/mqtt/model"
+	"github.com/imilchev/rpi-feeder/pkg/service/alerts"
+	"github.com/imilchev/rpi-feeder/pkg/service/config"
+	"github.com/imilchev/rpi-feeder/pkg/service/db/repos"
+	"go.uber.org/zap"
+)
+
+// maxCheckInterval is the longest time between two checks for stale feeders.
+const maxCheckInterval = 30 * time.Second
+
+// Watchdog marks feeders offline that are online but have not sent a
+// heartbeat within the configured timeout. This covers the cases in which the
+// last will of a feeder is never delivered, e.g. because the broker lost it.
+type Watchdog interface {
+	Start()
+	Stop()
+
+	// Seen records a heartbeat of the feeder. A feeder marked offline by the
+	// watchdog is marked online again.
+	Seen(clientId string) error
+}
+
+type watchdog struct {
+	feedersRepo repos.FeedersRepository
+	alerter     alerts.Alerter
+	timeout     time.Duration
+	interval    time.Duration
+	now         func() time.Time
+
+	// startedAt is when the timeout starts for feeders that have not sent a
+	// heartbeat since the watchdog was started. It is not stored as the last
+	// time they were online.
+	startedAt time.Time
+
+	mu       sync.Mutex
+	lastSeen map[string]time.Time
+
+	// stale holds the feeders marked offline by the watchdog.
+	stale map[string]bool
+
+	stopOnce    sync.Once
+	stopChan    chan struct{}
+	stoppedChan chan struct{}
+}
+
+func NewWatchdog(
+	feedersRepo repos.FeedersRepository, alerter alerts.Alerter, cfg config.Watchdog) Watchdog {
+	timeout := time.Duration(cfg.TimeoutSeconds) * time.Second
+	interval := timeout / 2
+	if interval > maxCheckInterval {
+		interval = maxCheckInterval
+	}
+	return newWatchdog(feedersRepo, alerter, timeout, interval, time.Now)
+}
+
+func newWatchdog(
+	feedersRepo repos.FeedersRepository,
+	alerter alerts.Alerter,
+	timeout, interval time.Duration,
+	now func() time.Time) *watchdog {
+	return &watchdog{
+		feedersRepo: feedersRepo,
+		alerter:     alerter,
+		timeout:     timeout,
+		interval:    interval,
+		now:         now,
+		startedAt:   now(),
+		lastSeen:    make(map[string]time.Time),
+		stale:       make(map[string]bool),
+		stopChan:    make(chan struct{}),
+		stoppedChan: make(chan struct{}),
+	}
+}
+
+func (w *watchdog) Start() {
+	go w.run()
+	zap.S().Infof("Marking feeders offline after %s without a heartbeat.", w.timeout)
+}
+
+func (w *watchdog) Stop() {
+	w.stopOnce.Do(func() {
+		close(w.stopChan)
+		<-w.stoppedChan
+	})
+}
+
+func (w *watchdog) Seen(clientId string) error {
+	w.mu.Lock()
+	w.lastSeen[clientId] = w.now()
+	wasStale := w.stale[clientId]
+	delete(w.stale, clientId)
+	w.mu.Unlock()
+
+	if !wasStale {
+		return nil
+	}
+
+	f, err := w.feedersRepo.GetFeederByClientId(clientId)
+	if err != nil {
+		return err
+	}
+	if f.Status == model.OfflineStatus {
+		zap.S().Infof("Feeder %s is reporting again.", clientId)
+		f.Status = model.OnlineStatus
+		f.LastOnline = nil
+		if _, err := w.feedersRepo.UpdateFeeder(f); err != nil {
+			return err
+		}
+	}
+	return w.alerter.ResolveStale(clientId)
+}
+
+func (w *watchdog) run() {
+	defer close(w.stoppedChan)
+
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			if err := w.check(); err != nil {
+				zap.S().Errorf("Failed to check for stale feeders. %v", err)
+			}
+		case <-w.stopChan:
+			return
+		}
+	}
+}
+
+// check marks the online feeders offline that have not sent a heartbeat
+// within the timeout.
+func (w *watchdog) check() error {
+	feeders, err := w.feedersRepo.GetFeeders()
+	if err != nil {
+		return err
+	}
+
+	now := w.now()
+	for _, f := range feeders {
+		if f.Status != model.OnlineStatus {
+			continue
+		}
+
+		w.mu.Lock()
+		lastSeen, ok := w.lastSeen[f.ClientId]
+		w.mu.Unlock()
+		if !ok && f.Telemetry != nil {
+			// The heartbeats before the watchdog was started are not known,
+			// the latest telemetry is the last time the feeder was seen.
+			lastSeen = time.Unix(f.Telemetry.Timestamp, 0)
+		}
+		since := lastSeen
+		if since.Before(w.startedAt) {
+			since = w.startedAt
+		}
+		if now.Sub(since) <= w.timeout {
+			continue
+		}
+
+		f.Status = model.OfflineStatus
+		f.LastOnline = nil
+		if lastSeen.IsZero() {
+			zap.S().Warnf("Feeder %s has not sent a heartbeat, marking it offline.", f.ClientId)
+		} else {
+			zap.S().Warnf("Feeder %s has not sent a heartbeat since %s, marking it offline.",
+				f.ClientId, lastSeen)
+			t := lastSeen.UTC().Unix()
+			f.LastOnline = &t
+		}
+		if _, err := w.feedersRepo.UpdateFeeder(f); err != nil {
+			return err
+		}
+
+		w.mu.Lock()
+		w.stale[f.ClientId] = true
+		w.mu.Unlock()
+		if err := w.alerter.RaiseStale(f.ClientId, lastSeen); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,183 @@
+package watchdog
+
+import (
+	"math/rand"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/imilchev/rpi-feeder/pkg/mqtt/model"
+	"github.com/imilchev/rpi-feeder/pkg/service/alerts"
+	"github.com/imilchev/rpi-feeder/pkg/service/config"
+	"github.com/imilchev/rpi-feeder/pkg/service/models"
+	fake "github.com/imilchev/rpi-feeder/tests/fake/repos"
+	modelUtils "github.com/imilchev/rpi-feeder/tests/utils/models"
+	"github.com/stretchr/testify/suite"
+)
+
+const timeout = 5 * time.Minute
+
+type WatchdogSuite struct {
+	suite.Suite
+	feeders *fake.FakeFeedersRepository
+	alerts  *fake.FakeAlertsRepository
+	w       *watchdog
+
+	mu  sync.Mutex
+	now time.Time
+}
+
+func (suite *WatchdogSuite) SetupTest() {
+	suite.feeders = &fake.FakeFeedersRepository{}
+	suite.alerts = &fake.FakeAlertsRepository{}
+	suite.now = time.Now().UTC()
+	alerter := alerts.NewAlerter(suite.alerts, config.Alerts{LowFoodLevel: 20, EmptyFoodLevel: 5})
+	suite.w = newWatchdog(suite.feeders, alerter, timeout, 10*time.Millisecond, suite.getNow)
+}
+
+func (suite *WatchdogSuite) TestCheck_Stale() {
+	f := suite.onlineFeeder()
+	suite.NoError(suite.w.Seen(f.ClientId))
+	lastSeen := suite.now
+
+	suite.advance(timeout + time.Second)
+	suite.NoError(suite.w.check())
+
+	ff, err := suite.feeders.GetFeederByClientId(f.ClientId)
+	suite.NoError(err)
+	suite.Equal(model.OfflineStatus, ff.Status)
+	suite.Equal(lastSeen.Unix(), *ff.LastOnline)
+
+	suite.Equal(1, len(suite.alerts.Alerts))
+	suite.Equal(models.StaleAlertType, suite.alerts.Alerts[0].Type)
+	suite.Equal(f.ClientId, suite.alerts.Alerts[0].ClientId)
+}
+
+func (suite *WatchdogSuite) TestCheck_NotSeenSinceStart() {
+	f := suite.onlineFeeder()
+	startedAt := suite.now
+
+	suite.advance(timeout + time.Second)
+	suite.NoError(suite.w.check())
+
+	ff, err := suite.feeders.GetFeederByClientId(f.ClientId)
+	suite.NoError(err)
+	suite.Equal(model.OfflineStatus, ff.Status)
+	suite.Nil(ff.LastOnline)
+
+	suite.Equal(1, len(suite.alerts.Alerts))
+	suite.NotContains(suite.alerts.Alerts[0].Message, startedAt.Format(time.RFC3339))
+}
+
+func (suite *WatchdogSuite) TestCheck_NotSeenSinceStartWithTelemetry() {
+	f := suite.onlineFeeder()
+	telemetry := modelUtils.RandomTelemetryForFeeder(f.ClientId)
+	telemetry.Timestamp = suite.now.Add(-time.Hour).Unix()
+	suite.feeders.Feeders[0].Telemetry = &telemetry
+
+	// The timeout starts when the watchdog is started.
+	suite.advance(timeout - time.Second)
+	suite.NoError(suite.w.check())
+	suite.Equal(model.OnlineStatus, suite.feeders.Feeders[0].Status)
+
+	suite.advance(2 * time.Second)
+	suite.NoError(suite.w.check())
+
+	ff, err := suite.feeders.GetFeederByClientId(f.ClientId)
+	suite.NoError(err)
+	suite.Equal(model.OfflineStatus, ff.Status)
+	suite.Equal(telemetry.Timestamp, *ff.LastOnline)
+
+	suite.Equal(1, len(suite.alerts.Alerts))
+	suite.Contains(suite.alerts.Alerts[0].Message,
+		time.Unix(telemetry.Timestamp, 0).UTC().Format(time.RFC3339))
+}
+
+func (suite *WatchdogSuite) TestCheck_WithinTimeout() {
+	f := suite.onlineFeeder()
+	suite.advance(timeout - time.Second)
+	suite.NoError(suite.w.Seen(f.ClientId))
+
+	suite.advance(timeout - time.Second)
+	suite.NoError(suite.w.check())
+
+	ff, err := suite.feeders.GetFeederByClientId(f.ClientId)
+	suite.NoError(err)
+	suite.Equal(model.OnlineStatus, ff.Status)
+	suite.Empty(suite.alerts.Alerts)
+}
+
+func (suite *WatchdogSuite) TestCheck_OfflineFeedersIgnored() {
+	f := modelUtils.RandomFeeder()
+	f.Status = model.OfflineStatus
+	t := suite.now.Unix()
+	f.LastOnline = &t
+	suite.feeders.Feeders = append(suite.feeders.Feeders, f)
+
+	suite.advance(timeout + time.Second)
+	suite.NoError(suite.w.check())
+
+	suite.Equal(f, suite.feeders.Feeders[0])
+	suite.Empty(suite.alerts.Alerts)
+}
+
+func (suite *WatchdogSuite) TestCheck_AlertRaisedOnce() {
+	suite.onlineFeeder()
+
+	suite.advance(timeout + time.Second)
+	suite.NoError(suite.w.check())
+	suite.advance(timeout + time.Second)
+	suite.NoError(suite.w.check())
+
+	suite.Equal(1, len(suite.alerts.Alerts))
+}
+
+func (suite *WatchdogSuite) TestSeen_StaleFeederBack() {
+	f := suite.onlineFeeder()
+	suite.advance(timeout + time.Second)
+	suite.NoError(suite.w.check())
+
+	suite.NoError(suite.w.Seen(f.ClientId))
+
+	ff, err := suite.feeders.GetFeederByClientId(f.ClientId)
+	suite.NoError(err)
+	suite.Equal(model.OnlineStatus, ff.Status)
+	suite.Nil(ff.LastOnline)
+	suite.NotNil(suite.alerts.Alerts[0].ResolvedAt)
+}
+
+func (suite *WatchdogSuite) TestStart() {
+	suite.onlineFeeder()
+	suite.advance(timeout + time.Second)
+
+	suite.w.Start()
+	time.Sleep(25 * time.Millisecond)
+	suite.w.Stop()
+
+	suite.Equal(model.OfflineStatus, suite.feeders.Feeders[0].Status)
+}
+
+func (suite *WatchdogSuite) onlineFeeder() models.Feeder {
+	f := modelUtils.RandomFeeder()
+	f.Status = model.OnlineStatus
+	f.LastOnline = nil
+	suite.feeders.Feeders = append(suite.feeders.Feeders, f)
+	return f
+}
+
+func (suite *WatchdogSuite) getNow() time.Time {
+	suite.mu.Lock()
+	defer suite.mu.Unlock()
+	return suite.now
+}
+
+func (suite *WatchdogSuite) advance(d time.Duration) {
+	suite.mu.Lock()
+	defer suite.mu.Unlock()
+	suite.now = suite.now.Add(d)
+}
+
+func TestWatchdogSuite(t *testing.T) {
+	rand.Seed(time.Now().UnixNano())
+	suite.Run(t, new(WatchdogSuite))
+}
@@ -1,6 +1,35 @@
 package utils
 
-import "github.com/go-playground/validator/v10"
+import (
+	"time"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/imilchev/rpi-feeder/pkg/version"
+	"github.com/robfig/cron/v3"
+)
 
 // use a single instance of Validate, it caches struct info
-var Validate = validator.New()
+var Validate = newValidator()
+
+func newValidator() *validator.Validate {
+	v := validator.New()
+	if err := v.RegisterValidation("cron", isCron); err != nil {
+		panic(err)
+	}
+	if err := v.RegisterValidation("semver", isSemver); err != nil {
+		panic(err)
+	}
+	return v
+}
+
+// isCron validates that a field is a standard 5-field cron expression that
+// matches at some point, unlike e.g. "0 0 30 2 *".
+func isCron(fl validator.FieldLevel) bool {
+	schedule, err := cron.ParseStandard(fl.Field().String())
+	return err == nil && !schedule.Next(time.Now()).IsZero()
+}
+
+// isSemver validates that a field is a semantic version.
+func isSemver(fl validator.FieldLevel) bool {
+	return version.IsSemantic(fl.Field().String())
+}
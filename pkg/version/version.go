@@ -0,0 +1,159 @@
+package version
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// The build information is set at build time with ldflags, e.g.
+//
+//	go build -ldflags "-X github.com/imilchev/rpi-feeder/pkg/version.Version=v1.2.0"
+var (
+	Version   = "dev"
+	Commit    = "unknown"
+	BuildDate = "unknown"
+)
+
+type Info struct {
+	Version   string `json:"version"`
+	Commit    string `json:"commit"`
+	BuildDate string `json:"buildDate"`
+}
+
+// Get returns the build information of the running binary.
+func Get() Info {
+	return Info{Version: Version, Commit: Commit, BuildDate: BuildDate}
+}
+
+func (i Info) String() string {
+	return fmt.Sprintf("%s (commit %s, built %s)", i.Version, i.Commit, i.BuildDate)
+}
+
+// IsOlder returns true if v is an older semantic version than other. Versions
+// that are not semantic versions, like development builds, are never
+// considered older. Builds that are commits ahead of a version, as described
+// by git describe, are newer than the version.
+func IsOlder(v, other string) bool {
+	a, err := parse(v)
+	if err != nil {
+		return false
+	}
+	b, err := parse(other)
+	if err != nil {
+		return false
+	}
+	return a.compare(b) < 0
+}
+
+// IsSemantic returns true if v is a semantic version in the
+// vMAJOR.MINOR.PATCH[-PRERELEASE] format.
+func IsSemantic(v string) bool {
+	_, err := parse(v)
+	return err == nil
+}
+
+// describeSuffix matches the suffix git describe appends to the version of
+// builds that are commits ahead of it, e.g. -3-gabc123 or -3-gabc123-dirty.
+var describeSuffix = regexp.MustCompile(`-(\d+)-g[0-9a-f]+(-dirty)?$`)
+
+type semver struct {
+	major, minor, patch int
+	prerelease          string
+
+	// ahead is the amount of commits the build is ahead of the version.
+	ahead int
+}
+
+// parse parses a semantic version in the vMAJOR.MINOR.PATCH[-PRERELEASE]
+// format. The v prefix, build metadata and a git describe suffix are
+// optional.
+func parse(v string) (semver, error) {
+	s := strings.TrimPrefix(v, "v")
+	if i := strings.IndexByte(s, '+'); i >= 0 {
+		s = s[:i]
+	}
+
+	ahead := 0
+	if m := describeSuffix.FindStringSubmatch(s); m != nil {
+		ahead, _ = strconv.Atoi(m[1])
+		s = s[:len(s)-len(m[0])]
+	}
+	s = strings.TrimSuffix(s, "-dirty")
+
+	var prerelease string
+	if i := strings.IndexByte(s, '-'); i >= 0 {
+		s, prerelease = s[:i], s[i+1:]
+	}
+
+	parts := strings.Split(s, ".")
+	if len(parts) != 3 {
+		return semver{}, fmt.Errorf("%q is not a semantic version", v)
+	}
+	var nums [3]int
+	for i, p := range parts {
+		n, err := strconv.Atoi(p)
+		if err != nil || n < 0 {
+			return semver{}, fmt.Errorf("%q is not a semantic version", v)
+		}
+		nums[i] = n
+	}
+	return semver{
+		major:      nums[0],
+		minor:      nums[1],
+		patch:      nums[2],
+		prerelease: prerelease,
+		ahead:      ahead,
+	}, nil
+}
+
+func (a semver) compare(b semver) int {
+	for _, d := range []int{a.major - b.major, a.minor - b.minor, a.patch - b.patch} {
+		if d != 0 {
+			return d
+		}
+	}
+
+	// A pre-release precedes the release of the same version.
+	switch {
+	case a.prerelease == b.prerelease:
+		return a.ahead - b.ahead
+	case a.prerelease == "":
+		return 1
+	case b.prerelease == "":
+		return -1
+	}
+	return comparePrerelease(a.prerelease, b.prerelease)
+}
+
+// comparePrerelease compares the dot separated identifiers of two
+// pre-releases from left to right. Numeric identifiers are compared
+// numerically and precede alphanumeric ones, which are compared in ASCII
+// order. A pre-release with more identifiers takes precedence if the
+// preceding ones are equal.
+func comparePrerelease(a, b string) int {
+	as, bs := strings.Split(a, "."), strings.Split(b, ".")
+	for i := 0; i < len(as) && i < len(bs); i++ {
+		an, aErr := strconv.ParseUint(as[i], 10, 64)
+		bn, bErr := strconv.ParseUint(bs[i], 10, 64)
+		switch {
+		case aErr == nil && bErr == nil:
+			if an != bn {
+				if an < bn {
+					return -1
+				}
+				return 1
+			}
+		case aErr == nil:
+			return -1
+		case bErr == nil:
+			return 1
+		default:
+			if c := strings.Compare(as[i], bs[i]); c != 0 {
+				return c
+			}
+		}
+	}
+	return len(as) - len(bs)
+}
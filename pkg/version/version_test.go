@@ -0,0 +1,78 @@
+package version
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+)
+
+type VersionSuite struct {
+	suite.Suite
+}
+
+func (suite *VersionSuite) TestIsOlder() {
+	cases := []struct {
+		v, other string
+		older    bool
+	}{
+		{"v1.0.0", "v1.0.1", true},
+		{"v1.0.9", "v1.1.0", true},
+		{"v1.9.9", "v2.0.0", true},
+		{"1.2.0", "v1.3.0", true},
+		{"v1.2.0-rc.1", "v1.2.0", true},
+		{"v1.2.0-alpha", "v1.2.0-beta", true},
+		{"v1.2.0", "v1.2.0", false},
+		{"v1.2.0", "v1.2.0+build.5", false},
+		{"v1.2.0", "v1.2.0-rc.1", false},
+		{"v2.0.0", "v1.9.9", false},
+		{"dev", "v1.0.0", false},
+		{"v1.0.0", "dev", false},
+		{"v1.0", "v1.1.0", false},
+
+		// Pre-release identifiers are compared one by one.
+		{"v1.2.0-rc.9", "v1.2.0-rc.10", true},
+		{"v1.2.0-rc.10", "v1.2.0-rc.9", false},
+		{"v1.2.0-alpha", "v1.2.0-alpha.1", true},
+		{"v1.2.0-alpha.1", "v1.2.0-alpha.beta", true},
+		{"v1.2.0-alpha.beta", "v1.2.0-beta", true},
+		{"v1.2.0-beta.2", "v1.2.0-beta.11", true},
+		{"v1.2.0-rc.1", "v1.2.0-rc.1", false},
+
+		// Builds ahead of a tag are newer than the tag.
+		{"v1.2.0", "v1.2.0-3-gabc123", true},
+		{"v1.2.0-3-gabc123", "v1.2.0", false},
+		{"v1.2.0-3-gabc123", "v1.2.0-12-gdef456", true},
+		{"v1.2.0-12-gdef456", "v1.2.0-3-gabc123", false},
+		{"v1.2.0-3-gabc123", "v1.2.1", true},
+		{"v1.2.0-rc.1-3-gabc123", "v1.2.0", true},
+		{"v1.2.0-rc.1", "v1.2.0-rc.1-3-gabc123", true},
+		{"v1.2.0-3-gabc123-dirty", "v1.2.0", false},
+		{"v1.2.0-dirty", "v1.2.0", false},
+		{"v1.2.0", "v1.2.0-dirty", false},
+	}
+	for _, c := range cases {
+		suite.Equal(c.older, IsOlder(c.v, c.other), "%s < %s", c.v, c.other)
+	}
+}
+
+func (suite *VersionSuite) TestIsSemantic() {
+	suite.True(IsSemantic("v1.2.0"))
+	suite.True(IsSemantic("1.2.0-rc.1+build.5"))
+	suite.True(IsSemantic("v1.2.0-3-gabc123-dirty"))
+	suite.False(IsSemantic("dev"))
+	suite.False(IsSemantic("v1.2"))
+	suite.False(IsSemantic("v1.x.0"))
+}
+
+func (suite *VersionSuite) TestGet() {
+	Version, Commit, BuildDate = "v1.2.0", "abc123", "2022-02-01T08:00:00Z"
+	defer func() { Version, Commit, BuildDate = "dev", "unknown", "unknown" }()
+
+	i := Get()
+	suite.Equal(Info{Version: "v1.2.0", Commit: "abc123", BuildDate: "2022-02-01T08:00:00Z"}, i)
+	suite.Equal("v1.2.0 (commit abc123, built 2022-02-01T08:00:00Z)", i.String())
+}
+
+func TestVersionSuite(t *testing.T) {
+	suite.Run(t, new(VersionSuite))
+}
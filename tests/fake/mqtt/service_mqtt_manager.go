@@ -1,6 +1,8 @@
 package mqtt
 
 import (
+	"context"
+
 	"github.com/imilchev/rpi-feeder/pkg/mqtt/model"
 )
 
@@ -9,10 +11,44 @@ type FeedRequests struct {
 	Msg      model.FeedMessage
 }
 
+type CancelRequests struct {
+	ClientId string
+	Msg      model.CancelMessage
+}
+
+type ScheduleRequests struct {
+	ClientId string
+	Msg      model.ScheduleMessage
+}
+
+type CalibrateRequests struct {
+	ClientId string
+	Msg      model.CalibrateMessage
+}
+
+type UpdateRequests struct {
+	ClientId string
+	Msg      model.UpdateMessage
+}
+
+type ConfigRequests struct {
+	ClientId string
+	Msg      model.ConfigMessage
+}
+
 // FakeServiceMqttManager provides an easy way of mocking a MqttManager.
 // The functions in this fake implementation do not perform any validation.
 type FakeServiceMqttManager struct {
-	Feeds []FeedRequests
+	Feeds      []FeedRequests
+	Cancels    []CancelRequests
+	Schedules  []ScheduleRequests
+	Calibrates []CalibrateRequests
+	Updates    []UpdateRequests
+	Configs    []ConfigRequests
+
+	// FeedResult If this is set, it is returned by SendFeedCommandAndWait.
+	// Otherwise SendFeedCommandAndWait waits until its context is done.
+	FeedResult *model.FeedResultMessage
 
 	// Error If this is set, any function will return it.
 	Error error
@@ -27,6 +63,66 @@ func (m *FakeServiceMqttManager) SendFeedCommand(clientId string, msg model.Feed
 	return nil
 }
 
+func (m *FakeServiceMqttManager) SendFeedCommandAndWait(
+	ctx context.Context, clientId string, msg model.FeedMessage) (model.FeedResultMessage, error) {
+	if err := m.SendFeedCommand(clientId, msg); err != nil {
+		return model.FeedResultMessage{}, err
+	}
+
+	if m.FeedResult != nil {
+		result := *m.FeedResult
+		result.CommandId = msg.CommandId
+		return result, nil
+	}
+	<-ctx.Done()
+	return model.FeedResultMessage{}, ctx.Err()
+}
+
+func (m *FakeServiceMqttManager) SendCancel(clientId string, msg model.CancelMessage) error {
+	if m.Error != nil {
+		return m.Error
+	}
+
+	m.Cancels = append(m.Cancels, CancelRequests{ClientId: clientId, Msg: msg})
+	return nil
+}
+
+func (m *FakeServiceMqttManager) SendSchedule(clientId string, msg model.ScheduleMessage) error {
+	if m.Error != nil {
+		return m.Error
+	}
+
+	m.Schedules = append(m.Schedules, ScheduleRequests{ClientId: clientId, Msg: msg})
+	return nil
+}
+
+func (m *FakeServiceMqttManager) SendCalibrate(clientId string, msg model.CalibrateMessage) error {
+	if m.Error != nil {
+		return m.Error
+	}
+
+	m.Calibrates = append(m.Calibrates, CalibrateRequests{ClientId: clientId, Msg: msg})
+	return nil
+}
+
+func (m *FakeServiceMqttManager) SendUpdate(clientId string, msg model.UpdateMessage) error {
+	if m.Error != nil {
+		return m.Error
+	}
+
+	m.Updates = append(m.Updates, UpdateRequests{ClientId: clientId, Msg: msg})
+	return nil
+}
+
+func (m *FakeServiceMqttManager) SendConfig(clientId string, msg model.ConfigMessage) error {
+	if m.Error != nil {
+		return m.Error
+	}
+
+	m.Configs = append(m.Configs, ConfigRequests{ClientId: clientId, Msg: msg})
+	return nil
+}
+
 func (m *FakeServiceMqttManager) Stop() error {
 	if m.Error != nil {
 		return m.Error
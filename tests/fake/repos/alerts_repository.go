@@ -0,0 +1,84 @@
+package repos
+
+import (
+	"fmt"
+
+	"github.com/imilchev/rpi-feeder/pkg/service/models"
+)
+
+// FakeAlertsRepository provides an easy way of mocking an AlertsRepository.
+// The functions in this fake implementation do not perform any validation.
+type FakeAlertsRepository struct {
+	Alerts []models.Alert
+
+	// Error If this is set, any function will return it.
+	Error error
+}
+
+func (r *FakeAlertsRepository) CreateAlert(a models.Alert) (models.Alert, error) {
+	if r.Error != nil {
+		return models.Alert{}, r.Error
+	}
+
+	a.Id = len(r.Alerts) + 1
+	r.Alerts = append(r.Alerts, a)
+	return a, nil
+}
+
+func (r *FakeAlertsRepository) GetAlerts(activeOnly bool) (a []models.Alert, err error) {
+	if r.Error != nil {
+		return a, r.Error
+	}
+
+	a = make([]models.Alert, 0)
+	for _, al := range r.Alerts {
+		if !activeOnly || al.Active() {
+			a = append(a, al)
+		}
+	}
+	return a, nil
+}
+
+func (r *FakeAlertsRepository) GetUnresolvedAlert(clientId string, t models.AlertType) (models.Alert, error) {
+	if r.Error != nil {
+		return models.Alert{}, r.Error
+	}
+
+	for i := len(r.Alerts) - 1; i >= 0; i-- {
+		a := r.Alerts[i]
+		if a.ClientId == clientId && a.Type == t && a.ResolvedAt == nil {
+			return a, nil
+		}
+	}
+	return models.Alert{}, models.NewDoesNotExistError("Alert", "Type", string(t))
+}
+
+func (r *FakeAlertsRepository) AcknowledgeAlert(id int, at int64) (models.Alert, error) {
+	if r.Error != nil {
+		return models.Alert{}, r.Error
+	}
+
+	for i, a := range r.Alerts {
+		if a.Id == id {
+			if a.AcknowledgedAt == nil {
+				r.Alerts[i].AcknowledgedAt = &at
+			}
+			return r.Alerts[i], nil
+		}
+	}
+	return models.Alert{}, fmt.Errorf("not found")
+}
+
+func (r *FakeAlertsRepository) ResolveAlerts(clientId string, t models.AlertType, at int64) error {
+	if r.Error != nil {
+		return r.Error
+	}
+
+	for i, a := range r.Alerts {
+		if a.ClientId == clientId && a.Type == t && a.ResolvedAt == nil {
+			resolvedAt := at
+			r.Alerts[i].ResolvedAt = &resolvedAt
+		}
+	}
+	return nil
+}
@@ -0,0 +1,39 @@
+package repos
+
+import (
+	"github.com/imilchev/rpi-feeder/pkg/service/models"
+)
+
+// FakeCalibrationsRepository provides an easy way of mocking a
+// CalibrationsRepository. The functions in this fake implementation do not
+// perform any validation.
+type FakeCalibrationsRepository struct {
+	Calibrations []models.Calibration
+
+	// Error If this is set, any function will return it.
+	Error error
+}
+
+func (r *FakeCalibrationsRepository) CreateCalibration(c models.Calibration) (models.Calibration, error) {
+	if r.Error != nil {
+		return models.Calibration{}, r.Error
+	}
+
+	c.Id = len(r.Calibrations) + 1
+	r.Calibrations = append(r.Calibrations, c)
+	return c, nil
+}
+
+func (r *FakeCalibrationsRepository) GetCalibrationsForFeeder(clientId string) (c []models.Calibration, err error) {
+	if r.Error != nil {
+		return c, r.Error
+	}
+
+	c = make([]models.Calibration, 0)
+	for _, m := range r.Calibrations {
+		if m.ClientId == clientId {
+			c = append(c, m)
+		}
+	}
+	return c, nil
+}
@@ -0,0 +1,58 @@
+package repos
+
+import (
+	"fmt"
+
+	"github.com/imilchev/rpi-feeder/pkg/service/models"
+)
+
+// FakeFeedCommandsRepository provides an easy way of mocking a
+// FeedCommandsRepository. The functions in this fake implementation do not
+// perform any validation.
+type FakeFeedCommandsRepository struct {
+	FeedCommands []models.FeedCommand
+
+	// Error If this is set, any function will return it.
+	Error error
+}
+
+func (r *FakeFeedCommandsRepository) CreateFeedCommand(c models.FeedCommand) (models.FeedCommand, error) {
+	if r.Error != nil {
+		return models.FeedCommand{}, r.Error
+	}
+
+	for _, cc := range r.FeedCommands {
+		if cc.ClientId == c.ClientId && cc.Id == c.Id {
+			return models.FeedCommand{}, models.NewAlreadyExistsError("Command", "Id", c.Id)
+		}
+	}
+	r.FeedCommands = append(r.FeedCommands, c)
+	return c, nil
+}
+
+func (r *FakeFeedCommandsRepository) GetFeedCommand(clientId, id string) (models.FeedCommand, error) {
+	if r.Error != nil {
+		return models.FeedCommand{}, r.Error
+	}
+
+	for _, c := range r.FeedCommands {
+		if c.ClientId == clientId && c.Id == id {
+			return c, nil
+		}
+	}
+	return models.FeedCommand{}, models.NewDoesNotExistError("Command", "Id", id)
+}
+
+func (r *FakeFeedCommandsRepository) UpdateFeedCommand(c models.FeedCommand) (models.FeedCommand, error) {
+	if r.Error != nil {
+		return models.FeedCommand{}, r.Error
+	}
+
+	for i, cc := range r.FeedCommands {
+		if cc.ClientId == c.ClientId && cc.Id == c.Id {
+			r.FeedCommands[i] = c
+			return c, nil
+		}
+	}
+	return models.FeedCommand{}, fmt.Errorf("not found")
+}
@@ -30,3 +30,16 @@ func (r *FakeFeedLogsRepository) GetLogsForFeeder(clientId string) (f []models.F
 	f = append(f, r.FeedLogs...)
 	return f, nil
 }
+
+func (r *FakeFeedLogsRepository) GetLogsForFeederSince(clientId string, since int64) (f []models.FeedLog, err error) {
+	if r.Error != nil {
+		return f, r.Error
+	}
+
+	for _, l := range r.FeedLogs {
+		if l.ClientId == clientId && l.Timestamp > since {
+			f = append(f, l)
+		}
+	}
+	return f, nil
+}
@@ -0,0 +1,47 @@
+package repos
+
+import (
+	"fmt"
+
+	"github.com/imilchev/rpi-feeder/pkg/service/models"
+)
+
+// FakeFeederConfigsRepository provides an easy way of mocking a
+// FeederConfigsRepository. The functions in this fake implementation do not
+// perform any validation.
+type FakeFeederConfigsRepository struct {
+	Configs []models.FeederConfig
+
+	// Error If this is set, any function will return it.
+	Error error
+}
+
+func (r *FakeFeederConfigsRepository) GetFeederConfig(clientId string) (models.FeederConfig, error) {
+	if r.Error != nil {
+		return models.FeederConfig{}, r.Error
+	}
+
+	for _, c := range r.Configs {
+		if c.ClientId == clientId {
+			return c, nil
+		}
+	}
+	return models.FeederConfig{}, fmt.Errorf("not found")
+}
+
+func (r *FakeFeederConfigsRepository) SetFeederConfig(c models.FeederConfig) (models.FeederConfig, error) {
+	if r.Error != nil {
+		return models.FeederConfig{}, r.Error
+	}
+
+	for i, cc := range r.Configs {
+		if cc.ClientId == c.ClientId {
+			c.Revision = cc.Revision + 1
+			r.Configs[i] = c
+			return c, nil
+		}
+	}
+	c.Revision = 1
+	r.Configs = append(r.Configs, c)
+	return c, nil
+}
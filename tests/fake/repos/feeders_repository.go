@@ -59,3 +59,17 @@ func (r *FakeFeedersRepository) UpdateFeeder(f models.Feeder) (models.Feeder, er
 	}
 	return models.Feeder{}, fmt.Errorf("not found")
 }
+
+func (r *FakeFeedersRepository) UpdateFeederTelemetry(t models.Telemetry) error {
+	if r.Error != nil {
+		return r.Error
+	}
+
+	for i, f := range r.Feeders {
+		if f.ClientId == t.ClientId {
+			r.Feeders[i].Telemetry = &t
+			return nil
+		}
+	}
+	return fmt.Errorf("not found")
+}
@@ -0,0 +1,39 @@
+package repos
+
+import (
+	"github.com/imilchev/rpi-feeder/pkg/service/models"
+)
+
+// FakeFoodLevelsRepository provides an easy way of mocking a
+// FoodLevelsRepository. The functions in this fake implementation do not
+// perform any validation.
+type FakeFoodLevelsRepository struct {
+	FoodLevels []models.FoodLevel
+
+	// Error If this is set, any function will return it.
+	Error error
+}
+
+func (r *FakeFoodLevelsRepository) CreateFoodLevel(l models.FoodLevel) (models.FoodLevel, error) {
+	if r.Error != nil {
+		return models.FoodLevel{}, r.Error
+	}
+
+	l.Id = len(r.FoodLevels) + 1
+	r.FoodLevels = append(r.FoodLevels, l)
+	return l, nil
+}
+
+func (r *FakeFoodLevelsRepository) GetFoodLevelsForFeeder(clientId string) (l []models.FoodLevel, err error) {
+	if r.Error != nil {
+		return l, r.Error
+	}
+
+	l = make([]models.FoodLevel, 0)
+	for _, f := range r.FoodLevels {
+		if f.ClientId == clientId {
+			l = append(l, f)
+		}
+	}
+	return l, nil
+}
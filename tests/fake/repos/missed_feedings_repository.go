@@ -0,0 +1,40 @@
+package repos
+
+import (
+	"github.com/imilchev/rpi-feeder/pkg/service/models"
+)
+
+// FakeMissedFeedingsRepository provides an easy way of mocking a
+// MissedFeedingsRepository. The functions in this fake implementation do not
+// perform any validation.
+type FakeMissedFeedingsRepository struct {
+	MissedFeedings []models.MissedFeeding
+
+	// Error If this is set, any function will return it.
+	Error error
+}
+
+func (r *FakeMissedFeedingsRepository) CreateMissedFeedings(
+	f []models.MissedFeeding) (fs []models.MissedFeeding, err error) {
+	if r.Error != nil {
+		return fs, r.Error
+	}
+
+	r.MissedFeedings = append(r.MissedFeedings, f...)
+	return f, nil
+}
+
+func (r *FakeMissedFeedingsRepository) GetMissedFeedingsForFeeder(
+	clientId string) (f []models.MissedFeeding, err error) {
+	if r.Error != nil {
+		return f, r.Error
+	}
+
+	f = make([]models.MissedFeeding, 0)
+	for _, m := range r.MissedFeedings {
+		if m.ClientId == clientId {
+			f = append(f, m)
+		}
+	}
+	return f, nil
+}
@@ -0,0 +1,53 @@
+package repos
+
+import (
+	"fmt"
+
+	"github.com/imilchev/rpi-feeder/pkg/service/models"
+)
+
+// FakeReleasesRepository provides an easy way of mocking a
+// ReleasesRepository. The functions in this fake implementation do not
+// perform any validation.
+type FakeReleasesRepository struct {
+	Releases []models.Release
+
+	// Error If this is set, any function will return it.
+	Error error
+}
+
+func (r *FakeReleasesRepository) CreateRelease(m models.Release) (models.Release, error) {
+	if r.Error != nil {
+		return models.Release{}, r.Error
+	}
+
+	if _, err := r.GetRelease(m.Version); err == nil {
+		return models.Release{}, models.NewAlreadyExistsError("Release", "Version", m.Version)
+	}
+
+	m.Id = len(r.Releases) + 1
+	r.Releases = append(r.Releases, m)
+	return m, nil
+}
+
+func (r *FakeReleasesRepository) GetReleases() (rs []models.Release, err error) {
+	if r.Error != nil {
+		return rs, r.Error
+	}
+
+	rs = make([]models.Release, 0, len(r.Releases))
+	return append(rs, r.Releases...), nil
+}
+
+func (r *FakeReleasesRepository) GetRelease(version string) (models.Release, error) {
+	if r.Error != nil {
+		return models.Release{}, r.Error
+	}
+
+	for _, m := range r.Releases {
+		if m.Version == version {
+			return m, nil
+		}
+	}
+	return models.Release{}, fmt.Errorf("not found")
+}
@@ -0,0 +1,81 @@
+package repos
+
+import (
+	"fmt"
+
+	"github.com/imilchev/rpi-feeder/pkg/service/models"
+)
+
+// FakeSchedulesRepository provides an easy way of mocking a SchedulesRepository.
+// The functions in this fake implementation do not perform any validation.
+type FakeSchedulesRepository struct {
+	Schedules []models.Schedule
+
+	// Error If this is set, any function will return it.
+	Error error
+}
+
+func (r *FakeSchedulesRepository) CreateSchedule(s models.Schedule) (models.Schedule, error) {
+	if r.Error != nil {
+		return models.Schedule{}, r.Error
+	}
+
+	s.Id = len(r.Schedules) + 1
+	r.Schedules = append(r.Schedules, s)
+	return s, nil
+}
+
+func (r *FakeSchedulesRepository) GetSchedulesForFeeder(clientId string) (s []models.Schedule, err error) {
+	if r.Error != nil {
+		return s, r.Error
+	}
+
+	s = make([]models.Schedule, 0)
+	for _, ss := range r.Schedules {
+		if ss.ClientId == clientId {
+			s = append(s, ss)
+		}
+	}
+	return s, nil
+}
+
+func (r *FakeSchedulesRepository) GetSchedule(clientId string, id int) (models.Schedule, error) {
+	if r.Error != nil {
+		return models.Schedule{}, r.Error
+	}
+
+	for _, s := range r.Schedules {
+		if s.ClientId == clientId && s.Id == id {
+			return s, nil
+		}
+	}
+	return models.Schedule{}, fmt.Errorf("not found")
+}
+
+func (r *FakeSchedulesRepository) UpdateSchedule(s models.Schedule) (models.Schedule, error) {
+	if r.Error != nil {
+		return models.Schedule{}, r.Error
+	}
+
+	for i, ss := range r.Schedules {
+		if ss.ClientId == s.ClientId && ss.Id == s.Id {
+			r.Schedules[i] = s
+			return s, nil
+		}
+	}
+	return models.Schedule{}, fmt.Errorf("not found")
+}
+
+func (r *FakeSchedulesRepository) DeleteSchedule(clientId string, id int) error {
+	if r.Error != nil {
+		return r.Error
+	}
+
+	for i, s := range r.Schedules {
+		if s.ClientId == clientId && s.Id == id {
+			r.Schedules = append(r.Schedules[:i], r.Schedules[i+1:]...)
+			return nil
+		}
+	}
+	return fmt.Errorf("not found")
+}
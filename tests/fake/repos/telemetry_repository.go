@@ -0,0 +1,39 @@
+package repos
+
+import (
+	"github.com/imilchev/rpi-feeder/pkg/service/models"
+)
+
+// FakeTelemetryRepository provides an easy way of mocking a
+// TelemetryRepository. The functions in this fake implementation do not
+// perform any validation.
+type FakeTelemetryRepository struct {
+	Telemetry []models.Telemetry
+
+	// Error If this is set, any function will return it.
+	Error error
+}
+
+func (r *FakeTelemetryRepository) CreateTelemetry(t models.Telemetry) (models.Telemetry, error) {
+	if r.Error != nil {
+		return models.Telemetry{}, r.Error
+	}
+
+	t.Id = len(r.Telemetry) + 1
+	r.Telemetry = append(r.Telemetry, t)
+	return t, nil
+}
+
+func (r *FakeTelemetryRepository) GetTelemetryForFeeder(clientId string) (t []models.Telemetry, err error) {
+	if r.Error != nil {
+		return t, r.Error
+	}
+
+	t = make([]models.Telemetry, 0)
+	for _, m := range r.Telemetry {
+		if m.ClientId == clientId {
+			t = append(t, m)
+		}
+	}
+	return t, nil
+}
@@ -0,0 +1,188 @@
+package broker
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"sync"
+
+	"github.com/eclipse/paho.golang/packets"
+)
+
+// Client is a client that connected to the broker.
+type Client struct {
+	ClientId string
+
+	// CommonName is the common name of the client certificate.
+	CommonName string
+}
+
+// Message is a message published to the broker.
+type Message struct {
+	Topic   string
+	Payload []byte
+}
+
+// Broker is a minimal in-process MQTT 5 broker for testing connections over
+// TLS. Clients must present a certificate signed by the CA of the certs. It
+// acknowledges connects, subscriptions and publishes and records the clients
+// and the published messages. Messages are not delivered to subscribers.
+type Broker struct {
+	ln net.Listener
+	wg sync.WaitGroup
+
+	mu        sync.Mutex
+	conns     map[net.Conn]struct{}
+	clients   []Client
+	published []Message
+}
+
+// NewBroker starts a broker listening on a random port of 127.0.0.1 with the
+// server certificate of certs.
+func NewBroker(certs Certs) (*Broker, error) {
+	cert, err := tls.LoadX509KeyPair(certs.ServerCertFile, certs.ServerKeyFile)
+	if err != nil {
+		return nil, err
+	}
+	ca, err := ioutil.ReadFile(certs.CaFile)
+	if err != nil {
+		return nil, err
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(ca) {
+		return nil, errors.New("invalid CA")
+	}
+
+	ln, err := tls.Listen("tcp", "127.0.0.1:0", &tls.Config{
+		Certificates: []tls.Certificate{cert},
+		ClientCAs:    pool,
+		ClientAuth:   tls.RequireAndVerifyClientCert,
+		MinVersion:   tls.VersionTLS12,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	b := &Broker{ln: ln, conns: make(map[net.Conn]struct{})}
+	b.wg.Add(1)
+	go b.accept()
+	return b, nil
+}
+
+// Url returns the URL to connect to the broker with.
+func (b *Broker) Url() string {
+	return fmt.Sprintf("mqtts://%s", b.ln.Addr())
+}
+
+// Clients returns the clients that connected to the broker so far.
+func (b *Broker) Clients() []Client {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return append([]Client{}, b.clients...)
+}
+
+// Published returns the messages published to topic so far.
+func (b *Broker) Published(topic string) []Message {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	var msgs []Message
+	for _, m := range b.published {
+		if m.Topic == topic {
+			msgs = append(msgs, m)
+		}
+	}
+	return msgs
+}
+
+// Close stops the broker and closes the connections of the clients.
+func (b *Broker) Close() {
+	b.ln.Close()
+	b.mu.Lock()
+	for c := range b.conns {
+		c.Close()
+	}
+	b.mu.Unlock()
+	b.wg.Wait()
+}
+
+func (b *Broker) accept() {
+	defer b.wg.Done()
+	for {
+		conn, err := b.ln.Accept()
+		if err != nil {
+			return
+		}
+		b.mu.Lock()
+		b.conns[conn] = struct{}{}
+		b.mu.Unlock()
+
+		b.wg.Add(1)
+		go func() {
+			defer b.wg.Done()
+			b.serve(conn.(*tls.Conn)) //nolint
+			conn.Close()
+			b.mu.Lock()
+			delete(b.conns, conn)
+			b.mu.Unlock()
+		}()
+	}
+}
+
+// serve handles the packets of a client until it disconnects.
+func (b *Broker) serve(conn *tls.Conn) error {
+	if err := conn.Handshake(); err != nil {
+		return err
+	}
+	commonName := conn.ConnectionState().PeerCertificates[0].Subject.CommonName
+
+	for {
+		p, err := packets.ReadPacket(conn)
+		if err != nil {
+			return err
+		}
+
+		var resp *packets.ControlPacket
+		switch c := p.Content.(type) {
+		case *packets.Connect:
+			b.mu.Lock()
+			b.clients = append(b.clients, Client{ClientId: c.ClientID, CommonName: commonName})
+			b.mu.Unlock()
+			resp = packets.NewControlPacket(packets.CONNACK)
+		case *packets.Subscribe:
+			resp = packets.NewControlPacket(packets.SUBACK)
+			suback := resp.Content.(*packets.Suback)
+			suback.PacketID = c.PacketID
+			for _, s := range c.Subscriptions {
+				suback.Reasons = append(suback.Reasons, s.QoS)
+			}
+		case *packets.Publish:
+			b.mu.Lock()
+			b.published = append(b.published, Message{Topic: c.Topic, Payload: c.Payload})
+			b.mu.Unlock()
+			switch c.QoS {
+			case 1:
+				resp = packets.NewControlPacket(packets.PUBACK)
+				resp.Content.(*packets.Puback).PacketID = c.PacketID
+			case 2:
+				resp = packets.NewControlPacket(packets.PUBREC)
+				resp.Content.(*packets.Pubrec).PacketID = c.PacketID
+			}
+		case *packets.Pubrel:
+			resp = packets.NewControlPacket(packets.PUBCOMP)
+			resp.Content.(*packets.Pubcomp).PacketID = c.PacketID
+		case *packets.Pingreq:
+			resp = packets.NewControlPacket(packets.PINGRESP)
+		case *packets.Disconnect:
+			return nil
+		}
+
+		if resp != nil {
+			if _, err := resp.WriteTo(conn); err != nil {
+				return err
+			}
+		}
+	}
+}
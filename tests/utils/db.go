@@ -95,6 +95,15 @@ func GetTestDb() (*gorm.DB, error) {
 
 func CleanupDb(db *gorm.DB) error {
 	return db.Exec(`TRUNCATE TABLE "feed_logs" CASCADE;
+					TRUNCATE TABLE "schedules" CASCADE;
+					TRUNCATE TABLE "missed_feedings" CASCADE;
+					TRUNCATE TABLE "feed_commands" CASCADE;
+					TRUNCATE TABLE "food_levels" CASCADE;
+					TRUNCATE TABLE "calibrations" CASCADE;
+					TRUNCATE TABLE "alerts" CASCADE;
+					TRUNCATE TABLE "telemetry" CASCADE;
+					TRUNCATE TABLE "releases" CASCADE;
+					TRUNCATE TABLE "feeder_configs" CASCADE;
 					TRUNCATE TABLE "feeders" CASCADE;`).Error
 }
 
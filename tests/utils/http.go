@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"io"
 	"io/ioutil"
+	"mime/multipart"
 	"net/http"
 	"net/http/httptest"
 )
@@ -18,12 +19,42 @@ func ParseResponse(v interface{}, resp *http.Response) error {
 //
 // The value can be nil, which means the body is empty.
 func PostJsonRequest(uri string, v interface{}) *http.Request {
+	return jsonRequest(http.MethodPost, uri, v)
+}
+
+// PutJsonRequest creates a new PUT request with JSON body.
+//
+// The value can be nil, which means the body is empty.
+func PutJsonRequest(uri string, v interface{}) *http.Request {
+	return jsonRequest(http.MethodPut, uri, v)
+}
+
+// PostMultipartRequest creates a new POST request with a multipart form body
+// made of the fields and files. The files are keyed by their field name.
+func PostMultipartRequest(uri string, fields map[string]string, files map[string][]byte) *http.Request {
+	body := &bytes.Buffer{}
+	w := multipart.NewWriter(body)
+	for k, v := range fields {
+		_ = w.WriteField(k, v)
+	}
+	for k, v := range files {
+		fw, _ := w.CreateFormFile(k, k)
+		_, _ = fw.Write(v)
+	}
+	_ = w.Close()
+
+	req := httptest.NewRequest(http.MethodPost, uri, body)
+	req.Header.Add(`Content-Type`, w.FormDataContentType())
+	return req
+}
+
+func jsonRequest(method, uri string, v interface{}) *http.Request {
 	var body io.Reader
 	if v != nil {
 		b, _ := json.Marshal(v)
 		body = bytes.NewReader(b)
 	}
-	req := httptest.NewRequest(http.MethodPost, uri, body)
+	req := httptest.NewRequest(method, uri, body)
 	req.Header.Add(`Content-Type`, `application/json`)
 	return req
 }
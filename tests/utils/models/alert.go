@@ -0,0 +1,52 @@
+package models
+
+import (
+	"math/rand"
+	"time"
+
+	dbm "github.com/imilchev/rpi-feeder/pkg/service/db/models"
+	"github.com/imilchev/rpi-feeder/pkg/service/models"
+	"github.com/imilchev/rpi-feeder/tests/utils"
+)
+
+var alertTypes = []models.AlertType{
+	models.LowFoodAlertType,
+	models.EmptyHopperAlertType,
+	models.FeedFailedAlertType,
+	models.StaleAlertType,
+	models.UpdateFailedAlertType,
+}
+
+func RandomAlertForFeeder(clientId string) models.Alert {
+	return models.Alert{
+		ClientId:  clientId,
+		Type:      alertTypes[rand.Intn(len(alertTypes))],
+		Message:   utils.RandString(20),
+		CreatedAt: time.Now().UTC().Add(-time.Duration(rand.Intn(48)) * time.Hour).Unix(),
+	}
+}
+
+func RandomAlertsForFeeder(clientId string) []models.Alert {
+	var a []models.Alert
+	count := rand.Intn(5) + 1
+
+	for i := 0; i < count; i++ {
+		alert := RandomAlertForFeeder(clientId)
+		if utils.RandBool() {
+			t := alert.CreatedAt + int64(rand.Intn(3600))
+			alert.AcknowledgedAt = &t
+		}
+		a = append(a, alert)
+	}
+	return a
+}
+
+func RandomDbAlertsForFeeder(clientId string) []dbm.Alert {
+	var a []dbm.Alert
+	for _, m := range RandomAlertsForFeeder(clientId) {
+		d := dbm.Alert{}
+		d.FromApi(m)
+		a = append(a, d)
+	}
+	return a
+}
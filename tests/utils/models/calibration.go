@@ -0,0 +1,40 @@
+package models
+
+import (
+	"math/rand"
+	"time"
+
+	dbm "github.com/imilchev/rpi-feeder/pkg/service/db/models"
+	"github.com/imilchev/rpi-feeder/pkg/service/models"
+)
+
+func RandomCalibrationForFeeder(clientId string) models.Calibration {
+	return models.Calibration{
+		ClientId:        clientId,
+		MsPerGram:       float64(rand.Intn(200) + 1),
+		OffsetMs:        float64(rand.Intn(500)),
+		GramsPerPortion: float64(rand.Intn(20) + 1),
+		PortionMs:       uint64(rand.Intn(5000) + 1),
+		Timestamp:       time.Now().UTC().Add(-time.Duration(rand.Intn(48)) * time.Hour).Unix(),
+	}
+}
+
+func RandomCalibrationsForFeeder(clientId string) []models.Calibration {
+	var c []models.Calibration
+	count := rand.Intn(5) + 1
+
+	for i := 0; i < count; i++ {
+		c = append(c, RandomCalibrationForFeeder(clientId))
+	}
+	return c
+}
+
+func RandomDbCalibrationsForFeeder(clientId string) []dbm.Calibration {
+	var c []dbm.Calibration
+	for _, m := range RandomCalibrationsForFeeder(clientId) {
+		d := dbm.Calibration{}
+		d.FromApi(m)
+		c = append(c, d)
+	}
+	return c
+}
@@ -0,0 +1,26 @@
+package models
+
+import (
+	"math/rand"
+	"time"
+
+	"github.com/google/uuid"
+	dbm "github.com/imilchev/rpi-feeder/pkg/service/db/models"
+	"github.com/imilchev/rpi-feeder/pkg/service/models"
+)
+
+func RandomFeedCommandForFeeder(clientId string) models.FeedCommand {
+	return models.FeedCommand{
+		Id:        uuid.NewString(),
+		ClientId:  clientId,
+		Portions:  uint(rand.Intn(10) + 1),
+		Status:    models.PendingCommandStatus,
+		CreatedAt: time.Now().UTC().Unix(),
+	}
+}
+
+func RandomDbFeedCommandForFeeder(clientId string) dbm.FeedCommand {
+	c := dbm.FeedCommand{}
+	c.FromApi(RandomFeedCommandForFeeder(clientId))
+	return c
+}
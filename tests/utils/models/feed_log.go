@@ -12,6 +12,7 @@ func RandomFeedLogForFeeder(clientId string) models.FeedLog {
 	return models.FeedLog{
 		ClientId:  clientId,
 		Portions:  uint(rand.Intn(10) + 1),
+		Grams:     float64(rand.Intn(100)),
 		Timestamp: time.Now().UTC().Unix(),
 	}
 }
@@ -34,6 +35,7 @@ func RandomDbFeedLogsForFeeder(clientId string) []dbm.FeedLog {
 		f = append(f, dbm.FeedLog{
 			ClientId:  clientId,
 			Portions:  uint(rand.Intn(10) + 1),
+			Grams:     float64(rand.Intn(100)),
 			Timestamp: time.Unix(time.Now().UTC().Unix(), 0),
 		})
 	}
@@ -0,0 +1,28 @@
+package models
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/rand"
+	"time"
+
+	dbm "github.com/imilchev/rpi-feeder/pkg/service/db/models"
+	"github.com/imilchev/rpi-feeder/pkg/service/models"
+)
+
+func RandomFeederConfigForFeeder(clientId string) models.FeederConfig {
+	return models.FeederConfig{
+		ClientId: clientId,
+		Revision: rand.Intn(100) + 1,
+		Config: json.RawMessage(fmt.Sprintf(
+			`{"portionMs": %d, "limits": {"maxPortionsPerDay": %d}}`,
+			rand.Intn(2000)+100, rand.Intn(10)+1)),
+		UpdatedAt: time.Now().UTC().Add(-time.Duration(rand.Intn(48)) * time.Hour).Unix(),
+	}
+}
+
+func RandomDbFeederConfigForFeeder(clientId string) dbm.FeederConfig {
+	d := dbm.FeederConfig{}
+	d.FromApi(RandomFeederConfigForFeeder(clientId))
+	return d
+}
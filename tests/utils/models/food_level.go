@@ -0,0 +1,38 @@
+package models
+
+import (
+	"math/rand"
+	"time"
+
+	dbm "github.com/imilchev/rpi-feeder/pkg/service/db/models"
+	"github.com/imilchev/rpi-feeder/pkg/service/models"
+)
+
+func RandomFoodLevelForFeeder(clientId string) models.FoodLevel {
+	return models.FoodLevel{
+		ClientId:  clientId,
+		Level:     float64(rand.Intn(101)),
+		Distance:  float64(rand.Intn(30)),
+		Timestamp: time.Now().UTC().Add(-time.Duration(rand.Intn(48)) * time.Hour).Unix(),
+	}
+}
+
+func RandomFoodLevelsForFeeder(clientId string) []models.FoodLevel {
+	var l []models.FoodLevel
+	count := rand.Intn(15) + 1
+
+	for i := 0; i < count; i++ {
+		l = append(l, RandomFoodLevelForFeeder(clientId))
+	}
+	return l
+}
+
+func RandomDbFoodLevelsForFeeder(clientId string) []dbm.FoodLevel {
+	var l []dbm.FoodLevel
+	for _, m := range RandomFoodLevelsForFeeder(clientId) {
+		d := dbm.FoodLevel{}
+		d.FromApi(m)
+		l = append(l, d)
+	}
+	return l
+}
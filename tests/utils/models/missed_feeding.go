@@ -0,0 +1,39 @@
+package models
+
+import (
+	"math/rand"
+	"time"
+
+	dbm "github.com/imilchev/rpi-feeder/pkg/service/db/models"
+	"github.com/imilchev/rpi-feeder/pkg/service/models"
+	"github.com/imilchev/rpi-feeder/tests/utils"
+)
+
+func RandomMissedFeedingForFeeder(clientId string) models.MissedFeeding {
+	return models.MissedFeeding{
+		ClientId:    clientId,
+		Portions:    uint(rand.Intn(10) + 1),
+		ScheduledAt: time.Now().UTC().Add(-time.Duration(rand.Intn(48)) * time.Hour).Unix(),
+		CaughtUp:    utils.RandBool(),
+	}
+}
+
+func RandomMissedFeedingsForFeeder(clientId string) []models.MissedFeeding {
+	var f []models.MissedFeeding
+	count := rand.Intn(15) + 1
+
+	for i := 0; i < count; i++ {
+		f = append(f, RandomMissedFeedingForFeeder(clientId))
+	}
+	return f
+}
+
+func RandomDbMissedFeedingsForFeeder(clientId string) []dbm.MissedFeeding {
+	var f []dbm.MissedFeeding
+	for _, m := range RandomMissedFeedingsForFeeder(clientId) {
+		d := dbm.MissedFeeding{}
+		d.FromApi(m)
+		f = append(f, d)
+	}
+	return f
+}
@@ -0,0 +1,51 @@
+package models
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"math/rand"
+	"time"
+
+	dbm "github.com/imilchev/rpi-feeder/pkg/service/db/models"
+	"github.com/imilchev/rpi-feeder/pkg/service/models"
+	"github.com/imilchev/rpi-feeder/tests/utils"
+)
+
+func RandomRelease() models.Release {
+	sum := sha256.Sum256([]byte(utils.RandString(20)))
+	return models.Release{
+		Version: fmt.Sprintf(
+			"v%d.%d.%d", rand.Intn(10), rand.Intn(100), rand.Intn(1000)),
+		Sha256:    hex.EncodeToString(sum[:]),
+		Size:      int64(rand.Intn(20000000) + 1),
+		CreatedAt: time.Now().UTC().Add(-time.Duration(rand.Intn(48)) * time.Hour).Unix(),
+	}
+}
+
+// RandomReleases returns releases with distinct versions.
+func RandomReleases() []models.Release {
+	var r []models.Release
+	count := rand.Intn(5) + 1
+
+	versions := make(map[string]bool)
+	for len(r) < count {
+		release := RandomRelease()
+		if versions[release.Version] {
+			continue
+		}
+		versions[release.Version] = true
+		r = append(r, release)
+	}
+	return r
+}
+
+func RandomDbReleases() []dbm.Release {
+	var r []dbm.Release
+	for _, m := range RandomReleases() {
+		d := dbm.Release{}
+		d.FromApi(m)
+		r = append(r, d)
+	}
+	return r
+}
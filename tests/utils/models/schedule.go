@@ -0,0 +1,43 @@
+package models
+
+import (
+	"fmt"
+	"math/rand"
+
+	dbm "github.com/imilchev/rpi-feeder/pkg/service/db/models"
+	"github.com/imilchev/rpi-feeder/pkg/service/models"
+	"github.com/imilchev/rpi-feeder/tests/utils"
+)
+
+func RandomScheduleForFeeder(clientId string) models.Schedule {
+	s := models.Schedule{
+		ClientId: clientId,
+		Portions: uint(rand.Intn(10) + 1),
+	}
+	if utils.RandBool() {
+		s.Cron = fmt.Sprintf("%d %d * * *", rand.Intn(60), rand.Intn(24))
+	} else {
+		s.Time = fmt.Sprintf("%02d:%02d", rand.Intn(24), rand.Intn(60))
+	}
+	return s
+}
+
+func RandomSchedulesForFeeder(clientId string) []models.Schedule {
+	var s []models.Schedule
+	count := rand.Intn(5) + 1
+
+	for i := 0; i < count; i++ {
+		s = append(s, RandomScheduleForFeeder(clientId))
+	}
+	return s
+}
+
+func RandomDbSchedulesForFeeder(clientId string) []dbm.Schedule {
+	var s []dbm.Schedule
+	for _, m := range RandomSchedulesForFeeder(clientId) {
+		d := dbm.Schedule{}
+		d.FromApi(m)
+		s = append(s, d)
+	}
+	return s
+}
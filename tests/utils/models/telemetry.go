@@ -0,0 +1,46 @@
+package models
+
+import (
+	"math/rand"
+	"time"
+
+	dbm "github.com/imilchev/rpi-feeder/pkg/service/db/models"
+	"github.com/imilchev/rpi-feeder/pkg/service/models"
+	"github.com/imilchev/rpi-feeder/tests/utils"
+)
+
+func RandomTelemetryForFeeder(clientId string) models.Telemetry {
+	t := models.Telemetry{
+		ClientId:          clientId,
+		UptimeSeconds:     uint64(rand.Intn(86400)),
+		FreeDiskBytes:     uint64(rand.Int63n(1 << 34)),
+		UnflushedFeedLogs: uint(rand.Intn(10)),
+		MqttReconnects:    uint(rand.Intn(10)),
+		Timestamp:         time.Now().UTC().Add(-time.Duration(rand.Intn(48)) * time.Hour).Unix(),
+	}
+	if utils.RandBool() {
+		temp := float64(rand.Intn(40) + 30)
+		t.CpuTemperature = &temp
+	}
+	return t
+}
+
+func RandomTelemetrySeriesForFeeder(clientId string) []models.Telemetry {
+	var t []models.Telemetry
+	count := rand.Intn(15) + 1
+
+	for i := 0; i < count; i++ {
+		t = append(t, RandomTelemetryForFeeder(clientId))
+	}
+	return t
+}
+
+func RandomDbTelemetrySeriesForFeeder(clientId string) []dbm.Telemetry {
+	var t []dbm.Telemetry
+	for _, m := range RandomTelemetrySeriesForFeeder(clientId) {
+		d := dbm.Telemetry{}
+		d.FromApi(m)
+		t = append(t, d)
+	}
+	return t
+}